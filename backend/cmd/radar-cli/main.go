@@ -0,0 +1,228 @@
+// Comando radar-cli lê o radar continuamente e exibe cada telegrama
+// decodificado no terminal (valores brutos e convertidos), opcionalmente
+// gravando no Redis. Usa o mesmo cliente, decodificador e detecção de
+// mudanças do servidor (internal/radar).
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"radar_go/internal/config"
+	"radar_go/internal/models"
+	"radar_go/internal/radar"
+	"radar_go/internal/redis"
+	"radar_go/pkg/logger"
+)
+
+func main() {
+	configFile := config.FileFlag(flag.CommandLine)
+	config.BindFlag(flag.CommandLine, "host", "radar.host", "Endereço do radar")
+	config.BindFlag(flag.CommandLine, "port", "radar.port", "Porta do radar")
+	config.BindFlag(flag.CommandLine, "protocol", "radar.protocol", "Protocolo: ascii ou binary")
+	config.BindFlag(flag.CommandLine, "sample-rate", "radar.sampleRate", "Intervalo entre leituras (ex.: 100ms)")
+	config.BindFlag(flag.CommandLine, "redis", "redis.enabled", "Gravar as leituras no Redis")
+	config.BindFlag(flag.CommandLine, "log-level", "log.level", "Nível de log: debug, info, warn ou error")
+	count := flag.Int("n", 0, "Número de leituras antes de encerrar (0 = contínuo)")
+	verbose := flag.Bool("v", false, "Exibir também o telegrama bruto e o hex dump")
+	flag.Parse()
+
+	logger.Init()
+
+	config.SetFile(*configFile)
+	cfg, err := config.Parse()
+	if err != nil {
+		logger.Fatal("Erro ao carregar configurações", err)
+	}
+	if level, err := logger.ParseLevel(cfg.Log.Level); err == nil {
+		logger.SetLevel(level)
+	}
+	if format, err := logger.ParseFormat(cfg.Log.Format); err == nil {
+		logger.SetFormat(format)
+	}
+
+	logger.Info("=== Iniciando Coleta do Radar SICK ===")
+
+	client := radar.NewRadarClient(cfg.Radar.Host, cfg.Radar.Port, cfg.Radar.Protocol)
+	defer client.Close()
+
+	redisService, err := redis.NewService(cfg.Redis)
+	if err != nil {
+		logger.Fatal("Erro ao inicializar serviço Redis", err)
+	}
+	defer redisService.Shutdown()
+
+	if redisService.IsConnected() {
+		printRedisKeys(cfg.Redis.Prefix)
+	}
+
+	logger.Infof("Iniciando coleta de dados do radar em %s:%d usando protocolo %s. Taxa de amostragem: %v",
+		cfg.Radar.Host, cfg.Radar.Port, cfg.Radar.Protocol, cfg.Radar.SampleRate)
+	logger.Info("Pressione Ctrl+C para interromper.")
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	ticker := time.NewTicker(cfg.Radar.SampleRate)
+	defer ticker.Stop()
+
+	var (
+		readings          int
+		consecutiveErrors int
+		lastVelocities    [7]float64
+	)
+
+	for {
+		select {
+		case <-quit:
+			logger.Info("Processo interrompido pelo usuário.")
+			return
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		response, err := client.SendCommand(radar.DataCommand)
+		if err != nil {
+			consecutiveErrors++
+			logger.Errorf("Erro ao comunicar com o radar: %v. Tentativa %d", err, consecutiveErrors)
+
+			if consecutiveErrors > cfg.Radar.MaxConsecutiveErrors {
+				logger.Errorf("ALERTA: Múltiplas falhas de comunicação com o radar. Verifique a conexão física!")
+				writeStatus(redisService, "falha_comunicacao", err.Error(), consecutiveErrors)
+
+				// Pausa mais longa após muitas falhas consecutivas
+				time.Sleep(cfg.Radar.ReconnectDelay)
+			}
+			continue
+		}
+		latency := time.Since(start)
+
+		if consecutiveErrors > 0 {
+			logger.Infof("Comunicação com o radar restaurada após %d tentativas", consecutiveErrors)
+			consecutiveErrors = 0
+			writeStatus(redisService, "ok", "", 0)
+		}
+
+		telegram, metrics, err := decode(response, cfg.Radar.Protocol)
+		if err != nil {
+			logger.Errorf("Erro ao decodificar valores: %v", err)
+			continue
+		}
+
+		if radar.Obstructed(metrics) {
+			metrics.Status = "obstruido"
+		}
+		radar.DetectVelocityChanges(metrics, lastVelocities)
+		lastVelocities = metrics.Velocities
+
+		printReading(response, telegram, metrics, latency, *verbose)
+
+		if redisService.IsConnected() {
+			if err := redisService.WriteMetrics(metrics); err != nil {
+				logger.Errorf("Erro ao escrever métricas no Redis: %v", err)
+			}
+			if len(metrics.VelocityChanges) > 0 {
+				if err := redisService.WriteVelocityChanges(metrics.VelocityChanges); err != nil {
+					logger.Errorf("Erro ao escrever mudanças de velocidade no Redis: %v", err)
+				}
+			}
+		}
+
+		readings++
+		if *count > 0 && readings >= *count {
+			return
+		}
+	}
+}
+
+// decode decodifica a resposta com o decodificador do servidor. No protocolo
+// ASCII, retorna também o telegrama com os valores brutos para exibição.
+func decode(response, protocol string) (*radar.Telegram, *models.RadarMetrics, error) {
+	if strings.ToLower(protocol) != "ascii" {
+		metrics, err := radar.Decode(response, protocol)
+		return nil, metrics, err
+	}
+
+	telegram, err := radar.ParseASCII(response)
+	if err != nil {
+		return nil, nil, err
+	}
+	return telegram, telegram.Metrics(), nil
+}
+
+// printReading exibe uma leitura no terminal
+func printReading(response string, telegram *radar.Telegram, metrics *models.RadarMetrics, latency time.Duration, verbose bool) {
+	fmt.Printf("\n=== %s | status: %s | %v ===\n",
+		metrics.Timestamp.Format("15:04:05.000"), metrics.Status, latency.Round(time.Microsecond))
+
+	if verbose {
+		fmt.Println("Resposta do radar:")
+		fmt.Printf("%q\n", response)
+		fmt.Println("Hex dump dos primeiros 50 bytes:")
+		fmt.Println(radar.HexDump(response, 50))
+	}
+
+	if telegram != nil {
+		printBlock("Posição", "pos", "m", telegram.Positions)
+		printBlock("Velocidade", "vel", "m/s", telegram.Velocities)
+	} else {
+		for i := range metrics.Positions {
+			fmt.Printf("  pos%d: %.3fm   vel%d: %.3fm/s\n", i+1, metrics.Positions[i], i+1, metrics.Velocities[i])
+		}
+	}
+
+	if metrics.Status == "obstruido" {
+		fmt.Println("ALERTA: Radar possivelmente obstruído - todas as posições são zero!")
+	}
+	for _, change := range metrics.VelocityChanges {
+		fmt.Printf("Mudança detectada na velocidade %d: %.3f -> %.3f (Δ%.3f)\n",
+			change.Index+1, change.OldValue, change.NewValue, change.ChangeValue)
+	}
+}
+
+// printBlock exibe os valores brutos e convertidos de um bloco do telegrama
+func printBlock(title, prefix, unit string, block *radar.Block) {
+	if block == nil {
+		fmt.Printf("Bloco de %s não encontrado ou formato inesperado.\n", title)
+		return
+	}
+
+	fmt.Printf("Bloco de %s (%s). Escala: %f\n", title, block.Name, block.Scale)
+	for i := range block.Values {
+		fmt.Printf("  %s%d: HEX=%s -> DEC=%d -> %.3f%s\n",
+			prefix, i+1, block.Hex[i], block.Decimal[i], block.Values[i], unit)
+	}
+}
+
+// writeStatus grava o status do radar no Redis, se conectado
+func writeStatus(redisService *redis.Service, status, lastError string, errorCount int) {
+	if !redisService.IsConnected() {
+		return
+	}
+	err := redisService.WriteStatus(models.RadarStatus{
+		Status:     status,
+		Timestamp:  time.Now(),
+		LastError:  lastError,
+		ErrorCount: errorCount,
+	})
+	if err != nil {
+		logger.Errorf("Erro ao escrever status no Redis: %v", err)
+	}
+}
+
+// printRedisKeys exibe as chaves gravadas no Redis, para integração com o app
+func printRedisKeys(prefix string) {
+	fmt.Println("\n=== Informações para integração com React Native ===")
+	fmt.Println("Dados armazenados no Redis que podem ser consultados pelo React Native:")
+	fmt.Printf("1. Última atualização: %s:latest_update\n", prefix)
+	fmt.Printf("2. Valores atuais: %s:vel1, %s:vel2, ...\n", prefix, prefix)
+	fmt.Printf("3. Mudanças recentes: %s:velocity_changes\n", prefix)
+	fmt.Printf("4. Mudanças por velocidade: %s:vel1:changes, %s:vel2:changes, ...\n", prefix, prefix)
+	fmt.Printf("5. Contador de mudanças: %s:vel1:change_count, %s:vel2:change_count, ...\n", prefix, prefix)
+	fmt.Println("=============================================")
+}
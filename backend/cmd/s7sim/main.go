@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"radar_go/internal/s7sim"
+	"radar_go/pkg/logger"
+)
+
+func main() {
+	addr := flag.String("addr", ":102", "Endereço de escuta ISO-on-TCP")
+	dbs := flag.String("db", "10:64", "Lista de DBs no formato numero:tamanho separados por vírgula (ex.: 10:64,1:16)")
+	pdu := flag.Int("pdu", s7sim.DefaultPDULength, "Tamanho máximo de PDU negociado")
+	verbose := flag.Bool("v", false, "Registrar cada escrita recebida")
+	flag.Parse()
+
+	// Inicializar logger
+	logger.Init()
+	if *verbose {
+		logger.SetLevel(logger.DEBUG)
+	}
+
+	// Criar simulador e blocos de dados
+	server := s7sim.NewServer()
+	server.SetPDULength(*pdu)
+
+	if err := configureDBs(server, *dbs); err != nil {
+		logger.Fatal("Configuração de DBs inválida", err)
+	}
+
+	server.OnWrite(func(w s7sim.WriteRecord) {
+		logger.Debugf("Escrita: %s % X", formatTarget(w), w.Data)
+	})
+
+	if err := server.Listen(*addr); err != nil {
+		logger.Fatal("Erro ao iniciar simulador S7", err)
+	}
+	logger.Infof("Simulador S7 escutando em %s (PDU %d, DBs: %s)", server.Addr(), *pdu, *dbs)
+
+	// Aguardar sinal de encerramento
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	server.Close()
+	logger.Infof("Simulador encerrado após %d requisições", server.RequestCount())
+}
+
+// configureDBs cria os blocos de dados listados em spec ("10:64,1:16")
+func configureDBs(server *s7sim.Server, spec string) error {
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		numStr, sizeStr, ok := strings.Cut(entry, ":")
+		if !ok {
+			return fmt.Errorf("entrada %q sem tamanho (use numero:tamanho)", entry)
+		}
+
+		number, err := strconv.Atoi(numStr)
+		if err != nil || number < 1 {
+			return fmt.Errorf("número de DB inválido em %q", entry)
+		}
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil || size < 1 {
+			return fmt.Errorf("tamanho de DB inválido em %q", entry)
+		}
+
+		server.Memory().AddDB(number, size)
+	}
+	return nil
+}
+
+// formatTarget descreve o destino de uma escrita em notação S7
+func formatTarget(w s7sim.WriteRecord) string {
+	var area string
+	switch w.Area {
+	case s7sim.AreaDB:
+		area = fmt.Sprintf("DB%d.DBB", w.DBNumber)
+	case s7sim.AreaMerker:
+		area = "MB"
+	case s7sim.AreaInput:
+		area = "IB"
+	case s7sim.AreaOutput:
+		area = "QB"
+	case s7sim.AreaTimer:
+		return fmt.Sprintf("T%d", w.Offset)
+	case s7sim.AreaCounter:
+		return fmt.Sprintf("C%d", w.Offset)
+	}
+
+	if w.Bit >= 0 {
+		return fmt.Sprintf("%s%d.%d", strings.TrimSuffix(area, "B"), w.Offset, w.Bit)
+	}
+	return fmt.Sprintf("%s%d", area, w.Offset)
+}
@@ -1,96 +1,144 @@
 package main
 
 import (
-	"context"
+	"flag"
 	"fmt"
 	"os"
-	"os/signal"
 	"path/filepath"
-	"syscall"
+	"strconv"
+	"strings"
 	"time"
 
 	"radar_go/internal/config"
-	"radar_go/internal/server"
+	"radar_go/internal/version"
 	"radar_go/pkg/logger"
 )
 
 func main() {
-	// Configurar diretório de logs
-	logDir := filepath.Join(".", "logs")
-	os.MkdirAll(logDir, 0755)
-
-	// Inicializar logger
-	logger.Init()
-	logger.SetLevel(logger.DEBUG) // Usar DEBUG para ter mais informações durante desenvolvimento
-	logger.EnableFileLogging(logDir, "radar")
-	defer logger.Sync()
+	command, args := parseCommand(os.Args[1:])
+
+	switch command {
+	case "serve":
+		runServe(args)
+	case "check-config":
+		os.Exit(runCheckConfig(args))
+	case "probe":
+		os.Exit(runProbe(args))
+	case "version":
+		fmt.Println(version.String())
+	case "help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "Comando desconhecido: %s\n\n", command)
+		usage()
+		os.Exit(2)
+	}
+}
 
-	// Exibir banner de inicialização
-	displayBanner()
+// parseCommand separa o comando dos seus argumentos. Sem comando (ou só com
+// flags), o comando é serve; a flag --check-config, anterior aos subcomandos,
+// continua aceita como alias de check-config.
+func parseCommand(args []string) (string, []string) {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return args[0], args[1:]
+	}
 
-	logger.Info("Iniciando SICK Radar Monitor")
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "check-config" {
+			continue
+		}
 
-	// Carregar configurações
-	cfg, err := config.Load()
-	if err != nil {
-		logger.Fatal("Erro ao carregar configurações", err)
+		enabled := true
+		if hasValue {
+			var err error
+			if enabled, err = strconv.ParseBool(value); err != nil {
+				break // Valor inválido: o erro é reportado pelas flags de serve
+			}
+		}
+		rest := append(append([]string{}, args[:i]...), args[i+1:]...)
+		if enabled {
+			return "check-config", rest
+		}
+		return "serve", rest
 	}
+	return "serve", args
+}
 
-	// Garantir que temos a taxa de amostragem correta para desempenho ideal
-	if cfg.Radar.SampleRate > 100*time.Millisecond {
-		logger.Warn("Taxa de amostragem muito baixa. Definindo para 100ms (10Hz)")
-		cfg.Radar.SampleRate = 100 * time.Millisecond
-	}
+// usage exibe os comandos disponíveis
+func usage() {
+	name := filepath.Base(os.Args[0])
+	fmt.Fprintf(os.Stderr, `Uso: %s [comando] [flags]
 
-	logger.Infof("Configuração carregada: Radar em %s:%d, Redis em %s:%d",
-		cfg.Radar.Host, cfg.Radar.Port, cfg.Redis.Host, cfg.Redis.Port)
-	logger.Infof("Taxa de amostragem: %v", cfg.Radar.SampleRate)
+Comandos:
+  serve         Inicia o servidor (padrão)
+  check-config  Exibe a configuração efetiva, valida e encerra (também --check-config)
+  probe         Lê um telegrama do radar e exibe os valores decodificados
+  version       Exibe a versão
 
-	// Criar e iniciar o servidor
-	srv, err := server.NewServer(cfg)
-	if err != nil {
-		logger.Fatal("Erro ao criar servidor", err)
-	}
+Use "%s <comando> -h" para as flags de cada comando. Flags têm precedência
+sobre o arquivo de configuração e as variáveis de ambiente.
+`, name, name)
+}
 
-	// Iniciar o servidor em uma goroutine separada
-	go func() {
-		logger.Infof("Servidor iniciado na porta %d", cfg.Server.Port)
-		if err := srv.Start(); err != nil {
-			logger.Fatal("Erro ao iniciar o servidor", err)
-		}
-	}()
+// serverFlags registra as flags de serve, também aceitas por check-config
+func serverFlags(fs *flag.FlagSet) *string {
+	configFile := config.FileFlag(fs)
+	config.BindFlag(fs, "port", "server.port", "Porta HTTP/WebSocket")
+	config.BindFlag(fs, "log-level", "log.level", "Nível de log: debug, info, warn ou error")
+	config.BindFlag(fs, "log-format", "log.format", "Formato do log: text ou json")
+	config.BindFlag(fs, "log-dir", "log.dir", "Diretório dos arquivos de log (vazio = apenas terminal)")
+	return configFile
+}
 
-	// Configurar captura de sinais para shutdown gracioso
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+// runCheckConfig imprime a configuração efetiva e os problemas encontrados.
+// Retorna o código de saída: 0 se válida, 1 caso contrário.
+func runCheckConfig(args []string) int {
+	fs := flag.NewFlagSet("check-config", flag.ExitOnError)
+	configFile := serverFlags(fs)
+	fs.Parse(args)
 
-	logger.Info("Desligando servidor...")
+	// Logs em stderr, para que stdout tenha apenas o JSON
+	logger.SetOutput(os.Stderr)
 
-	// Criar contexto com timeout para o shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	config.SetFile(*configFile)
+	cfg, err := config.Parse()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erro ao carregar configurações: %v\n", err)
+		return 1
+	}
 
-	// Desligar o servidor
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Error("Erro durante o shutdown do servidor", err)
+	data, err := cfg.MarshalReadable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erro ao serializar configurações: %v\n", err)
+		return 1
 	}
+	fmt.Println(string(data))
 
-	logger.Info("Servidor encerrado com sucesso")
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Fprintln(os.Stderr, "Configuração válida")
+	return 0
 }
 
 // displayBanner exibe um banner de inicialização
 func displayBanner() {
 	banner := `
- _______ __   __ _______            _____  _______ _______ _______  ______ 
- |______   \_/   |       |      |  |     | |_____| |  |  | |_____| |_____/ 
+ _______ __   __ _______            _____  _______ _______ _______  ______
+ |______   \_/   |       |      |  |     | |_____| |  |  | |_____| |_____/
  ______|    |    |_____  |_____ |_ |_____| |     | |  |  | |     | |    \_
-                                                                           
+
  _______ _______  ______       __   __ _______ __   _ _____ _______ _______  ______
  |  |  | |       |    _ |        \_/   |______ | \  |   |   |______ |_____| |_____/
- |  |  | |_____  |_____| .        |    |______ |  \_| __|__ |     | |     | |    \_  v1.0
+ |  |  | |_____  |_____| .        |    |______ |  \_| __|__ |     | |     | |    \_  v%s
                                                                    REAL-TIME EDITION
  `
-	fmt.Println(banner)
+	fmt.Printf(banner+"\n", version.Version)
 	fmt.Printf("Iniciando em %s\n\n", time.Now().Format("2006-01-02 15:04:05"))
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		args    []string
+		command string
+		rest    []string
+	}{
+		{nil, "serve", nil},
+		{[]string{"-port", "9090"}, "serve", []string{"-port", "9090"}},
+		{[]string{"serve", "-port", "9090"}, "serve", []string{"-port", "9090"}},
+		{[]string{"check-config", "-config", "x.json"}, "check-config", []string{"-config", "x.json"}},
+		{[]string{"probe", "-check-config"}, "probe", []string{"-check-config"}},
+
+		// Flag anterior aos subcomandos
+		{[]string{"--check-config"}, "check-config", []string{}},
+		{[]string{"-check-config"}, "check-config", []string{}},
+		{[]string{"-port", "9090", "--check-config", "-log-level", "debug"}, "check-config",
+			[]string{"-port", "9090", "-log-level", "debug"}},
+		{[]string{"--check-config=true"}, "check-config", []string{}},
+		{[]string{"--check-config=false", "-port", "9090"}, "serve", []string{"-port", "9090"}},
+		{[]string{"--", "--check-config"}, "serve", []string{"--", "--check-config"}},
+	}
+
+	for _, tt := range tests {
+		command, rest := parseCommand(tt.args)
+		if command != tt.command || !reflect.DeepEqual(rest, tt.rest) {
+			t.Errorf("parseCommand(%q) = %q, %q; esperado %q, %q", tt.args, command, rest, tt.command, tt.rest)
+		}
+	}
+}
+
+func TestCheckConfigFlagParses(t *testing.T) {
+	// A flag antiga e o subcomando chegam às mesmas flags de check-config
+	for _, args := range [][]string{
+		{"--check-config", "-config", "x.json", "-port", "9090"},
+		{"check-config", "-config", "x.json", "-port", "9090"},
+	} {
+		command, rest := parseCommand(args)
+		if command != "check-config" {
+			t.Fatalf("parseCommand(%q) = %q, esperado check-config", args, command)
+		}
+
+		fs := flag.NewFlagSet("check-config", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		configFile := serverFlags(fs)
+		if err := fs.Parse(rest); err != nil {
+			t.Errorf("flags de %q: %v", args, err)
+			continue
+		}
+		if *configFile != "x.json" {
+			t.Errorf("--config de %q = %q", args, *configFile)
+		}
+	}
+}
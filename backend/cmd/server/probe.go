@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"radar_go/internal/config"
+	"radar_go/internal/radar"
+	"radar_go/pkg/logger"
+)
+
+// runProbe conecta ao radar, lê um telegrama e imprime os valores
+// decodificados em JSON. Retorna o código de saída.
+func runProbe(args []string) int {
+	fs := flag.NewFlagSet("probe", flag.ExitOnError)
+	configFile := config.FileFlag(fs)
+	config.BindFlag(fs, "host", "radar.host", "Endereço do radar")
+	config.BindFlag(fs, "port", "radar.port", "Porta do radar")
+	config.BindFlag(fs, "protocol", "radar.protocol", "Protocolo: ascii ou binary")
+	fs.Parse(args)
+
+	// Logs em stderr, para que stdout tenha apenas o JSON
+	logger.SetOutput(os.Stderr)
+
+	// Só a seção radar é usada; o restante não precisa ser válido
+	config.SetFile(*configFile)
+	cfg, err := config.Parse()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erro ao carregar configurações: %v\n", err)
+		return 1
+	}
+
+	client := radar.NewRadarClient(cfg.Radar.Host, cfg.Radar.Port, cfg.Radar.Protocol)
+	if err := client.Connect(); err != nil {
+		fmt.Fprintf(os.Stderr, "Erro ao conectar ao radar: %v\n", err)
+		return 1
+	}
+	defer client.Close()
+
+	start := time.Now()
+	response, err := client.SendCommand(radar.DataCommand)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erro ao ler telegrama: %v\n", err)
+		return 1
+	}
+	latency := time.Since(start)
+
+	metrics, err := client.DecodeValues(response)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erro ao decodificar telegrama: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Telegrama recebido: %q\n", response)
+		return 1
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"radar":    net.JoinHostPort(cfg.Radar.Host, strconv.Itoa(cfg.Radar.Port)),
+		"protocol": strings.ToLower(cfg.Radar.Protocol),
+		"latency":  latency.String(),
+		"telegram": strings.Trim(response, "\x02\x03\r\n"),
+		"metrics":  metrics,
+	}, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erro ao serializar resultado: %v\n", err)
+		return 1
+	}
+	fmt.Println(string(data))
+	return 0
+}
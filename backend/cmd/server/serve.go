@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"radar_go/internal/config"
+	"radar_go/internal/server"
+	"radar_go/pkg/logger"
+)
+
+// Acima deste intervalo, os clientes em tempo real percebem atraso
+const realTimeSampleRate = 100 * time.Millisecond
+
+// runServe carrega a configuração e executa o servidor até SIGINT/SIGTERM
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	configFile := serverFlags(fs)
+	fs.Parse(args)
+
+	// Inicializar logger
+	logger.Init()
+
+	// Exibir banner de inicialização
+	displayBanner()
+
+	logger.Info("Iniciando SICK Radar Monitor")
+
+	// Carregar configurações
+	config.SetFile(*configFile)
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Erro ao carregar configurações", err)
+	}
+
+	// Nível, formato e arquivos de log (rotacionados)
+	if err := server.ConfigureLogging(cfg.Log); err != nil {
+		logger.Warnf("Log em arquivo desabilitado: %v", err)
+	}
+	defer logger.Sync()
+
+	if cfg.Radar.SampleRate > realTimeSampleRate {
+		logger.Warnf("Taxa de amostragem de %v, abaixo de 10Hz: clientes em tempo real podem perceber atraso",
+			cfg.Radar.SampleRate)
+	}
+
+	logger.Infof("Configuração carregada de %s: Radar em %s:%d, Redis em %s:%d",
+		config.File(), cfg.Radar.Host, cfg.Radar.Port, cfg.Redis.Host, cfg.Redis.Port)
+	logger.Infof("Taxa de amostragem: %v", cfg.Radar.SampleRate)
+
+	// Criar e iniciar o servidor
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		logger.Fatal("Erro ao criar servidor", err)
+	}
+
+	// Iniciar o servidor em uma goroutine separada
+	go func() {
+		logger.Infof("Servidor iniciado na porta %d", cfg.Server.Port)
+		if err := srv.Start(); err != nil {
+			logger.Fatal("Erro ao iniciar o servidor", err)
+		}
+	}()
+
+	// SIGHUP recarrega a configuração sem reiniciar o serviço
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			logger.Info("SIGHUP recebido, recarregando configuração")
+			srv.Reload(server.ReloadSignal)
+		}
+	}()
+
+	// Configurar captura de sinais para shutdown gracioso
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logger.Info("Desligando servidor...")
+
+	// Criar contexto com timeout para o shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	// Desligar o servidor
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("Erro durante o shutdown do servidor", err)
+	}
+
+	logger.Info("Servidor encerrado com sucesso")
+}
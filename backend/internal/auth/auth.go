@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"radar_go/internal/config"
+)
+
+// Erros de autenticação
+var (
+	ErrNoCredentials      = errors.New("credenciais ausentes")
+	ErrInvalidCredentials = errors.New("credenciais inválidas")
+)
+
+// apiKey é uma chave configurada, guardada como hash para comparação em tempo constante
+type apiKey struct {
+	name string
+	hash [sha256.Size]byte
+	role Role
+}
+
+// Authenticator valida chaves de API e tokens JWT
+type Authenticator struct {
+	enabled       bool
+	anonymousRole Role
+	origins       map[string]bool
+
+	keys []apiKey
+
+	// Validação JWT
+	hmacSecret []byte
+	rsaKey     *rsa.PublicKey
+	methods    []string
+	roleClaim  string
+	parser     *jwt.Parser
+}
+
+// NewAuthenticator cria o autenticador a partir da configuração
+func NewAuthenticator(cfg config.AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{
+		enabled:   cfg.Enabled,
+		origins:   make(map[string]bool),
+		roleClaim: cfg.JWT.RoleClaim,
+	}
+	if a.roleClaim == "" {
+		a.roleClaim = "role"
+	}
+
+	for _, origin := range cfg.AllowedOrigins {
+		a.origins[strings.TrimSuffix(origin, "/")] = true
+	}
+
+	if cfg.AnonymousRole != "" {
+		role, err := ParseRole(cfg.AnonymousRole)
+		if err != nil {
+			return nil, fmt.Errorf("anonymousRole: %w", err)
+		}
+		a.anonymousRole = role
+	}
+
+	for i, key := range cfg.APIKeys {
+		if key.Key == "" {
+			return nil, fmt.Errorf("apiKeys[%d]: chave vazia", i)
+		}
+		role, err := ParseRole(key.Role)
+		if err != nil {
+			return nil, fmt.Errorf("apiKeys[%d]: %w", i, err)
+		}
+		name := key.Name
+		if name == "" {
+			name = fmt.Sprintf("apikey-%d", i+1)
+		}
+		a.keys = append(a.keys, apiKey{name: name, hash: sha256.Sum256([]byte(key.Key)), role: role})
+	}
+
+	if cfg.JWT.Secret != "" {
+		a.hmacSecret = []byte(cfg.JWT.Secret)
+		a.methods = append(a.methods, jwt.SigningMethodHS256.Alg())
+	}
+	if cfg.JWT.PublicKeyFile != "" {
+		pem, err := os.ReadFile(cfg.JWT.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler chave pública JWT: %w", err)
+		}
+		a.rsaKey, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("chave pública JWT inválida: %w", err)
+		}
+		a.methods = append(a.methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	if a.enabled && len(a.keys) == 0 && len(a.methods) == 0 && a.anonymousRole == RoleNone {
+		return nil, fmt.Errorf("autenticação habilitada sem chaves de API, JWT ou papel anônimo")
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(a.methods),
+		jwt.WithLeeway(cfg.JWT.Leeway),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.JWT.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.JWT.Issuer))
+	}
+	if cfg.JWT.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.JWT.Audience))
+	}
+	a.parser = jwt.NewParser(options...)
+
+	return a, nil
+}
+
+// Enabled informa se a autenticação está habilitada
+func (a *Authenticator) Enabled() bool {
+	return a.enabled
+}
+
+// Authenticate identifica a requisição. Credenciais são aceitas em
+// "Authorization: Bearer <jwt ou chave>", no cabeçalho X-API-Key ou nos
+// parâmetros access_token/api_key (navegadores não enviam cabeçalhos em
+// WebSocket e EventSource).
+func (a *Authenticator) Authenticate(r *http.Request) (*Identity, error) {
+	if !a.enabled {
+		return &Identity{Subject: "anonymous", Role: RoleAdmin, Method: MethodNone}, nil
+	}
+
+	token := credentials(r)
+	if token == "" {
+		if a.anonymousRole != RoleNone {
+			return &Identity{Subject: "anonymous", Role: a.anonymousRole, Method: MethodAnonymous}, nil
+		}
+		return nil, ErrNoCredentials
+	}
+
+	// Tokens JWT têm três partes separadas por ponto; chaves de API não
+	if strings.Count(token, ".") == 2 && len(a.methods) > 0 {
+		return a.authenticateJWT(token)
+	}
+	return a.authenticateKey(token)
+}
+
+// credentials extrai a credencial da requisição
+func credentials(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		if scheme, token, ok := strings.Cut(header, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	query := r.URL.Query()
+	if token := query.Get("access_token"); token != "" {
+		return token
+	}
+	return query.Get("api_key")
+}
+
+// authenticateKey procura a chave entre as configuradas
+func (a *Authenticator) authenticateKey(key string) (*Identity, error) {
+	hash := sha256.Sum256([]byte(key))
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(hash[:], k.hash[:]) == 1 {
+			return &Identity{Subject: k.name, Role: k.role, Method: MethodAPIKey}, nil
+		}
+	}
+	return nil, ErrInvalidCredentials
+}
+
+// authenticateJWT valida assinatura, validade, emissor e audiência do token
+func (a *Authenticator) authenticateJWT(token string) (*Identity, error) {
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		switch t.Method.Alg() {
+		case jwt.SigningMethodHS256.Alg():
+			return a.hmacSecret, nil
+		case jwt.SigningMethodRS256.Alg():
+			return a.rsaKey, nil
+		}
+		return nil, fmt.Errorf("algoritmo não suportado: %s", t.Method.Alg())
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	role, err := claimRole(claims[a.roleClaim])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		subject, _ = claims["preferred_username"].(string)
+	}
+	if subject == "" {
+		subject = "jwt"
+	}
+	return &Identity{Subject: subject, Role: role, Method: MethodJWT}, nil
+}
+
+// claimRole lê o papel de uma claim de texto ou lista; em listas vale o maior papel
+func claimRole(value interface{}) (Role, error) {
+	switch v := value.(type) {
+	case string:
+		return ParseRole(v)
+	case []interface{}:
+		best := RoleNone
+		for _, item := range v {
+			name, _ := item.(string)
+			if role, err := ParseRole(name); err == nil && role > best {
+				best = role
+			}
+		}
+		if best != RoleNone {
+			return best, nil
+		}
+	}
+	return RoleNone, fmt.Errorf("token sem papel válido")
+}
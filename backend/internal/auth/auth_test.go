@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"radar_go/internal/config"
+)
+
+const testSecret = "segredo-de-teste-com-32-bytes-ok"
+
+// testRSAKey gera um par RSA e grava a chave pública em PEM
+func testRSAKey(t *testing.T) (*rsa.PrivateKey, string, []byte) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	path := filepath.Join(t.TempDir(), "jwt.pem")
+	if err := os.WriteFile(path, publicPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return key, path, publicPEM
+}
+
+// newTestAuthenticator cria um autenticador habilitado ou falha o teste
+func newTestAuthenticator(t *testing.T, cfg config.AuthConfig) *Authenticator {
+	t.Helper()
+
+	cfg.Enabled = true
+	a, err := NewAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	return a
+}
+
+// sign assina as claims com o método e a chave informados
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("erro ao assinar token: %v", err)
+	}
+	return token
+}
+
+// validClaims retorna claims válidas por uma hora com o papel informado
+func validClaims(role interface{}) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":  "maria",
+		"role": role,
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+// authenticateToken autentica uma requisição com "Authorization: Bearer <token>"
+func authenticateToken(a *Authenticator, token string) (*Identity, error) {
+	r := httptest.NewRequest("GET", "/api/current", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return a.Authenticate(r)
+}
+
+func TestAuthenticateJWT(t *testing.T) {
+	privateKey, publicKeyFile, publicPEM := testRSAKey(t)
+
+	hmacOnly := newTestAuthenticator(t, config.AuthConfig{JWT: config.JWTConfig{Secret: testSecret}})
+	rsaOnly := newTestAuthenticator(t, config.AuthConfig{JWT: config.JWTConfig{PublicKeyFile: publicKeyFile}})
+	strict := newTestAuthenticator(t, config.AuthConfig{JWT: config.JWTConfig{
+		Secret: testSecret, Issuer: "https://idp", Audience: "radar", RoleClaim: "roles",
+	}})
+
+	expired := validClaims("viewer")
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	noExp := validClaims("viewer")
+	delete(noExp, "exp")
+	notYet := validClaims("viewer")
+	notYet["nbf"] = time.Now().Add(time.Hour).Unix()
+	scoped := jwt.MapClaims{
+		"sub": "joao", "iss": "https://idp", "aud": "radar",
+		"roles": []interface{}{"viewer", "desconhecido", "engineer"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+	wrongIssuer := jwt.MapClaims{"iss": "https://outro", "aud": "radar", "roles": "admin",
+		"exp": time.Now().Add(time.Hour).Unix()}
+
+	noneToken := sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, validClaims("admin"))
+
+	tests := []struct {
+		name    string
+		a       *Authenticator
+		token   string
+		subject string
+		role    Role
+	}{
+		{"HS256 válido", hmacOnly, sign(t, jwt.SigningMethodHS256, []byte(testSecret), validClaims("operator")), "maria", RoleOperator},
+		{"RS256 válido", rsaOnly, sign(t, jwt.SigningMethodRS256, privateKey, validClaims("admin")), "maria", RoleAdmin},
+		{"lista de papéis usa o maior", strict, sign(t, jwt.SigningMethodHS256, []byte(testSecret), scoped), "joao", RoleEngineer},
+
+		{"segredo errado", hmacOnly, sign(t, jwt.SigningMethodHS256, []byte("outro-segredo"), validClaims("admin")), "", RoleNone},
+		{"alg none", hmacOnly, noneToken, "", RoleNone},
+		{"alg none com RS256", rsaOnly, noneToken, "", RoleNone},
+		// Confusão de algoritmo: HS256 assinado com a chave pública RS256
+		{"HS256 com RS256 configurado", rsaOnly, sign(t, jwt.SigningMethodHS256, publicPEM, validClaims("admin")), "", RoleNone},
+		{"RS256 com apenas HS256", hmacOnly, sign(t, jwt.SigningMethodRS256, privateKey, validClaims("admin")), "", RoleNone},
+		{"expirado", hmacOnly, sign(t, jwt.SigningMethodHS256, []byte(testSecret), expired), "", RoleNone},
+		{"sem exp", hmacOnly, sign(t, jwt.SigningMethodHS256, []byte(testSecret), noExp), "", RoleNone},
+		{"antes de nbf", hmacOnly, sign(t, jwt.SigningMethodHS256, []byte(testSecret), notYet), "", RoleNone},
+		{"papel desconhecido", hmacOnly, sign(t, jwt.SigningMethodHS256, []byte(testSecret), validClaims("root")), "", RoleNone},
+		{"sem papel", hmacOnly, sign(t, jwt.SigningMethodHS256, []byte(testSecret), validClaims(nil)), "", RoleNone},
+		{"lista sem papel válido", hmacOnly, sign(t, jwt.SigningMethodHS256, []byte(testSecret),
+			validClaims([]interface{}{"root", 3})), "", RoleNone},
+		{"emissor errado", strict, sign(t, jwt.SigningMethodHS256, []byte(testSecret), wrongIssuer), "", RoleNone},
+		{"sem audiência", strict, sign(t, jwt.SigningMethodHS256, []byte(testSecret), validClaims("admin")), "", RoleNone},
+		{"assinatura adulterada", hmacOnly, sign(t, jwt.SigningMethodHS256, []byte(testSecret), validClaims("viewer")) + "x", "", RoleNone},
+	}
+
+	for _, tt := range tests {
+		identity, err := authenticateToken(tt.a, tt.token)
+		if tt.role == RoleNone {
+			if !errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("%s: Authenticate = %v, %v; esperado ErrInvalidCredentials", tt.name, identity, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Authenticate: %v", tt.name, err)
+			continue
+		}
+		if identity.Subject != tt.subject || identity.Role != tt.role || identity.Method != MethodJWT {
+			t.Errorf("%s: identidade = %s, esperado %s/%s via jwt", tt.name, identity, tt.subject, tt.role)
+		}
+	}
+}
+
+func TestAuthenticateJWTLeeway(t *testing.T) {
+	a := newTestAuthenticator(t, config.AuthConfig{JWT: config.JWTConfig{Secret: testSecret, Leeway: time.Minute}})
+
+	claims := validClaims("viewer")
+	claims["exp"] = time.Now().Add(-10 * time.Second).Unix()
+	if _, err := authenticateToken(a, sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims)); err != nil {
+		t.Errorf("token expirado dentro da tolerância recusado: %v", err)
+	}
+}
+
+func TestNewAuthenticatorErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.AuthConfig
+	}{
+		{"sem credenciais", config.AuthConfig{Enabled: true}},
+		{"papel anônimo inválido", config.AuthConfig{AnonymousRole: "guest"}},
+		{"chave vazia", config.AuthConfig{APIKeys: []config.APIKeyConfig{{Role: "viewer"}}}},
+		{"papel da chave inválido", config.AuthConfig{APIKeys: []config.APIKeyConfig{{Key: "k", Role: "root"}}}},
+		{"chave pública ausente", config.AuthConfig{JWT: config.JWTConfig{PublicKeyFile: "/nao/existe.pem"}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewAuthenticator(tt.cfg); err == nil {
+			t.Errorf("%s: NewAuthenticator sem erro", tt.name)
+		}
+	}
+
+	// Desabilitada, a autenticação não exige credenciais configuradas
+	a, err := NewAuthenticator(config.AuthConfig{})
+	if err != nil {
+		t.Fatalf("NewAuthenticator desabilitado: %v", err)
+	}
+	identity, err := a.Authenticate(httptest.NewRequest("GET", "/api/current", nil))
+	if err != nil || identity.Role != RoleAdmin || identity.Method != MethodNone {
+		t.Errorf("identidade com autenticação desabilitada = %s, %v", identity, err)
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	for _, role := range []Role{RoleViewer, RoleOperator, RoleEngineer, RoleAdmin} {
+		parsed, err := ParseRole(role.String())
+		if err != nil || parsed != role {
+			t.Errorf("ParseRole(%q) = %v, %v", role.String(), parsed, err)
+		}
+	}
+	for _, name := range []string{"", "none", "Admin", "root"} {
+		if _, err := ParseRole(name); err == nil {
+			t.Errorf("ParseRole(%q) sem erro", name)
+		}
+	}
+}
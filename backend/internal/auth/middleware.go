@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"radar_go/pkg/logger"
+)
+
+// Middleware autentica a requisição e associa a identidade ao contexto.
+// Requisições sem credenciais válidas recebem 401.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, err := a.Authenticate(r)
+		if err != nil {
+			if !errors.Is(err, ErrNoCredentials) {
+				logger.Audit().Warnf("Autenticação recusada para %s %s de %s: %v", r.Method, r.URL.Path, r.RemoteAddr, err)
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="radar"`)
+			writeError(w, http.StatusUnauthorized, "Autenticação necessária")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identity)))
+	})
+}
+
+// RequireRole restringe o handler a identidades com ao menos o papel informado
+func RequireRole(role Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identity := FromContext(r.Context())
+		if identity == nil {
+			writeError(w, http.StatusUnauthorized, "Autenticação necessária")
+			return
+		}
+		if !identity.Role.Allows(role) {
+			logger.Audit().Warnf("Acesso negado a %s %s para %s (requer %s)", r.Method, r.URL.Path, identity, role)
+			writeError(w, http.StatusForbidden, "Permissão insuficiente: requer papel "+role.String())
+			return
+		}
+		next(w, r)
+	}
+}
+
+// AllowOrigin verifica se a origem pode acessar a API e o WebSocket. Sem
+// origens configuradas, ou sem cabeçalho Origin (clientes fora do navegador),
+// todas são aceitas.
+func (a *Authenticator) AllowOrigin(origin string) bool {
+	if len(a.origins) == 0 || origin == "" {
+		return true
+	}
+	return a.origins[strings.TrimSuffix(origin, "/")]
+}
+
+// SetCORSHeaders define Access-Control-Allow-Origin conforme as origens aceitas
+func (a *Authenticator) SetCORSHeaders(w http.ResponseWriter, r *http.Request) {
+	if len(a.origins) == 0 {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+
+	w.Header().Add("Vary", "Origin")
+	if origin := r.Header.Get("Origin"); origin != "" && a.AllowOrigin(origin) {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+}
+
+// writeError responde com {"error": message}
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"radar_go/internal/config"
+)
+
+// testKeys são as chaves de API usadas nos testes, uma por papel
+var testKeys = []config.APIKeyConfig{
+	{Name: "painel", Key: "chave-viewer", Role: "viewer"},
+	{Name: "operador", Key: "chave-operator", Role: "operator"},
+	{Name: "engenharia", Key: "chave-engineer", Role: "engineer"},
+	{Key: "chave-admin", Role: "admin"},
+}
+
+// serveAuthenticated executa a requisição pelo Middleware, retornando a
+// resposta e a identidade que chegou ao handler
+func serveAuthenticated(a *Authenticator, r *http.Request) (*httptest.ResponseRecorder, *Identity) {
+	var identity *Identity
+	handler := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity = FromContext(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w, identity
+}
+
+func TestMiddlewareCredentials(t *testing.T) {
+	a := newTestAuthenticator(t, config.AuthConfig{APIKeys: testKeys, JWT: config.JWTConfig{Secret: testSecret}})
+
+	operatorToken := sign(t, jwt.SigningMethodHS256, []byte(testSecret), validClaims("operator"))
+	expiredClaims := validClaims("admin")
+	expiredClaims["exp"] = time.Now().Add(-time.Hour).Unix()
+	expiredToken := sign(t, jwt.SigningMethodHS256, []byte(testSecret), expiredClaims)
+
+	tests := []struct {
+		name    string
+		target  string
+		headers map[string]string
+		status  int
+		subject string
+		role    Role
+		method  string
+	}{
+		{"sem credenciais", "/api/current", nil, http.StatusUnauthorized, "", RoleNone, ""},
+		{"Bearer com chave", "/api/current", map[string]string{"Authorization": "Bearer chave-viewer"},
+			http.StatusOK, "painel", RoleViewer, MethodAPIKey},
+		{"Bearer minúsculo", "/api/current", map[string]string{"Authorization": "bearer chave-engineer"},
+			http.StatusOK, "engenharia", RoleEngineer, MethodAPIKey},
+		{"Bearer com JWT", "/api/current", map[string]string{"Authorization": "Bearer " + operatorToken},
+			http.StatusOK, "maria", RoleOperator, MethodJWT},
+		{"X-API-Key", "/api/current", map[string]string{"X-API-Key": "chave-admin"},
+			http.StatusOK, "apikey-4", RoleAdmin, MethodAPIKey},
+		{"access_token", "/ws?access_token=" + operatorToken, nil, http.StatusOK, "maria", RoleOperator, MethodJWT},
+		{"api_key", "/api/stream?api_key=chave-operator", nil, http.StatusOK, "operador", RoleOperator, MethodAPIKey},
+		{"Bearer tem precedência", "/api/current?api_key=chave-admin",
+			map[string]string{"Authorization": "Bearer chave-viewer"}, http.StatusOK, "painel", RoleViewer, MethodAPIKey},
+
+		{"chave inválida", "/api/current", map[string]string{"X-API-Key": "chave-errada"},
+			http.StatusUnauthorized, "", RoleNone, ""},
+		{"api_key inválida", "/api/current?api_key=chave-errada", nil, http.StatusUnauthorized, "", RoleNone, ""},
+		{"JWT expirado", "/api/current", map[string]string{"Authorization": "Bearer " + expiredToken},
+			http.StatusUnauthorized, "", RoleNone, ""},
+		{"JWT expirado em access_token", "/ws?access_token=" + expiredToken, nil,
+			http.StatusUnauthorized, "", RoleNone, ""},
+		{"esquema Basic", "/api/current", map[string]string{"Authorization": "Basic Y2hhdmUtYWRtaW4="},
+			http.StatusUnauthorized, "", RoleNone, ""},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", tt.target, nil)
+		for name, value := range tt.headers {
+			r.Header.Set(name, value)
+		}
+
+		w, identity := serveAuthenticated(a, r)
+		if w.Code != tt.status {
+			t.Errorf("%s: status %d, esperado %d", tt.name, w.Code, tt.status)
+			continue
+		}
+		if tt.status == http.StatusUnauthorized {
+			if identity != nil {
+				t.Errorf("%s: handler executado sem autenticação", tt.name)
+			}
+			if w.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("%s: 401 sem WWW-Authenticate", tt.name)
+			}
+			continue
+		}
+		if identity == nil || identity.Subject != tt.subject || identity.Role != tt.role || identity.Method != tt.method {
+			t.Errorf("%s: identidade = %s, esperado %s/%s via %s", tt.name, identity, tt.subject, tt.role, tt.method)
+		}
+	}
+}
+
+func TestMiddlewareAnonymousRole(t *testing.T) {
+	a := newTestAuthenticator(t, config.AuthConfig{AnonymousRole: "viewer", APIKeys: testKeys})
+
+	w, identity := serveAuthenticated(a, httptest.NewRequest("GET", "/api/current", nil))
+	if w.Code != http.StatusOK || identity == nil || identity.Role != RoleViewer || identity.Method != MethodAnonymous {
+		t.Errorf("sem credenciais: status %d, identidade %s; esperado viewer anônimo", w.Code, identity)
+	}
+
+	// Credencial inválida não cai no papel anônimo
+	r := httptest.NewRequest("GET", "/api/current", nil)
+	r.Header.Set("X-API-Key", "chave-errada")
+	if w, _ := serveAuthenticated(a, r); w.Code != http.StatusUnauthorized {
+		t.Errorf("chave inválida com papel anônimo: status %d, esperado 401", w.Code)
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	roles := []Role{RoleViewer, RoleOperator, RoleEngineer, RoleAdmin}
+
+	for _, required := range roles {
+		handler := RequireRole(required, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		for _, role := range roles {
+			r := httptest.NewRequest("POST", "/api/admin/config", nil)
+			r = r.WithContext(WithIdentity(r.Context(), &Identity{Subject: "teste", Role: role, Method: MethodAPIKey}))
+
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			want := http.StatusForbidden
+			if role >= required {
+				want = http.StatusNoContent
+			}
+			if w.Code != want {
+				t.Errorf("papel %s em rota %s: status %d, esperado %d", role, required, w.Code, want)
+			}
+		}
+
+		// Sem identidade (rota fora do Middleware)
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("POST", "/api/admin/config", nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("rota %s sem identidade: status %d, esperado 401", required, w.Code)
+		}
+	}
+}
+
+func TestRoleOrder(t *testing.T) {
+	order := []Role{RoleNone, RoleViewer, RoleOperator, RoleEngineer, RoleAdmin}
+	for i, role := range order {
+		for j, required := range order {
+			if got := role.Allows(required); got != (i >= j) {
+				t.Errorf("%s.Allows(%s) = %v", role, required, got)
+			}
+		}
+	}
+}
+
+func TestAllowOrigin(t *testing.T) {
+	open := newTestAuthenticator(t, config.AuthConfig{APIKeys: testKeys})
+	if !open.AllowOrigin("https://qualquer") {
+		t.Error("sem origens configuradas, todas devem ser aceitas")
+	}
+
+	a := newTestAuthenticator(t, config.AuthConfig{APIKeys: testKeys, AllowedOrigins: []string{"https://painel.local/"}})
+	for origin, want := range map[string]bool{
+		"https://painel.local":  true,
+		"https://painel.local/": true,
+		"":                      true, // Clientes fora do navegador
+		"https://outro.local":   false,
+		"http://painel.local":   false,
+	} {
+		if got := a.AllowOrigin(origin); got != want {
+			t.Errorf("AllowOrigin(%q) = %v, esperado %v", origin, got, want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+)
+
+// Role é o papel de uma identidade; cada papel inclui as permissões dos anteriores
+type Role int
+
+const (
+	RoleNone     Role = iota
+	RoleViewer        // Leitura de dados, histórico e streams
+	RoleOperator      // Ações de operação (ex.: reconhecer alarmes)
+	RoleEngineer      // Diagnóstico e comandos ao radar/PLC
+	RoleAdmin         // Configuração
+)
+
+var roleNames = map[Role]string{
+	RoleViewer:   "viewer",
+	RoleOperator: "operator",
+	RoleEngineer: "engineer",
+	RoleAdmin:    "admin",
+}
+
+// ParseRole converte o nome de um papel
+func ParseRole(name string) (Role, error) {
+	for role, roleName := range roleNames {
+		if roleName == name {
+			return role, nil
+		}
+	}
+	return RoleNone, fmt.Errorf("papel desconhecido: %q (use viewer, operator, engineer ou admin)", name)
+}
+
+// String retorna o nome do papel
+func (r Role) String() string {
+	if name, ok := roleNames[r]; ok {
+		return name
+	}
+	return "none"
+}
+
+// MarshalText serializa o papel pelo nome
+func (r Role) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// Allows verifica se o papel inclui as permissões de required
+func (r Role) Allows(required Role) bool {
+	return r >= required
+}
+
+// Métodos de autenticação registrados na identidade
+const (
+	MethodNone      = "none"      // Autenticação desabilitada
+	MethodAnonymous = "anonymous" // Sem credenciais, com o papel anônimo
+	MethodAPIKey    = "apikey"
+	MethodJWT       = "jwt"
+)
+
+// Identity é a identidade autenticada de uma requisição ou conexão
+type Identity struct {
+	Subject string `json:"subject"`
+	Role    Role   `json:"role"`
+	Method  string `json:"method"`
+}
+
+// String formata a identidade para os logs (ex.: "maria/operator via jwt")
+func (i *Identity) String() string {
+	if i == nil {
+		return "desconhecido"
+	}
+	return fmt.Sprintf("%s/%s via %s", i.Subject, i.Role, i.Method)
+}
+
+type contextKey struct{}
+
+// WithIdentity associa a identidade ao contexto da requisição
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, identity)
+}
+
+// FromContext retorna a identidade da requisição, ou nil se não autenticada
+func FromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(contextKey{}).(*Identity)
+	return identity
+}
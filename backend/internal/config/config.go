@@ -1,17 +1,27 @@
 package config
 
 import (
-	"encoding/json"
+	"fmt"
 	"os"
 	"time"
 )
 
 // Config representa a configuração completa da aplicação
 type Config struct {
-	Server ServerConfig `json:"server"`
-	Radar  RadarConfig  `json:"radar"`
-	Redis  RedisConfig  `json:"redis"`
-	PLC    PLCConfig    `json:"plc"`
+	Server    ServerConfig    `json:"server"`
+	WebSocket WebSocketConfig `json:"websocket"`
+	Radar     RadarConfig     `json:"radar"`
+	Redis     RedisConfig     `json:"redis"`
+	PLC       PLCConfig       `json:"plc"`
+	Modbus    ModbusConfig    `json:"modbus"`
+	OPCUA     OPCUAConfig     `json:"opcua"`
+	MQTT      MQTTConfig      `json:"mqtt"`
+	Auth      AuthConfig      `json:"auth"`
+	Events    EventsConfig    `json:"events"`
+	Log       LogConfig       `json:"log"`
+
+	// Problemas encontrados na leitura do JSON, relatados por Validate
+	decodeErrors []FieldError
 }
 
 // ServerConfig contém configurações do servidor HTTP/WebSocket
@@ -20,10 +30,37 @@ type ServerConfig struct {
 	ReadTimeout     time.Duration `json:"readTimeout"`
 	WriteTimeout    time.Duration `json:"writeTimeout"`
 	ShutdownTimeout time.Duration `json:"shutdownTimeout"`
+	WatchInterval   time.Duration `json:"watchInterval"` // Verificação de mudanças no arquivo de configuração (0 desabilita; SIGHUP continua recarregando)
+	TLS             TLSConfig     `json:"tls"`
+}
+
+// TLSConfig contém configurações de HTTPS/WSS do servidor
+type TLSConfig struct {
+	Enabled  bool   `json:"enabled"`
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+
+	// CA dos certificados de cliente; se definido, exige TLS mútuo
+	ClientCAFile string `json:"clientCaFile"`
+
+	// Gera um certificado autoassinado se certFile/keyFile não existirem
+	AutoGenerate bool `json:"autoGenerate"`
+
+	// Nomes e IPs adicionais no certificado gerado (além do hostname e do IP local)
+	Hosts []string `json:"hosts"`
+}
+
+// WebSocketConfig contém configurações das conexões WebSocket
+type WebSocketConfig struct {
+	SendBufferSize   int           `json:"sendBufferSize"`   // Mensagens na fila de envio de cada cliente
+	Backpressure     string        `json:"backpressure"`     // Política padrão com a fila cheia: drop_oldest, coalesce ou downsample
+	DownsampleFactor int           `json:"downsampleFactor"` // downsample: entrega 1 de cada N métricas com a fila acima da metade
+	StallTimeout     time.Duration `json:"stallTimeout"`     // Tempo sem conseguir escrever até desconectar o cliente
 }
 
 // RadarConfig contém configurações do Radar SICK
 type RadarConfig struct {
+	ID                   string        `json:"id"` // Identificador do radar nas mensagens WebSocket
 	Host                 string        `json:"host"`
 	Port                 int           `json:"port"`
 	Protocol             string        `json:"protocol"`
@@ -37,10 +74,13 @@ type RadarConfig struct {
 type RedisConfig struct {
 	Host     string `json:"host"`
 	Port     int    `json:"port"`
-	Password string `json:"password"`
+	Password string `json:"password" secret:"true"`
 	DB       int    `json:"db"`
 	Prefix   string `json:"prefix"`
 	Enabled  bool   `json:"enabled"`
+
+	// Janela de amostras completas mantidas para o replay histórico
+	SampleRetention time.Duration `json:"sampleRetention"`
 }
 
 // PLCConfig contém configurações para comunicação com o PLC S71500
@@ -52,34 +92,209 @@ type PLCConfig struct {
 	UpdateRate   time.Duration `json:"updateRate"`
 	ReadTimeout  time.Duration `json:"readTimeout"`
 	WriteTimeout time.Duration `json:"writeTimeout"`
+	Heartbeat    string        `json:"heartbeat"` // Endereço do contador (WORD) incrementado a cada ciclo; vazio = desabilitado
+}
+
+// ModbusConfig contém configurações do servidor Modbus TCP
+type ModbusConfig struct {
+	Enabled       bool              `json:"enabled"`
+	Port          int               `json:"port"`
+	UnitID        int               `json:"unitId"`        // 0 = responder a qualquer unit ID
+	WordOrder     string            `json:"wordOrder"`     // Ordem dos FLOAT32: "ABCD", "CDAB", "BADC" ou "DCBA"
+	ScaleToInt16  bool              `json:"scaleToInt16"`  // Publicar valores como INT16 escalados em vez de FLOAT32
+	VelocityScale float64           `json:"velocityScale"` // Multiplicador das velocidades no modo INT16
+	PositionScale float64           `json:"positionScale"` // Multiplicador das posições no modo INT16
+	StaleTimeout  time.Duration     `json:"staleTimeout"`  // Tempo sem dados até sinalizar alarme
+	Registers     ModbusRegisterMap `json:"registers"`
+}
+
+// ModbusRegisterMap define os endereços iniciais (base 0) de cada bloco de registradores
+type ModbusRegisterMap struct {
+	Velocities int `json:"velocities"`
+	Positions  int `json:"positions"`
+	Status     int `json:"status"`
+	Alarms     int `json:"alarms"`
+	Heartbeat  int `json:"heartbeat"`
 }
 
-// Load carrega a configuração do arquivo ou usa valores padrão
+// OPCUAConfig contém configurações do servidor OPC UA
+type OPCUAConfig struct {
+	Enabled        bool          `json:"enabled"`
+	Port           int           `json:"port"`
+	Hostname       string        `json:"hostname"`       // Host anunciado na URL do endpoint (vazio = hostname da máquina)
+	ApplicationURI string        `json:"applicationUri"` // URI da aplicação servidora
+	NamespaceURI   string        `json:"namespaceUri"`   // Namespace dos nós do radar (ns=1)
+	Manufacturer   string        `json:"manufacturer"`   // Informação do dispositivo publicada em DeviceInfo
+	Model          string        `json:"model"`
+	MaxSessions    int           `json:"maxSessions"`
+	SessionTimeout time.Duration `json:"sessionTimeout"` // Timeout máximo de sessão aceito
+	StaleTimeout   time.Duration `json:"staleTimeout"`   // Tempo sem dados até sinalizar alarme
+	VelocityRange  OPCUARange    `json:"velocityRange"`  // EURange das variáveis de velocidade (m/s)
+	PositionRange  OPCUARange    `json:"positionRange"`  // EURange das variáveis de posição (m)
+}
+
+// OPCUARange define a faixa de engenharia de uma variável analógica
+type OPCUARange struct {
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+// MQTTConfig contém configurações do publicador MQTT
+type MQTTConfig struct {
+	Enabled           bool            `json:"enabled"`
+	Broker            string          `json:"broker"`   // Ex.: tcp://localhost:1883 ou ssl://broker:8883
+	ClientID          string          `json:"clientId"` // Vazio = radar_go-<hostname>
+	Username          string          `json:"username"`
+	Password          string          `json:"password" secret:"true"`
+	QoS               byte            `json:"qos"`    // QoS das publicações (0, 1 ou 2)
+	Retain            bool            `json:"retain"` // Retain das métricas, status e alarmes
+	KeepAlive         time.Duration   `json:"keepAlive"`
+	ConnectTimeout    time.Duration   `json:"connectTimeout"`  // Também usado como timeout de cada publicação
+	PublishInterval   time.Duration   `json:"publishInterval"` // Intervalo mínimo entre métricas (0 = todas)
+	StaleTimeout      time.Duration   `json:"staleTimeout"`    // Tempo sem dados até sinalizar alarme
+	TopicPrefix       string          `json:"topicPrefix"`
+	Topics            MQTTTopics      `json:"topics"`
+	BufferFile        string          `json:"bufferFile"`        // Buffer em disco enquanto offline (vazio = desabilitado)
+	BufferMaxMessages int             `json:"bufferMaxMessages"` // Mantém apenas as amostras mais recentes
+	Sparkplug         SparkplugConfig `json:"sparkplug"`
+}
+
+// MQTTTopics define os tópicos (relativos a TopicPrefix) de cada tipo de mensagem
+type MQTTTopics struct {
+	Metrics         string `json:"metrics"`
+	VelocityChanges string `json:"velocityChanges"`
+	Status          string `json:"status"`
+	Alarms          string `json:"alarms"`
+	Availability    string `json:"availability"` // "online"/"offline", também usado como LWT
+}
+
+// SparkplugConfig habilita a codificação Sparkplug B no lugar dos tópicos JSON
+type SparkplugConfig struct {
+	Enabled    bool   `json:"enabled"`
+	GroupID    string `json:"groupId"`
+	EdgeNodeID string `json:"edgeNodeId"`
+	DeviceID   string `json:"deviceId"`
+}
+
+// AuthConfig contém configurações de autenticação de /api e /ws
+type AuthConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// Papel atribuído a requisições sem credenciais ("" = recusar). Ex.:
+	// "viewer" para painéis somente leitura sem login.
+	AnonymousRole string `json:"anonymousRole"`
+
+	// Origens aceitas em CORS e no WebSocket (vazio = qualquer origem)
+	AllowedOrigins []string `json:"allowedOrigins"`
+
+	APIKeys []APIKeyConfig `json:"apiKeys"`
+	JWT     JWTConfig      `json:"jwt"`
+}
+
+// APIKeyConfig define uma chave de API e o papel concedido
+type APIKeyConfig struct {
+	Name string `json:"name"` // Identidade registrada nos logs
+	Key  string `json:"key" secret:"true"`
+	Role string `json:"role"` // viewer, operator, engineer ou admin
+}
+
+// JWTConfig define a validação de tokens JWT (HS256 e/ou RS256)
+type JWTConfig struct {
+	Secret        string        `json:"secret" secret:"true"` // Chave HS256 (vazio = HS256 desabilitado)
+	PublicKeyFile string        `json:"publicKeyFile"`        // Chave pública PEM para RS256 (vazio = RS256 desabilitado)
+	Issuer        string        `json:"issuer"`               // Claim iss exigida (vazio = não verificada)
+	Audience      string        `json:"audience"`             // Claim aud exigida (vazio = não verificada)
+	RoleClaim     string        `json:"roleClaim"`            // Claim com o papel (texto ou lista)
+	Leeway        time.Duration `json:"leeway"`               // Tolerância de relógio para exp/nbf
+}
+
+// EventsConfig contém configurações do registro de eventos operacionais
+// (conexões, status, alarmes, recargas e comandos). Os eventos vão para um
+// stream do Redis; sem Redis, para o arquivo File (JSON Lines).
+type EventsConfig struct {
+	Enabled   bool   `json:"enabled"`
+	File      string `json:"file"`      // Arquivo usado enquanto o Redis estiver indisponível
+	MaxEvents int    `json:"maxEvents"` // Eventos mantidos no stream e no arquivo (0 = sem limite)
+}
+
+// LogConfig contém configurações de log
+type LogConfig struct {
+	Level  string `json:"level"`  // debug, info, warn ou error
+	Format string `json:"format"` // text ou json (uma linha JSON por entrada, para coletores)
+	Dir    string `json:"dir"`    // Diretório dos arquivos de log (vazio = apenas terminal)
+
+	// Rotação de radar.log e radar_error.log em Dir
+	MaxSizeMB int           `json:"maxSizeMB"` // Tamanho máximo de cada arquivo (0 = sem limite)
+	Daily     bool          `json:"daily"`     // Rotacionar também na virada do dia
+	MaxFiles  int           `json:"maxFiles"`  // Arquivos rotacionados mantidos (0 = sem limite)
+	MaxAge    time.Duration `json:"maxAge"`    // Idade máxima dos arquivos rotacionados (0 = sem limite)
+	Compress  bool          `json:"compress"`  // Compactar os arquivos rotacionados com gzip
+
+	// Avisos e erros idênticos repetidos dentro da janela são escritos uma
+	// vez e resumidos em "repetida N vezes" (0 = escrever todos)
+	DedupWindow time.Duration `json:"dedupWindow"`
+}
+
+// Load carrega a configuração (padrões, arquivo, variáveis de ambiente e
+// flags) e a valida, relatando todos os problemas encontrados
 func Load() (*Config, error) {
+	config, err := Parse()
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// DefaultFile é o arquivo de configuração padrão, no diretório de trabalho.
+// Se não existir, são usados os valores padrão.
+const DefaultFile = "config.json"
+
+// Arquivo lido por Parse (alterado pela flag --config)
+var file = DefaultFile
+
+// SetFile define o arquivo de configuração. Diferente do padrão, um arquivo
+// definido explicitamente precisa existir.
+func SetFile(path string) {
+	file = path
+}
+
+// File retorna o arquivo de configuração em uso
+func File() string {
+	return file
+}
+
+// Parse carrega a configuração do arquivo ou usa valores padrão, sem validar.
+// Ordem de precedência: padrões, arquivo, variáveis de ambiente e flags.
+func Parse() (*Config, error) {
 	config := getDefaultConfig()
 
 	// Verificar se existe um arquivo de configuração
-	if _, err := os.Stat("config.json"); err == nil {
-		file, err := os.Open("config.json")
+	if _, err := os.Stat(file); err == nil {
+		f, err := os.Open(file)
 		if err != nil {
 			return nil, err
 		}
-		defer file.Close()
+		defer f.Close()
 
-		decoder := json.NewDecoder(file)
-		if err := decoder.Decode(&config); err != nil {
-			return nil, err
+		if err := decodeJSON(f, &config); err != nil {
+			return nil, fmt.Errorf("erro ao ler %s: %w", file, err)
 		}
+	} else if file != DefaultFile {
+		return nil, fmt.Errorf("arquivo de configuração não encontrado: %w", err)
 	}
 
 	// Sobrescrever com variáveis de ambiente, se existirem
-	applyEnvironmentOverrides(&config)
+	if err := applyEnvironmentOverrides(&config); err != nil {
+		return nil, fmt.Errorf("erro nas variáveis de ambiente: %w", err)
+	}
 
-	return &config, nil
-}
+	// Valores definidos por flags de linha de comando
+	if err := applyFlagOverrides(&config); err != nil {
+		return nil, fmt.Errorf("erro nas flags: %w", err)
+	}
 
-// applyEnvironmentOverrides sobrescreve configurações com variáveis de ambiente
-func applyEnvironmentOverrides(config *Config) {
-	// Implementar a lógica para substituir configurações por variáveis de ambiente
-	// Exemplo: RADAR_HOST, REDIS_PORT, SERVER_PORT, etc.
+	return &config, nil
 }
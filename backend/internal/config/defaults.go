@@ -10,8 +10,22 @@ func getDefaultConfig() Config {
 			ReadTimeout:     30 * time.Second,
 			WriteTimeout:    30 * time.Second,
 			ShutdownTimeout: 10 * time.Second,
+			WatchInterval:   2 * time.Second,
+			TLS: TLSConfig{
+				Enabled:      false,
+				CertFile:     "data/tls/server.crt",
+				KeyFile:      "data/tls/server.key",
+				AutoGenerate: true,
+			},
+		},
+		WebSocket: WebSocketConfig{
+			SendBufferSize:   256,
+			Backpressure:     "coalesce",
+			DownsampleFactor: 4,
+			StallTimeout:     30 * time.Second,
 		},
 		Radar: RadarConfig{
+			ID:                   "radar1",
 			Host:                 "192.168.1.84",
 			Port:                 2111,
 			Protocol:             "ascii",
@@ -27,6 +41,8 @@ func getDefaultConfig() Config {
 			DB:       0,
 			Prefix:   "radar_sick",
 			Enabled:  true,
+
+			SampleRetention: time.Hour,
 		},
 		PLC: PLCConfig{
 			Enabled:      false,
@@ -36,6 +52,90 @@ func getDefaultConfig() Config {
 			UpdateRate:   500 * time.Millisecond,
 			ReadTimeout:  5 * time.Second,
 			WriteTimeout: 5 * time.Second,
+			Heartbeat:    "DB10.DBW58",
+		},
+		Modbus: ModbusConfig{
+			Enabled:       false,
+			Port:          502,
+			UnitID:        0,
+			WordOrder:     "ABCD",
+			ScaleToInt16:  false,
+			VelocityScale: 100,  // 0,01 m/s por unidade
+			PositionScale: 1000, // 1 mm por unidade
+			StaleTimeout:  2 * time.Second,
+			Registers: ModbusRegisterMap{
+				Velocities: 0,
+				Positions:  100,
+				Status:     200,
+				Alarms:     201,
+				Heartbeat:  202,
+			},
+		},
+		OPCUA: OPCUAConfig{
+			Enabled:        false,
+			Port:           4840,
+			Hostname:       "",
+			ApplicationURI: "urn:radar_go:server",
+			NamespaceURI:   "urn:radar_go:radar",
+			Manufacturer:   "SICK",
+			Model:          "RMS1000",
+			MaxSessions:    10,
+			SessionTimeout: 60 * time.Second,
+			StaleTimeout:   2 * time.Second,
+			VelocityRange:  OPCUARange{Low: -50, High: 50},
+			PositionRange:  OPCUARange{Low: 0, High: 200},
+		},
+		MQTT: MQTTConfig{
+			Enabled:         false,
+			Broker:          "tcp://localhost:1883",
+			ClientID:        "",
+			QoS:             1,
+			Retain:          true,
+			KeepAlive:       30 * time.Second,
+			ConnectTimeout:  10 * time.Second,
+			PublishInterval: 0,
+			StaleTimeout:    2 * time.Second,
+			TopicPrefix:     "radar_sick",
+			Topics: MQTTTopics{
+				Metrics:         "metrics",
+				VelocityChanges: "velocity_changes",
+				Status:          "status",
+				Alarms:          "alarms",
+				Availability:    "availability",
+			},
+			BufferFile:        "data/mqtt_buffer.jsonl",
+			BufferMaxMessages: 10000,
+			Sparkplug: SparkplugConfig{
+				Enabled:    false,
+				GroupID:    "radar_go",
+				EdgeNodeID: "radar_sick",
+				DeviceID:   "RMS1000",
+			},
+		},
+		Auth: AuthConfig{
+			Enabled:       false,
+			AnonymousRole: "",
+			JWT: JWTConfig{
+				RoleClaim: "role",
+				Leeway:    30 * time.Second,
+			},
+		},
+		Events: EventsConfig{
+			Enabled:   true,
+			File:      "data/events.jsonl",
+			MaxEvents: 10000,
+		},
+		Log: LogConfig{
+			Level:     "info",
+			Format:    "text",
+			Dir:       "logs",
+			MaxSizeMB: 50,
+			Daily:     true,
+			MaxFiles:  14,
+			MaxAge:    30 * 24 * time.Hour,
+			Compress:  true,
+
+			DedupWindow: 10 * time.Second,
 		},
 	}
 }
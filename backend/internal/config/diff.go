@@ -0,0 +1,66 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Diff lista os caminhos em JSON dos campos alterados entre duas
+// configurações (ex.: "radar.sampleRate", "redis.prefix"). Listas são
+// comparadas por inteiro.
+func Diff(old, new *Config) []string {
+	return diffValues(reflect.ValueOf(*old), reflect.ValueOf(*new), "")
+}
+
+// diffValues compara os valores recursivamente, descendo em structs
+func diffValues(old, new reflect.Value, path string) []string {
+	if old.Kind() != reflect.Struct || old.Type() == durationType {
+		if reflect.DeepEqual(old.Interface(), new.Interface()) {
+			return nil
+		}
+		return []string{path}
+	}
+
+	var changed []string
+	t := old.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := jsonName(field)
+		if name == "-" {
+			continue
+		}
+		changed = append(changed, diffValues(old.Field(i), new.Field(i), joinPath(path, name))...)
+	}
+	return changed
+}
+
+// Sections retorna as seções de primeiro nível presentes em paths
+// (ex.: "radar.host" → "radar"), sem repetição
+func Sections(paths []string) []string {
+	var sections []string
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		section := strings.SplitN(path, ".", 2)[0]
+		if !seen[section] {
+			seen[section] = true
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
+
+// RestoreSection copia de from a seção de primeiro nível name (ex.: "modbus")
+func (c *Config) RestoreSection(from *Config, name string) {
+	dst := reflect.ValueOf(c).Elem()
+	src := reflect.ValueOf(from).Elem()
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Type().Field(i)
+		if field.IsExported() && jsonName(field) == name {
+			dst.Field(i).Set(src.Field(i))
+			return
+		}
+	}
+}
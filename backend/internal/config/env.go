@@ -0,0 +1,183 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"radar_go/pkg/logger"
+)
+
+// Prefixo das variáveis de ambiente. Cada campo de Config é mapeado pelo
+// caminho das tags json: Radar.Host → RADAR_RADAR_HOST, PLC.Enabled →
+// RADAR_PLC_ENABLED, Modbus.Registers.Status → RADAR_MODBUS_REGISTERS_STATUS.
+const envPrefix = "RADAR"
+
+// Sufixo das variáveis que apontam para um arquivo com o valor (segredos
+// montados pelo orquestrador, ex.: RADAR_REDIS_PASSWORD_FILE=/run/secrets/redis)
+const envFileSuffix = "_FILE"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnvironmentOverrides sobrescreve configurações com variáveis de ambiente.
+//
+// Formatos: durações como "500ms" ou "2m", bools como "true"/"1", listas de
+// texto separadas por vírgula e demais listas/objetos em JSON
+// (ex.: RADAR_AUTH_API_KEYS='[{"name":"scada","key":"...","role":"viewer"}]').
+func applyEnvironmentOverrides(config *Config) error {
+	applied, err := overrideStruct(reflect.ValueOf(config).Elem(), envPrefix)
+	if err != nil {
+		return err
+	}
+
+	if len(applied) > 0 {
+		// Apenas os nomes: os valores podem conter segredos
+		logger.Infof("Configuração sobrescrita por variáveis de ambiente: %s", strings.Join(applied, ", "))
+	}
+	return nil
+}
+
+// overrideStruct aplica as variáveis aos campos de v, recursivamente, e
+// retorna os nomes das variáveis usadas
+func overrideStruct(v reflect.Value, prefix string) ([]string, error) {
+	var applied []string
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := jsonName(field)
+		if tag == "-" {
+			continue
+		}
+		name := prefix + "_" + envName(tag)
+
+		value := v.Field(i)
+		if value.Kind() == reflect.Struct {
+			nested, err := overrideStruct(value, name)
+			if err != nil {
+				return nil, err
+			}
+			applied = append(applied, nested...)
+			continue
+		}
+
+		raw, source, ok, err := lookupEnv(name)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if err := setValue(value, raw); err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		applied = append(applied, source)
+	}
+	return applied, nil
+}
+
+// lookupEnv lê o arquivo indicado por <nome>_FILE ou, na sua ausência, a
+// variável. O arquivo tem precedência: é a forma recomendada para segredos.
+func lookupEnv(name string) (value, source string, ok bool, err error) {
+	if path, fromFile := os.LookupEnv(name + envFileSuffix); fromFile {
+		if _, direct := os.LookupEnv(name); direct {
+			logger.Warnf("%s e %s definidas ao mesmo tempo; usando %s", name, name+envFileSuffix, name+envFileSuffix)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", false, fmt.Errorf("%s: %w", name+envFileSuffix, err)
+		}
+		// Arquivos de segredo costumam terminar com quebra de linha
+		return strings.TrimRight(string(data), "\r\n"), name + envFileSuffix, true, nil
+	}
+
+	if value, direct := os.LookupEnv(name); direct {
+		return value, name, true, nil
+	}
+	return "", "", false, nil
+}
+
+// setValue converte o texto para o tipo do campo
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("duração inválida %q (use ex.: 500ms, 30s, 2m)", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("valor booleano inválido %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("número inteiro inválido %q", raw)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("número inteiro inválido %q", raw)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("número inválido %q", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(raw), "[") {
+			items := []string{}
+			for _, item := range strings.Split(raw, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			v.Set(reflect.ValueOf(items))
+			return nil
+		}
+		fallthrough
+	default:
+		if err := json.Unmarshal([]byte(raw), v.Addr().Interface()); err != nil {
+			return fmt.Errorf("JSON inválido: %w", err)
+		}
+	}
+	return nil
+}
+
+// envName converte um nome camelCase para MAIÚSCULAS_COM_SUBLINHADO
+// (ex.: "clientCaFile" → "CLIENT_CA_FILE", "unitId" → "UNIT_ID")
+func envName(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,252 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvName(t *testing.T) {
+	for name, want := range map[string]string{
+		"host":                 "HOST",
+		"sampleRate":           "SAMPLE_RATE",
+		"unitId":               "UNIT_ID",
+		"clientCaFile":         "CLIENT_CA_FILE",
+		"maxConsecutiveErrors": "MAX_CONSECUTIVE_ERRORS",
+		"opcua":                "OPCUA",
+		"applicationURI":       "APPLICATION_URI",
+		"ipv6Enabled":          "IPV6_ENABLED",
+	} {
+		if got := envName(name); got != want {
+			t.Errorf("envName(%q) = %q, esperado %q", name, got, want)
+		}
+	}
+}
+
+// envNames lista os nomes de variáveis gerados para os campos de t
+func envNames(t reflect.Type, prefix string) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || jsonName(field) == "-" {
+			continue
+		}
+		name := prefix + "_" + envName(jsonName(field))
+		if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+			names = append(names, envNames(field.Type, name)...)
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestEnvNamesGenerated(t *testing.T) {
+	names := envNames(reflect.TypeOf(Config{}), envPrefix)
+
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			t.Errorf("variável %s gerada para dois campos", name)
+		}
+		seen[name] = true
+	}
+
+	for _, want := range []string{
+		"RADAR_RADAR_HOST",
+		"RADAR_RADAR_SAMPLE_RATE",
+		"RADAR_PLC_ENABLED",
+		"RADAR_REDIS_PASSWORD",
+		"RADAR_MODBUS_UNIT_ID",
+		"RADAR_MODBUS_REGISTERS_STATUS",
+		"RADAR_SERVER_TLS_CLIENT_CA_FILE",
+		"RADAR_MQTT_SPARKPLUG_EDGE_NODE_ID",
+		"RADAR_AUTH_API_KEYS",
+		"RADAR_AUTH_JWT_ROLE_CLAIM",
+	} {
+		if !seen[want] {
+			t.Errorf("variável %s não gerada", want)
+		}
+	}
+}
+
+// envTarget reúne um campo de cada tipo aceito por setValue
+type envTarget struct {
+	Text     string
+	Duration time.Duration
+	Enabled  bool
+	Count    int
+	Small    int8
+	QoS      byte
+	Scale    float64
+	Hosts    []string
+	Ports    []int
+	Keys     []APIKeyConfig
+	Range    OPCUARange
+}
+
+func TestSetValue(t *testing.T) {
+	tests := []struct {
+		field string
+		raw   string
+		want  interface{}
+	}{
+		{"Text", " com espaços ", " com espaços "},
+		{"Duration", "500ms", 500 * time.Millisecond},
+		{"Duration", "2m30s", 150 * time.Second},
+		{"Enabled", "true", true},
+		{"Enabled", "1", true},
+		{"Enabled", "FALSE", false},
+		{"Count", "-42", -42},
+		{"Small", "127", int8(127)},
+		{"QoS", "2", byte(2)},
+		{"Scale", "0.001", 0.001},
+		{"Hosts", "a.local, 10.0.0.1 ,,b", []string{"a.local", "10.0.0.1", "b"}},
+		{"Hosts", `["x,y", "z"]`, []string{"x,y", "z"}},
+		{"Hosts", "", []string{}},
+		{"Ports", "[502, 503]", []int{502, 503}},
+		{"Keys", `[{"name":"scada","key":"k","role":"viewer"}]`, []APIKeyConfig{{Name: "scada", Key: "k", Role: "viewer"}}},
+		{"Range", `{"low":-5,"high":5}`, OPCUARange{Low: -5, High: 5}},
+	}
+
+	for _, tt := range tests {
+		var target envTarget
+		field := reflect.ValueOf(&target).Elem().FieldByName(tt.field)
+		if err := setValue(field, tt.raw); err != nil {
+			t.Errorf("setValue(%s, %q): %v", tt.field, tt.raw, err)
+			continue
+		}
+		if got := field.Interface(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("setValue(%s, %q) = %#v, esperado %#v", tt.field, tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestSetValueErrors(t *testing.T) {
+	tests := []struct {
+		field string
+		raw   string
+		want  string
+	}{
+		{"Duration", "500", "duração inválida"},
+		{"Duration", "1 minuto", "duração inválida"},
+		{"Enabled", "sim", "booleano inválido"},
+		{"Count", "1.5", "inteiro inválido"},
+		{"Small", "128", "inteiro inválido"}, // Fora do intervalo do int8
+		{"QoS", "-1", "inteiro inválido"},
+		{"Scale", "um", "número inválido"},
+		{"Ports", "502,503", "JSON inválido"},
+		{"Keys", `{"name":"scada"}`, "JSON inválido"},
+	}
+
+	for _, tt := range tests {
+		var target envTarget
+		field := reflect.ValueOf(&target).Elem().FieldByName(tt.field)
+		err := setValue(field, tt.raw)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("setValue(%s, %q) = %v, esperado %q", tt.field, tt.raw, err, tt.want)
+		}
+	}
+}
+
+func TestApplyEnvironmentOverrides(t *testing.T) {
+	t.Setenv("RADAR_RADAR_HOST", "10.1.2.3")
+	t.Setenv("RADAR_RADAR_SAMPLE_RATE", "50ms")
+	t.Setenv("RADAR_PLC_ENABLED", "true")
+	t.Setenv("RADAR_MODBUS_REGISTERS_STATUS", "300")
+	t.Setenv("RADAR_AUTH_ALLOWED_ORIGINS", "https://a, https://b")
+
+	config := getDefaultConfig()
+	if err := applyEnvironmentOverrides(&config); err != nil {
+		t.Fatalf("applyEnvironmentOverrides: %v", err)
+	}
+
+	if config.Radar.Host != "10.1.2.3" || config.Radar.SampleRate != 50*time.Millisecond ||
+		!config.PLC.Enabled || config.Modbus.Registers.Status != 300 ||
+		!reflect.DeepEqual(config.Auth.AllowedOrigins, []string{"https://a", "https://b"}) {
+		t.Errorf("configuração após as variáveis: radar %+v, plc %v, status %d, origens %v",
+			config.Radar, config.PLC.Enabled, config.Modbus.Registers.Status, config.Auth.AllowedOrigins)
+	}
+
+	// Campos sem variável mantêm o padrão
+	if defaults := getDefaultConfig(); config.Radar.Port != defaults.Radar.Port {
+		t.Errorf("porta do radar = %d, esperado o padrão %d", config.Radar.Port, defaults.Radar.Port)
+	}
+
+	// O erro indica a variável
+	t.Setenv("RADAR_RADAR_PORT", "porta")
+	err := applyEnvironmentOverrides(&config)
+	if err == nil || !strings.HasPrefix(err.Error(), "RADAR_RADAR_PORT:") {
+		t.Errorf("erro = %v, esperado prefixo RADAR_RADAR_PORT", err)
+	}
+}
+
+// writeSecret grava um arquivo de segredo e retorna o caminho
+func writeSecret(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEnvironmentFileSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		plain   *string
+		want    string
+	}{
+		{"quebra de linha final", "s3nha\n", nil, "s3nha"},
+		{"CRLF final", "s3nha\r\n", nil, "s3nha"},
+		{"várias quebras", "s3nha\n\n", nil, "s3nha"},
+		{"espaços preservados", " s3nha \n", nil, " s3nha "},
+		{"quebra interna preservada", "linha1\nlinha2\n", nil, "linha1\nlinha2"},
+		{"precedência sobre a variável", "do-arquivo\n", ptr("da-variavel"), "do-arquivo"},
+		{"arquivo vazio", "", ptr("da-variavel"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RADAR_REDIS_PASSWORD_FILE", writeSecret(t, tt.content))
+			if tt.plain != nil {
+				t.Setenv("RADAR_REDIS_PASSWORD", *tt.plain)
+			}
+
+			config := getDefaultConfig()
+			if err := applyEnvironmentOverrides(&config); err != nil {
+				t.Fatalf("applyEnvironmentOverrides: %v", err)
+			}
+			if config.Redis.Password != tt.want {
+				t.Errorf("senha do Redis = %q, esperado %q", config.Redis.Password, tt.want)
+			}
+		})
+	}
+
+	// _FILE também vale para campos que não são segredos
+	t.Run("campo numérico", func(t *testing.T) {
+		t.Setenv("RADAR_RADAR_PORT_FILE", writeSecret(t, "2112\n"))
+		config := getDefaultConfig()
+		if err := applyEnvironmentOverrides(&config); err != nil || config.Radar.Port != 2112 {
+			t.Errorf("porta do radar = %d, %v; esperado 2112", config.Radar.Port, err)
+		}
+	})
+
+	t.Run("arquivo inexistente", func(t *testing.T) {
+		t.Setenv("RADAR_REDIS_PASSWORD_FILE", filepath.Join(t.TempDir(), "nao-existe"))
+		config := getDefaultConfig()
+		err := applyEnvironmentOverrides(&config)
+		if err == nil || !strings.Contains(err.Error(), "RADAR_REDIS_PASSWORD_FILE") {
+			t.Errorf("erro = %v, esperado menção a RADAR_REDIS_PASSWORD_FILE", err)
+		}
+	})
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// FileEnv é a variável de ambiente equivalente à flag --config
+const FileEnv = "RADAR_CONFIG"
+
+// override é um valor definido por flag de linha de comando
+type override struct {
+	path  string // Caminho em JSON do campo (ex.: "server.port")
+	value string
+}
+
+// Valores das flags, reaplicados a cada Parse (inclusive nas recargas)
+var overrides []override
+
+// SetOverride define o valor de um campo pelo caminho em JSON, com a mesma
+// conversão das variáveis de ambiente (ex.: "log.level", "debug"). Tem
+// precedência sobre o arquivo e o ambiente.
+func SetOverride(path, value string) error {
+	// Verificar caminho e valor agora, para a flag falhar na inicialização
+	config := getDefaultConfig()
+	field, err := fieldByPath(reflect.ValueOf(&config).Elem(), path)
+	if err != nil {
+		return err
+	}
+	if err := setValue(field, value); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	overrides = append(overrides, override{path: path, value: value})
+	return nil
+}
+
+// FileFlag registra a flag --config (padrão: $RADAR_CONFIG ou config.json).
+// Após fs.Parse, o valor deve ser passado para SetFile.
+func FileFlag(fs *flag.FlagSet) *string {
+	path := DefaultFile
+	if env := os.Getenv(FileEnv); env != "" {
+		path = env
+	}
+	return fs.String("config", path, "Arquivo de configuração (env: "+FileEnv+")")
+}
+
+// BindFlag registra uma flag que define o campo path da configuração
+// (ex.: --port → "server.port"), indicando o campo na ajuda. Campos bool
+// aceitam a flag sem valor (--redis equivale a --redis=true).
+func BindFlag(fs *flag.FlagSet, name, path, usage string) {
+	defaults := getDefaultConfig()
+	field, err := fieldByPath(reflect.ValueOf(&defaults).Elem(), path)
+	if err != nil {
+		panic(fmt.Sprintf("flag --%s: %v", name, err))
+	}
+
+	fs.Var(&overrideFlag{path: path, isBool: field.Kind() == reflect.Bool},
+		name, fmt.Sprintf("%s (config: %s)", usage, path))
+}
+
+// overrideFlag é o flag.Value das flags registradas por BindFlag
+type overrideFlag struct {
+	path   string
+	value  string
+	isBool bool
+}
+
+func (f *overrideFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.value
+}
+
+func (f *overrideFlag) Set(value string) error {
+	if err := SetOverride(f.path, value); err != nil {
+		return err
+	}
+	f.value = value
+	return nil
+}
+
+// IsBoolFlag permite a forma sem valor para campos bool
+func (f *overrideFlag) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// applyFlagOverrides aplica os valores definidos por SetOverride
+func applyFlagOverrides(config *Config) error {
+	for _, o := range overrides {
+		field, err := fieldByPath(reflect.ValueOf(config).Elem(), o.path)
+		if err != nil {
+			return err
+		}
+		if err := setValue(field, o.value); err != nil {
+			return fmt.Errorf("%s: %w", o.path, err)
+		}
+	}
+	return nil
+}
+
+// fieldByPath encontra o campo pelo caminho em JSON (ex.: "server.tls.enabled")
+func fieldByPath(v reflect.Value, path string) (reflect.Value, error) {
+	for _, name := range strings.Split(path, ".") {
+		if v.Kind() != reflect.Struct || v.Type() == durationType {
+			return reflect.Value{}, fmt.Errorf("campo desconhecido %q", path)
+		}
+		field, _, ok := fieldByJSONName(v.Type(), name)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("campo desconhecido %q", path)
+		}
+		v = v.FieldByIndex(field.Index)
+	}
+	return v, nil
+}
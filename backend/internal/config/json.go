@@ -0,0 +1,178 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Valor exibido no lugar dos campos marcados com `secret:"true"`
+const redacted = "********"
+
+// decodeJSON lê a configuração em JSON sobre os valores atuais de config.
+// Durações aceitam texto ("100ms", "2m") além de nanossegundos; campos
+// desconhecidos e durações inválidas são guardados para Validate.
+func decodeJSON(r io.Reader, config *Config) error {
+	var raw interface{}
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return err
+	}
+
+	normalized, problems := normalizeJSON(raw, reflect.TypeOf(*config), "")
+	data, err := json.Marshal(normalized)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return err
+	}
+
+	config.decodeErrors = problems
+	return nil
+}
+
+// normalizeJSON percorre o valor decodificado junto com o tipo Go de destino,
+// convertendo durações em texto para nanossegundos
+func normalizeJSON(raw interface{}, t reflect.Type, path string) (interface{}, []FieldError) {
+	if t == durationType {
+		text, ok := raw.(string)
+		if !ok {
+			return raw, nil
+		}
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			// Removido para manter o valor padrão; o erro vai para o relatório
+			return nil, []FieldError{{Path: path, Message: fmt.Sprintf("duração inválida %q (use ex.: 500ms, 30s, 2m)", text)}}
+		}
+		return int64(d), nil
+	}
+
+	var problems []FieldError
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return raw, nil
+		}
+		// Em ordem, para um relatório estável
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			value := obj[key]
+			field, name, ok := fieldByJSONName(t, key)
+			if !ok {
+				problems = append(problems, FieldError{Path: joinPath(path, key), Message: "campo desconhecido"})
+				continue
+			}
+			normalized, nested := normalizeJSON(value, field.Type, joinPath(path, name))
+			obj[key] = normalized
+			problems = append(problems, nested...)
+		}
+	case reflect.Slice:
+		list, ok := raw.([]interface{})
+		if !ok {
+			return raw, nil
+		}
+		for i, item := range list {
+			normalized, nested := normalizeJSON(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+			list[i] = normalized
+			problems = append(problems, nested...)
+		}
+	}
+	return raw, problems
+}
+
+// fieldByJSONName encontra o campo pela tag json, sem diferenciar maiúsculas
+// (como encoding/json)
+func fieldByJSONName(t reflect.Type, key string) (reflect.StructField, string, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := jsonName(field)
+		if name != "-" && strings.EqualFold(name, key) {
+			return field, name, true
+		}
+	}
+	return reflect.StructField{}, "", false
+}
+
+// jsonName retorna o nome do campo em JSON
+func jsonName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// joinPath monta o caminho de um campo aninhado (ex.: "server.tls.certFile")
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// MarshalReadable serializa a configuração efetiva para exibição: durações
+// como texto e segredos mascarados
+func (c *Config) MarshalReadable() ([]byte, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(readableJSON(raw, reflect.TypeOf(*c), false), "", "  ")
+}
+
+// readableJSON converte durações para texto e mascara segredos preenchidos
+func readableJSON(raw interface{}, t reflect.Type, secret bool) interface{} {
+	if t == durationType {
+		if n, ok := raw.(float64); ok {
+			return time.Duration(n).String()
+		}
+		return raw
+	}
+	if secret {
+		if text, ok := raw.(string); ok && text != "" {
+			return redacted
+		}
+		return raw
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return raw
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := jsonName(field)
+			if value, ok := obj[name]; ok && field.IsExported() {
+				obj[name] = readableJSON(value, field.Type, field.Tag.Get("secret") == "true")
+			}
+		}
+	case reflect.Slice:
+		if list, ok := raw.([]interface{}); ok {
+			for i, item := range list {
+				list[i] = readableJSON(item, t.Elem(), false)
+			}
+		}
+	}
+	return raw
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// decodeString aplica o JSON sobre a configuração padrão
+func decodeString(t *testing.T, data string) Config {
+	t.Helper()
+
+	config := getDefaultConfig()
+	if err := decodeJSON(strings.NewReader(data), &config); err != nil {
+		t.Fatalf("decodeJSON: %v", err)
+	}
+	return config
+}
+
+func TestDecodeJSONDurations(t *testing.T) {
+	config := decodeString(t, `{
+		"radar": {"sampleRate": "250ms", "reconnectDelay": 2000000000},
+		"plc": {"updateRate": "1m30s", "readTimeout": 500000000},
+		"server": {"tls": {"enabled": false}, "shutdownTimeout": "10s"},
+		"auth": {"jwt": {"leeway": "30s"}}
+	}`)
+
+	tests := []struct {
+		path string
+		got  time.Duration
+		want time.Duration
+	}{
+		{"radar.sampleRate", config.Radar.SampleRate, 250 * time.Millisecond},
+		{"radar.reconnectDelay", config.Radar.ReconnectDelay, 2 * time.Second},
+		{"plc.updateRate", config.PLC.UpdateRate, 90 * time.Second},
+		{"plc.readTimeout", config.PLC.ReadTimeout, 500 * time.Millisecond},
+		{"server.shutdownTimeout", config.Server.ShutdownTimeout, 10 * time.Second},
+		{"auth.jwt.leeway", config.Auth.JWT.Leeway, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, esperado %v", tt.path, tt.got, tt.want)
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+
+	// Campos ausentes mantêm o padrão
+	if defaults := getDefaultConfig(); config.Radar.Host != defaults.Radar.Host || config.PLC.WriteTimeout != defaults.PLC.WriteTimeout {
+		t.Errorf("padrões perdidos: radar.host %q, plc.writeTimeout %v", config.Radar.Host, config.PLC.WriteTimeout)
+	}
+}
+
+func TestDecodeJSONProblems(t *testing.T) {
+	config := decodeString(t, `{
+		"radar": {"sampleRate": "rápido", "hots": "10.0.0.1", "Port": 2112},
+		"auth": {"apiKeys": [{"key": "k", "role": "viewer"}, {"key": "k2", "role": "admin", "scope": "x"}]},
+		"mqtt": {"connectTimeout": "5 s"},
+		"extra": true
+	}`)
+
+	// Duração inválida mantém o padrão; chaves sem diferenciar maiúsculas
+	if defaults := getDefaultConfig(); config.Radar.SampleRate != defaults.Radar.SampleRate {
+		t.Errorf("radar.sampleRate = %v, esperado o padrão %v", config.Radar.SampleRate, defaults.Radar.SampleRate)
+	}
+	if config.Radar.Port != 2112 {
+		t.Errorf("radar.port = %d, esperado 2112", config.Radar.Port)
+	}
+
+	want := []string{"auth.apiKeys[1].scope", "extra", "mqtt.connectTimeout", "radar.hots", "radar.sampleRate"}
+	if got := errorPaths(t, config.Validate()); !reflect.DeepEqual(got, want) {
+		t.Errorf("caminhos = %v, esperado %v", got, want)
+	}
+
+	for _, fieldErr := range config.decodeErrors {
+		if fieldErr.Path == "radar.sampleRate" && !strings.Contains(fieldErr.Message, `"rápido"`) {
+			t.Errorf("mensagem sem o valor recebido: %s", fieldErr.Message)
+		}
+	}
+}
+
+func TestDecodeJSONSyntaxError(t *testing.T) {
+	config := getDefaultConfig()
+	if err := decodeJSON(strings.NewReader(`{"radar": {`), &config); err == nil {
+		t.Error("decodeJSON sem erro para JSON truncado")
+	}
+	if err := decodeJSON(strings.NewReader(`{"radar": {"port": "2112"}}`), &config); err == nil {
+		t.Error("decodeJSON sem erro para tipo incompatível")
+	}
+}
+
+func TestMarshalReadable(t *testing.T) {
+	config := getDefaultConfig()
+	config.Redis.Password = "segredo"
+	config.Auth.APIKeys = []APIKeyConfig{{Name: "scada", Key: "chave", Role: "viewer"}}
+
+	data, err := config.MarshalReadable()
+	if err != nil {
+		t.Fatalf("MarshalReadable: %v", err)
+	}
+	text := string(data)
+	if strings.Contains(text, "segredo") || strings.Contains(text, `"chave"`) {
+		t.Errorf("segredos expostos:\n%s", text)
+	}
+
+	var readable struct {
+		Radar struct {
+			SampleRate string `json:"sampleRate"`
+		} `json:"radar"`
+		Redis struct {
+			Password string `json:"password"`
+		} `json:"redis"`
+		Auth struct {
+			JWT struct {
+				Secret string `json:"secret"`
+			} `json:"jwt"`
+		} `json:"auth"`
+	}
+	if err := json.Unmarshal(data, &readable); err != nil {
+		t.Fatal(err)
+	}
+	if readable.Radar.SampleRate != config.Radar.SampleRate.String() {
+		t.Errorf("radar.sampleRate = %q, esperado %q", readable.Radar.SampleRate, config.Radar.SampleRate)
+	}
+	if readable.Redis.Password != redacted || readable.Auth.JWT.Secret != "" {
+		t.Errorf("redis.password = %q, auth.jwt.secret = %q", readable.Redis.Password, readable.Auth.JWT.Secret)
+	}
+
+	// A saída legível é aceita de volta como configuração
+	config.Redis.Password = ""
+	config.Auth.APIKeys = nil
+	data, err = config.MarshalReadable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := decodeString(t, string(data))
+	if err := decoded.Validate(); err != nil {
+		t.Errorf("saída de MarshalReadable inválida: %v", err)
+	}
+	if decoded.Radar != config.Radar || decoded.PLC != config.PLC || decoded.Modbus != config.Modbus {
+		t.Error("saída de MarshalReadable não reproduz a configuração")
+	}
+}
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// FieldError é um problema em um campo da configuração, identificado pelo
+// caminho em JSON (ex.: "radar.sampleRate")
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidationErrors reúne todos os problemas encontrados por Validate
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, 0, len(e)+1)
+	lines = append(lines, fmt.Sprintf("configuração inválida (%d problema(s)):", len(e)))
+	for _, fieldErr := range e {
+		lines = append(lines, "  - "+fieldErr.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Papéis aceitos em auth (definidos em internal/auth)
+var validRoles = []string{"viewer", "operator", "engineer", "admin"}
+
+// Validate verifica a configuração e retorna ValidationErrors com todos os
+// problemas encontrados, ou nil. Seções desabilitadas não são verificadas.
+func (c *Config) Validate() error {
+	v := &validator{}
+	v.errs = append(v.errs, c.decodeErrors...)
+
+	// Servidor
+	v.port("server.port", c.Server.Port)
+	v.nonNegative("server.readTimeout", c.Server.ReadTimeout)
+	v.nonNegative("server.writeTimeout", c.Server.WriteTimeout)
+	v.positive("server.shutdownTimeout", c.Server.ShutdownTimeout)
+	v.nonNegative("server.watchInterval", c.Server.WatchInterval)
+	if c.Server.TLS.Enabled {
+		v.required("server.tls.certFile", c.Server.TLS.CertFile)
+		v.required("server.tls.keyFile", c.Server.TLS.KeyFile)
+	}
+
+	// WebSocket
+	v.oneOf("websocket.backpressure", c.WebSocket.Backpressure, "drop_oldest", "coalesce", "downsample")
+	v.min("websocket.sendBufferSize", c.WebSocket.SendBufferSize, 2)
+	v.min("websocket.downsampleFactor", c.WebSocket.DownsampleFactor, 1)
+	v.positive("websocket.stallTimeout", c.WebSocket.StallTimeout)
+
+	// Radar
+	v.required("radar.id", c.Radar.ID)
+	v.required("radar.host", c.Radar.Host)
+	v.port("radar.port", c.Radar.Port)
+	v.oneOf("radar.protocol", strings.ToLower(c.Radar.Protocol), "ascii", "binary")
+	v.positive("radar.sampleRate", c.Radar.SampleRate)
+	v.min("radar.maxConsecutiveErrors", c.Radar.MaxConsecutiveErrors, 1)
+	v.positive("radar.reconnectDelay", c.Radar.ReconnectDelay)
+
+	// Redis
+	if c.Redis.Enabled {
+		v.required("redis.host", c.Redis.Host)
+		v.port("redis.port", c.Redis.Port)
+		v.min("redis.db", c.Redis.DB, 0)
+		v.required("redis.prefix", c.Redis.Prefix)
+		v.positive("redis.sampleRetention", c.Redis.SampleRetention)
+	}
+
+	// PLC
+	if c.PLC.Enabled {
+		v.required("plc.host", c.PLC.Host)
+		v.between("plc.rack", c.PLC.Rack, 0, 7)
+		v.between("plc.slot", c.PLC.Slot, 0, 31)
+		v.positive("plc.updateRate", c.PLC.UpdateRate)
+		v.positive("plc.readTimeout", c.PLC.ReadTimeout)
+		v.positive("plc.writeTimeout", c.PLC.WriteTimeout)
+	}
+
+	// Modbus
+	if c.Modbus.Enabled {
+		v.port("modbus.port", c.Modbus.Port)
+		v.between("modbus.unitId", c.Modbus.UnitID, 0, 247)
+		if c.Modbus.WordOrder != "" {
+			v.oneOf("modbus.wordOrder", strings.ToUpper(c.Modbus.WordOrder), "ABCD", "CDAB", "BADC", "DCBA")
+		}
+		if c.Modbus.ScaleToInt16 {
+			v.positiveFloat("modbus.velocityScale", c.Modbus.VelocityScale)
+			v.positiveFloat("modbus.positionScale", c.Modbus.PositionScale)
+		}
+		v.positive("modbus.staleTimeout", c.Modbus.StaleTimeout)
+		v.between("modbus.registers.velocities", c.Modbus.Registers.Velocities, 0, 65535)
+		v.between("modbus.registers.positions", c.Modbus.Registers.Positions, 0, 65535)
+		v.between("modbus.registers.status", c.Modbus.Registers.Status, 0, 65535)
+		v.between("modbus.registers.alarms", c.Modbus.Registers.Alarms, 0, 65535)
+		v.between("modbus.registers.heartbeat", c.Modbus.Registers.Heartbeat, 0, 65535)
+	}
+
+	// OPC UA
+	if c.OPCUA.Enabled {
+		v.port("opcua.port", c.OPCUA.Port)
+		v.required("opcua.applicationUri", c.OPCUA.ApplicationURI)
+		v.required("opcua.namespaceUri", c.OPCUA.NamespaceURI)
+		v.min("opcua.maxSessions", c.OPCUA.MaxSessions, 1)
+		v.positive("opcua.sessionTimeout", c.OPCUA.SessionTimeout)
+		v.positive("opcua.staleTimeout", c.OPCUA.StaleTimeout)
+		v.rangeOrder("opcua.velocityRange", c.OPCUA.VelocityRange)
+		v.rangeOrder("opcua.positionRange", c.OPCUA.PositionRange)
+	}
+
+	// MQTT
+	if c.MQTT.Enabled {
+		v.broker("mqtt.broker", c.MQTT.Broker)
+		v.between("mqtt.qos", int(c.MQTT.QoS), 0, 2)
+		v.positive("mqtt.keepAlive", c.MQTT.KeepAlive)
+		v.positive("mqtt.connectTimeout", c.MQTT.ConnectTimeout)
+		v.nonNegative("mqtt.publishInterval", c.MQTT.PublishInterval)
+		v.positive("mqtt.staleTimeout", c.MQTT.StaleTimeout)
+		v.min("mqtt.bufferMaxMessages", c.MQTT.BufferMaxMessages, 0)
+		if c.MQTT.Sparkplug.Enabled {
+			v.required("mqtt.sparkplug.groupId", c.MQTT.Sparkplug.GroupID)
+			v.required("mqtt.sparkplug.edgeNodeId", c.MQTT.Sparkplug.EdgeNodeID)
+			v.required("mqtt.sparkplug.deviceId", c.MQTT.Sparkplug.DeviceID)
+		}
+	}
+
+	// Autenticação
+	if c.Auth.AnonymousRole != "" {
+		v.oneOf("auth.anonymousRole", c.Auth.AnonymousRole, validRoles...)
+	}
+	for i, key := range c.Auth.APIKeys {
+		path := fmt.Sprintf("auth.apiKeys[%d]", i)
+		v.required(path+".key", key.Key)
+		v.oneOf(path+".role", key.Role, validRoles...)
+	}
+	v.nonNegative("auth.jwt.leeway", c.Auth.JWT.Leeway)
+	if c.Auth.Enabled && len(c.Auth.APIKeys) == 0 && c.Auth.JWT.Secret == "" &&
+		c.Auth.JWT.PublicKeyFile == "" && c.Auth.AnonymousRole == "" {
+		v.add("auth", "autenticação habilitada sem apiKeys, jwt.secret, jwt.publicKeyFile ou anonymousRole")
+	}
+
+	// Eventos
+	if c.Events.Enabled {
+		v.required("events.file", c.Events.File)
+		v.min("events.maxEvents", c.Events.MaxEvents, 0)
+	}
+
+	// Log
+	v.oneOf("log.level", strings.ToLower(c.Log.Level), "debug", "info", "warn", "warning", "error")
+	v.oneOf("log.format", strings.ToLower(c.Log.Format), "text", "json")
+	v.min("log.maxSizeMB", c.Log.MaxSizeMB, 0)
+	v.min("log.maxFiles", c.Log.MaxFiles, 0)
+	v.nonNegative("log.maxAge", c.Log.MaxAge)
+	v.nonNegative("log.dedupWindow", c.Log.DedupWindow)
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// validator acumula os problemas encontrados
+type validator struct {
+	errs ValidationErrors
+}
+
+func (v *validator) add(path, format string, args ...interface{}) {
+	v.errs = append(v.errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) required(path, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(path, "obrigatório")
+	}
+}
+
+func (v *validator) port(path string, port int) {
+	if port < 1 || port > 65535 {
+		v.add(path, "porta inválida %d (use 1-65535)", port)
+	}
+}
+
+func (v *validator) min(path string, value, min int) {
+	if value < min {
+		v.add(path, "deve ser no mínimo %d (atual: %d)", min, value)
+	}
+}
+
+func (v *validator) between(path string, value, min, max int) {
+	if value < min || value > max {
+		v.add(path, "deve estar entre %d e %d (atual: %d)", min, max, value)
+	}
+}
+
+func (v *validator) positive(path string, d time.Duration) {
+	if d <= 0 {
+		v.add(path, "deve ser uma duração positiva (atual: %v; use ex.: \"100ms\")", d)
+	}
+}
+
+func (v *validator) nonNegative(path string, d time.Duration) {
+	if d < 0 {
+		v.add(path, "não pode ser negativo (atual: %v)", d)
+	}
+}
+
+func (v *validator) positiveFloat(path string, value float64) {
+	if value <= 0 {
+		v.add(path, "deve ser positivo (atual: %v)", value)
+	}
+}
+
+func (v *validator) oneOf(path, value string, options ...string) {
+	for _, option := range options {
+		if value == option {
+			return
+		}
+	}
+	v.add(path, "valor inválido %q (use %s)", value, strings.Join(options, ", "))
+}
+
+func (v *validator) rangeOrder(path string, r OPCUARange) {
+	if r.Low >= r.High {
+		v.add(path, "low (%v) deve ser menor que high (%v)", r.Low, r.High)
+	}
+}
+
+func (v *validator) broker(path, broker string) {
+	u, err := url.Parse(broker)
+	if err != nil || u.Host == "" {
+		v.add(path, "URL inválida %q (use ex.: tcp://host:1883)", broker)
+		return
+	}
+	switch u.Scheme {
+	case "tcp", "ssl", "tls", "mqtt", "mqtts", "ws", "wss":
+	default:
+		v.add(path, "esquema %q não suportado (use tcp, ssl, tls, mqtt, mqtts, ws ou wss)", u.Scheme)
+	}
+}
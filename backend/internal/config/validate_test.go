@@ -0,0 +1,123 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// errorPaths retorna os caminhos relatados por Validate, em ordem
+func errorPaths(t *testing.T, err error) []string {
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Validate retornou %T, esperado ValidationErrors: %v", err, err)
+	}
+	paths := make([]string, len(errs))
+	for i, fieldErr := range errs {
+		paths[i] = fieldErr.Path
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestValidateDefaults(t *testing.T) {
+	config := getDefaultConfig()
+	if err := config.Validate(); err != nil {
+		t.Errorf("configuração padrão inválida: %v", err)
+	}
+
+	// Seções opcionais habilitadas com os padrões também são válidas
+	config.Redis.Enabled = true
+	config.PLC.Enabled = true
+	config.Modbus.Enabled = true
+	config.OPCUA.Enabled = true
+	config.Events.Enabled = true
+	if err := config.Validate(); err != nil {
+		t.Errorf("padrões das seções opcionais inválidos: %v", err)
+	}
+}
+
+func TestValidateFieldPaths(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		paths  []string
+	}{
+		{"porta do servidor", func(c *Config) { c.Server.Port = 70000 }, []string{"server.port"}},
+		{"TLS sem certificado", func(c *Config) {
+			c.Server.TLS.Enabled = true
+			c.Server.TLS.CertFile = ""
+			c.Server.TLS.KeyFile = ""
+		}, []string{"server.tls.certFile", "server.tls.keyFile"}},
+		{"radar", func(c *Config) {
+			c.Radar.Host = ""
+			c.Radar.Protocol = "udp"
+			c.Radar.SampleRate = 0
+		}, []string{"radar.host", "radar.protocol", "radar.sampleRate"}},
+		{"protocolo em maiúsculas", func(c *Config) { c.Radar.Protocol = "ASCII" }, nil},
+		{"backpressure", func(c *Config) { c.WebSocket.Backpressure = "block" }, []string{"websocket.backpressure"}},
+		{"PLC habilitado", func(c *Config) {
+			c.PLC.Enabled = true
+			c.PLC.Rack = 8
+			c.PLC.UpdateRate = -time.Second
+		}, []string{"plc.rack", "plc.updateRate"}},
+		{"PLC desabilitado não é verificado", func(c *Config) {
+			c.PLC.Enabled = false
+			c.PLC.Rack = 8
+		}, nil},
+		{"Modbus", func(c *Config) {
+			c.Modbus.Enabled = true
+			c.Modbus.WordOrder = "ACBD"
+			c.Modbus.Registers.Status = 70000
+		}, []string{"modbus.registers.status", "modbus.wordOrder"}},
+		{"MQTT com Sparkplug", func(c *Config) {
+			c.MQTT.Enabled = true
+			c.MQTT.Broker = "http://broker"
+			c.MQTT.QoS = 3
+			c.MQTT.Sparkplug.Enabled = true
+			c.MQTT.Sparkplug.GroupID = ""
+		}, []string{"mqtt.broker", "mqtt.qos", "mqtt.sparkplug.groupId"}},
+		{"chaves de API", func(c *Config) {
+			c.Auth.APIKeys = []APIKeyConfig{{Key: "k", Role: "viewer"}, {Key: "", Role: "root"}}
+		}, []string{"auth.apiKeys[1].key", "auth.apiKeys[1].role"}},
+		{"autenticação sem credenciais", func(c *Config) { c.Auth.Enabled = true }, []string{"auth"}},
+		{"log", func(c *Config) {
+			c.Log.Level = "trace"
+			c.Log.Format = "xml"
+			c.Log.MaxAge = -time.Hour
+		}, []string{"log.format", "log.level", "log.maxAge"}},
+	}
+
+	for _, tt := range tests {
+		config := getDefaultConfig()
+		tt.modify(&config)
+
+		if got := errorPaths(t, config.Validate()); !reflect.DeepEqual(got, tt.paths) {
+			t.Errorf("%s: caminhos = %v, esperado %v", tt.name, got, tt.paths)
+		}
+	}
+}
+
+func TestValidationErrorsMessage(t *testing.T) {
+	config := getDefaultConfig()
+	config.Server.Port = 0
+	config.Radar.Host = ""
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Validate sem erro")
+	}
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], "2 problema(s)") ||
+		!strings.HasPrefix(lines[1], "  - server.port: ") || !strings.HasPrefix(lines[2], "  - radar.host: ") {
+		t.Errorf("mensagem inesperada:\n%s", err)
+	}
+}
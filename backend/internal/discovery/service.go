@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strings"
 	"sync"
 
 	"radar_go/pkg/logger"
@@ -33,6 +34,7 @@ type DiscoveryService struct {
 	port         int
 	running      bool
 	serverIP     string
+	fingerprint  string // SHA-256 do certificado TLS (vazio = sem TLS)
 }
 
 // NewDiscoveryService cria um novo serviço de descoberta
@@ -52,6 +54,14 @@ func NewDiscoveryService(port int) *DiscoveryService {
 	}
 }
 
+// SetTLSFingerprint anuncia TLS e a impressão digital do certificado nos
+// registros TXT (deve ser chamado antes de Start)
+func (s *DiscoveryService) SetTLSFingerprint(fingerprint string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.fingerprint = fingerprint
+}
+
 // Start inicia o serviço de descoberta
 func (s *DiscoveryService) Start() error {
 	s.mutex.Lock()
@@ -68,18 +78,25 @@ func (s *DiscoveryService) Start() error {
 	}
 	s.serverIP = ip
 
+	// Metadados
+	txt := []string{
+		fmt.Sprintf("version=1.0"),
+		fmt.Sprintf("ip=%s", ip),
+		fmt.Sprintf("name=SICK Radar Monitor"),
+	}
+	if s.fingerprint != "" {
+		// Sem os ":" para caber com folga no limite de 255 bytes por registro
+		txt = append(txt, "tls=1", "fp=sha256:"+strings.ReplaceAll(s.fingerprint, ":", ""))
+	}
+
 	// Iniciar o servidor zeroconf
 	server, err := zeroconf.Register(
 		s.instanceName, // Nome de instância
 		ServiceType,    // Tipo de serviço
 		ServiceDomain,  // Domínio
 		s.port,         // Porta
-		[]string{ // Metadados
-			fmt.Sprintf("version=1.0"),
-			fmt.Sprintf("ip=%s", ip),
-			fmt.Sprintf("name=SICK Radar Monitor"),
-		},
-		nil, // Interfaces de rede (todas)
+		txt,            // Metadados
+		nil,            // Interfaces de rede (todas)
 	)
 
 	if err != nil {
@@ -0,0 +1,159 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"radar_go/internal/models"
+)
+
+// eventFile guarda eventos em um arquivo JSON Lines enquanto o Redis está
+// indisponível. Apenas os max mais recentes são mantidos; os mais antigos
+// são descartados na compactação.
+type eventFile struct {
+	path string
+	max  int
+
+	mu    sync.Mutex
+	file  *os.File
+	count int
+}
+
+// newEventFile abre o arquivo, contando os eventos de uma execução anterior
+func newEventFile(path string, max int) (*eventFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório do registro de eventos: %w", err)
+	}
+
+	f := &eventFile{path: path, max: max}
+
+	events, err := f.readAll()
+	if err != nil {
+		return nil, err
+	}
+	f.count = len(events)
+
+	return f, nil
+}
+
+// Append adiciona um evento ao final do arquivo
+func (f *eventFile) Append(event models.Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("erro ao abrir registro de eventos: %w", err)
+		}
+		f.file = file
+	}
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar evento: %w", err)
+	}
+	if _, err := f.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("erro ao gravar registro de eventos: %w", err)
+	}
+	f.count++
+
+	// Compactar com folga de 10% para não reescrever o arquivo a cada evento
+	if f.max > 0 && f.count > f.max+f.max/10 {
+		events, err := f.readAll()
+		if err != nil {
+			return err
+		}
+		if len(events) > f.max {
+			events = events[len(events)-f.max:]
+		}
+		return f.replace(events)
+	}
+	return nil
+}
+
+// ReadAll lê os eventos do mais antigo para o mais recente
+func (f *eventFile) ReadAll() ([]models.Event, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.readAll()
+}
+
+// Close fecha o arquivo aberto para escrita
+func (f *eventFile) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+}
+
+// replace substitui o conteúdo do arquivo pelos eventos informados. Requer f.mu.
+func (f *eventFile) replace(events []models.Event) error {
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+
+	tmpPath := f.path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("erro ao criar registro de eventos: %w", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			file.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("erro ao gravar registro de eventos: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("erro ao gravar registro de eventos: %w", err)
+	}
+	file.Close()
+
+	if err := os.Rename(tmpPath, f.path); err != nil {
+		return fmt.Errorf("erro ao substituir registro de eventos: %w", err)
+	}
+	f.count = len(events)
+	return nil
+}
+
+// readAll lê os eventos ignorando linhas corrompidas (ex.: queda de energia
+// durante a gravação). Requer f.mu.
+func (f *eventFile) readAll() ([]models.Event, error) {
+	file, err := os.Open(f.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler registro de eventos: %w", err)
+	}
+	defer file.Close()
+
+	var events []models.Event
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var event models.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler registro de eventos: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,254 @@
+package events
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"radar_go/internal/config"
+	"radar_go/internal/models"
+	"radar_go/internal/redis"
+	"radar_go/pkg/logger"
+)
+
+// Eventos aguardando gravação; com a fila cheia, novos eventos são descartados
+const queueSize = 256
+
+// Handler recebe cada evento depois de gravado (ex.: envio pelo WebSocket)
+type Handler func(event models.Event)
+
+// Query filtra a consulta ao registro de eventos
+type Query struct {
+	Types []models.EventType // Vazio = todos os tipos
+	From  time.Time
+	To    time.Time
+	Limit int // Máximo de eventos, mantendo os mais recentes (0 = sem limite)
+}
+
+// Journal é o registro de eventos operacionais e de auditoria. Os eventos
+// são gravados em segundo plano no stream do Redis ou, com o Redis
+// indisponível, no arquivo de fallback. Os métodos aceitam um Journal nil
+// (registro desabilitado).
+type Journal struct {
+	config       config.EventsConfig
+	redisService *redis.Service
+	file         *eventFile
+	log          *logger.Logger
+
+	queue   chan models.Event
+	running bool
+	mutex   sync.RWMutex // Protege running, radarID e o envio para queue
+	wg      sync.WaitGroup
+	radarID string
+	seq     uint64 // Sequência dos ids gerados para o arquivo
+
+	handlers     []Handler
+	handlersLock sync.RWMutex
+}
+
+// NewJournal cria o registro de eventos
+func NewJournal(cfg config.EventsConfig, redisService *redis.Service) (*Journal, error) {
+	file, err := newEventFile(cfg.File, cfg.MaxEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Journal{
+		config:       cfg,
+		redisService: redisService,
+		file:         file,
+		log:          logger.With("component", "events"),
+		queue:        make(chan models.Event, queueSize),
+	}, nil
+}
+
+// Start inicia a gravação dos eventos
+func (j *Journal) Start() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.running {
+		return nil
+	}
+
+	j.log.Infof("Iniciando registro de eventos (fallback: %s)", j.config.File)
+	j.wg.Add(1)
+	go j.writeLoop()
+
+	j.running = true
+	return nil
+}
+
+// Stop grava os eventos pendentes e encerra o registro
+func (j *Journal) Stop() {
+	if j == nil {
+		return
+	}
+
+	j.mutex.Lock()
+	if !j.running {
+		j.mutex.Unlock()
+		return
+	}
+	j.running = false
+	close(j.queue)
+	j.mutex.Unlock()
+
+	j.wg.Wait()
+	j.file.Close()
+	j.log.Info("Registro de eventos encerrado")
+}
+
+// IsRunning verifica se o registro está em execução
+func (j *Journal) IsRunning() bool {
+	if j == nil {
+		return false
+	}
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.running
+}
+
+// SetRadarID define o radar associado aos eventos que não informam um
+func (j *Journal) SetRadarID(radarID string) {
+	if j == nil {
+		return
+	}
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.radarID = radarID
+}
+
+// RegisterHandler registra uma função chamada para cada evento gravado
+func (j *Journal) RegisterHandler(handler Handler) {
+	if j == nil {
+		return
+	}
+	j.handlersLock.Lock()
+	defer j.handlersLock.Unlock()
+	j.handlers = append(j.handlers, handler)
+}
+
+// Record registra um evento sem bloquear quem o origina. Timestamp e RadarID
+// são preenchidos se vazios.
+func (j *Journal) Record(event models.Event) {
+	if j == nil {
+		return
+	}
+
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+
+	if !j.running {
+		return
+	}
+	if event.RadarID == "" {
+		event.RadarID = j.radarID
+	}
+
+	select {
+	case j.queue <- event:
+	default:
+		j.log.Warnf("Fila do registro de eventos cheia, evento %s descartado: %s", event.Type, event.Message)
+	}
+}
+
+// writeLoop grava os eventos da fila e notifica os handlers
+func (j *Journal) writeLoop() {
+	defer j.wg.Done()
+
+	for event := range j.queue {
+		event = j.store(event)
+
+		j.handlersLock.RLock()
+		handlers := j.handlers
+		j.handlersLock.RUnlock()
+
+		for _, handler := range handlers {
+			handler(event)
+		}
+	}
+}
+
+// store grava o evento no Redis ou, sem conexão, no arquivo
+func (j *Journal) store(event models.Event) models.Event {
+	if j.redisService != nil && j.redisService.IsConnected() {
+		id, err := j.redisService.WriteEvent(event, int64(j.config.MaxEvents))
+		if err == nil {
+			event.ID = id
+			return event
+		}
+		j.log.Warnf("Erro ao gravar evento no Redis, usando %s: %v", j.config.File, err)
+	}
+
+	event.ID = fmt.Sprintf("f%d-%d", event.Timestamp.UnixMilli(), atomic.AddUint64(&j.seq, 1))
+	if err := j.file.Append(event); err != nil {
+		j.log.Errorf("Erro ao gravar evento %s: %v", event.Type, err)
+	}
+	return event
+}
+
+// Query consulta os eventos do Redis e do arquivo de fallback, em ordem
+// cronológica. Com o Redis indisponível, retorna apenas os do arquivo.
+func (j *Journal) Query(q Query) ([]models.Event, error) {
+	if j == nil {
+		return nil, fmt.Errorf("registro de eventos desabilitado")
+	}
+
+	var events []models.Event
+	if j.redisService != nil && j.redisService.IsConnected() {
+		stored, err := j.redisService.GetEvents(q.From, q.To)
+		if err != nil {
+			j.log.Warnf("Erro ao consultar eventos no Redis: %v", err)
+		}
+		events = append(events, stored...)
+	}
+
+	stored, err := j.file.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range stored {
+		if !event.Timestamp.Before(q.From) && !event.Timestamp.After(q.To) {
+			events = append(events, event)
+		}
+	}
+
+	if len(q.Types) > 0 {
+		wanted := make(map[models.EventType]bool, len(q.Types))
+		for _, eventType := range q.Types {
+			wanted[eventType] = true
+		}
+		filtered := events[:0]
+		for _, event := range events {
+			if wanted[event.Type] {
+				filtered = append(filtered, event)
+			}
+		}
+		events = filtered
+	}
+
+	sort.SliceStable(events, func(a, b int) bool {
+		return events[a].Timestamp.Before(events[b].Timestamp)
+	})
+	if q.Limit > 0 && len(events) > q.Limit {
+		events = events[len(events)-q.Limit:]
+	}
+	return events, nil
+}
+
+// ParseType valida o nome de um tipo de evento
+func ParseType(name string) (models.EventType, error) {
+	for _, eventType := range models.EventTypes {
+		if string(eventType) == name {
+			return eventType, nil
+		}
+	}
+	return "", fmt.Errorf("tipo de evento desconhecido: %q", name)
+}
@@ -0,0 +1,252 @@
+package events
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"radar_go/internal/config"
+	"radar_go/internal/models"
+	"radar_go/internal/redis"
+)
+
+// newTestJournal cria um registro com o arquivo de fallback em um diretório
+// temporário
+func newTestJournal(t *testing.T, maxEvents int, redisService *redis.Service) *Journal {
+	t.Helper()
+
+	cfg := config.EventsConfig{
+		Enabled:   true,
+		File:      filepath.Join(t.TempDir(), "events", "journal.jsonl"),
+		MaxEvents: maxEvents,
+	}
+	journal, err := NewJournal(cfg, redisService)
+	if err != nil {
+		t.Fatalf("NewJournal: %v", err)
+	}
+	if err := journal.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(journal.Stop)
+	return journal
+}
+
+// unreachableRedis retorna um serviço Redis habilitado apontando para uma
+// porta sem servidor, ou seja, em modo offline
+func unreachableRedis(t *testing.T) *redis.Service {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	service, err := redis.NewService(config.RedisConfig{Enabled: true, Host: "127.0.0.1", Port: port, Prefix: "radar"})
+	if err != nil {
+		t.Fatalf("redis.NewService: %v", err)
+	}
+	if service.IsConnected() {
+		t.Fatal("Redis conectado a uma porta sem servidor")
+	}
+	return service
+}
+
+// allEvents consulta todos os eventos do arquivo
+func allEvents(t *testing.T, journal *Journal) []models.Event {
+	t.Helper()
+
+	events, err := journal.Query(Query{To: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	return events
+}
+
+func TestJournalFileFallback(t *testing.T) {
+	journal := newTestJournal(t, 100, unreachableRedis(t))
+	journal.SetRadarID("radar-1")
+
+	var mu sync.Mutex
+	var notified []models.Event
+	journal.RegisterHandler(func(event models.Event) {
+		mu.Lock()
+		notified = append(notified, event)
+		mu.Unlock()
+	})
+
+	journal.Record(models.Event{Type: models.EventRadarConnected, Message: "conectado"})
+	journal.Record(models.Event{Type: models.EventRadarLost, RadarID: "radar-2", Message: "perdido"})
+	journal.Stop() // Grava a fila pendente
+
+	events := allEvents(t, journal)
+	if len(events) != 2 {
+		t.Fatalf("%d eventos no arquivo, esperado 2", len(events))
+	}
+	for _, event := range events {
+		if !strings.HasPrefix(event.ID, "f") || event.Timestamp.IsZero() {
+			t.Errorf("evento %+v sem id do arquivo ou sem horário", event)
+		}
+	}
+	if events[0].RadarID != "radar-1" || events[1].RadarID != "radar-2" {
+		t.Errorf("radares = %q, %q; esperado radar-1, radar-2", events[0].RadarID, events[1].RadarID)
+	}
+	if events[0].ID == events[1].ID {
+		t.Errorf("ids repetidos: %s", events[0].ID)
+	}
+
+	// Os handlers recebem o evento já com o id gravado
+	mu.Lock()
+	defer mu.Unlock()
+	if len(notified) != 2 || notified[0].ID != events[0].ID {
+		t.Errorf("handlers notificados com %+v", notified)
+	}
+
+	// Parado, o registro ignora novos eventos
+	journal.Record(models.Event{Type: models.EventRadarConnected})
+	if got := len(allEvents(t, journal)); got != 2 {
+		t.Errorf("%d eventos após Stop, esperado 2", got)
+	}
+}
+
+func TestJournalMaxEvents(t *testing.T) {
+	const maxEvents = 20
+	journal := newTestJournal(t, maxEvents, nil)
+
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 3*maxEvents; i++ {
+		journal.Record(models.Event{Type: models.EventStatusChanged, Timestamp: base.Add(time.Duration(i) * time.Second)})
+	}
+	journal.Stop()
+
+	// A compactação tem folga de 10%, mas nunca passa dela
+	events := allEvents(t, journal)
+	if len(events) < maxEvents || len(events) > maxEvents+maxEvents/10 {
+		t.Fatalf("%d eventos no arquivo, esperado entre %d e %d", len(events), maxEvents, maxEvents+maxEvents/10)
+	}
+	// Os mais recentes são mantidos
+	if last := events[len(events)-1].Timestamp; !last.Equal(base.Add(time.Duration(3*maxEvents-1) * time.Second)) {
+		t.Errorf("último evento em %v, esperado o mais recente", last)
+	}
+
+	// Reaberto, o arquivo mantém a contagem da execução anterior
+	reopened, err := NewJournal(journal.config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.file.count != len(events) {
+		t.Errorf("contagem ao reabrir = %d, esperado %d", reopened.file.count, len(events))
+	}
+}
+
+func TestJournalQuery(t *testing.T) {
+	journal := newTestJournal(t, 100, nil)
+
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+
+	// Gravados fora de ordem: a consulta ordena pelo horário
+	for _, event := range []models.Event{
+		{Type: models.EventRadarLost, Timestamp: at(30)},
+		{Type: models.EventRadarConnected, Timestamp: at(0)},
+		{Type: models.EventStatusChanged, Timestamp: at(10)},
+		{Type: models.EventRadarConnected, Timestamp: at(20)},
+		{Type: models.EventStatusChanged, Timestamp: at(40)},
+	} {
+		journal.Record(event)
+	}
+	journal.Stop()
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []int // Minutos dos eventos esperados
+	}{
+		{"todos", Query{From: at(0), To: at(40)}, []int{0, 10, 20, 30, 40}},
+		{"intervalo inclusivo", Query{From: at(10), To: at(30)}, []int{10, 20, 30}},
+		{"intervalo vazio", Query{From: at(41), To: at(50)}, nil},
+		{"um tipo", Query{Types: []models.EventType{models.EventRadarConnected}, From: at(0), To: at(40)}, []int{0, 20}},
+		{"dois tipos", Query{
+			Types: []models.EventType{models.EventRadarLost, models.EventStatusChanged},
+			From:  at(0), To: at(40),
+		}, []int{10, 30, 40}},
+		{"tipo e intervalo", Query{Types: []models.EventType{models.EventStatusChanged}, From: at(5), To: at(35)}, []int{10}},
+		{"limite mantém os mais recentes", Query{From: at(0), To: at(40), Limit: 2}, []int{30, 40}},
+		{"limite acima do total", Query{From: at(0), To: at(40), Limit: 10}, []int{0, 10, 20, 30, 40}},
+		{"limite após o filtro", Query{Types: []models.EventType{models.EventRadarConnected}, From: at(0), To: at(40), Limit: 1}, []int{20}},
+	}
+
+	for _, tt := range tests {
+		events, err := journal.Query(tt.query)
+		if err != nil {
+			t.Errorf("%s: Query: %v", tt.name, err)
+			continue
+		}
+		var got []int
+		for _, event := range events {
+			got = append(got, int(event.Timestamp.Sub(base)/time.Minute))
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: eventos nos minutos %v, esperado %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: eventos nos minutos %v, esperado %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+
+	var disabled *Journal
+	if _, err := disabled.Query(Query{}); err == nil {
+		t.Error("Query em registro nil sem erro")
+	}
+}
+
+func TestJournalQueueFull(t *testing.T) {
+	journal := newTestJournal(t, 0, nil)
+
+	// O handler prende a gravação no primeiro evento até a fila encher
+	release := make(chan struct{})
+	blocked := make(chan struct{})
+	var once sync.Once
+	journal.RegisterHandler(func(models.Event) {
+		once.Do(func() {
+			close(blocked)
+			<-release
+		})
+	})
+
+	journal.Record(models.Event{Type: models.EventRadarConnected})
+	select {
+	case <-blocked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("primeiro evento não chegou ao handler")
+	}
+
+	// Fila cheia: Record não bloqueia e os excedentes são descartados
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < queueSize+50; i++ {
+			journal.Record(models.Event{Type: models.EventStatusChanged})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Record bloqueou com a fila cheia")
+	}
+
+	close(release)
+	journal.Stop()
+
+	if got, want := len(allEvents(t, journal)), 1+queueSize; got != want {
+		t.Errorf("%d eventos gravados, esperado %d", got, want)
+	}
+}
@@ -0,0 +1,91 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Códigos de alarme publicados no registrador de alarmes (bits)
+const (
+	// AlarmObstructed radar possivelmente obstruído (todas as posições zero)
+	AlarmObstructed uint16 = 1 << 0
+	// AlarmNoData nenhuma métrica recebida dentro de StaleTimeout
+	AlarmNoData uint16 = 1 << 1
+	// AlarmNotOK status do radar diferente de "ok"
+	AlarmNotOK uint16 = 1 << 2
+)
+
+// Códigos de status publicados no registrador de status
+const (
+	StatusUnknown    uint16 = 0
+	StatusOK         uint16 = 1
+	StatusObstructed uint16 = 2
+	StatusNoData     uint16 = 3
+)
+
+// statusCode converte o status textual do radar para o código numérico
+func statusCode(status string) uint16 {
+	switch status {
+	case "ok":
+		return StatusOK
+	case "obstruido":
+		return StatusObstructed
+	case "falha_comunicacao":
+		return StatusNoData
+	default:
+		return StatusUnknown
+	}
+}
+
+// wordOrder define como um FLOAT32 é distribuído em dois registradores
+type wordOrder string
+
+const (
+	orderABCD wordOrder = "ABCD" // Big-endian, palavra alta primeiro (padrão Modbus)
+	orderCDAB wordOrder = "CDAB" // Palavras trocadas (comum em Schneider)
+	orderBADC wordOrder = "BADC" // Bytes trocados dentro de cada palavra
+	orderDCBA wordOrder = "DCBA" // Little-endian completo
+)
+
+// parseWordOrder valida a ordem configurada
+func parseWordOrder(value string) (wordOrder, error) {
+	order := wordOrder(strings.ToUpper(strings.TrimSpace(value)))
+	switch order {
+	case "":
+		return orderABCD, nil
+	case orderABCD, orderCDAB, orderBADC, orderDCBA:
+		return order, nil
+	default:
+		return "", fmt.Errorf("ordem de palavras inválida: %q (use ABCD, CDAB, BADC ou DCBA)", value)
+	}
+}
+
+// encodeFloat32 converte um float para dois registradores na ordem informada
+func encodeFloat32(value float64, order wordOrder) [2]uint16 {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], math.Float32bits(float32(value)))
+
+	switch order {
+	case orderCDAB:
+		b = [4]byte{b[2], b[3], b[0], b[1]}
+	case orderBADC:
+		b = [4]byte{b[1], b[0], b[3], b[2]}
+	case orderDCBA:
+		b = [4]byte{b[3], b[2], b[1], b[0]}
+	}
+
+	return [2]uint16{binary.BigEndian.Uint16(b[0:]), binary.BigEndian.Uint16(b[2:])}
+}
+
+// scaleToInt16 multiplica o valor pela escala, arredonda e satura no intervalo do INT16
+func scaleToInt16(value float64, scale float64) uint16 {
+	scaled := math.Round(value * scale)
+	if scaled > math.MaxInt16 {
+		scaled = math.MaxInt16
+	} else if scaled < math.MinInt16 {
+		scaled = math.MinInt16
+	}
+	return uint16(int16(scaled))
+}
@@ -0,0 +1,125 @@
+package modbus
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"radar_go/internal/config"
+)
+
+func TestEncodeFloat32WordOrders(t *testing.T) {
+	// 123.456 em IEEE 754: 42 F6 E9 79
+	tests := []struct {
+		order wordOrder
+		want  [2]uint16
+	}{
+		{orderABCD, [2]uint16{0x42F6, 0xE979}},
+		{orderCDAB, [2]uint16{0xE979, 0x42F6}},
+		{orderBADC, [2]uint16{0xF642, 0x79E9}},
+		{orderDCBA, [2]uint16{0x79E9, 0xF642}},
+	}
+
+	for _, tt := range tests {
+		if got := encodeFloat32(123.456, tt.order); got != tt.want {
+			t.Errorf("encodeFloat32(123.456, %s) = %04X, esperado %04X", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestParseWordOrder(t *testing.T) {
+	for value, want := range map[string]wordOrder{
+		"":       orderABCD,
+		"ABCD":   orderABCD,
+		"cdab":   orderCDAB,
+		" BADC ": orderBADC,
+		"DCBA":   orderDCBA,
+	} {
+		if got, err := parseWordOrder(value); err != nil || got != want {
+			t.Errorf("parseWordOrder(%q) = %q, %v; esperado %q", value, got, err, want)
+		}
+	}
+
+	if _, err := parseWordOrder("ACBD"); err == nil {
+		t.Error("parseWordOrder(\"ACBD\") sem erro")
+	}
+}
+
+func TestScaleToInt16(t *testing.T) {
+	tests := []struct {
+		value, scale float64
+		want         int16
+	}{
+		{1.234, 100, 123},
+		{1.235, 100, 124},   // Arredonda para o mais próximo
+		{-1.235, 100, -124}, // Metade arredonda para longe do zero
+		{0.0049, 100, 0},
+		{-0.5, 1, -1},
+		{327.67, 100, math.MaxInt16},
+		{400, 100, math.MaxInt16}, // Satura em vez de transbordar
+		{-400, 100, math.MinInt16},
+		{math.Inf(1), 1, math.MaxInt16},
+		{math.Inf(-1), 1, math.MinInt16},
+	}
+
+	for _, tt := range tests {
+		if got := int16(scaleToInt16(tt.value, tt.scale)); got != tt.want {
+			t.Errorf("scaleToInt16(%v, %v) = %d, esperado %d", tt.value, tt.scale, got, tt.want)
+		}
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	for status, want := range map[string]uint16{
+		"ok":                StatusOK,
+		"obstruido":         StatusObstructed,
+		"falha_comunicacao": StatusNoData,
+		"initializing":      StatusUnknown,
+	} {
+		if got := statusCode(status); got != want {
+			t.Errorf("statusCode(%q) = %d, esperado %d", status, got, want)
+		}
+	}
+}
+
+func TestRegisterMapOverlap(t *testing.T) {
+	registers := config.ModbusRegisterMap{Velocities: 0, Positions: 100, Status: 200, Alarms: 201, Heartbeat: 202}
+
+	tests := []struct {
+		name    string
+		modify  func(*config.ModbusConfig)
+		wantErr string
+	}{
+		{"padrão", func(*config.ModbusConfig) {}, ""},
+		{"FLOAT32 encostados", func(c *config.ModbusConfig) { c.Registers.Positions = 14 }, ""},
+		{"FLOAT32 sobrepostos", func(c *config.ModbusConfig) { c.Registers.Positions = 13 }, "velocities e positions"},
+		{"INT16 encostados", func(c *config.ModbusConfig) {
+			c.ScaleToInt16 = true
+			c.Registers.Positions = 7
+		}, ""},
+		{"INT16 sobrepostos", func(c *config.ModbusConfig) {
+			c.ScaleToInt16 = true
+			c.Registers.Positions = 6
+		}, "velocities e positions"},
+		{"status dentro das posições", func(c *config.ModbusConfig) { c.Registers.Status = 105 }, "positions e status"},
+		{"alarmes e heartbeat iguais", func(c *config.ModbusConfig) { c.Registers.Heartbeat = 201 }, "alarms e heartbeat"},
+		{"endereço negativo", func(c *config.ModbusConfig) { c.Registers.Status = -1 }, "status fora"},
+		{"além de 65535", func(c *config.ModbusConfig) { c.Registers.Velocities = 65530 }, "velocities fora"},
+	}
+
+	for _, tt := range tests {
+		cfg := config.ModbusConfig{Registers: registers}
+		tt.modify(&cfg)
+
+		_, err := NewService(cfg)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: NewService: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: erro = %v, esperado %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"radar_go/pkg/logger"
+)
+
+// Códigos de função suportados
+const (
+	funcReadHoldingRegisters byte = 0x03
+	funcReadInputRegisters   byte = 0x04
+)
+
+// Códigos de exceção Modbus
+const (
+	exceptionIllegalFunction    byte = 0x01
+	exceptionIllegalDataAddress byte = 0x02
+	exceptionIllegalDataValue   byte = 0x03
+)
+
+const (
+	mbapHeaderSize  = 7   // Transação(2) + Protocolo(2) + Comprimento(2) + Unit ID(1)
+	maxPDUSize      = 253 // Tamanho máximo do PDU Modbus
+	maxReadQuantity = 125 // Máximo de registradores por leitura
+	idleTimeout     = 60 * time.Second
+)
+
+// acceptLoop aceita conexões de clientes Modbus até o listener ser fechado
+func (s *Service) acceptLoop(listener net.Listener) {
+	defer s.wg.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !s.IsRunning() {
+				return
+			}
+			logger.Warnf("Erro ao aceitar conexão Modbus: %v", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		s.connMutex.Lock()
+		s.conns[conn] = struct{}{}
+		s.connMutex.Unlock()
+
+		s.wg.Add(1)
+		go s.handleConn(conn)
+	}
+}
+
+// handleConn processa as requisições de um cliente até a conexão ser encerrada
+func (s *Service) handleConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.connMutex.Lock()
+		delete(s.conns, conn)
+		s.connMutex.Unlock()
+		conn.Close()
+	}()
+
+	logger.Infof("Cliente Modbus conectado: %s", conn.RemoteAddr())
+
+	header := make([]byte, mbapHeaderSize)
+	for {
+		conn.SetReadDeadline(time.Now().Add(idleTimeout))
+
+		if _, err := io.ReadFull(conn, header); err != nil {
+			if !errors.Is(err, io.EOF) && s.IsRunning() {
+				logger.Debugf("Conexão Modbus %s encerrada: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+
+		protocol := binary.BigEndian.Uint16(header[2:4])
+		length := int(binary.BigEndian.Uint16(header[4:6]))
+		if protocol != 0 || length < 2 || length > maxPDUSize+1 {
+			logger.Warnf("Quadro Modbus inválido de %s (protocolo %d, comprimento %d)",
+				conn.RemoteAddr(), protocol, length)
+			return
+		}
+
+		pdu := make([]byte, length-1)
+		if _, err := io.ReadFull(conn, pdu); err != nil {
+			return
+		}
+
+		unitID := header[6]
+		if !s.acceptsUnit(unitID) {
+			// Outro escravo no mesmo gateway: não responder
+			continue
+		}
+
+		response := s.handlePDU(pdu)
+
+		frame := make([]byte, mbapHeaderSize+len(response))
+		copy(frame, header[:4])
+		binary.BigEndian.PutUint16(frame[4:6], uint16(len(response)+1))
+		frame[6] = unitID
+		copy(frame[mbapHeaderSize:], response)
+
+		if _, err := conn.Write(frame); err != nil {
+			logger.Debugf("Erro ao responder cliente Modbus %s: %v", conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
+
+// handlePDU executa uma requisição Modbus e retorna o PDU de resposta
+func (s *Service) handlePDU(pdu []byte) []byte {
+	function := pdu[0]
+
+	switch function {
+	case funcReadHoldingRegisters, funcReadInputRegisters:
+		if len(pdu) != 5 {
+			return exceptionResponse(function, exceptionIllegalDataValue)
+		}
+
+		start := int(binary.BigEndian.Uint16(pdu[1:3]))
+		quantity := int(binary.BigEndian.Uint16(pdu[3:5]))
+		if quantity < 1 || quantity > maxReadQuantity {
+			return exceptionResponse(function, exceptionIllegalDataValue)
+		}
+
+		values, ok := s.readRegisters(start, quantity)
+		if !ok {
+			return exceptionResponse(function, exceptionIllegalDataAddress)
+		}
+
+		response := make([]byte, 2+quantity*2)
+		response[0] = function
+		response[1] = byte(quantity * 2)
+		for i, value := range values {
+			binary.BigEndian.PutUint16(response[2+i*2:], value)
+		}
+		return response
+
+	default:
+		return exceptionResponse(function, exceptionIllegalFunction)
+	}
+}
+
+// exceptionResponse monta um PDU de exceção
+func exceptionResponse(function byte, code byte) []byte {
+	return []byte{function | 0x80, code}
+}
@@ -0,0 +1,239 @@
+package modbus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"radar_go/internal/config"
+	"radar_go/internal/models"
+)
+
+// testConfig retorna o mapa padrão de registradores em uma porta livre
+func testConfig() config.ModbusConfig {
+	return config.ModbusConfig{
+		Enabled:       true,
+		Port:          0,
+		WordOrder:     "ABCD",
+		VelocityScale: 100,
+		PositionScale: 1000,
+		StaleTimeout:  time.Minute,
+		Registers:     config.ModbusRegisterMap{Velocities: 0, Positions: 100, Status: 200, Alarms: 201, Heartbeat: 202},
+	}
+}
+
+// startService inicia o servidor Modbus e conecta um cliente TCP a ele
+func startService(t *testing.T, cfg config.ModbusConfig) (*Service, net.Conn) {
+	t.Helper()
+
+	service, err := NewService(cfg)
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	if err := service.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(service.Stop)
+
+	service.mutex.RLock()
+	addr := service.listener.Addr().String()
+	service.mutex.RUnlock()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("erro ao conectar ao servidor Modbus: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	return service, conn
+}
+
+// request envia um quadro MBAP e retorna o PDU da resposta
+func request(t *testing.T, conn net.Conn, transaction uint16, unitID byte, pdu []byte) []byte {
+	t.Helper()
+
+	send(t, conn, transaction, unitID, pdu)
+
+	header := make([]byte, mbapHeaderSize)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		t.Fatalf("erro ao ler cabeçalho da resposta: %v", err)
+	}
+	if got := binary.BigEndian.Uint16(header[0:2]); got != transaction {
+		t.Errorf("resposta da transação %d, esperada %d", got, transaction)
+	}
+	if header[6] != unitID {
+		t.Errorf("resposta do unit ID %d, esperado %d", header[6], unitID)
+	}
+
+	response := make([]byte, binary.BigEndian.Uint16(header[4:6])-1)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		t.Fatalf("erro ao ler PDU da resposta: %v", err)
+	}
+	return response
+}
+
+// send envia um quadro MBAP sem aguardar resposta
+func send(t *testing.T, conn net.Conn, transaction uint16, unitID byte, pdu []byte) {
+	t.Helper()
+
+	frame := make([]byte, mbapHeaderSize+len(pdu))
+	binary.BigEndian.PutUint16(frame[0:2], transaction)
+	binary.BigEndian.PutUint16(frame[4:6], uint16(len(pdu)+1))
+	frame[6] = unitID
+	copy(frame[mbapHeaderSize:], pdu)
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatalf("erro ao enviar requisição: %v", err)
+	}
+}
+
+// readPDU monta o PDU de leitura de registradores
+func readPDU(function byte, start, quantity uint16) []byte {
+	pdu := []byte{function, 0, 0, 0, 0}
+	binary.BigEndian.PutUint16(pdu[1:3], start)
+	binary.BigEndian.PutUint16(pdu[3:5], quantity)
+	return pdu
+}
+
+// registers decodifica a resposta de leitura
+func registers(t *testing.T, function byte, response []byte) []uint16 {
+	t.Helper()
+
+	if len(response) < 2 || response[0] != function || int(response[1]) != len(response)-2 {
+		t.Fatalf("resposta inválida: % X", response)
+	}
+	values := make([]uint16, response[1]/2)
+	for i := range values {
+		values[i] = binary.BigEndian.Uint16(response[2+i*2:])
+	}
+	return values
+}
+
+func TestReadRegistersFC03FC04(t *testing.T) {
+	service, conn := startService(t, testConfig())
+
+	service.UpdateMetrics(models.RadarMetrics{
+		Velocities: [7]float64{123.456, -1},
+		Positions:  [7]float64{0, 2.5},
+		Status:     "obstruido",
+	})
+
+	for i, function := range []byte{funcReadHoldingRegisters, funcReadInputRegisters} {
+		// Velocidades 1 e 2 (FLOAT32 ABCD)
+		values := registers(t, function, request(t, conn, uint16(i*10+1), 1, readPDU(function, 0, 4)))
+		want := []uint16{0x42F6, 0xE979, 0xBF80, 0x0000}
+		if !equalRegisters(values, want) {
+			t.Errorf("FC%02d velocidades = %04X, esperado %04X", function, values, want)
+		}
+
+		// Posição 2
+		values = registers(t, function, request(t, conn, uint16(i*10+2), 1, readPDU(function, 102, 2)))
+		if got := math.Float32frombits(uint32(values[0])<<16 | uint32(values[1])); got != 2.5 {
+			t.Errorf("FC%02d posição 2 = %v, esperado 2.5", function, got)
+		}
+
+		// Status, alarmes e heartbeat
+		values = registers(t, function, request(t, conn, uint16(i*10+3), 1, readPDU(function, 200, 3)))
+		if want := []uint16{StatusObstructed, AlarmObstructed | AlarmNotOK, 1}; !equalRegisters(values, want) {
+			t.Errorf("FC%02d status/alarmes/heartbeat = %v, esperado %v", function, values, want)
+		}
+	}
+}
+
+func TestReadRegistersInt16AndStale(t *testing.T) {
+	cfg := testConfig()
+	cfg.ScaleToInt16 = true
+	cfg.StaleTimeout = 50 * time.Millisecond
+	service, conn := startService(t, cfg)
+
+	// Sem métricas: NoData
+	values := registers(t, funcReadHoldingRegisters, request(t, conn, 1, 1, readPDU(funcReadHoldingRegisters, 200, 2)))
+	if values[0] != StatusNoData || values[1] != AlarmNoData|AlarmNotOK {
+		t.Errorf("sem dados: status %d, alarmes %03b", values[0], values[1])
+	}
+
+	service.UpdateMetrics(models.RadarMetrics{Velocities: [7]float64{1.234, -500}, Status: "ok"})
+	values = registers(t, funcReadHoldingRegisters, request(t, conn, 2, 1, readPDU(funcReadHoldingRegisters, 0, 2)))
+	if int16(values[0]) != 123 || int16(values[1]) != math.MinInt16 {
+		t.Errorf("velocidades INT16 = %d, %d; esperado 123, %d", int16(values[0]), int16(values[1]), math.MinInt16)
+	}
+	values = registers(t, funcReadHoldingRegisters, request(t, conn, 3, 1, readPDU(funcReadHoldingRegisters, 200, 2)))
+	if values[0] != StatusOK || values[1] != 0 {
+		t.Errorf("com dados: status %d, alarmes %03b", values[0], values[1])
+	}
+
+	// Dados antigos voltam a sinalizar NoData
+	time.Sleep(2 * cfg.StaleTimeout)
+	values = registers(t, funcReadHoldingRegisters, request(t, conn, 4, 1, readPDU(funcReadHoldingRegisters, 200, 2)))
+	if values[0] != StatusNoData || values[1] != AlarmNoData|AlarmNotOK {
+		t.Errorf("dados antigos: status %d, alarmes %03b", values[0], values[1])
+	}
+}
+
+func TestExceptionResponses(t *testing.T) {
+	_, conn := startService(t, testConfig())
+
+	tests := []struct {
+		name string
+		pdu  []byte
+		want []byte
+	}{
+		{"função ilegal", []byte{0x06, 0x00, 0x00, 0x00, 0x01}, []byte{0x86, exceptionIllegalFunction}},
+		{"endereço além do mapa", readPDU(funcReadHoldingRegisters, 200, 10), []byte{0x83, exceptionIllegalDataAddress}},
+		{"endereço inexistente", readPDU(funcReadInputRegisters, 60000, 1), []byte{0x84, exceptionIllegalDataAddress}},
+		{"registradores demais", readPDU(funcReadHoldingRegisters, 0, maxReadQuantity+1), []byte{0x83, exceptionIllegalDataValue}},
+		{"quantidade zero", readPDU(funcReadInputRegisters, 0, 0), []byte{0x84, exceptionIllegalDataValue}},
+		{"PDU truncado", []byte{funcReadHoldingRegisters, 0x00, 0x00}, []byte{0x83, exceptionIllegalDataValue}},
+	}
+
+	for i, tt := range tests {
+		if got := request(t, conn, uint16(i+1), 1, tt.pdu); !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: resposta % X, esperado % X", tt.name, got, tt.want)
+		}
+	}
+
+	// O limite de 125 registradores ainda é aceito
+	values := registers(t, funcReadHoldingRegisters,
+		request(t, conn, 100, 1, readPDU(funcReadHoldingRegisters, 0, maxReadQuantity)))
+	if len(values) != maxReadQuantity {
+		t.Errorf("%d registradores lidos, esperado %d", len(values), maxReadQuantity)
+	}
+}
+
+func TestUnitIDFilter(t *testing.T) {
+	cfg := testConfig()
+	cfg.UnitID = 5
+	_, conn := startService(t, cfg)
+
+	// Requisição para outro escravo é ignorada; a seguinte é respondida
+	send(t, conn, 1, 7, readPDU(funcReadHoldingRegisters, 0, 1))
+	registers(t, funcReadHoldingRegisters, request(t, conn, 2, 5, readPDU(funcReadHoldingRegisters, 0, 1)))
+}
+
+func TestInvalidFrameClosesConnection(t *testing.T) {
+	_, conn := startService(t, testConfig())
+
+	// Protocolo diferente de 0 (não Modbus)
+	frame := []byte{0x00, 0x01, 0x00, 0x01, 0x00, 0x06, 0x01, 0x03, 0x00, 0x00, 0x00, 0x01}
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := conn.Read(make([]byte, 16)); err == nil {
+		t.Errorf("%d bytes recebidos após quadro inválido, esperado encerramento", n)
+	}
+}
+
+func equalRegisters(a, b []uint16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
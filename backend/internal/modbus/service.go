@@ -0,0 +1,243 @@
+package modbus
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"radar_go/internal/config"
+	"radar_go/internal/models"
+	"radar_go/pkg/logger"
+)
+
+// Service expõe as métricas do radar como registradores Modbus TCP.
+// Os mesmos valores podem ser lidos como holding registers (FC03) ou
+// input registers (FC04).
+type Service struct {
+	config    config.ModbusConfig
+	order     wordOrder
+	listener  net.Listener
+	conns     map[net.Conn]struct{}
+	running   bool
+	mutex     sync.RWMutex
+	connMutex sync.Mutex
+	wg        sync.WaitGroup
+
+	// Imagem dos registradores
+	registers  []uint16
+	status     uint16
+	alarms     uint16
+	heartbeat  uint16
+	lastUpdate time.Time
+}
+
+// NewService cria um novo serviço Modbus TCP
+func NewService(cfg config.ModbusConfig) (*Service, error) {
+	order, err := parseWordOrder(cfg.WordOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	service := &Service{
+		config:    cfg,
+		order:     order,
+		conns:     make(map[net.Conn]struct{}),
+		registers: make([]uint16, registerCount(cfg)),
+	}
+
+	if err := service.checkRegisterMap(); err != nil {
+		return nil, err
+	}
+
+	return service, nil
+}
+
+// registerCount calcula quantos registradores a imagem precisa para cobrir o mapa
+func registerCount(cfg config.ModbusConfig) int {
+	valueWidth := 14 // 7 valores FLOAT32
+	if cfg.ScaleToInt16 {
+		valueWidth = 7
+	}
+
+	count := 0
+	for _, end := range []int{
+		cfg.Registers.Velocities + valueWidth,
+		cfg.Registers.Positions + valueWidth,
+		cfg.Registers.Status + 1,
+		cfg.Registers.Alarms + 1,
+		cfg.Registers.Heartbeat + 1,
+	} {
+		if end > count {
+			count = end
+		}
+	}
+	return count
+}
+
+// checkRegisterMap verifica se os blocos configurados não se sobrepõem
+func (s *Service) checkRegisterMap() error {
+	valueWidth := 14
+	if s.config.ScaleToInt16 {
+		valueWidth = 7
+	}
+
+	type block struct {
+		name       string
+		start, end int
+	}
+	regs := s.config.Registers
+	blocks := []block{
+		{"velocities", regs.Velocities, regs.Velocities + valueWidth},
+		{"positions", regs.Positions, regs.Positions + valueWidth},
+		{"status", regs.Status, regs.Status + 1},
+		{"alarms", regs.Alarms, regs.Alarms + 1},
+		{"heartbeat", regs.Heartbeat, regs.Heartbeat + 1},
+	}
+
+	for i, a := range blocks {
+		if a.start < 0 || a.end > 65536 {
+			return fmt.Errorf("bloco Modbus %s fora do intervalo de endereços", a.name)
+		}
+		for _, b := range blocks[i+1:] {
+			if a.start < b.end && b.start < a.end {
+				return fmt.Errorf("blocos Modbus %s e %s se sobrepõem", a.name, b.name)
+			}
+		}
+	}
+	return nil
+}
+
+// Start inicia o servidor Modbus TCP
+func (s *Service) Start() error {
+	if !s.config.Enabled {
+		logger.Info("Serviço Modbus desabilitado por configuração")
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.running {
+		return nil
+	}
+
+	addr := net.JoinHostPort("", strconv.Itoa(s.config.Port))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar servidor Modbus em %s: %w", addr, err)
+	}
+
+	s.listener = listener
+	s.running = true
+
+	s.wg.Add(1)
+	go s.acceptLoop(listener)
+
+	logger.Infof("Servidor Modbus TCP iniciado na porta %d (ordem %s, INT16: %v)",
+		s.config.Port, s.order, s.config.ScaleToInt16)
+	return nil
+}
+
+// Stop para o servidor Modbus TCP
+func (s *Service) Stop() {
+	s.mutex.Lock()
+	if !s.running {
+		s.mutex.Unlock()
+		return
+	}
+	s.running = false
+	s.listener.Close()
+	s.mutex.Unlock()
+
+	s.connMutex.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.connMutex.Unlock()
+
+	s.wg.Wait()
+	logger.Info("Serviço Modbus parado")
+}
+
+// IsRunning verifica se o serviço está em execução
+func (s *Service) IsRunning() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.running
+}
+
+// UpdateMetrics atualiza a imagem de registradores com as métricas do radar.
+// Compatível com radar.MetricsHandler.
+func (s *Service) UpdateMetrics(metrics models.RadarMetrics) {
+	if !s.config.Enabled {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	regs := s.config.Registers
+	for i := 0; i < 7; i++ {
+		if s.config.ScaleToInt16 {
+			s.registers[regs.Velocities+i] = scaleToInt16(metrics.Velocities[i], s.config.VelocityScale)
+			s.registers[regs.Positions+i] = scaleToInt16(metrics.Positions[i], s.config.PositionScale)
+		} else {
+			vel := encodeFloat32(metrics.Velocities[i], s.order)
+			pos := encodeFloat32(metrics.Positions[i], s.order)
+			copy(s.registers[regs.Velocities+i*2:], vel[:])
+			copy(s.registers[regs.Positions+i*2:], pos[:])
+		}
+	}
+
+	s.status = statusCode(metrics.Status)
+	s.alarms = 0
+	if metrics.Status == "obstruido" {
+		s.alarms |= AlarmObstructed
+	}
+	if metrics.Status != "ok" {
+		s.alarms |= AlarmNotOK
+	}
+	s.heartbeat++
+	s.lastUpdate = time.Now()
+}
+
+// readRegisters retorna uma cópia de quantity registradores a partir de start,
+// com status e alarmes atualizados conforme a idade dos dados
+func (s *Service) readRegisters(start, quantity int) ([]uint16, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if start < 0 || start+quantity > len(s.registers) {
+		return nil, false
+	}
+
+	values := make([]uint16, quantity)
+	copy(values, s.registers[start:start+quantity])
+
+	status, alarms := s.status, s.alarms
+	if s.lastUpdate.IsZero() || time.Since(s.lastUpdate) > s.config.StaleTimeout {
+		status = StatusNoData
+		alarms |= AlarmNoData | AlarmNotOK
+	}
+
+	// Registradores dinâmicos
+	regs := s.config.Registers
+	for addr, value := range map[int]uint16{
+		regs.Status:    status,
+		regs.Alarms:    alarms,
+		regs.Heartbeat: s.heartbeat,
+	} {
+		if addr >= start && addr < start+quantity {
+			values[addr-start] = value
+		}
+	}
+
+	return values, true
+}
+
+// acceptsUnit verifica se o unit ID da requisição é atendido por este servidor
+func (s *Service) acceptsUnit(unitID byte) bool {
+	return s.config.UnitID == 0 || int(unitID) == s.config.UnitID
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// EventType identifica o tipo de um evento do registro operacional
+type EventType string
+
+// Tipos de evento registrados
+const (
+	EventRadarConnected     EventType = "radar_connected"     // Comunicação com o radar estabelecida
+	EventRadarLost          EventType = "radar_lost"          // Comunicação perdida após maxConsecutiveErrors
+	EventStatusChanged      EventType = "status_changed"      // Status do radar alterado
+	EventClientConnected    EventType = "client_connected"    // Cliente WebSocket conectado
+	EventClientDisconnected EventType = "client_disconnected" // Cliente WebSocket desconectado
+	EventAlarmRaised        EventType = "alarm_raised"        // Alarme ativado
+	EventAlarmCleared       EventType = "alarm_cleared"       // Alarme desativado
+	EventAlarmAcked         EventType = "alarm_acked"         // Alarme reconhecido por um operador
+	EventConfigReloaded     EventType = "config_reloaded"     // Configuração recarregada
+	EventPLCCommand         EventType = "plc_command"         // Comando enviado ao PLC pela API
+)
+
+// EventTypes lista os tipos de evento conhecidos
+var EventTypes = []EventType{
+	EventRadarConnected, EventRadarLost, EventStatusChanged,
+	EventClientConnected, EventClientDisconnected,
+	EventAlarmRaised, EventAlarmCleared, EventAlarmAcked,
+	EventConfigReloaded, EventPLCCommand,
+}
+
+// Event é uma entrada do registro de eventos operacionais e de auditoria
+type Event struct {
+	ID        string                 `json:"id"` // Id no stream do Redis, ou "f<ms>-<seq>" no arquivo de fallback
+	Type      EventType              `json:"type"`
+	Timestamp time.Time              `json:"timestamp"`
+	RadarID   string                 `json:"radarId,omitempty"`
+	Actor     string                 `json:"actor,omitempty"` // Identidade que originou o evento, se houver
+	Message   string                 `json:"message"`
+	Data      map[string]interface{} `json:"data,omitempty"` // Detalhes específicos do tipo
+}
+
+// EventMessage é uma mensagem WebSocket com um evento registrado
+type EventMessage struct {
+	WebSocketMessage
+	Event Event `json:"event"`
+}
@@ -4,10 +4,13 @@ import "time"
 
 // WebSocketMessage representa a estrutura base de todas as mensagens WebSocket
 type WebSocketMessage struct {
-	Type      string      `json:"type"`            // Tipo da mensagem: "metrics", "status", "velocity_changes", etc.
-	Timestamp time.Time   `json:"timestamp"`       // Timestamp da mensagem
-	Data      interface{} `json:"data,omitempty"`  // Dados adicionais específicos do tipo
-	Error     string      `json:"error,omitempty"` // Mensagem de erro, se houver
+	Type      string      `json:"type"`              // Tipo da mensagem: "metrics", "status", "velocity_changes", etc.
+	Timestamp time.Time   `json:"timestamp"`         // Timestamp da mensagem
+	Data      interface{} `json:"data,omitempty"`    // Dados adicionais específicos do tipo
+	Error     string      `json:"error,omitempty"`   // Mensagem de erro, se houver
+	RadarID   string      `json:"radarId,omitempty"` // Radar de origem (mensagens de dados)
+	ReplyTo   string      `json:"replyTo,omitempty"` // ID do comando respondido, se houver
+	Replay    bool        `json:"replay,omitempty"`  // Dado histórico reenviado por um replay
 }
 
 // MetricsMessage é uma mensagem específica para métricas do radar
@@ -32,6 +35,55 @@ type StatusMessage struct {
 	ErrorCount int    `json:"errorCount,omitempty"`
 }
 
+// ChannelMessage é uma mensagem com os valores de um único canal do radar
+type ChannelMessage struct {
+	WebSocketMessage
+	Channel  int     `json:"channel"` // Canal (1-7)
+	Velocity float64 `json:"velocity"`
+	Position float64 `json:"position"`
+	Status   string  `json:"status"`
+}
+
+// AlarmsMessage é uma mensagem enviada quando o estado dos alarmes muda
+type AlarmsMessage struct {
+	WebSocketMessage
+	Active               bool `json:"active"`
+	Obstructed           bool `json:"obstructed"`
+	CommunicationFailure bool `json:"communicationFailure"`
+	NotOK                bool `json:"notOk"`
+
+	// Alarmes ativos reconhecidos por um operador
+	Acknowledged []string `json:"acknowledged,omitempty"`
+}
+
+// SubscriptionMessage confirma as assinaturas atuais de um cliente
+type SubscriptionMessage struct {
+	WebSocketMessage
+	Topics  []string `json:"topics"`            // Tópicos assinados ("*" = todos)
+	Radars  []string `json:"radars,omitempty"`  // Radares filtrados (vazio = todos)
+	MaxRate float64  `json:"maxRate,omitempty"` // Mensagens por segundo de métricas (0 = sem limite)
+}
+
+// ErrorMessage é uma mensagem de erro estruturada. Error mantém a descrição
+// legível usada pelas versões antigas do aplicativo.
+type ErrorMessage struct {
+	WebSocketMessage
+	Code    string      `json:"code"`              // Código estável do erro (ex.: "invalid_params")
+	Command string      `json:"command,omitempty"` // Comando que originou o erro
+	Field   string      `json:"field,omitempty"`   // Campo inválido, em erros de validação
+	Details interface{} `json:"details,omitempty"` // Informações adicionais específicas do código
+}
+
+// ReplayStateMessage informa o estado do replay histórico de um cliente
+type ReplayStateMessage struct {
+	WebSocketMessage
+	State    string    `json:"state"`    // "playing", "paused", "stopped", "finished" ou "error"
+	From     time.Time `json:"from"`     // Início do intervalo reproduzido
+	To       time.Time `json:"to"`       // Fim do intervalo reproduzido
+	Position time.Time `json:"position"` // Timestamp da última amostra enviada (ou do seek)
+	Speed    float64   `json:"speed"`    // Fator de velocidade (1 = tempo real)
+}
+
 // HistoryMessage é uma mensagem específica para histórico de velocidade
 type HistoryMessage struct {
 	WebSocketMessage
@@ -41,9 +93,11 @@ type HistoryMessage struct {
 
 // CommandMessage é uma mensagem de comando do cliente para o servidor
 type CommandMessage struct {
-	Type   string      `json:"type"`             // Tipo de comando: "get_history", "get_status", etc.
-	Params interface{} `json:"params,omitempty"` // Parâmetros adicionais
-	ID     string      `json:"id,omitempty"`     // ID opcional para correlacionar solicitações/respostas
+	Type    string      `json:"type"`             // Tipo de comando: "get_history", "get_status", etc.
+	Params  interface{} `json:"params,omitempty"` // Parâmetros adicionais
+	ID      string      `json:"id,omitempty"`     // ID opcional, devolvido em replyTo na resposta
+	Version int         `json:"v,omitempty"`      // Versão do protocolo usada pelo cliente (0 = 1)
+	Time    int64       `json:"time,omitempty"`   // Ping no formato antigo do aplicativo ({"type":"ping","time":...})
 }
 
 // ClientCommand representa um comando enviado pelo cliente
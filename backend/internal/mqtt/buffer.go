@@ -0,0 +1,153 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"radar_go/internal/models"
+)
+
+// diskBuffer guarda amostras do radar em um arquivo JSON Lines enquanto o
+// broker está inacessível. Apenas as BufferMaxMessages mais recentes são
+// mantidas; as mais antigas são descartadas na compactação.
+type diskBuffer struct {
+	path  string
+	max   int
+	file  *os.File
+	count int
+}
+
+// newDiskBuffer abre o buffer, contando as amostras de uma execução anterior
+func newDiskBuffer(path string, max int) (*diskBuffer, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório do buffer MQTT: %w", err)
+	}
+
+	b := &diskBuffer{path: path, max: max}
+
+	samples, err := b.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	b.count = len(samples)
+
+	return b, nil
+}
+
+// Len retorna o número de amostras no buffer
+func (b *diskBuffer) Len() int {
+	return b.count
+}
+
+// Append adiciona uma amostra ao final do buffer
+func (b *diskBuffer) Append(metrics models.RadarMetrics) error {
+	if b.file == nil {
+		file, err := os.OpenFile(b.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("erro ao abrir buffer MQTT: %w", err)
+		}
+		b.file = file
+	}
+
+	line, err := json.Marshal(metrics)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar amostra: %w", err)
+	}
+	if _, err := b.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("erro ao gravar buffer MQTT: %w", err)
+	}
+	b.count++
+
+	// Compactar com folga de 10% para não reescrever o arquivo a cada amostra
+	if b.max > 0 && b.count > b.max+b.max/10 {
+		samples, err := b.ReadAll()
+		if err != nil {
+			return err
+		}
+		if len(samples) > b.max {
+			samples = samples[len(samples)-b.max:]
+		}
+		return b.Replace(samples)
+	}
+	return nil
+}
+
+// Replace substitui o conteúdo do buffer pelas amostras informadas
+func (b *diskBuffer) Replace(samples []models.RadarMetrics) error {
+	b.Close()
+
+	if len(samples) == 0 {
+		b.count = 0
+		if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("erro ao remover buffer MQTT: %w", err)
+		}
+		return nil
+	}
+
+	tmpPath := b.path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("erro ao criar buffer MQTT: %w", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, sample := range samples {
+		if err := encoder.Encode(sample); err != nil {
+			file.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("erro ao gravar buffer MQTT: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("erro ao gravar buffer MQTT: %w", err)
+	}
+	file.Close()
+
+	if err := os.Rename(tmpPath, b.path); err != nil {
+		return fmt.Errorf("erro ao substituir buffer MQTT: %w", err)
+	}
+	b.count = len(samples)
+	return nil
+}
+
+// Close fecha o arquivo aberto para escrita
+func (b *diskBuffer) Close() {
+	if b.file != nil {
+		b.file.Close()
+		b.file = nil
+	}
+}
+
+// ReadAll lê as amostras da mais antiga para a mais recente, ignorando linhas
+// corrompidas (ex.: queda de energia durante a gravação)
+func (b *diskBuffer) ReadAll() ([]models.RadarMetrics, error) {
+	file, err := os.Open(b.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler buffer MQTT: %w", err)
+	}
+	defer file.Close()
+
+	var samples []models.RadarMetrics
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var sample models.RadarMetrics
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			continue
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler buffer MQTT: %w", err)
+	}
+	return samples, nil
+}
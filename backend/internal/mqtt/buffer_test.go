@@ -0,0 +1,222 @@
+package mqtt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"radar_go/internal/models"
+)
+
+// sample cria uma amostra identificada pela velocidade do primeiro canal
+func sample(n int) models.RadarMetrics {
+	return models.RadarMetrics{
+		Timestamp:  time.Date(2024, 5, 1, 12, 0, n, 0, time.UTC),
+		Status:     "ok",
+		Velocities: [7]float64{float64(n)},
+	}
+}
+
+// sampleIDs lista as velocidades do primeiro canal das amostras
+func sampleIDs(samples []models.RadarMetrics) []int {
+	ids := make([]int, len(samples))
+	for i, s := range samples {
+		ids[i] = int(s.Velocities[0])
+	}
+	return ids
+}
+
+// countLines conta as linhas do arquivo do buffer
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Count(string(data), "\n")
+}
+
+func equalIDs(got []int, from, to int) bool {
+	if len(got) != to-from+1 {
+		return false
+	}
+	for i, id := range got {
+		if id != from+i {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDiskBufferAppendAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "buffer.jsonl")
+
+	buffer, err := newDiskBuffer(path, 100)
+	if err != nil {
+		t.Fatalf("newDiskBuffer: %v", err)
+	}
+	for i := 1; i <= 5; i++ {
+		if err := buffer.Append(sample(i)); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	if buffer.Len() != 5 {
+		t.Errorf("Len = %d, esperado 5", buffer.Len())
+	}
+	buffer.Close()
+
+	// Nova execução: as amostras pendentes são contadas e lidas em ordem
+	buffer, err = newDiskBuffer(path, 100)
+	if err != nil {
+		t.Fatalf("newDiskBuffer: %v", err)
+	}
+	defer buffer.Close()
+	if buffer.Len() != 5 {
+		t.Errorf("Len após reabrir = %d, esperado 5", buffer.Len())
+	}
+
+	samples, err := buffer.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if ids := sampleIDs(samples); !equalIDs(ids, 1, 5) {
+		t.Errorf("amostras = %v, esperado 1-5", ids)
+	}
+	if !samples[0].Timestamp.Equal(sample(1).Timestamp) || samples[0].Status != "ok" {
+		t.Errorf("amostra reenviada difere da gravada: %+v", samples[0])
+	}
+
+	// Reenvio parcial: mantém apenas as não enviadas
+	if err := buffer.Replace(samples[3:]); err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+	samples, _ = buffer.ReadAll()
+	if ids := sampleIDs(samples); !equalIDs(ids, 4, 5) || buffer.Len() != 2 {
+		t.Errorf("após Replace: %v (Len %d), esperado 4-5", ids, buffer.Len())
+	}
+
+	// Novas amostras continuam após as restantes
+	if err := buffer.Append(sample(6)); err != nil {
+		t.Fatal(err)
+	}
+	samples, _ = buffer.ReadAll()
+	if ids := sampleIDs(samples); !equalIDs(ids, 4, 6) {
+		t.Errorf("após Append: %v, esperado 4-6", ids)
+	}
+
+	// Reenvio completo remove o arquivo
+	if err := buffer.Replace(nil); err != nil {
+		t.Fatalf("Replace(nil): %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("arquivo do buffer não removido: %v", err)
+	}
+	if buffer.Len() != 0 {
+		t.Errorf("Len após esvaziar = %d", buffer.Len())
+	}
+}
+
+func TestDiskBufferCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buffer.jsonl")
+
+	buffer, err := newDiskBuffer(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buffer.Close()
+
+	// Até 10% acima do limite o arquivo não é reescrito
+	for i := 1; i <= 11; i++ {
+		if err := buffer.Append(sample(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := countLines(t, path); n != 11 || buffer.Len() != 11 {
+		t.Errorf("%d linhas (Len %d) antes da compactação, esperado 11", n, buffer.Len())
+	}
+
+	// Acima da folga, mantém apenas as 10 mais recentes
+	if err := buffer.Append(sample(12)); err != nil {
+		t.Fatal(err)
+	}
+	if n := countLines(t, path); n != 10 || buffer.Len() != 10 {
+		t.Errorf("%d linhas (Len %d) após a compactação, esperado 10", n, buffer.Len())
+	}
+	samples, _ := buffer.ReadAll()
+	if ids := sampleIDs(samples); !equalIDs(ids, 3, 12) {
+		t.Errorf("amostras após a compactação = %v, esperado 3-12", ids)
+	}
+
+	// O limite vale por toda a execução
+	for i := 13; i <= 40; i++ {
+		if err := buffer.Append(sample(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := countLines(t, path); n > 11 {
+		t.Errorf("%d linhas no buffer, limite 10 (+10%%)", n)
+	}
+	samples, _ = buffer.ReadAll()
+	if ids := sampleIDs(samples); ids[len(ids)-1] != 40 {
+		t.Errorf("amostra mais recente = %d, esperado 40", ids[len(ids)-1])
+	}
+}
+
+func TestDiskBufferUnlimited(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buffer.jsonl")
+
+	buffer, err := newDiskBuffer(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buffer.Close()
+
+	for i := 1; i <= 50; i++ {
+		if err := buffer.Append(sample(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := countLines(t, path); n != 50 {
+		t.Errorf("%d linhas sem limite, esperado 50", n)
+	}
+}
+
+func TestDiskBufferSkipsCorruptedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buffer.jsonl")
+
+	buffer, err := newDiskBuffer(path, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buffer.Append(sample(1))
+	buffer.Close()
+
+	// Linha truncada por queda de energia no meio da gravação
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"positions":[1,2` + "\n")
+	file.Close()
+
+	buffer, err = newDiskBuffer(path, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buffer.Close()
+	buffer.Append(sample(2))
+
+	samples, err := buffer.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if ids := sampleIDs(samples); !equalIDs(ids, 1, 2) {
+		t.Errorf("amostras = %v, esperado 1-2", ids)
+	}
+}
@@ -0,0 +1,300 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"radar_go/internal/models"
+	"radar_go/pkg/logger"
+)
+
+// metricsPayload é a mensagem JSON publicada no tópico de métricas
+type metricsPayload struct {
+	Timestamp  time.Time  `json:"timestamp"`
+	Status     string     `json:"status"`
+	Positions  [7]float64 `json:"positions"`
+	Velocities [7]float64 `json:"velocities"`
+	Historical bool       `json:"historical,omitempty"` // Reenviada do buffer em disco
+}
+
+// velocityChangesPayload é a mensagem JSON publicada a cada mudança de velocidade
+type velocityChangesPayload struct {
+	Timestamp  time.Time               `json:"timestamp"`
+	Changes    []models.VelocityChange `json:"changes"`
+	Historical bool                    `json:"historical,omitempty"`
+}
+
+// statusPayload é a mensagem JSON publicada quando o status do radar muda
+type statusPayload struct {
+	Status    string    `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// alarmsPayload é a mensagem JSON publicada quando os alarmes mudam
+type alarmsPayload struct {
+	alarmState
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// publishJSON serializa e publica uma mensagem JSON
+func (s *Service) publishJSON(topic string, retained bool, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar mensagem MQTT: %w", err)
+	}
+	return s.publish(s.topic(topic), retained, data)
+}
+
+// publishPlain publica uma amostra nos tópicos JSON
+func (s *Service) publishPlain(metrics models.RadarMetrics, due bool) error {
+	if due {
+		if err := s.publishMetricsJSON(metrics, false); err != nil {
+			return err
+		}
+	}
+
+	if len(metrics.VelocityChanges) > 0 {
+		payload := velocityChangesPayload{Timestamp: metrics.Timestamp, Changes: metrics.VelocityChanges}
+		if err := s.publishJSON(s.config.Topics.VelocityChanges, false, payload); err != nil {
+			return err
+		}
+	}
+
+	return s.publishState(false)
+}
+
+// publishMetricsJSON publica posições e velocidades no tópico de métricas.
+// Amostras históricas nunca são retidas para não sobrescrever o valor atual.
+func (s *Service) publishMetricsJSON(metrics models.RadarMetrics, historical bool) error {
+	payload := metricsPayload{
+		Timestamp:  metrics.Timestamp,
+		Status:     metrics.Status,
+		Positions:  metrics.Positions,
+		Velocities: metrics.Velocities,
+		Historical: historical,
+	}
+	return s.publishJSON(s.config.Topics.Metrics, s.config.Retain && !historical, payload)
+}
+
+// publishState publica status e alarmes quando mudam (ou sempre, se force)
+func (s *Service) publishState(force bool) error {
+	now := time.Now()
+
+	// Sem amostras ainda não há status a publicar, apenas o alarme NoData
+	if (force || s.current.Status != s.sentStatus) && s.current.Status != "" {
+		payload := statusPayload{Status: s.current.Status, Timestamp: now}
+		if err := s.publishJSON(s.config.Topics.Status, s.config.Retain, payload); err != nil {
+			return err
+		}
+		s.sentStatus = s.current.Status
+	}
+
+	if force || s.alarms != s.sentAlarms {
+		payload := alarmsPayload{alarmState: s.alarms, Timestamp: now}
+		if err := s.publishJSON(s.config.Topics.Alarms, s.config.Retain, payload); err != nil {
+			return err
+		}
+		s.sentAlarms = s.alarms
+	}
+
+	return nil
+}
+
+// publishHistorical reenvia uma amostra do buffer nos tópicos JSON
+func (s *Service) publishHistorical(sample models.RadarMetrics) error {
+	if err := s.publishMetricsJSON(sample, true); err != nil {
+		return err
+	}
+	if len(sample.VelocityChanges) > 0 {
+		payload := velocityChangesPayload{Timestamp: sample.Timestamp, Changes: sample.VelocityChanges, Historical: true}
+		return s.publishJSON(s.config.Topics.VelocityChanges, false, payload)
+	}
+	return nil
+}
+
+// nodeTopic monta um tópico Sparkplug do edge node
+func (s *Service) nodeTopic(messageType string) string {
+	sp := s.config.Sparkplug
+	return sparkplugTopic(sp.GroupID, messageType, sp.EdgeNodeID, "")
+}
+
+// deviceTopic monta um tópico Sparkplug do dispositivo (o radar)
+func (s *Service) deviceTopic(messageType string) string {
+	sp := s.config.Sparkplug
+	return sparkplugTopic(sp.GroupID, messageType, sp.EdgeNodeID, sp.DeviceID)
+}
+
+// deathPayload codifica o NDEATH usado como LWT e no encerramento
+func (s *Service) deathPayload(bdSeq int64) []byte {
+	return encodeSparkplugPayload(time.Now(), -1, []sparkplugMetric{
+		{name: metricBdSeq, datatype: spTypeInt64, value: bdSeq},
+	})
+}
+
+// nextSeq retorna o próximo número de sequência Sparkplug (0-255)
+func (s *Service) nextSeq() int64 {
+	seq := s.seq
+	s.seq = (s.seq + 1) % 256
+	return int64(seq)
+}
+
+// publishSparkplug publica uma mensagem Sparkplug (QoS 0, sem retain, conforme a especificação)
+func (s *Service) publishSparkplug(topic string, seq int64, metrics []sparkplugMetric) error {
+	return s.publishQoS(topic, 0, false, encodeSparkplugPayload(time.Now(), seq, metrics))
+}
+
+// publishBirth publica NBIRTH e DBIRTH com o estado completo, reiniciando a sequência
+func (s *Service) publishBirth() error {
+	s.mutex.RLock()
+	bdSeq := s.bdSeq
+	s.mutex.RUnlock()
+
+	s.seq = 0
+	nodeMetrics := []sparkplugMetric{
+		{name: metricBdSeq, datatype: spTypeInt64, value: bdSeq},
+		{name: metricRebirth, alias: aliasRebirth, datatype: spTypeBoolean, value: false},
+	}
+	if err := s.publishSparkplug(s.nodeTopic(msgNBIRTH), s.nextSeq(), nodeMetrics); err != nil {
+		return err
+	}
+
+	deviceMetrics := s.deviceMetrics(s.current, true, true)
+	if err := s.publishSparkplug(s.deviceTopic(msgDBIRTH), s.nextSeq(), deviceMetrics); err != nil {
+		return err
+	}
+
+	s.sentValues = [2][7]float64{s.current.Velocities, s.current.Positions}
+	s.sentStatus = s.current.Status
+	s.sentAlarms = s.alarms
+
+	logger.Infof("Sparkplug B: NBIRTH/DBIRTH publicados (bdSeq %d)", bdSeq)
+	return nil
+}
+
+// publishDeviceData publica em DDATA apenas as métricas que mudaram
+func (s *Service) publishDeviceData(metrics models.RadarMetrics, due bool) error {
+	changed := make(map[int]bool, len(metrics.VelocityChanges))
+	for _, change := range metrics.VelocityChanges {
+		changed[change.Index] = true
+	}
+
+	var data []sparkplugMetric
+	for i := 0; i < 7; i++ {
+		if metrics.Velocities[i] != s.sentValues[0][i] && (due || changed[i]) {
+			data = append(data, sparkplugMetric{alias: aliasVelocityBase + uint64(i), datatype: spTypeDouble,
+				value: metrics.Velocities[i], timestamp: metrics.Timestamp})
+			s.sentValues[0][i] = metrics.Velocities[i]
+		}
+		if metrics.Positions[i] != s.sentValues[1][i] && due {
+			data = append(data, sparkplugMetric{alias: aliasPositionBase + uint64(i), datatype: spTypeDouble,
+				value: metrics.Positions[i], timestamp: metrics.Timestamp})
+			s.sentValues[1][i] = metrics.Positions[i]
+		}
+	}
+
+	if metrics.Status != s.sentStatus {
+		data = append(data, sparkplugMetric{alias: aliasStatus, datatype: spTypeString, value: metrics.Status})
+		s.sentStatus = metrics.Status
+	}
+	data = append(data, s.alarmMetrics(&s.sentAlarms, false)...)
+	s.sentAlarms = s.alarms
+
+	if len(data) == 0 {
+		return nil
+	}
+	return s.publishSparkplug(s.deviceTopic(msgDDATA), s.nextSeq(), data)
+}
+
+// publishHistoricalData reenvia uma amostra do buffer como DDATA histórico
+func (s *Service) publishHistoricalData(sample models.RadarMetrics) error {
+	data := s.deviceMetrics(sample, false, false)
+	for i := range data {
+		data[i].historical = true
+		data[i].timestamp = sample.Timestamp
+	}
+	return s.publishSparkplug(s.deviceTopic(msgDDATA), s.nextSeq(), data)
+}
+
+// publishDeath publica DDEATH e NDEATH no encerramento gracioso
+func (s *Service) publishDeath() error {
+	if err := s.publishSparkplug(s.deviceTopic(msgDDEATH), s.nextSeq(), nil); err != nil {
+		return err
+	}
+
+	s.mutex.RLock()
+	bdSeq := s.bdSeq
+	s.mutex.RUnlock()
+	return s.publishQoS(s.nodeTopic(msgNDEATH), 1, false, s.deathPayload(bdSeq))
+}
+
+// deviceMetrics lista as métricas do dispositivo. Com named, inclui os nomes
+// (obrigatórios no DBIRTH); com alarms, inclui o estado atual dos alarmes.
+func (s *Service) deviceMetrics(metrics models.RadarMetrics, named bool, alarms bool) []sparkplugMetric {
+	name := func(n string) string {
+		if named {
+			return n
+		}
+		return ""
+	}
+
+	list := []sparkplugMetric{
+		{name: name("Status"), alias: aliasStatus, datatype: spTypeString, value: metrics.Status},
+	}
+	for i := 0; i < 7; i++ {
+		list = append(list,
+			sparkplugMetric{name: name(velocityMetricName(i)), alias: aliasVelocityBase + uint64(i),
+				datatype: spTypeDouble, value: metrics.Velocities[i]},
+			sparkplugMetric{name: name(positionMetricName(i)), alias: aliasPositionBase + uint64(i),
+				datatype: spTypeDouble, value: metrics.Positions[i]},
+		)
+	}
+	if alarms {
+		list = append(list, s.alarmMetrics(nil, named)...)
+	}
+	return list
+}
+
+// alarmMetrics lista os alarmes atuais; com previous, apenas os que mudaram
+func (s *Service) alarmMetrics(previous *alarmState, named bool) []sparkplugMetric {
+	var old alarmState
+	if previous != nil {
+		old = *previous
+	}
+
+	var list []sparkplugMetric
+	add := func(name string, alias uint64, value, old bool) {
+		if previous != nil && value == old {
+			return
+		}
+		if !named {
+			name = ""
+		}
+		list = append(list, sparkplugMetric{name: name, alias: alias, datatype: spTypeBoolean, value: value})
+	}
+
+	add("Alarms/Active", aliasAlarmActive, s.alarms.Active, old.Active)
+	add("Alarms/Obstructed", aliasAlarmObstructed, s.alarms.Obstructed, old.Obstructed)
+	add("Alarms/NoData", aliasAlarmNoData, s.alarms.NoData, old.NoData)
+	add("Alarms/NotOK", aliasAlarmNotOK, s.alarms.NotOK, old.NotOK)
+	return list
+}
+
+// handleNodeCommand trata NCMD; apenas "Node Control/Rebirth" é suportado
+func (s *Service) handleNodeCommand(_ paho.Client, msg paho.Message) {
+	rebirth, err := isRebirthRequest(msg.Payload())
+	if err != nil {
+		logger.Warnf("NCMD Sparkplug inválido: %v", err)
+		return
+	}
+	if !rebirth {
+		return
+	}
+
+	select {
+	case s.rebirths <- struct{}{}:
+	default:
+	}
+}
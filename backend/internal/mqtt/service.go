@@ -0,0 +1,521 @@
+// Package mqtt publica as métricas do radar em um broker MQTT, em tópicos JSON
+// ou, opcionalmente, no formato Sparkplug B. Amostras recebidas enquanto o
+// broker está inacessível são guardadas em disco e reenviadas na reconexão.
+package mqtt
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"radar_go/internal/config"
+	"radar_go/internal/models"
+	"radar_go/pkg/logger"
+)
+
+// Capacidade da fila entre o hook do radar e a goroutine de publicação
+const updateQueueSize = 100
+
+// Service publica as métricas do radar via MQTT
+type Service struct {
+	config   config.MQTTConfig
+	clientID string
+	client   paho.Client
+	buffer   *diskBuffer
+
+	updates  chan models.RadarMetrics
+	connects chan struct{}
+	rebirths chan struct{}
+	stopChan chan struct{}
+	running  bool
+	mutex    sync.RWMutex
+	wg       sync.WaitGroup
+
+	bdSeq    int64 // Sequência de nascimento/morte Sparkplug (protegida por mutex)
+	buffered int64 // Amostras no buffer em disco (atômico)
+	dropped  uint64
+
+	// Estado da publicação, acessado apenas pela goroutine run
+	online      bool
+	current     models.RadarMetrics
+	lastUpdate  time.Time
+	stale       bool
+	alarms      alarmState
+	lastMetrics time.Time
+	sentStatus  string
+	sentAlarms  alarmState
+	sentValues  [2][7]float64 // Velocidades e posições do último DBIRTH/DDATA
+	seq         uint64
+}
+
+// alarmState representa os alarmes publicados
+type alarmState struct {
+	Active     bool `json:"active"`
+	Obstructed bool `json:"obstructed"`
+	NoData     bool `json:"noData"`
+	NotOK      bool `json:"notOk"`
+}
+
+// computeAlarms deriva os alarmes do status do radar, como no serviço Modbus
+func computeAlarms(status string, noData bool) alarmState {
+	alarms := alarmState{
+		Obstructed: status == "obstruido",
+		NoData:     noData,
+		NotOK:      status != "ok",
+	}
+	alarms.Active = alarms.Obstructed || alarms.NoData || alarms.NotOK
+	return alarms
+}
+
+// NewService cria um novo publicador MQTT
+func NewService(cfg config.MQTTConfig) (*Service, error) {
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	clientID := cfg.ClientID
+	if clientID == "" {
+		hostname, _ := os.Hostname()
+		if hostname == "" {
+			hostname = "localhost"
+		}
+		clientID = "radar_go-" + hostname
+	}
+
+	service := &Service{
+		config:   cfg,
+		clientID: clientID,
+		stale:    true,
+		alarms:   computeAlarms("", true),
+	}
+
+	if cfg.BufferFile != "" {
+		buffer, err := newDiskBuffer(cfg.BufferFile, cfg.BufferMaxMessages)
+		if err != nil {
+			return nil, err
+		}
+		service.buffer = buffer
+		service.buffered = int64(buffer.Len())
+		if buffer.Len() > 0 {
+			logger.Infof("Buffer MQTT contém %d amostras pendentes de uma execução anterior", buffer.Len())
+		}
+	}
+
+	return service, nil
+}
+
+// validateConfig verifica a configuração antes de criar o cliente
+func validateConfig(cfg config.MQTTConfig) error {
+	if cfg.Broker == "" {
+		return fmt.Errorf("broker MQTT não configurado")
+	}
+	if cfg.QoS > 2 {
+		return fmt.Errorf("QoS MQTT inválido: %d", cfg.QoS)
+	}
+
+	if cfg.Sparkplug.Enabled {
+		for name, id := range map[string]string{
+			"groupId":    cfg.Sparkplug.GroupID,
+			"edgeNodeId": cfg.Sparkplug.EdgeNodeID,
+			"deviceId":   cfg.Sparkplug.DeviceID,
+		} {
+			if id == "" || strings.ContainsAny(id, "/+#") {
+				return fmt.Errorf("identificador Sparkplug %s inválido: %q", name, id)
+			}
+		}
+		return nil
+	}
+
+	topics := cfg.Topics
+	for name, topic := range map[string]string{
+		"metrics":         topics.Metrics,
+		"velocityChanges": topics.VelocityChanges,
+		"status":          topics.Status,
+		"alarms":          topics.Alarms,
+		"availability":    topics.Availability,
+	} {
+		if topic == "" || strings.ContainsAny(topic, "+#") {
+			return fmt.Errorf("tópico MQTT %s inválido: %q", name, topic)
+		}
+	}
+	return nil
+}
+
+// Start conecta ao broker e inicia a publicação. A conexão é estabelecida em
+// segundo plano: um broker inacessível não impede a inicialização.
+func (s *Service) Start() error {
+	if !s.config.Enabled {
+		logger.Info("Serviço MQTT desabilitado por configuração")
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.running {
+		return nil
+	}
+
+	s.updates = make(chan models.RadarMetrics, updateQueueSize)
+	s.connects = make(chan struct{}, 1)
+	s.rebirths = make(chan struct{}, 1)
+	s.stopChan = make(chan struct{})
+	s.client = paho.NewClient(s.clientOptions())
+	s.running = true
+
+	s.client.Connect()
+
+	s.wg.Add(1)
+	go s.run(s.stopChan)
+
+	mode := "JSON"
+	if s.config.Sparkplug.Enabled {
+		mode = "Sparkplug B"
+	}
+	logger.Infof("Publicador MQTT iniciado: broker %s, cliente %s, formato %s", s.config.Broker, s.clientID, mode)
+	return nil
+}
+
+// Stop publica as mensagens de encerramento e desconecta do broker
+func (s *Service) Stop() {
+	s.mutex.Lock()
+	if !s.running {
+		s.mutex.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stopChan)
+	s.mutex.Unlock()
+
+	s.wg.Wait()
+	logger.Info("Serviço MQTT parado")
+}
+
+// IsRunning verifica se o serviço está em execução
+func (s *Service) IsRunning() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.running
+}
+
+// IsConnected verifica se há conexão ativa com o broker
+func (s *Service) IsConnected() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.running && s.client.IsConnectionOpen()
+}
+
+// BufferedCount retorna o número de amostras aguardando envio no buffer em disco
+func (s *Service) BufferedCount() int {
+	return int(atomic.LoadInt64(&s.buffered))
+}
+
+// UpdateMetrics enfileira as métricas para publicação sem bloquear o radar.
+// Compatível com radar.MetricsHandler.
+func (s *Service) UpdateMetrics(metrics models.RadarMetrics) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if !s.running {
+		return
+	}
+
+	select {
+	case s.updates <- metrics:
+	default:
+		if atomic.AddUint64(&s.dropped, 1)%100 == 1 {
+			logger.Warnf("Fila MQTT cheia, %d amostras descartadas", atomic.LoadUint64(&s.dropped))
+		}
+	}
+}
+
+// clientOptions monta as opções do cliente, incluindo o LWT
+func (s *Service) clientOptions() *paho.ClientOptions {
+	opts := paho.NewClientOptions().
+		AddBroker(s.config.Broker).
+		SetClientID(s.clientID).
+		SetUsername(s.config.Username).
+		SetPassword(s.config.Password).
+		SetCleanSession(true).
+		SetKeepAlive(s.config.KeepAlive).
+		SetConnectTimeout(s.config.ConnectTimeout).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(5 * time.Second).
+		SetMaxReconnectInterval(30 * time.Second)
+
+	if s.config.Sparkplug.Enabled {
+		// Chamado com mutex adquirido por Start
+		opts.SetBinaryWill(s.nodeTopic(msgNDEATH), s.deathPayload(s.bdSeq), 1, false)
+		// Cada nova conexão usa um bdSeq novo, que o NBIRTH seguinte repete
+		opts.SetReconnectingHandler(func(_ paho.Client, opts *paho.ClientOptions) {
+			s.mutex.Lock()
+			s.bdSeq = (s.bdSeq + 1) % 256
+			bdSeq := s.bdSeq
+			s.mutex.Unlock()
+			opts.SetBinaryWill(s.nodeTopic(msgNDEATH), s.deathPayload(bdSeq), 1, false)
+		})
+	} else {
+		opts.SetWill(s.topic(s.config.Topics.Availability), "offline", s.config.QoS, true)
+	}
+
+	opts.SetOnConnectHandler(func(paho.Client) {
+		select {
+		case s.connects <- struct{}{}:
+		default:
+		}
+	})
+	opts.SetConnectionLostHandler(func(_ paho.Client, err error) {
+		logger.Warnf("Conexão com o broker MQTT perdida: %v", err)
+	})
+
+	return opts
+}
+
+// run é a única goroutine que publica, mantendo a ordem das mensagens
+// e a sequência Sparkplug consistentes
+func (s *Service) run(stop chan struct{}) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			s.shutdown()
+			return
+		case metrics := <-s.updates:
+			s.handleMetrics(metrics)
+		case <-s.connects:
+			s.handleConnect()
+		case <-s.rebirths:
+			if s.online {
+				logger.Info("Rebirth Sparkplug solicitado pelo host")
+				if err := s.publishBirth(); err != nil {
+					s.goOffline(err)
+				}
+			}
+		case now := <-ticker.C:
+			s.checkStale(now)
+		}
+	}
+}
+
+// handleConnect publica o nascimento da sessão e esvazia o buffer em disco
+func (s *Service) handleConnect() {
+	logger.Infof("Conectado ao broker MQTT %s", s.config.Broker)
+
+	var err error
+	if s.config.Sparkplug.Enabled {
+		// NCMD deve ser assinado antes do NBIRTH
+		token := s.client.Subscribe(s.nodeTopic(msgNCMD), 1, s.handleNodeCommand)
+		if token.WaitTimeout(s.publishTimeout()) && token.Error() != nil {
+			logger.Warnf("Erro ao assinar comandos Sparkplug: %v", token.Error())
+		}
+		err = s.publishBirth()
+	} else {
+		err = s.publish(s.topic(s.config.Topics.Availability), true, []byte("online"))
+	}
+	if err != nil {
+		s.goOffline(err)
+		return
+	}
+	s.online = true
+
+	if err := s.flushBuffer(); err != nil {
+		s.goOffline(err)
+		return
+	}
+
+	// Estado atual retido depois do histórico reenviado
+	if !s.config.Sparkplug.Enabled {
+		if !s.lastUpdate.IsZero() {
+			err = s.publishMetricsJSON(s.current, false)
+		}
+		if err == nil {
+			err = s.publishState(true)
+		}
+		if err != nil {
+			s.goOffline(err)
+		}
+	}
+}
+
+// handleMetrics publica uma amostra ou a guarda no buffer se estiver offline
+func (s *Service) handleMetrics(metrics models.RadarMetrics) {
+	s.current = metrics
+	s.lastUpdate = time.Now()
+	s.stale = false
+	s.alarms = computeAlarms(metrics.Status, false)
+
+	// Limitar a taxa de métricas; mudanças de velocidade e status sempre passam
+	due := s.config.PublishInterval <= 0 || metrics.Timestamp.Sub(s.lastMetrics) >= s.config.PublishInterval
+	if due {
+		s.lastMetrics = metrics.Timestamp
+	}
+
+	if s.online && !s.client.IsConnectionOpen() {
+		s.goOffline(nil)
+	}
+	if !s.online {
+		if due || len(metrics.VelocityChanges) > 0 {
+			s.bufferSample(metrics)
+		}
+		return
+	}
+
+	var err error
+	if s.config.Sparkplug.Enabled {
+		err = s.publishDeviceData(metrics, due)
+	} else {
+		err = s.publishPlain(metrics, due)
+	}
+	if err != nil {
+		s.goOffline(err)
+		s.bufferSample(metrics)
+	}
+}
+
+// checkStale sinaliza o alarme NoData quando não há métricas dentro de StaleTimeout
+func (s *Service) checkStale(now time.Time) {
+	if s.config.StaleTimeout <= 0 || s.stale || s.lastUpdate.IsZero() ||
+		now.Sub(s.lastUpdate) < s.config.StaleTimeout {
+		return
+	}
+
+	s.stale = true
+	s.alarms = computeAlarms(s.current.Status, true)
+	logger.Warnf("MQTT: nenhuma métrica do radar há mais de %v", s.config.StaleTimeout)
+
+	if !s.online {
+		return
+	}
+
+	var err error
+	if s.config.Sparkplug.Enabled {
+		err = s.publishDeviceData(s.current, false)
+	} else {
+		err = s.publishState(false)
+	}
+	if err != nil {
+		s.goOffline(err)
+	}
+}
+
+// goOffline passa a bufferizar as amostras até a próxima conexão
+func (s *Service) goOffline(err error) {
+	if s.online {
+		if err != nil {
+			logger.Warnf("Publicação MQTT falhou, bufferizando amostras: %v", err)
+		} else {
+			logger.Warn("Broker MQTT indisponível, bufferizando amostras")
+		}
+	}
+	s.online = false
+}
+
+// bufferSample guarda uma amostra no buffer em disco
+func (s *Service) bufferSample(metrics models.RadarMetrics) {
+	if s.buffer == nil {
+		return
+	}
+	if err := s.buffer.Append(metrics); err != nil {
+		logger.Errorf("Erro ao bufferizar amostra MQTT: %v", err)
+		return
+	}
+	atomic.StoreInt64(&s.buffered, int64(s.buffer.Len()))
+}
+
+// flushBuffer reenvia as amostras bufferizadas, mantendo as não enviadas
+func (s *Service) flushBuffer() error {
+	if s.buffer == nil || s.buffer.Len() == 0 {
+		return nil
+	}
+
+	samples, err := s.buffer.ReadAll()
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Reenviando %d amostras do buffer MQTT", len(samples))
+
+	sent := 0
+	for _, sample := range samples {
+		if s.config.Sparkplug.Enabled {
+			err = s.publishHistoricalData(sample)
+		} else {
+			err = s.publishHistorical(sample)
+		}
+		if err != nil {
+			break
+		}
+		sent++
+	}
+
+	if replaceErr := s.buffer.Replace(samples[sent:]); replaceErr != nil {
+		logger.Errorf("Erro ao atualizar buffer MQTT: %v", replaceErr)
+	}
+	atomic.StoreInt64(&s.buffered, int64(s.buffer.Len()))
+	return err
+}
+
+// shutdown publica o encerramento da sessão e desconecta
+func (s *Service) shutdown() {
+	if s.online && s.client.IsConnectionOpen() {
+		var err error
+		if s.config.Sparkplug.Enabled {
+			err = s.publishDeath()
+		} else {
+			err = s.publish(s.topic(s.config.Topics.Availability), true, []byte("offline"))
+		}
+		if err != nil {
+			logger.Warnf("Erro ao publicar encerramento MQTT: %v", err)
+		}
+	}
+	s.online = false
+
+	s.client.Disconnect(250)
+	if s.buffer != nil {
+		s.buffer.Close()
+	}
+}
+
+// publish envia uma mensagem com o QoS configurado e aguarda a confirmação
+func (s *Service) publish(topic string, retained bool, payload []byte) error {
+	return s.publishQoS(topic, s.config.QoS, retained, payload)
+}
+
+// publishQoS envia uma mensagem com QoS explícito (usado pelo Sparkplug)
+func (s *Service) publishQoS(topic string, qos byte, retained bool, payload []byte) error {
+	token := s.client.Publish(topic, qos, retained, payload)
+	if !token.WaitTimeout(s.publishTimeout()) {
+		return fmt.Errorf("timeout ao publicar em %s", topic)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("erro ao publicar em %s: %w", topic, err)
+	}
+	return nil
+}
+
+// publishTimeout retorna o tempo máximo de espera por uma confirmação
+func (s *Service) publishTimeout() time.Duration {
+	if s.config.ConnectTimeout > 0 {
+		return s.config.ConnectTimeout
+	}
+	return 10 * time.Second
+}
+
+// topic monta um tópico JSON a partir de TopicPrefix
+func (s *Service) topic(name string) string {
+	prefix := strings.TrimSuffix(s.config.TopicPrefix, "/")
+	if prefix == "" {
+		return name
+	}
+	return prefix + "/" + name
+}
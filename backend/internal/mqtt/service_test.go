@@ -0,0 +1,515 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.mqtt.golang/packets"
+
+	"radar_go/internal/config"
+	"radar_go/internal/models"
+)
+
+// testMessage é uma mensagem recebida pelo broker de teste
+type testMessage struct {
+	topic    string
+	payload  []byte
+	qos      byte
+	retained bool
+}
+
+// testBroker é um broker MQTT 3.1.1 mínimo em uma porta local: registra
+// conexões, publicações e assinaturas, sem repassar mensagens entre clientes
+type testBroker struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mutex         sync.Mutex
+	conns         map[net.Conn]*sync.Mutex // Mutex de escrita por conexão
+	connects      []*packets.ConnectPacket
+	messages      []testMessage
+	subscriptions []string
+	refuse        bool
+}
+
+// startBroker inicia o broker de teste; é fechado ao fim do teste
+func startBroker(t *testing.T) *testBroker {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("erro ao iniciar o broker de teste: %v", err)
+	}
+
+	broker := &testBroker{listener: listener, conns: make(map[net.Conn]*sync.Mutex)}
+	broker.wg.Add(1)
+	go broker.accept()
+	t.Cleanup(broker.close)
+	return broker
+}
+
+// url retorna o endereço do broker no formato do cliente paho
+func (b *testBroker) url() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+func (b *testBroker) accept() {
+	defer b.wg.Done()
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		b.wg.Add(1)
+		go b.serve(conn)
+	}
+}
+
+// serve trata uma conexão de cliente até DISCONNECT ou erro
+func (b *testBroker) serve(conn net.Conn) {
+	defer b.wg.Done()
+	defer conn.Close()
+
+	packet, err := packets.ReadPacket(conn)
+	if err != nil {
+		return
+	}
+	connect, ok := packet.(*packets.ConnectPacket)
+	if !ok {
+		return
+	}
+
+	connack := packets.NewControlPacket(packets.Connack).(*packets.ConnackPacket)
+	b.mutex.Lock()
+	if b.refuse {
+		b.mutex.Unlock()
+		connack.ReturnCode = packets.ErrRefusedServerUnavailable
+		connack.Write(conn)
+		return
+	}
+	writeMutex := &sync.Mutex{}
+	b.conns[conn] = writeMutex
+	b.connects = append(b.connects, connect)
+	b.mutex.Unlock()
+
+	defer func() {
+		b.mutex.Lock()
+		delete(b.conns, conn)
+		b.mutex.Unlock()
+	}()
+
+	write := func(packet packets.ControlPacket) error {
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		return packet.Write(conn)
+	}
+	if write(connack) != nil {
+		return
+	}
+
+	for {
+		packet, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+
+		var reply packets.ControlPacket
+		switch p := packet.(type) {
+		case *packets.PublishPacket:
+			b.mutex.Lock()
+			b.messages = append(b.messages, testMessage{
+				topic:    p.TopicName,
+				payload:  p.Payload,
+				qos:      p.Qos,
+				retained: p.Retain,
+			})
+			b.mutex.Unlock()
+			if p.Qos == 1 {
+				puback := packets.NewControlPacket(packets.Puback).(*packets.PubackPacket)
+				puback.MessageID = p.MessageID
+				reply = puback
+			}
+		case *packets.SubscribePacket:
+			b.mutex.Lock()
+			b.subscriptions = append(b.subscriptions, p.Topics...)
+			b.mutex.Unlock()
+			suback := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket)
+			suback.MessageID = p.MessageID
+			suback.ReturnCodes = p.Qoss
+			reply = suback
+		case *packets.PingreqPacket:
+			reply = packets.NewControlPacket(packets.Pingresp)
+		case *packets.DisconnectPacket:
+			return
+		}
+
+		if reply != nil && write(reply) != nil {
+			return
+		}
+	}
+}
+
+// setRefuse faz o broker recusar (CONNACK "servidor indisponível") novas conexões
+func (b *testBroker) setRefuse(refuse bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.refuse = refuse
+}
+
+// dropConnections fecha as conexões ativas sem DISCONNECT, como uma queda de rede
+func (b *testBroker) dropConnections() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for conn := range b.conns {
+		conn.Close()
+	}
+}
+
+// send publica uma mensagem QoS 0 para todos os clientes conectados
+func (b *testBroker) send(topic string, payload []byte) {
+	publish := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+	publish.TopicName = topic
+	publish.Payload = payload
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for conn, writeMutex := range b.conns {
+		writeMutex.Lock()
+		publish.Write(conn)
+		writeMutex.Unlock()
+	}
+}
+
+// messagesOn retorna as mensagens recebidas em um tópico, em ordem
+func (b *testBroker) messagesOn(topic string) []testMessage {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var list []testMessage
+	for _, message := range b.messages {
+		if message.topic == topic {
+			list = append(list, message)
+		}
+	}
+	return list
+}
+
+// connectPackets retorna os CONNECT aceitos pelo broker
+func (b *testBroker) connectPackets() []*packets.ConnectPacket {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]*packets.ConnectPacket(nil), b.connects...)
+}
+
+// subscribed verifica se algum cliente assinou o tópico
+func (b *testBroker) subscribed(topic string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, subscription := range b.subscriptions {
+		if subscription == topic {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *testBroker) close() {
+	b.listener.Close()
+	b.dropConnections()
+	b.wg.Wait()
+}
+
+// waitFor aguarda a condição ser satisfeita ou falha após o tempo limite
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("tempo esgotado aguardando %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// testConfig retorna uma configuração JSON apontando para o broker de teste
+func testConfig(broker *testBroker) config.MQTTConfig {
+	return config.MQTTConfig{
+		Enabled:        true,
+		Broker:         broker.url(),
+		ClientID:       "radar-test",
+		QoS:            1,
+		Retain:         true,
+		KeepAlive:      30 * time.Second,
+		ConnectTimeout: 2 * time.Second,
+		TopicPrefix:    "radar",
+		Topics: config.MQTTTopics{
+			Metrics:         "metrics",
+			VelocityChanges: "velocity_changes",
+			Status:          "status",
+			Alarms:          "alarms",
+			Availability:    "availability",
+		},
+	}
+}
+
+// startService cria e inicia o publicador; é parado antes do broker
+func startService(t *testing.T, cfg config.MQTTConfig) *Service {
+	t.Helper()
+
+	service, err := NewService(cfg)
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	if err := service.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(service.Stop)
+	return service
+}
+
+// decodeMetrics decodifica uma mensagem do tópico de métricas
+func decodeMetrics(t *testing.T, message testMessage) metricsPayload {
+	t.Helper()
+
+	var payload metricsPayload
+	if err := json.Unmarshal(message.payload, &payload); err != nil {
+		t.Fatalf("métricas inválidas %q: %v", message.payload, err)
+	}
+	return payload
+}
+
+func TestPublishJSON(t *testing.T) {
+	broker := startBroker(t)
+	service := startService(t, testConfig(broker))
+
+	waitFor(t, 5*time.Second, "availability online", func() bool {
+		return len(broker.messagesOn("radar/availability")) == 1
+	})
+	if online := broker.messagesOn("radar/availability")[0]; string(online.payload) != "online" || !online.retained {
+		t.Errorf("availability = %q (retain %v), esperado online retido", online.payload, online.retained)
+	}
+	if !service.IsConnected() {
+		t.Error("IsConnected = false após a conexão")
+	}
+
+	// LWT: availability offline retido
+	connect := broker.connectPackets()[0]
+	if !connect.WillFlag || connect.WillTopic != "radar/availability" ||
+		string(connect.WillMessage) != "offline" || !connect.WillRetain || connect.ClientIdentifier != "radar-test" {
+		t.Errorf("CONNECT inesperado: will %v %q %q retain %v, cliente %q", connect.WillFlag,
+			connect.WillTopic, connect.WillMessage, connect.WillRetain, connect.ClientIdentifier)
+	}
+
+	metrics := models.RadarMetrics{
+		Timestamp:  time.Now(),
+		Status:     "obstruido",
+		Positions:  [7]float64{1.5, 2, 3},
+		Velocities: [7]float64{0.25, -1},
+		VelocityChanges: []models.VelocityChange{
+			{Index: 1, OldValue: 0, NewValue: -1},
+		},
+	}
+	service.UpdateMetrics(metrics)
+
+	waitFor(t, 5*time.Second, "alarmes após as métricas", func() bool {
+		return len(broker.messagesOn("radar/alarms")) == 2
+	})
+
+	published := broker.messagesOn("radar/metrics")
+	if len(published) != 1 {
+		t.Fatalf("%d mensagens de métricas, esperada 1", len(published))
+	}
+	payload := decodeMetrics(t, published[0])
+	if payload.Velocities != metrics.Velocities || payload.Positions != metrics.Positions ||
+		payload.Status != "obstruido" || payload.Historical {
+		t.Errorf("métricas publicadas = %+v", payload)
+	}
+	if !published[0].retained || published[0].qos != 1 {
+		t.Errorf("métricas com retain %v, QoS %d; esperado retido, QoS 1", published[0].retained, published[0].qos)
+	}
+
+	changes := broker.messagesOn("radar/velocity_changes")
+	if len(changes) != 1 || changes[0].retained {
+		t.Fatalf("mudanças de velocidade = %+v, esperada 1 não retida", changes)
+	}
+	var changesPayload velocityChangesPayload
+	if err := json.Unmarshal(changes[0].payload, &changesPayload); err != nil ||
+		len(changesPayload.Changes) != 1 || changesPayload.Changes[0].Index != 1 {
+		t.Errorf("mudanças de velocidade = %s (%v)", changes[0].payload, err)
+	}
+
+	status := broker.messagesOn("radar/status")
+	if len(status) != 1 {
+		t.Fatalf("%d mensagens de status, esperada 1", len(status))
+	}
+	var statusMessage statusPayload
+	if err := json.Unmarshal(status[0].payload, &statusMessage); err != nil || statusMessage.Status != "obstruido" {
+		t.Errorf("status = %s (%v)", status[0].payload, err)
+	}
+
+	// Alarmes: NoData na conexão (sem amostras), depois obstrução
+	var alarms alarmsPayload
+	if err := json.Unmarshal(broker.messagesOn("radar/alarms")[0].payload, &alarms); err != nil ||
+		!alarms.NoData || !alarms.Active {
+		t.Errorf("alarmes iniciais = %+v (%v), esperado NoData", alarms.alarmState, err)
+	}
+	alarms = alarmsPayload{}
+	if err := json.Unmarshal(broker.messagesOn("radar/alarms")[1].payload, &alarms); err != nil ||
+		alarms.alarmState != (alarmState{Active: true, Obstructed: true, NotOK: true}) {
+		t.Errorf("alarmes = %+v (%v), esperado obstruído", alarms.alarmState, err)
+	}
+
+	// Encerramento gracioso publica offline retido
+	service.Stop()
+	availability := broker.messagesOn("radar/availability")
+	if last := availability[len(availability)-1]; string(last.payload) != "offline" || !last.retained {
+		t.Errorf("availability no encerramento = %q (retain %v)", last.payload, last.retained)
+	}
+}
+
+func TestReconnectReplaysBuffer(t *testing.T) {
+	broker := startBroker(t)
+	cfg := testConfig(broker)
+	cfg.BufferFile = filepath.Join(t.TempDir(), "mqtt_buffer.jsonl")
+	cfg.BufferMaxMessages = 100
+	service := startService(t, cfg)
+
+	waitFor(t, 5*time.Second, "availability online", func() bool {
+		return len(broker.messagesOn("radar/availability")) == 1
+	})
+
+	// Queda da conexão com o broker recusando a reconexão
+	broker.setRefuse(true)
+	broker.dropConnections()
+	waitFor(t, 5*time.Second, "detecção da queda", func() bool { return !service.IsConnected() })
+
+	start := time.Now()
+	for i := 1; i <= 3; i++ {
+		service.UpdateMetrics(models.RadarMetrics{
+			Timestamp:  start.Add(time.Duration(i) * time.Second),
+			Status:     "ok",
+			Velocities: [7]float64{float64(i)},
+		})
+	}
+	waitFor(t, 5*time.Second, "amostras no buffer", func() bool { return service.BufferedCount() == 3 })
+	if n := len(broker.messagesOn("radar/metrics")); n != 0 {
+		t.Fatalf("%d métricas publicadas com o broker recusando conexões", n)
+	}
+
+	// Broker de volta: reenvio do histórico em ordem, depois o estado atual retido
+	broker.setRefuse(false)
+	waitFor(t, 20*time.Second, "reconexão", func() bool {
+		return len(broker.messagesOn("radar/availability")) == 2
+	})
+	waitFor(t, 5*time.Second, "reenvio do buffer", func() bool {
+		return len(broker.messagesOn("radar/metrics")) == 4
+	})
+
+	published := broker.messagesOn("radar/metrics")
+	for i, message := range published[:3] {
+		payload := decodeMetrics(t, message)
+		if !payload.Historical || message.retained || payload.Velocities[0] != float64(i+1) {
+			t.Errorf("amostra histórica %d = %+v (retain %v)", i, payload, message.retained)
+		}
+	}
+	current := decodeMetrics(t, published[3])
+	if current.Historical || !published[3].retained || current.Velocities[0] != 3 {
+		t.Errorf("estado atual = %+v (retain %v), esperada a última amostra retida", current, published[3].retained)
+	}
+
+	if n := service.BufferedCount(); n != 0 {
+		t.Errorf("BufferedCount = %d após o reenvio", n)
+	}
+	if n := len(broker.connectPackets()); n != 2 {
+		t.Errorf("%d conexões aceitas, esperadas 2", n)
+	}
+}
+
+func TestPublishSparkplug(t *testing.T) {
+	broker := startBroker(t)
+	cfg := testConfig(broker)
+	cfg.Sparkplug = config.SparkplugConfig{Enabled: true, GroupID: "Plant", EdgeNodeID: "Edge", DeviceID: "Radar"}
+	service := startService(t, cfg)
+
+	nbirth := "spBv1.0/Plant/NBIRTH/Edge"
+	dbirth := "spBv1.0/Plant/DBIRTH/Edge/Radar"
+	ddata := "spBv1.0/Plant/DDATA/Edge/Radar"
+	ncmd := "spBv1.0/Plant/NCMD/Edge"
+
+	waitFor(t, 5*time.Second, "DBIRTH", func() bool { return len(broker.messagesOn(dbirth)) == 1 })
+	if !broker.subscribed(ncmd) {
+		t.Errorf("%s não assinado", ncmd)
+	}
+
+	// LWT: NDEATH com o mesmo bdSeq do NBIRTH
+	connect := broker.connectPackets()[0]
+	death := decodeSparkplugPayload(t, connect.WillMessage)
+	if connect.WillTopic != "spBv1.0/Plant/NDEATH/Edge" || connect.WillQos != 1 || connect.WillRetain ||
+		len(death.metrics) != 1 || death.metrics[0].name != metricBdSeq || death.seq != -1 {
+		t.Errorf("LWT inesperado: %s %+v", connect.WillTopic, death)
+	}
+
+	birth := decodeSparkplugPayload(t, broker.messagesOn(nbirth)[0].payload)
+	if birth.seq != 0 || len(birth.metrics) != 2 || birth.metrics[0].name != metricBdSeq ||
+		birth.metrics[0].value != death.metrics[0].value || birth.metrics[1].name != metricRebirth {
+		t.Errorf("NBIRTH = %+v", birth)
+	}
+
+	device := decodeSparkplugPayload(t, broker.messagesOn(dbirth)[0].payload)
+	if device.seq != 1 {
+		t.Errorf("DBIRTH com seq %d, esperado 1", device.seq)
+	}
+	names := make(map[string]uint64)
+	for _, metric := range device.metrics {
+		names[metric.name] = metric.alias
+	}
+	if names["Status"] != aliasStatus || names[velocityMetricName(0)] != aliasVelocityBase ||
+		names[positionMetricName(6)] != aliasPositionBase+6 || names["Alarms/NoData"] != aliasAlarmNoData {
+		t.Errorf("métricas do DBIRTH sem nome ou alias esperado: %v", names)
+	}
+	if message := broker.messagesOn(dbirth)[0]; message.qos != 0 || message.retained {
+		t.Errorf("DBIRTH com QoS %d, retain %v; esperado QoS 0 sem retain", message.qos, message.retained)
+	}
+
+	service.UpdateMetrics(models.RadarMetrics{Timestamp: time.Now(), Status: "ok", Velocities: [7]float64{5}})
+	waitFor(t, 5*time.Second, "DDATA", func() bool { return len(broker.messagesOn(ddata)) == 1 })
+
+	data := decodeSparkplugPayload(t, broker.messagesOn(ddata)[0].payload)
+	if data.seq != 2 {
+		t.Errorf("DDATA com seq %d, esperado 2", data.seq)
+	}
+	values := make(map[uint64]interface{})
+	for _, metric := range data.metrics {
+		if metric.name != "" {
+			t.Errorf("DDATA com nome de métrica %q", metric.name)
+		}
+		values[metric.alias] = metric.value
+	}
+	if values[aliasVelocityBase] != 5.0 || values[aliasStatus] != "ok" || values[aliasAlarmNoData] != false {
+		t.Errorf("DDATA = %v", values)
+	}
+	if _, ok := values[aliasPositionBase]; ok {
+		t.Error("DDATA inclui posição inalterada")
+	}
+
+	// Rebirth solicitado pelo host: novo NBIRTH com a sequência reiniciada
+	broker.send(ncmd, encodeSparkplugPayload(time.Now(), -1, []sparkplugMetric{
+		{name: metricRebirth, datatype: spTypeBoolean, value: true},
+	}))
+	waitFor(t, 5*time.Second, "DBIRTH após rebirth", func() bool { return len(broker.messagesOn(dbirth)) == 2 })
+
+	if rebirth := decodeSparkplugPayload(t, broker.messagesOn(nbirth)[1].payload); rebirth.seq != 0 {
+		t.Errorf("NBIRTH do rebirth com seq %d, esperado 0", rebirth.seq)
+	}
+	device = decodeSparkplugPayload(t, broker.messagesOn(dbirth)[1].payload)
+	for _, metric := range device.metrics {
+		if metric.alias == aliasVelocityBase && metric.value != 5.0 {
+			t.Errorf("DBIRTH do rebirth com velocidade %v, esperado o valor atual 5", metric.value)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package mqtt
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Namespace e tipos de mensagem Sparkplug B
+const (
+	sparkplugNamespace = "spBv1.0"
+
+	msgNBIRTH = "NBIRTH"
+	msgNDEATH = "NDEATH"
+	msgDBIRTH = "DBIRTH"
+	msgDDEATH = "DDEATH"
+	msgDDATA  = "DDATA"
+	msgNCMD   = "NCMD"
+)
+
+// Tipos de dado Sparkplug B (campo Metric.datatype)
+const (
+	spTypeInt64   uint32 = 4
+	spTypeDouble  uint32 = 10
+	spTypeBoolean uint32 = 11
+	spTypeString  uint32 = 12
+)
+
+// Nomes e aliases das métricas. Os aliases são únicos no edge node,
+// permitindo que DDATA carregue apenas o alias.
+const (
+	metricBdSeq   = "bdSeq"
+	metricRebirth = "Node Control/Rebirth"
+
+	aliasRebirth         uint64 = 1
+	aliasStatus          uint64 = 10
+	aliasAlarmActive     uint64 = 11
+	aliasAlarmObstructed uint64 = 12
+	aliasAlarmNoData     uint64 = 13
+	aliasAlarmNotOK      uint64 = 14
+	aliasVelocityBase    uint64 = 100
+	aliasPositionBase    uint64 = 200
+)
+
+// Campos protobuf de org.eclipse.tahu.protobuf.Payload e Payload.Metric
+const (
+	fieldPayloadTimestamp = 1
+	fieldPayloadMetrics   = 2
+	fieldPayloadSeq       = 3
+
+	fieldMetricName         = 1
+	fieldMetricAlias        = 2
+	fieldMetricTimestamp    = 3
+	fieldMetricDatatype     = 4
+	fieldMetricIsHistorical = 5
+	fieldMetricLongValue    = 11
+	fieldMetricDoubleValue  = 13
+	fieldMetricBooleanValue = 14
+	fieldMetricStringValue  = 15
+)
+
+// Tipos de codificação protobuf
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errProtobuf = errors.New("payload Sparkplug inválido")
+
+// sparkplugMetric é uma métrica a ser codificada em um payload Sparkplug B
+type sparkplugMetric struct {
+	name       string // Omitido em DDATA, onde o alias identifica a métrica
+	alias      uint64
+	datatype   uint32
+	value      interface{}
+	timestamp  time.Time
+	historical bool
+}
+
+// sparkplugTopic monta o tópico spBv1.0/<grupo>/<tipo>/<edge node>[/<dispositivo>]
+func sparkplugTopic(groupID, messageType, edgeNodeID, deviceID string) string {
+	topic := fmt.Sprintf("%s/%s/%s/%s", sparkplugNamespace, groupID, messageType, edgeNodeID)
+	if deviceID != "" {
+		topic += "/" + deviceID
+	}
+	return topic
+}
+
+// velocityMetricName retorna o nome da métrica de velocidade do canal (base 0)
+func velocityMetricName(index int) string {
+	return fmt.Sprintf("Velocity/Channel%d", index+1)
+}
+
+// positionMetricName retorna o nome da métrica de posição do canal (base 0)
+func positionMetricName(index int) string {
+	return fmt.Sprintf("Position/Channel%d", index+1)
+}
+
+// encodeSparkplugPayload codifica um Payload Sparkplug B.
+// seq negativo omite o campo (usado em NDEATH).
+func encodeSparkplugPayload(timestamp time.Time, seq int64, metrics []sparkplugMetric) []byte {
+	var b []byte
+	b = appendVarintField(b, fieldPayloadTimestamp, uint64(timestamp.UnixMilli()))
+	for _, metric := range metrics {
+		b = appendBytesField(b, fieldPayloadMetrics, encodeSparkplugMetric(metric))
+	}
+	if seq >= 0 {
+		b = appendVarintField(b, fieldPayloadSeq, uint64(seq))
+	}
+	return b
+}
+
+// encodeSparkplugMetric codifica uma Payload.Metric
+func encodeSparkplugMetric(metric sparkplugMetric) []byte {
+	var b []byte
+	if metric.name != "" {
+		b = appendBytesField(b, fieldMetricName, []byte(metric.name))
+	}
+	if metric.alias != 0 {
+		b = appendVarintField(b, fieldMetricAlias, metric.alias)
+	}
+	if !metric.timestamp.IsZero() {
+		b = appendVarintField(b, fieldMetricTimestamp, uint64(metric.timestamp.UnixMilli()))
+	}
+	b = appendVarintField(b, fieldMetricDatatype, uint64(metric.datatype))
+	if metric.historical {
+		b = appendVarintField(b, fieldMetricIsHistorical, 1)
+	}
+
+	switch v := metric.value.(type) {
+	case int64:
+		b = appendVarintField(b, fieldMetricLongValue, uint64(v))
+	case float64:
+		b = appendTag(b, fieldMetricDoubleValue, wireFixed64)
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+	case bool:
+		value := uint64(0)
+		if v {
+			value = 1
+		}
+		b = appendVarintField(b, fieldMetricBooleanValue, value)
+	case string:
+		b = appendBytesField(b, fieldMetricStringValue, []byte(v))
+	}
+	return b
+}
+
+// isRebirthRequest verifica se um payload NCMD solicita "Node Control/Rebirth"
+func isRebirthRequest(payload []byte) (bool, error) {
+	rebirth := false
+	err := walkProtobuf(payload, func(field int, wire int, value uint64, data []byte) error {
+		if field != fieldPayloadMetrics || wire != wireBytes {
+			return nil
+		}
+
+		var name string
+		var alias, boolean uint64
+		err := walkProtobuf(data, func(field int, wire int, value uint64, data []byte) error {
+			switch {
+			case field == fieldMetricName && wire == wireBytes:
+				name = string(data)
+			case field == fieldMetricAlias && wire == wireVarint:
+				alias = value
+			case field == fieldMetricBooleanValue && wire == wireVarint:
+				boolean = value
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if (name == metricRebirth || alias == aliasRebirth) && boolean != 0 {
+			rebirth = true
+		}
+		return nil
+	})
+	return rebirth, err
+}
+
+// walkProtobuf percorre os campos de uma mensagem protobuf chamando fn para cada um.
+// Para campos varint/fixos o valor vem em value; para campos length-delimited, em data.
+func walkProtobuf(b []byte, fn func(field int, wire int, value uint64, data []byte) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errProtobuf
+		}
+		b = b[n:]
+
+		field, wire := int(tag>>3), int(tag&7)
+		var value uint64
+		var data []byte
+
+		switch wire {
+		case wireVarint:
+			value, n = binary.Uvarint(b)
+			if n <= 0 {
+				return errProtobuf
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return errProtobuf
+			}
+			value = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return errProtobuf
+			}
+			value = uint64(binary.LittleEndian.Uint32(b))
+			b = b[4:]
+		case wireBytes:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < length {
+				return errProtobuf
+			}
+			data = b[n : n+int(length)]
+			b = b[n+int(length):]
+		default:
+			return errProtobuf
+		}
+
+		if err := fn(field, wire, value, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendTag adiciona a chave (número do campo + tipo) de um campo protobuf
+func appendTag(b []byte, field int, wire int) []byte {
+	return binary.AppendUvarint(b, uint64(field)<<3|uint64(wire))
+}
+
+// appendVarintField adiciona um campo varint
+func appendVarintField(b []byte, field int, value uint64) []byte {
+	b = appendTag(b, field, wireVarint)
+	return binary.AppendUvarint(b, value)
+}
+
+// appendBytesField adiciona um campo length-delimited
+func appendBytesField(b []byte, field int, data []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
@@ -0,0 +1,219 @@
+package mqtt
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+// decodedPayload é um Payload Sparkplug B decodificado nos testes
+type decodedPayload struct {
+	timestamp uint64
+	seq       int64 // -1 quando ausente
+	metrics   []decodedMetric
+}
+
+// decodedMetric é uma Payload.Metric decodificada nos testes
+type decodedMetric struct {
+	name       string
+	alias      uint64
+	timestamp  uint64
+	datatype   uint32
+	historical bool
+	value      interface{}
+}
+
+// decodeSparkplugPayload decodifica um payload com walkProtobuf, usando os
+// tipos de dado declarados em cada métrica
+func decodeSparkplugPayload(t *testing.T, data []byte) decodedPayload {
+	t.Helper()
+
+	payload := decodedPayload{seq: -1}
+	err := walkProtobuf(data, func(field int, wire int, value uint64, data []byte) error {
+		switch field {
+		case fieldPayloadTimestamp:
+			payload.timestamp = value
+		case fieldPayloadSeq:
+			payload.seq = int64(value)
+		case fieldPayloadMetrics:
+			var metric decodedMetric
+			err := walkProtobuf(data, func(field int, wire int, value uint64, data []byte) error {
+				switch field {
+				case fieldMetricName:
+					metric.name = string(data)
+				case fieldMetricAlias:
+					metric.alias = value
+				case fieldMetricTimestamp:
+					metric.timestamp = value
+				case fieldMetricDatatype:
+					metric.datatype = uint32(value)
+				case fieldMetricIsHistorical:
+					metric.historical = value != 0
+				case fieldMetricLongValue:
+					metric.value = int64(value)
+				case fieldMetricDoubleValue:
+					if wire != wireFixed64 {
+						t.Errorf("double codificado com wire type %d", wire)
+					}
+					metric.value = math.Float64frombits(value)
+				case fieldMetricBooleanValue:
+					metric.value = value != 0
+				case fieldMetricStringValue:
+					metric.value = string(data)
+				default:
+					t.Errorf("campo inesperado na métrica: %d", field)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			payload.metrics = append(payload.metrics, metric)
+		default:
+			t.Errorf("campo inesperado no payload: %d", field)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("payload Sparkplug inválido: %v", err)
+	}
+	return payload
+}
+
+func TestSparkplugMetricKnownBytes(t *testing.T) {
+	tests := []struct {
+		metric sparkplugMetric
+		want   []byte
+	}{
+		{
+			sparkplugMetric{name: metricBdSeq, datatype: spTypeInt64, value: int64(3)},
+			[]byte{0x0A, 0x05, 'b', 'd', 'S', 'e', 'q', 0x20, 0x04, 0x58, 0x03},
+		},
+		{
+			sparkplugMetric{alias: aliasAlarmNoData, datatype: spTypeBoolean, value: true},
+			[]byte{0x10, 0x0D, 0x20, 0x0B, 0x70, 0x01},
+		},
+		{
+			sparkplugMetric{alias: aliasStatus, datatype: spTypeString, value: "ok"},
+			[]byte{0x10, 0x0A, 0x20, 0x0C, 0x7A, 0x02, 'o', 'k'},
+		},
+		{
+			// Alias 200 ocupa dois bytes de varint; double é fixed64 little-endian
+			sparkplugMetric{alias: aliasPositionBase, datatype: spTypeDouble, value: 1.0},
+			[]byte{0x10, 0xC8, 0x01, 0x20, 0x0A, 0x69, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF0, 0x3F},
+		},
+		{
+			sparkplugMetric{alias: aliasVelocityBase, datatype: spTypeDouble, value: 0.0,
+				timestamp: time.UnixMilli(1000), historical: true},
+			[]byte{0x10, 0x64, 0x18, 0xE8, 0x07, 0x20, 0x0A, 0x28, 0x01, 0x69, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := encodeSparkplugMetric(tt.metric); !bytes.Equal(got, tt.want) {
+			t.Errorf("encodeSparkplugMetric(%+v) = % X, esperado % X", tt.metric, got, tt.want)
+		}
+	}
+}
+
+func TestSparkplugPayloadKnownBytes(t *testing.T) {
+	metrics := []sparkplugMetric{{alias: aliasStatus, datatype: spTypeString, value: "ok"}}
+
+	got := encodeSparkplugPayload(time.UnixMilli(1000), 5, metrics)
+	want := []byte{
+		0x08, 0xE8, 0x07, // timestamp = 1000
+		0x12, 0x08, 0x10, 0x0A, 0x20, 0x0C, 0x7A, 0x02, 'o', 'k', // metrics
+		0x18, 0x05, // seq = 5
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("encodeSparkplugPayload = % X, esperado % X", got, want)
+	}
+
+	// seq negativo omite o campo (NDEATH)
+	got = encodeSparkplugPayload(time.UnixMilli(1000), -1, nil)
+	if want := []byte{0x08, 0xE8, 0x07}; !bytes.Equal(got, want) {
+		t.Errorf("payload sem seq = % X, esperado % X", got, want)
+	}
+}
+
+func TestSparkplugPayloadRoundTrip(t *testing.T) {
+	timestamp := time.UnixMilli(1714564800123)
+	metrics := []sparkplugMetric{
+		{name: metricBdSeq, datatype: spTypeInt64, value: int64(255)},
+		{name: "Status", alias: aliasStatus, datatype: spTypeString, value: "obstruido"},
+		{name: velocityMetricName(0), alias: aliasVelocityBase, datatype: spTypeDouble, value: -12.75,
+			timestamp: timestamp, historical: true},
+		{alias: aliasPositionBase + 6, datatype: spTypeDouble, value: 199.5},
+		{name: "Alarms/Active", alias: aliasAlarmActive, datatype: spTypeBoolean, value: false},
+	}
+
+	payload := decodeSparkplugPayload(t, encodeSparkplugPayload(timestamp, 42, metrics))
+	if payload.timestamp != uint64(timestamp.UnixMilli()) || payload.seq != 42 {
+		t.Errorf("timestamp/seq = %d/%d", payload.timestamp, payload.seq)
+	}
+	if len(payload.metrics) != len(metrics) {
+		t.Fatalf("%d métricas decodificadas, esperado %d", len(payload.metrics), len(metrics))
+	}
+
+	for i, want := range metrics {
+		got := payload.metrics[i]
+		wantTimestamp := uint64(0)
+		if !want.timestamp.IsZero() {
+			wantTimestamp = uint64(want.timestamp.UnixMilli())
+		}
+		if got.name != want.name || got.alias != want.alias || got.datatype != want.datatype ||
+			got.historical != want.historical || got.timestamp != wantTimestamp || got.value != want.value {
+			t.Errorf("métrica %d = %+v, esperado %+v", i, got, want)
+		}
+	}
+}
+
+func TestIsRebirthRequest(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		payload []byte
+		want    bool
+	}{
+		{"por nome", encodeSparkplugPayload(now, -1, []sparkplugMetric{
+			{name: metricRebirth, datatype: spTypeBoolean, value: true}}), true},
+		{"por alias", encodeSparkplugPayload(now, -1, []sparkplugMetric{
+			{alias: aliasRebirth, datatype: spTypeBoolean, value: true}}), true},
+		{"valor falso", encodeSparkplugPayload(now, -1, []sparkplugMetric{
+			{name: metricRebirth, datatype: spTypeBoolean, value: false}}), false},
+		{"outra métrica", encodeSparkplugPayload(now, -1, []sparkplugMetric{
+			{name: "Node Control/Reboot", datatype: spTypeBoolean, value: true}}), false},
+		{"vazio", nil, false},
+	}
+
+	for _, tt := range tests {
+		got, err := isRebirthRequest(tt.payload)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: isRebirthRequest = %v, %v; esperado %v", tt.name, got, err, tt.want)
+		}
+	}
+
+	// Payloads malformados
+	for _, payload := range [][]byte{
+		{0x12, 0x05, 0x0A},       // Comprimento além do fim
+		{0x08},                   // Varint ausente
+		{0x0B},                   // Wire type 3 não suportado
+		{0x11, 0x00, 0x00, 0x00}, // Fixed64 truncado
+		{0xFF, 0xFF, 0xFF, 0xFF}, // Tag truncada
+		{0x12, 0x02, 0x58, 0xFF}, // Métrica com varint truncado
+	} {
+		if _, err := isRebirthRequest(payload); err == nil {
+			t.Errorf("isRebirthRequest(% X) sem erro", payload)
+		}
+	}
+}
+
+func TestSparkplugTopic(t *testing.T) {
+	if got := sparkplugTopic("Plant", msgNBIRTH, "Edge", ""); got != "spBv1.0/Plant/NBIRTH/Edge" {
+		t.Errorf("tópico do edge node = %q", got)
+	}
+	if got := sparkplugTopic("Plant", msgDDATA, "Edge", "Radar"); got != "spBv1.0/Plant/DDATA/Edge/Radar" {
+		t.Errorf("tópico do dispositivo = %q", got)
+	}
+}
@@ -0,0 +1,382 @@
+package opcua
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"radar_go/pkg/logger"
+)
+
+// Limites do transporte UA TCP
+const (
+	headerSize          = 8       // Tipo(3) + Chunk(1) + Tamanho(4)
+	symmetricHeaderSize = 24      // Cabeçalho + ChannelId + TokenId + Sequência + RequestId
+	bufferSize          = 65535   // Tamanho dos buffers anunciados no ACK
+	minBufferSize       = 8192    // Mínimo exigido pela especificação
+	maxMessageSize      = 4 << 20 // Tamanho máximo de uma mensagem remontada
+	helloTimeout        = 10 * time.Second
+	defaultLifetime     = 10 * time.Minute
+)
+
+// secureChannel representa uma conexão UA TCP com SecurityPolicy None
+type secureChannel struct {
+	service *Service
+	conn    net.Conn
+	id      uint32
+	tokenID uint32
+
+	sendBufferSize uint32 // Tamanho máximo de chunk aceito pelo cliente
+	sequence       uint32
+
+	writeMutex sync.Mutex
+	chunks     map[uint32][]byte // Corpos parciais por RequestId
+	closed     bool
+}
+
+// requestHeader contém os campos do RequestHeader usados pelo servidor
+type requestHeader struct {
+	authToken   NodeID
+	handle      uint32
+	timeoutHint uint32
+}
+
+// decodeRequestHeader lê um RequestHeader
+func decodeRequestHeader(d *decoder) requestHeader {
+	var h requestHeader
+	h.authToken = d.nodeID()
+	d.dateTime() // Timestamp
+	h.handle = d.uint32()
+	d.uint32() // ReturnDiagnostics
+	d.string() // AuditEntryId
+	h.timeoutHint = d.uint32()
+	d.extensionObject() // AdditionalHeader
+	return h
+}
+
+// newResponse inicia a codificação de uma resposta com ResponseHeader Good
+func newResponse(typeID uint32, handle uint32) *encoder {
+	e := &encoder{}
+	e.nodeID(ns0(typeID))
+	encodeResponseHeader(e, handle, StatusGood)
+	return e
+}
+
+// encodeResponseHeader codifica um ResponseHeader
+func encodeResponseHeader(e *encoder, handle uint32, result uint32) {
+	e.dateTime(time.Now())
+	e.uint32(handle)
+	e.uint32(result)
+	e.emptyDiagnosticInfo()
+	e.emptyArray() // StringTable
+	e.extensionObject(nil)
+}
+
+// serviceFault codifica uma resposta ServiceFault
+func serviceFault(handle uint32, status uint32) []byte {
+	e := &encoder{}
+	e.nodeID(ns0(idServiceFault))
+	encodeResponseHeader(e, handle, status)
+	return e.bytes()
+}
+
+// serve processa as mensagens da conexão até ela ser encerrada
+func (c *secureChannel) serve() {
+	defer c.close()
+
+	header := make([]byte, headerSize)
+	helloDone := false
+
+	for {
+		if !helloDone {
+			c.conn.SetReadDeadline(time.Now().Add(helloTimeout))
+		} else {
+			c.conn.SetReadDeadline(time.Time{})
+		}
+
+		if _, err := io.ReadFull(c.conn, header); err != nil {
+			if !errors.Is(err, io.EOF) && c.service.IsRunning() {
+				logger.Debugf("Conexão OPC UA %s encerrada: %v", c.conn.RemoteAddr(), err)
+			}
+			return
+		}
+
+		msgType := string(header[0:3])
+		chunkType := header[3]
+		size := binary.LittleEndian.Uint32(header[4:8])
+		if size < headerSize || size > bufferSize {
+			c.sendError(StatusBadTCPMessageTypeInvalid, "tamanho de mensagem inválido")
+			return
+		}
+
+		body := make([]byte, size-headerSize)
+		if _, err := io.ReadFull(c.conn, body); err != nil {
+			return
+		}
+
+		if !helloDone {
+			if msgType != "HEL" {
+				c.sendError(StatusBadTCPMessageTypeInvalid, "esperado HEL")
+				return
+			}
+			if err := c.handleHello(body); err != nil {
+				logger.Warnf("Handshake OPC UA inválido de %s: %v", c.conn.RemoteAddr(), err)
+				return
+			}
+			helloDone = true
+			continue
+		}
+
+		var err error
+		switch msgType {
+		case "OPN":
+			err = c.handleOpen(body)
+		case "MSG":
+			err = c.handleMessage(chunkType, body)
+		case "CLO":
+			return
+		default:
+			c.sendError(StatusBadTCPMessageTypeInvalid, "tipo de mensagem desconhecido: "+msgType)
+			return
+		}
+
+		if err != nil {
+			logger.Warnf("Erro na conexão OPC UA %s: %v", c.conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
+
+// handleHello processa HEL e responde ACK com os limites negociados
+func (c *secureChannel) handleHello(body []byte) error {
+	d := newDecoder(body)
+	d.uint32() // ProtocolVersion
+	receiveBufferSize := d.uint32()
+	d.uint32() // SendBufferSize
+	d.uint32() // MaxMessageSize
+	d.uint32() // MaxChunkCount
+	endpointURL := d.string()
+	if d.err != nil {
+		return d.err
+	}
+	if receiveBufferSize < minBufferSize {
+		c.sendError(StatusBadTCPMessageTypeInvalid, "buffer de recepção muito pequeno")
+		return fmt.Errorf("buffer de recepção do cliente muito pequeno: %d", receiveBufferSize)
+	}
+
+	c.sendBufferSize = receiveBufferSize
+	if c.sendBufferSize > bufferSize {
+		c.sendBufferSize = bufferSize
+	}
+
+	logger.Debugf("HEL OPC UA de %s (endpoint %s)", c.conn.RemoteAddr(), endpointURL)
+
+	e := &encoder{}
+	e.uint32(0)          // ProtocolVersion
+	e.uint32(bufferSize) // ReceiveBufferSize
+	e.uint32(c.sendBufferSize)
+	e.uint32(maxMessageSize)
+	e.uint32(0) // MaxChunkCount (sem limite)
+	return c.writeFrame("ACKF", e.bytes())
+}
+
+// handleOpen processa OpenSecureChannel (emissão ou renovação do token)
+func (c *secureChannel) handleOpen(body []byte) error {
+	d := newDecoder(body)
+	channelID := d.uint32()
+	policyURI := d.string()
+	d.byteString() // SenderCertificate
+	d.byteString() // ReceiverCertificateThumbprint
+	d.uint32()     // SequenceNumber
+	requestID := d.uint32()
+
+	typeID := d.expandedNodeID()
+	header := decodeRequestHeader(d)
+	d.uint32() // ClientProtocolVersion
+	requestType := d.uint32()
+	securityMode := d.uint32()
+	d.byteString() // ClientNonce
+	lifetime := d.uint32()
+	if d.err != nil {
+		return d.err
+	}
+
+	if typeID != ns0(idOpenSecureChannelRequest) {
+		c.sendError(StatusBadTCPMessageTypeInvalid, "esperado OpenSecureChannelRequest")
+		return fmt.Errorf("tipo inesperado em OPN: %s", typeID)
+	}
+	if policyURI != securityPolicyNone || securityMode != 1 {
+		c.sendError(StatusBadSecurityPolicyRejected, "apenas SecurityPolicy None é suportada")
+		return fmt.Errorf("política de segurança não suportada: %s (modo %d)", policyURI, securityMode)
+	}
+	if requestType == 1 && channelID != c.id {
+		c.sendError(StatusBadSecureChannelIDInvalid, "canal seguro inválido")
+		return fmt.Errorf("renovação para canal desconhecido %d", channelID)
+	}
+
+	c.writeMutex.Lock()
+	if requestType == 0 {
+		c.id = c.service.nextChannelID()
+	}
+	c.tokenID++
+	c.writeMutex.Unlock()
+
+	revised := time.Duration(lifetime) * time.Millisecond
+	if revised <= 0 || revised > time.Hour {
+		revised = defaultLifetime
+	}
+
+	e := newResponse(idOpenSecureChannelResponse, header.handle)
+	e.uint32(0) // ServerProtocolVersion
+	e.uint32(c.id)
+	e.uint32(c.tokenID)
+	e.dateTime(time.Now())
+	e.uint32(uint32(revised / time.Millisecond))
+	e.byteString([]byte{}) // ServerNonce
+
+	// Cabeçalho assimétrico da resposta
+	frame := &encoder{}
+	frame.uint32(c.id)
+	frame.string(securityPolicyNone)
+	frame.byteString(nil)
+	frame.byteString(nil)
+	frame.uint32(c.nextSequence())
+	frame.uint32(requestID)
+	frame.buf = append(frame.buf, e.bytes()...)
+
+	return c.writeFrame("OPNF", frame.bytes())
+}
+
+// handleMessage remonta chunks MSG e despacha a requisição completa
+func (c *secureChannel) handleMessage(chunkType byte, body []byte) error {
+	d := newDecoder(body)
+	channelID := d.uint32()
+	tokenID := d.uint32()
+	d.uint32() // SequenceNumber
+	requestID := d.uint32()
+	if d.err != nil {
+		return d.err
+	}
+	// O token anterior continua válido até o cliente adotar o renovado
+	if channelID != c.id || (tokenID != c.tokenID && tokenID != c.tokenID-1) {
+		c.sendError(StatusBadSecureChannelIDInvalid, "canal ou token inválido")
+		return fmt.Errorf("canal %d/token %d inválido", channelID, tokenID)
+	}
+
+	payload := d.remaining()
+	switch chunkType {
+	case 'C':
+		pending := append(c.chunks[requestID], payload...)
+		if len(pending) > maxMessageSize {
+			c.sendError(StatusBadTCPMessageTypeInvalid, "mensagem muito grande")
+			return fmt.Errorf("mensagem %d excede %d bytes", requestID, maxMessageSize)
+		}
+		c.chunks[requestID] = pending
+		return nil
+	case 'A':
+		delete(c.chunks, requestID)
+		return nil
+	case 'F':
+		if pending, ok := c.chunks[requestID]; ok {
+			payload = append(pending, payload...)
+			delete(c.chunks, requestID)
+		}
+	default:
+		return fmt.Errorf("tipo de chunk inválido: %q", chunkType)
+	}
+
+	response := c.service.dispatch(c, requestID, payload)
+	if response == nil {
+		// Resposta assíncrona (Publish) ou mensagem sem resposta
+		return nil
+	}
+	return c.sendResponse(requestID, response)
+}
+
+// sendResponse envia uma resposta dividida em chunks conforme o buffer do cliente
+func (c *secureChannel) sendResponse(requestID uint32, body []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	if c.closed {
+		return net.ErrClosed
+	}
+
+	maxBody := int(c.sendBufferSize) - symmetricHeaderSize
+	for {
+		part := body
+		chunkType := "F"
+		if len(part) > maxBody {
+			part = body[:maxBody]
+			chunkType = "C"
+		}
+
+		e := &encoder{}
+		e.uint32(c.id)
+		e.uint32(c.tokenID)
+		e.uint32(c.nextSequence())
+		e.uint32(requestID)
+		e.buf = append(e.buf, part...)
+		if err := c.writeFrameLocked("MSG"+chunkType, e.bytes()); err != nil {
+			return err
+		}
+
+		body = body[len(part):]
+		if chunkType == "F" {
+			return nil
+		}
+	}
+}
+
+// sendError envia uma mensagem ERR antes de encerrar a conexão
+func (c *secureChannel) sendError(status uint32, reason string) {
+	e := &encoder{}
+	e.uint32(status)
+	e.string(reason)
+	c.writeFrame("ERRF", e.bytes())
+}
+
+// writeFrame escreve uma mensagem com cabeçalho UA TCP
+func (c *secureChannel) writeFrame(kind string, body []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	return c.writeFrameLocked(kind, body)
+}
+
+// writeFrameLocked escreve uma mensagem; deve ser chamada com writeMutex adquirido
+func (c *secureChannel) writeFrameLocked(kind string, body []byte) error {
+	frame := make([]byte, headerSize, headerSize+len(body))
+	copy(frame, kind)
+	binary.LittleEndian.PutUint32(frame[4:], uint32(headerSize+len(body)))
+	frame = append(frame, body...)
+
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// nextSequence retorna o próximo número de sequência de envio
+func (c *secureChannel) nextSequence() uint32 {
+	return atomic.AddUint32(&c.sequence, 1)
+}
+
+// close encerra a conexão e descarta requisições Publish pendentes
+func (c *secureChannel) close() {
+	c.writeMutex.Lock()
+	alreadyClosed := c.closed
+	c.closed = true
+	c.writeMutex.Unlock()
+
+	if alreadyClosed {
+		return
+	}
+
+	c.conn.Close()
+	c.service.channelClosed(c)
+	logger.Infof("Cliente OPC UA desconectado: %s", c.conn.RemoteAddr())
+}
@@ -0,0 +1,696 @@
+package opcua
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Tipos de identificador de NodeId
+const (
+	kindNumeric byte = iota
+	kindString
+	kindGUID
+	kindOpaque
+)
+
+// NodeID identifica um nó no espaço de endereçamento.
+// É comparável e pode ser usado como chave de mapa.
+type NodeID struct {
+	Namespace uint16
+	Kind      byte
+	Numeric   uint32
+	Text      string // Identificador string, GUID (16 bytes) ou ByteString
+}
+
+// NewNumericNodeID cria um NodeId numérico
+func NewNumericNodeID(ns uint16, id uint32) NodeID {
+	return NodeID{Namespace: ns, Kind: kindNumeric, Numeric: id}
+}
+
+// NewStringNodeID cria um NodeId com identificador string
+func NewStringNodeID(ns uint16, id string) NodeID {
+	return NodeID{Namespace: ns, Kind: kindString, Text: id}
+}
+
+// IsNull indica se o NodeId é nulo (ns=0;i=0)
+func (n NodeID) IsNull() bool {
+	return n == NodeID{}
+}
+
+// String retorna a notação textual padrão (ex.: ns=1;s=Radar)
+func (n NodeID) String() string {
+	prefix := ""
+	if n.Namespace != 0 {
+		prefix = fmt.Sprintf("ns=%d;", n.Namespace)
+	}
+	switch n.Kind {
+	case kindString:
+		return prefix + "s=" + n.Text
+	case kindGUID:
+		return prefix + fmt.Sprintf("g=%x", n.Text)
+	case kindOpaque:
+		return prefix + fmt.Sprintf("b=%x", n.Text)
+	default:
+		return prefix + fmt.Sprintf("i=%d", n.Numeric)
+	}
+}
+
+// QualifiedName é um nome qualificado pelo índice do namespace
+type QualifiedName struct {
+	Namespace uint16
+	Name      string
+}
+
+// LocalizedText é um texto com localidade opcional
+type LocalizedText struct {
+	Locale string
+	Text   string
+}
+
+// ExtensionObject carrega uma estrutura já codificada em binário
+type ExtensionObject struct {
+	TypeID NodeID // NodeId da codificação binária (DefaultBinary)
+	Body   []byte
+}
+
+// DataValue é um valor com status e timestamps
+type DataValue struct {
+	Value           interface{}
+	HasValue        bool
+	Status          uint32
+	SourceTimestamp time.Time
+	ServerTimestamp time.Time
+}
+
+// Tipos internos de Variant
+const (
+	variantBoolean         byte = 1
+	variantSByte           byte = 2
+	variantByte            byte = 3
+	variantInt16           byte = 4
+	variantUInt16          byte = 5
+	variantInt32           byte = 6
+	variantUInt32          byte = 7
+	variantInt64           byte = 8
+	variantUInt64          byte = 9
+	variantFloat           byte = 10
+	variantDouble          byte = 11
+	variantString          byte = 12
+	variantDateTime        byte = 13
+	variantGUID            byte = 14
+	variantByteString      byte = 15
+	variantXMLElement      byte = 16
+	variantNodeID          byte = 17
+	variantExpandedNodeID  byte = 18
+	variantStatusCode      byte = 19
+	variantQualifiedName   byte = 20
+	variantLocalizedText   byte = 21
+	variantExtensionObject byte = 22
+	variantDataValue       byte = 23
+	variantVariant         byte = 24
+	variantDiagnosticInfo  byte = 25
+)
+
+// Diferença entre a época OPC UA (1601-01-01) e a época Unix, em intervalos de 100 ns
+const epochOffset = 116444736000000000
+
+// errDecode indica uma mensagem truncada ou malformada
+var errDecode = errors.New("mensagem OPC UA malformada")
+
+// encoder serializa valores no formato binário OPC UA (little-endian)
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) bytes() []byte { return e.buf }
+
+func (e *encoder) byte(v byte)     { e.buf = append(e.buf, v) }
+func (e *encoder) uint16(v uint16) { e.buf = binary.LittleEndian.AppendUint16(e.buf, v) }
+func (e *encoder) uint32(v uint32) { e.buf = binary.LittleEndian.AppendUint32(e.buf, v) }
+func (e *encoder) int32(v int32)   { e.uint32(uint32(v)) }
+func (e *encoder) uint64(v uint64) { e.buf = binary.LittleEndian.AppendUint64(e.buf, v) }
+func (e *encoder) int64(v int64)   { e.uint64(uint64(v)) }
+func (e *encoder) float(v float32) { e.uint32(math.Float32bits(v)) }
+func (e *encoder) double(v float64) {
+	e.uint64(math.Float64bits(v))
+}
+
+func (e *encoder) boolean(v bool) {
+	if v {
+		e.byte(1)
+	} else {
+		e.byte(0)
+	}
+}
+
+func (e *encoder) string(v string) {
+	e.int32(int32(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+// nullString codifica uma string nula (comprimento -1)
+func (e *encoder) nullString() { e.int32(-1) }
+
+func (e *encoder) byteString(v []byte) {
+	if v == nil {
+		e.int32(-1)
+		return
+	}
+	e.int32(int32(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+func (e *encoder) dateTime(t time.Time) {
+	if t.IsZero() {
+		e.int64(0)
+		return
+	}
+	e.int64(t.UnixNano()/100 + epochOffset)
+}
+
+func (e *encoder) nodeID(n NodeID) {
+	switch n.Kind {
+	case kindString:
+		e.byte(0x03)
+		e.uint16(n.Namespace)
+		e.string(n.Text)
+	case kindGUID:
+		e.byte(0x04)
+		e.uint16(n.Namespace)
+		e.buf = append(e.buf, n.Text...)
+	case kindOpaque:
+		e.byte(0x05)
+		e.uint16(n.Namespace)
+		e.byteString([]byte(n.Text))
+	default:
+		switch {
+		case n.Namespace == 0 && n.Numeric <= 0xFF:
+			e.byte(0x00)
+			e.byte(byte(n.Numeric))
+		case n.Namespace <= 0xFF && n.Numeric <= 0xFFFF:
+			e.byte(0x01)
+			e.byte(byte(n.Namespace))
+			e.uint16(uint16(n.Numeric))
+		default:
+			e.byte(0x02)
+			e.uint16(n.Namespace)
+			e.uint32(n.Numeric)
+		}
+	}
+}
+
+// expandedNodeID codifica um ExpandedNodeId local (sem URI nem índice de servidor)
+func (e *encoder) expandedNodeID(n NodeID) { e.nodeID(n) }
+
+func (e *encoder) qualifiedName(q QualifiedName) {
+	e.uint16(q.Namespace)
+	e.string(q.Name)
+}
+
+func (e *encoder) localizedText(l LocalizedText) {
+	var mask byte
+	if l.Locale != "" {
+		mask |= 0x01
+	}
+	if l.Text != "" {
+		mask |= 0x02
+	}
+	e.byte(mask)
+	if l.Locale != "" {
+		e.string(l.Locale)
+	}
+	if l.Text != "" {
+		e.string(l.Text)
+	}
+}
+
+func (e *encoder) extensionObject(x *ExtensionObject) {
+	if x == nil {
+		e.nodeID(NodeID{})
+		e.byte(0x00)
+		return
+	}
+	e.nodeID(x.TypeID)
+	e.byte(0x01)
+	e.byteString(x.Body)
+}
+
+// emptyDiagnosticInfo codifica um DiagnosticInfo vazio
+func (e *encoder) emptyDiagnosticInfo() { e.byte(0x00) }
+
+// emptyArray codifica um array vazio (comprimento 0)
+func (e *encoder) emptyArray() { e.int32(0) }
+
+func (e *encoder) stringArray(values []string) {
+	e.int32(int32(len(values)))
+	for _, v := range values {
+		e.string(v)
+	}
+}
+
+func (e *encoder) statusCodeArray(values []uint32) {
+	e.int32(int32(len(values)))
+	for _, v := range values {
+		e.uint32(v)
+	}
+}
+
+func (e *encoder) uint32Array(values []uint32) { e.statusCodeArray(values) }
+
+// variant codifica um valor Go como Variant OPC UA
+func (e *encoder) variant(value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		e.byte(0x00)
+	case bool:
+		e.byte(variantBoolean)
+		e.boolean(v)
+	case byte:
+		e.byte(variantByte)
+		e.byte(v)
+	case uint16:
+		e.byte(variantUInt16)
+		e.uint16(v)
+	case int32:
+		e.byte(variantInt32)
+		e.int32(v)
+	case uint32:
+		e.byte(variantUInt32)
+		e.uint32(v)
+	case int64:
+		e.byte(variantInt64)
+		e.int64(v)
+	case float32:
+		e.byte(variantFloat)
+		e.float(v)
+	case float64:
+		e.byte(variantDouble)
+		e.double(v)
+	case string:
+		e.byte(variantString)
+		e.string(v)
+	case time.Time:
+		e.byte(variantDateTime)
+		e.dateTime(v)
+	case NodeID:
+		e.byte(variantNodeID)
+		e.nodeID(v)
+	case QualifiedName:
+		e.byte(variantQualifiedName)
+		e.qualifiedName(v)
+	case LocalizedText:
+		e.byte(variantLocalizedText)
+		e.localizedText(v)
+	case *ExtensionObject:
+		e.byte(variantExtensionObject)
+		e.extensionObject(v)
+	case []string:
+		e.byte(variantString | 0x80)
+		e.stringArray(v)
+	case []uint32:
+		e.byte(variantUInt32 | 0x80)
+		e.uint32Array(v)
+	case []NodeID:
+		e.byte(variantNodeID | 0x80)
+		e.int32(int32(len(v)))
+		for _, n := range v {
+			e.nodeID(n)
+		}
+	default:
+		// Tipo não mapeado: publicar como texto para não quebrar o cliente
+		e.byte(variantString)
+		e.string(fmt.Sprint(v))
+	}
+}
+
+// dataValue codifica um DataValue
+func (e *encoder) dataValue(dv DataValue) {
+	var mask byte
+	if dv.HasValue {
+		mask |= 0x01
+	}
+	if dv.Status != StatusGood {
+		mask |= 0x02
+	}
+	if !dv.SourceTimestamp.IsZero() {
+		mask |= 0x04
+	}
+	if !dv.ServerTimestamp.IsZero() {
+		mask |= 0x08
+	}
+	e.byte(mask)
+	if dv.HasValue {
+		e.variant(dv.Value)
+	}
+	if dv.Status != StatusGood {
+		e.uint32(dv.Status)
+	}
+	if !dv.SourceTimestamp.IsZero() {
+		e.dateTime(dv.SourceTimestamp)
+	}
+	if !dv.ServerTimestamp.IsZero() {
+		e.dateTime(dv.ServerTimestamp)
+	}
+}
+
+// decoder lê valores no formato binário OPC UA. O primeiro erro é memorizado e
+// as leituras seguintes retornam zero, permitindo verificar err apenas no final.
+type decoder struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func newDecoder(data []byte) *decoder {
+	return &decoder{data: data}
+}
+
+// take retorna os próximos n bytes ou nil em caso de truncamento
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || d.pos+n > len(d.data) {
+		d.err = errDecode
+		return nil
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b
+}
+
+// remaining retorna os bytes ainda não lidos
+func (d *decoder) remaining() []byte {
+	if d.err != nil {
+		return nil
+	}
+	return d.data[d.pos:]
+}
+
+func (d *decoder) byte() byte {
+	if b := d.take(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) uint16() uint16 {
+	if b := d.take(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint32() uint32 {
+	if b := d.take(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) int32() int32 { return int32(d.uint32()) }
+
+func (d *decoder) uint64() uint64 {
+	if b := d.take(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) int64() int64    { return int64(d.uint64()) }
+func (d *decoder) double() float64 { return math.Float64frombits(d.uint64()) }
+func (d *decoder) boolean() bool   { return d.byte() != 0 }
+
+func (d *decoder) string() string {
+	n := d.int32()
+	if n <= 0 {
+		return ""
+	}
+	return string(d.take(int(n)))
+}
+
+func (d *decoder) byteString() []byte {
+	n := d.int32()
+	if n < 0 {
+		return nil
+	}
+	b := d.take(int(n))
+	if b == nil {
+		return nil
+	}
+	// ByteString vazia (comprimento 0) é distinta da nula
+	return append([]byte{}, b...)
+}
+
+func (d *decoder) dateTime() time.Time {
+	ticks := d.int64()
+	if ticks <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, (ticks-epochOffset)*100)
+}
+
+func (d *decoder) nodeIDWithMask(mask byte) NodeID {
+	switch mask & 0x3F {
+	case 0x00:
+		return NewNumericNodeID(0, uint32(d.byte()))
+	case 0x01:
+		ns := uint16(d.byte())
+		return NewNumericNodeID(ns, uint32(d.uint16()))
+	case 0x02:
+		ns := d.uint16()
+		return NewNumericNodeID(ns, d.uint32())
+	case 0x03:
+		ns := d.uint16()
+		return NewStringNodeID(ns, d.string())
+	case 0x04:
+		ns := d.uint16()
+		return NodeID{Namespace: ns, Kind: kindGUID, Text: string(d.take(16))}
+	case 0x05:
+		ns := d.uint16()
+		return NodeID{Namespace: ns, Kind: kindOpaque, Text: string(d.byteString())}
+	default:
+		if d.err == nil {
+			d.err = errDecode
+		}
+		return NodeID{}
+	}
+}
+
+func (d *decoder) nodeID() NodeID {
+	return d.nodeIDWithMask(d.byte())
+}
+
+// expandedNodeID lê um ExpandedNodeId, descartando URI e índice de servidor
+func (d *decoder) expandedNodeID() NodeID {
+	mask := d.byte()
+	id := d.nodeIDWithMask(mask)
+	if mask&0x80 != 0 {
+		d.string()
+	}
+	if mask&0x40 != 0 {
+		d.uint32()
+	}
+	return id
+}
+
+func (d *decoder) qualifiedName() QualifiedName {
+	ns := d.uint16()
+	return QualifiedName{Namespace: ns, Name: d.string()}
+}
+
+func (d *decoder) localizedText() LocalizedText {
+	mask := d.byte()
+	var l LocalizedText
+	if mask&0x01 != 0 {
+		l.Locale = d.string()
+	}
+	if mask&0x02 != 0 {
+		l.Text = d.string()
+	}
+	return l
+}
+
+func (d *decoder) extensionObject() *ExtensionObject {
+	typeID := d.nodeID()
+	switch d.byte() {
+	case 0x00:
+		return nil
+	case 0x01, 0x02:
+		return &ExtensionObject{TypeID: typeID, Body: d.byteString()}
+	default:
+		if d.err == nil {
+			d.err = errDecode
+		}
+		return nil
+	}
+}
+
+// arrayLength lê o comprimento de um array (nulo = 0)
+func (d *decoder) arrayLength() int {
+	n := d.int32()
+	if n < 0 {
+		return 0
+	}
+	if int(n) > len(d.data)-d.pos {
+		// Cada elemento ocupa ao menos um byte
+		if d.err == nil {
+			d.err = errDecode
+		}
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) stringArray() []string {
+	n := d.arrayLength()
+	values := make([]string, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		values = append(values, d.string())
+	}
+	return values
+}
+
+func (d *decoder) uint32Array() []uint32 {
+	n := d.arrayLength()
+	values := make([]uint32, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		values = append(values, d.uint32())
+	}
+	return values
+}
+
+// diagnosticInfo lê e descarta um DiagnosticInfo
+func (d *decoder) diagnosticInfo() {
+	mask := d.byte()
+	if mask&0x01 != 0 {
+		d.int32()
+	}
+	if mask&0x02 != 0 {
+		d.int32()
+	}
+	if mask&0x04 != 0 {
+		d.int32()
+	}
+	if mask&0x08 != 0 {
+		d.int32()
+	}
+	if mask&0x10 != 0 {
+		d.string()
+	}
+	if mask&0x20 != 0 {
+		d.uint32()
+	}
+	if mask&0x40 != 0 {
+		d.diagnosticInfo()
+	}
+}
+
+// variantValue lê um valor escalar de Variant do tipo informado
+func (d *decoder) variantValue(kind byte) interface{} {
+	switch kind {
+	case variantBoolean:
+		return d.boolean()
+	case variantSByte:
+		return int8(d.byte())
+	case variantByte:
+		return d.byte()
+	case variantInt16:
+		return int16(d.uint16())
+	case variantUInt16:
+		return d.uint16()
+	case variantInt32:
+		return d.int32()
+	case variantUInt32, variantStatusCode:
+		return d.uint32()
+	case variantInt64:
+		return d.int64()
+	case variantUInt64:
+		return d.uint64()
+	case variantFloat:
+		return math.Float32frombits(d.uint32())
+	case variantDouble:
+		return d.double()
+	case variantString, variantXMLElement:
+		return d.string()
+	case variantDateTime:
+		return d.dateTime()
+	case variantGUID:
+		return d.take(16)
+	case variantByteString:
+		return d.byteString()
+	case variantNodeID:
+		return d.nodeID()
+	case variantExpandedNodeID:
+		return d.expandedNodeID()
+	case variantQualifiedName:
+		return d.qualifiedName()
+	case variantLocalizedText:
+		return d.localizedText()
+	case variantExtensionObject:
+		return d.extensionObject()
+	case variantDataValue:
+		return d.dataValue()
+	case variantVariant:
+		return d.variant()
+	case variantDiagnosticInfo:
+		d.diagnosticInfo()
+		return nil
+	default:
+		if d.err == nil {
+			d.err = errDecode
+		}
+		return nil
+	}
+}
+
+// variant lê um Variant. Arrays são retornados como []interface{}.
+func (d *decoder) variant() interface{} {
+	mask := d.byte()
+	kind := mask & 0x3F
+	if kind == 0 {
+		return nil
+	}
+
+	if mask&0x80 == 0 {
+		return d.variantValue(kind)
+	}
+
+	n := d.arrayLength()
+	values := make([]interface{}, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		values = append(values, d.variantValue(kind))
+	}
+	if mask&0x40 != 0 {
+		d.uint32Array()
+	}
+	return values
+}
+
+func (d *decoder) dataValue() DataValue {
+	mask := d.byte()
+	var dv DataValue
+	if mask&0x01 != 0 {
+		dv.Value = d.variant()
+		dv.HasValue = true
+	}
+	if mask&0x02 != 0 {
+		dv.Status = d.uint32()
+	}
+	if mask&0x04 != 0 {
+		dv.SourceTimestamp = d.dateTime()
+	}
+	if mask&0x10 != 0 {
+		d.uint16()
+	}
+	if mask&0x08 != 0 {
+		dv.ServerTimestamp = d.dateTime()
+	}
+	if mask&0x20 != 0 {
+		d.uint16()
+	}
+	return dv
+}
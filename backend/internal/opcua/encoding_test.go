@@ -0,0 +1,307 @@
+package opcua
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNodeIDEncoding(t *testing.T) {
+	tests := []struct {
+		id   NodeID
+		want []byte
+	}{
+		// Forma de dois bytes: ns=0, id <= 255
+		{ns0(idObjectsFolder), []byte{0x00, 0x55}},
+		// Forma de quatro bytes: ns <= 255, id <= 65535
+		{NewNumericNodeID(1, 1000), []byte{0x01, 0x01, 0xE8, 0x03}},
+		{ns0(idServer), []byte{0x01, 0x00, 0xCD, 0x08}},
+		// Forma numérica completa
+		{NewNumericNodeID(300, 70000), []byte{0x02, 0x2C, 0x01, 0x70, 0x11, 0x01, 0x00}},
+		{NewStringNodeID(1, "Radar"), []byte{0x03, 0x01, 0x00, 0x05, 0x00, 0x00, 0x00, 'R', 'a', 'd', 'a', 'r'}},
+		{NodeID{Namespace: 2, Kind: kindOpaque, Text: "\x01\x02"}, []byte{0x05, 0x02, 0x00, 0x02, 0x00, 0x00, 0x00, 0x01, 0x02}},
+	}
+
+	for _, tt := range tests {
+		e := &encoder{}
+		e.nodeID(tt.id)
+		if !bytes.Equal(e.bytes(), tt.want) {
+			t.Errorf("nodeID(%s) = % X, esperado % X", tt.id, e.bytes(), tt.want)
+		}
+
+		d := newDecoder(e.bytes())
+		if got := d.nodeID(); d.err != nil || got != tt.id {
+			t.Errorf("decodificação de %s = %s (%v)", tt.id, got, d.err)
+		}
+		if len(d.remaining()) != 0 {
+			t.Errorf("decodificação de %s deixou %d bytes", tt.id, len(d.remaining()))
+		}
+	}
+
+	// GUID: 16 bytes sem prefixo de comprimento
+	guid := NodeID{Namespace: 1, Kind: kindGUID, Text: string(bytes.Repeat([]byte{0xAB}, 16))}
+	e := &encoder{}
+	e.nodeID(guid)
+	if len(e.bytes()) != 19 {
+		t.Errorf("NodeId GUID codificado com %d bytes, esperado 19", len(e.bytes()))
+	}
+	if got := newDecoder(e.bytes()).nodeID(); got != guid {
+		t.Errorf("decodificação de GUID = %s", got)
+	}
+}
+
+func TestExpandedNodeIDSkipsURIAndServerIndex(t *testing.T) {
+	// ExpandedNodeId com NamespaceUri (0x80) e ServerIndex (0x40)
+	data := []byte{0x80 | 0x40 | 0x00, 0x55, 0x02, 0x00, 0x00, 0x00, 'u', 'r', 0x07, 0x00, 0x00, 0x00, 0xFF}
+	d := newDecoder(data)
+	if got := d.expandedNodeID(); d.err != nil || got != ns0(idObjectsFolder) {
+		t.Fatalf("expandedNodeID = %s (%v)", got, d.err)
+	}
+	if rest := d.remaining(); !bytes.Equal(rest, []byte{0xFF}) {
+		t.Errorf("bytes restantes = % X, esperado FF", rest)
+	}
+}
+
+func TestPrimitiveEncoding(t *testing.T) {
+	e := &encoder{}
+	e.string("ab")
+	e.nullString()
+	e.byteString(nil)
+	e.byteString([]byte{})
+	e.boolean(true)
+	e.int32(-2)
+	e.double(1.5)
+	e.dateTime(time.Time{})
+
+	want := []byte{
+		0x02, 0x00, 0x00, 0x00, 'a', 'b', // String
+		0xFF, 0xFF, 0xFF, 0xFF, // String nula
+		0xFF, 0xFF, 0xFF, 0xFF, // ByteString nula
+		0x00, 0x00, 0x00, 0x00, // ByteString vazia
+		0x01,                   // Boolean
+		0xFE, 0xFF, 0xFF, 0xFF, // Int32
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF8, 0x3F, // Double
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // DateTime nulo
+	}
+	if !bytes.Equal(e.bytes(), want) {
+		t.Fatalf("codificação = % X\nesperado     % X", e.bytes(), want)
+	}
+
+	d := newDecoder(e.bytes())
+	if v := d.string(); v != "ab" {
+		t.Errorf("string = %q", v)
+	}
+	if v := d.string(); v != "" {
+		t.Errorf("string nula = %q", v)
+	}
+	if v := d.byteString(); v != nil {
+		t.Errorf("ByteString nula = %v", v)
+	}
+	if v := d.byteString(); v == nil || len(v) != 0 {
+		t.Errorf("ByteString vazia = %#v", v)
+	}
+	if !d.boolean() || d.int32() != -2 || d.double() != 1.5 || !d.dateTime().IsZero() {
+		t.Error("valores primitivos decodificados incorretamente")
+	}
+	if d.err != nil || len(d.remaining()) != 0 {
+		t.Errorf("decodificação incompleta: %v, %d bytes restantes", d.err, len(d.remaining()))
+	}
+}
+
+func TestDateTimeEncoding(t *testing.T) {
+	// Época Unix em intervalos de 100 ns desde 1601-01-01
+	e := &encoder{}
+	e.dateTime(time.Unix(0, 0))
+	if want := []byte{0x00, 0x80, 0x3E, 0xD5, 0xDE, 0xB1, 0x9D, 0x01}; !bytes.Equal(e.bytes(), want) {
+		t.Errorf("dateTime(época Unix) = % X, esperado % X", e.bytes(), want)
+	}
+
+	now := time.Now().Truncate(100 * time.Nanosecond)
+	e = &encoder{}
+	e.dateTime(now)
+	if got := newDecoder(e.bytes()).dateTime(); !got.Equal(now) {
+		t.Errorf("dateTime = %v, esperado %v", got, now)
+	}
+}
+
+func TestVariantRoundTrip(t *testing.T) {
+	timestamp := time.Date(2024, 5, 1, 12, 30, 0, 123456700, time.UTC)
+	extension := &ExtensionObject{TypeID: ns0(idRangeEncoding), Body: []byte{1, 2, 3}}
+
+	tests := []struct {
+		value interface{}
+		want  interface{} // Valor decodificado, quando difere do original
+	}{
+		{value: nil},
+		{value: true},
+		{value: byte(7)},
+		{value: uint16(65000)},
+		{value: int32(-123456)},
+		{value: uint32(4000000000)},
+		{value: int64(-1 << 40)},
+		{value: float32(3.25)},
+		{value: -12.5},
+		{value: "obstruído"},
+		{value: timestamp},
+		{value: NewStringNodeID(1, "Radar.Channels.Channel1.Velocity")},
+		{value: QualifiedName{Namespace: 1, Name: "Velocity"}},
+		{value: LocalizedText{Locale: "pt-BR", Text: "Velocidade"}},
+		{value: extension},
+		{value: []string{"a", "b"}, want: []interface{}{"a", "b"}},
+		{value: []uint32{1, 2}, want: []interface{}{uint32(1), uint32(2)}},
+		{value: []NodeID{ns0(idServer)}, want: []interface{}{ns0(idServer)}},
+	}
+
+	for _, tt := range tests {
+		want := tt.want
+		if want == nil {
+			want = tt.value
+		}
+
+		e := &encoder{}
+		e.variant(tt.value)
+		d := newDecoder(e.bytes())
+		got := d.variant()
+
+		if d.err != nil {
+			t.Errorf("variant(%#v): %v", tt.value, d.err)
+			continue
+		}
+		if len(d.remaining()) != 0 {
+			t.Errorf("variant(%#v) deixou %d bytes", tt.value, len(d.remaining()))
+		}
+		if gotTime, ok := got.(time.Time); ok {
+			if !gotTime.Equal(timestamp) {
+				t.Errorf("variant(DateTime) = %v, esperado %v", gotTime, timestamp)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("variant(%#v) = %#v, esperado %#v", tt.value, got, want)
+		}
+	}
+}
+
+func TestVariantKnownBytes(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  []byte
+	}{
+		{int32(-2), []byte{0x06, 0xFE, 0xFF, 0xFF, 0xFF}},
+		{true, []byte{0x01, 0x01}},
+		{1.0, []byte{0x0B, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF0, 0x3F}},
+		{"ok", []byte{0x0C, 0x02, 0x00, 0x00, 0x00, 'o', 'k'}},
+		{[]uint32{5}, []byte{0x87, 0x01, 0x00, 0x00, 0x00, 0x05, 0x00, 0x00, 0x00}},
+	}
+
+	for _, tt := range tests {
+		e := &encoder{}
+		e.variant(tt.value)
+		if !bytes.Equal(e.bytes(), tt.want) {
+			t.Errorf("variant(%#v) = % X, esperado % X", tt.value, e.bytes(), tt.want)
+		}
+	}
+}
+
+func TestDataValueRoundTrip(t *testing.T) {
+	source := time.Now().Add(-time.Second).Truncate(100 * time.Nanosecond)
+	server := time.Now().Truncate(100 * time.Nanosecond)
+
+	tests := []DataValue{
+		{},
+		{Value: 1.5, HasValue: true},
+		{Value: "ok", HasValue: true, SourceTimestamp: source, ServerTimestamp: server},
+		{Value: false, HasValue: true, Status: StatusUncertainLastUsableValue, SourceTimestamp: source},
+		{Status: StatusBadNodeIDUnknown},
+	}
+
+	for _, dv := range tests {
+		e := &encoder{}
+		e.dataValue(dv)
+		d := newDecoder(e.bytes())
+		got := d.dataValue()
+
+		if d.err != nil || len(d.remaining()) != 0 {
+			t.Errorf("dataValue(%+v): %v, %d bytes restantes", dv, d.err, len(d.remaining()))
+			continue
+		}
+		if got.HasValue != dv.HasValue || !reflect.DeepEqual(got.Value, dv.Value) || got.Status != dv.Status ||
+			!got.SourceTimestamp.Equal(dv.SourceTimestamp) || !got.ServerTimestamp.Equal(dv.ServerTimestamp) {
+			t.Errorf("dataValue = %+v, esperado %+v", got, dv)
+		}
+	}
+
+	// Sem valor nem timestamps, apenas a máscara vazia
+	e := &encoder{}
+	e.dataValue(DataValue{})
+	if !bytes.Equal(e.bytes(), []byte{0x00}) {
+		t.Errorf("DataValue vazio = % X, esperado 00", e.bytes())
+	}
+}
+
+func TestStructuresRoundTrip(t *testing.T) {
+	e := &encoder{}
+	e.qualifiedName(QualifiedName{Namespace: 1, Name: "Radar"})
+	e.localizedText(LocalizedText{})
+	e.localizedText(LocalizedText{Text: "Radar"})
+	e.extensionObject(nil)
+	e.extensionObject(&ExtensionObject{TypeID: ns0(idEUInformationEncoding), Body: []byte{9}})
+	e.stringArray([]string{"urn:a", ""})
+	e.uint32Array([]uint32{StatusGood, StatusBadNotWritable})
+	e.emptyDiagnosticInfo()
+
+	d := newDecoder(e.bytes())
+	if got := d.qualifiedName(); got != (QualifiedName{Namespace: 1, Name: "Radar"}) {
+		t.Errorf("qualifiedName = %+v", got)
+	}
+	if got := d.localizedText(); got != (LocalizedText{}) {
+		t.Errorf("localizedText vazio = %+v", got)
+	}
+	if got := d.localizedText(); got != (LocalizedText{Text: "Radar"}) {
+		t.Errorf("localizedText = %+v", got)
+	}
+	if got := d.extensionObject(); got != nil {
+		t.Errorf("extensionObject nulo = %+v", got)
+	}
+	if got := d.extensionObject(); got == nil || got.TypeID != ns0(idEUInformationEncoding) || !bytes.Equal(got.Body, []byte{9}) {
+		t.Errorf("extensionObject = %+v", got)
+	}
+	if got := d.stringArray(); !reflect.DeepEqual(got, []string{"urn:a", ""}) {
+		t.Errorf("stringArray = %q", got)
+	}
+	if got := d.uint32Array(); !reflect.DeepEqual(got, []uint32{StatusGood, StatusBadNotWritable}) {
+		t.Errorf("uint32Array = %v", got)
+	}
+	d.diagnosticInfo()
+	if d.err != nil || len(d.remaining()) != 0 {
+		t.Errorf("decodificação incompleta: %v, %d bytes restantes", d.err, len(d.remaining()))
+	}
+}
+
+func TestDecoderTruncated(t *testing.T) {
+	e := &encoder{}
+	e.variant(NewStringNodeID(1, "Radar.Channels"))
+	full := e.bytes()
+
+	// Qualquer prefixo incompleto resulta em erro, sem pânico
+	for n := 0; n < len(full); n++ {
+		d := newDecoder(full[:n])
+		d.variant()
+		if d.err == nil {
+			t.Errorf("variant truncado em %d de %d bytes decodificado sem erro", n, len(full))
+		}
+	}
+
+	// Comprimento de array maior que os dados disponíveis
+	d := newDecoder([]byte{0xFF, 0xFF, 0xFF, 0x7F})
+	if got := d.stringArray(); len(got) != 0 || d.err == nil {
+		t.Errorf("stringArray com comprimento inválido = %q, %v", got, d.err)
+	}
+
+	// Tipo de Variant desconhecido
+	d = newDecoder([]byte{0x3F})
+	if d.variant(); d.err == nil {
+		t.Error("Variant de tipo desconhecido decodificado sem erro")
+	}
+}
@@ -0,0 +1,206 @@
+package opcua
+
+// Códigos de status OPC UA utilizados pelo servidor
+const (
+	StatusGood                        uint32 = 0x00000000
+	StatusUncertainLastUsableValue    uint32 = 0x40900000
+	StatusBadUnexpectedError          uint32 = 0x80010000
+	StatusBadInternalError            uint32 = 0x80020000
+	StatusBadDecodingError            uint32 = 0x80070000
+	StatusBadTimeout                  uint32 = 0x800A0000
+	StatusBadServiceUnsupported       uint32 = 0x800B0000
+	StatusBadShutdown                 uint32 = 0x800C0000
+	StatusBadNothingToDo              uint32 = 0x800F0000
+	StatusBadTooManyOperations        uint32 = 0x80100000
+	StatusBadIdentityTokenInvalid     uint32 = 0x80200000
+	StatusBadSecureChannelIDInvalid   uint32 = 0x80220000
+	StatusBadSessionIDInvalid         uint32 = 0x80250000
+	StatusBadSessionClosed            uint32 = 0x80260000
+	StatusBadSessionNotActivated      uint32 = 0x80270000
+	StatusBadSubscriptionIDInvalid    uint32 = 0x80280000
+	StatusBadNodeIDUnknown            uint32 = 0x80340000
+	StatusBadAttributeIDInvalid       uint32 = 0x80350000
+	StatusBadIndexRangeInvalid        uint32 = 0x80360000
+	StatusBadNotWritable              uint32 = 0x803B0000
+	StatusBadMonitoringModeInvalid    uint32 = 0x80410000
+	StatusBadMonitoredItemIDInvalid   uint32 = 0x80420000
+	StatusBadFilterNotAllowed         uint32 = 0x80450000
+	StatusBadContinuationPointInvalid uint32 = 0x804A0000
+	StatusBadBrowseDirectionInvalid   uint32 = 0x804D0000
+	StatusBadSecurityPolicyRejected   uint32 = 0x80550000
+	StatusBadTooManySessions          uint32 = 0x80560000
+	StatusBadNoMatch                  uint32 = 0x806F0000
+	StatusBadTooManyPublishRequests   uint32 = 0x80780000
+	StatusBadNoSubscription           uint32 = 0x80790000
+	StatusBadMessageNotAvailable      uint32 = 0x807B0000
+	StatusBadSequenceNumberUnknown    uint32 = 0x807A0000
+	StatusBadTCPMessageTypeInvalid    uint32 = 0x807E0000
+	StatusBadTCPEndpointURLInvalid    uint32 = 0x80830000
+)
+
+// Identificadores das codificações binárias (DefaultBinary) dos serviços
+const (
+	idServiceFault                 uint32 = 397
+	idFindServersRequest           uint32 = 422
+	idFindServersResponse          uint32 = 425
+	idGetEndpointsRequest          uint32 = 428
+	idGetEndpointsResponse         uint32 = 431
+	idOpenSecureChannelRequest     uint32 = 446
+	idOpenSecureChannelResponse    uint32 = 449
+	idCloseSecureChannelRequest    uint32 = 452
+	idCreateSessionRequest         uint32 = 461
+	idCreateSessionResponse        uint32 = 464
+	idActivateSessionRequest       uint32 = 467
+	idActivateSessionResponse      uint32 = 470
+	idCloseSessionRequest          uint32 = 473
+	idCloseSessionResponse         uint32 = 476
+	idBrowseRequest                uint32 = 527
+	idBrowseResponse               uint32 = 530
+	idBrowseNextRequest            uint32 = 533
+	idBrowseNextResponse           uint32 = 536
+	idTranslateBrowsePathsRequest  uint32 = 554
+	idTranslateBrowsePathsResponse uint32 = 557
+	idReadRequest                  uint32 = 631
+	idReadResponse                 uint32 = 634
+	idWriteRequest                 uint32 = 673
+	idWriteResponse                uint32 = 676
+	idCreateMonitoredItemsRequest  uint32 = 751
+	idCreateMonitoredItemsResponse uint32 = 754
+	idModifyMonitoredItemsRequest  uint32 = 763
+	idModifyMonitoredItemsResponse uint32 = 766
+	idSetMonitoringModeRequest     uint32 = 769
+	idSetMonitoringModeResponse    uint32 = 772
+	idDeleteMonitoredItemsRequest  uint32 = 781
+	idDeleteMonitoredItemsResponse uint32 = 784
+	idCreateSubscriptionRequest    uint32 = 787
+	idCreateSubscriptionResponse   uint32 = 790
+	idModifySubscriptionRequest    uint32 = 793
+	idModifySubscriptionResponse   uint32 = 796
+	idSetPublishingModeRequest     uint32 = 799
+	idSetPublishingModeResponse    uint32 = 802
+	idDataChangeFilter             uint32 = 724
+	idDataChangeNotification       uint32 = 811
+	idPublishRequest               uint32 = 826
+	idPublishResponse              uint32 = 829
+	idRepublishRequest             uint32 = 832
+	idRepublishResponse            uint32 = 835
+	idDeleteSubscriptionsRequest   uint32 = 847
+	idDeleteSubscriptionsResponse  uint32 = 850
+	idServerStatusDataTypeEncoding uint32 = 864
+	idRangeEncoding                uint32 = 886
+	idEUInformationEncoding        uint32 = 889
+	idAnonymousIdentityToken       uint32 = 321
+)
+
+// Nós padrão do namespace 0
+const (
+	// Tipos de dados
+	idBoolean              uint32 = 1
+	idByte                 uint32 = 3
+	idInt32                uint32 = 6
+	idUInt32               uint32 = 7
+	idDouble               uint32 = 11
+	idString               uint32 = 12
+	idDateTime             uint32 = 13
+	idLocalizedText        uint32 = 21
+	idStructure            uint32 = 22
+	idBaseDataType         uint32 = 24
+	idNumber               uint32 = 26
+	idEnumeration          uint32 = 29
+	idServerState          uint32 = 852
+	idServerStatusDataType uint32 = 862
+	idRange                uint32 = 884
+	idEUInformation        uint32 = 887
+
+	// Tipos de referência
+	idReferences                uint32 = 31
+	idNonHierarchicalReferences uint32 = 32
+	idHierarchicalReferences    uint32 = 33
+	idHasChild                  uint32 = 34
+	idOrganizes                 uint32 = 35
+	idHasTypeDefinition         uint32 = 40
+	idAggregates                uint32 = 44
+	idHasSubtype                uint32 = 45
+	idHasProperty               uint32 = 46
+	idHasComponent              uint32 = 47
+
+	// Tipos de objeto e variável
+	idBaseObjectType       uint32 = 58
+	idFolderType           uint32 = 61
+	idBaseVariableType     uint32 = 62
+	idBaseDataVariableType uint32 = 63
+	idPropertyType         uint32 = 68
+	idServerType           uint32 = 2004
+	idServerStatusType     uint32 = 2138
+	idDataItemType         uint32 = 2365
+	idAnalogItemType       uint32 = 2368
+
+	// Pastas e objetos
+	idRootFolder           uint32 = 84
+	idObjectsFolder        uint32 = 85
+	idTypesFolder          uint32 = 86
+	idViewsFolder          uint32 = 87
+	idObjectTypesFolder    uint32 = 88
+	idVariableTypesFolder  uint32 = 89
+	idDataTypesFolder      uint32 = 90
+	idReferenceTypesFolder uint32 = 91
+
+	// Objeto Server
+	idServer                  uint32 = 2253
+	idServerArray             uint32 = 2254
+	idNamespaceArray          uint32 = 2255
+	idServerStatus            uint32 = 2256
+	idServerStatusStartTime   uint32 = 2257
+	idServerStatusCurrentTime uint32 = 2258
+	idServerStatusState       uint32 = 2259
+	idServiceLevel            uint32 = 2267
+)
+
+// Classes de nó
+const (
+	NodeClassObject        uint32 = 1
+	NodeClassVariable      uint32 = 2
+	NodeClassMethod        uint32 = 4
+	NodeClassObjectType    uint32 = 8
+	NodeClassVariableType  uint32 = 16
+	NodeClassReferenceType uint32 = 32
+	NodeClassDataType      uint32 = 64
+	NodeClassView          uint32 = 128
+)
+
+// Atributos suportados
+const (
+	attrNodeID                  uint32 = 1
+	attrNodeClass               uint32 = 2
+	attrBrowseName              uint32 = 3
+	attrDisplayName             uint32 = 4
+	attrDescription             uint32 = 5
+	attrWriteMask               uint32 = 6
+	attrUserWriteMask           uint32 = 7
+	attrIsAbstract              uint32 = 8
+	attrSymmetric               uint32 = 9
+	attrInverseName             uint32 = 10
+	attrContainsNoLoops         uint32 = 11
+	attrEventNotifier           uint32 = 12
+	attrValue                   uint32 = 13
+	attrDataType                uint32 = 14
+	attrValueRank               uint32 = 15
+	attrArrayDimensions         uint32 = 16
+	attrAccessLevel             uint32 = 17
+	attrUserAccessLevel         uint32 = 18
+	attrMinimumSamplingInterval uint32 = 19
+	attrHistorizing             uint32 = 20
+)
+
+// URIs do perfil de transporte e política de segurança
+const (
+	securityPolicyNone   = "http://opcfoundation.org/UA/SecurityPolicy#None"
+	transportProfileURI  = "http://opcfoundation.org/UA-Profile/Transport/uatcp-uasc-uabinary"
+	unitsNamespaceURI    = "http://www.opcfoundation.org/UA/units/un/cefact"
+	standardNamespaceURI = "http://opcfoundation.org/UA/"
+)
+
+// ns0 cria um NodeId numérico do namespace padrão
+func ns0(id uint32) NodeID {
+	return NewNumericNodeID(0, id)
+}
@@ -0,0 +1,380 @@
+package opcua
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Níveis de acesso das variáveis
+const (
+	accessCurrentRead byte = 0x01
+)
+
+// Reference liga dois nós por um tipo de referência
+type Reference struct {
+	TypeID    NodeID
+	Target    NodeID
+	IsForward bool
+}
+
+// Node representa um nó do espaço de endereçamento
+type Node struct {
+	ID          NodeID
+	Class       uint32
+	BrowseName  QualifiedName
+	DisplayName LocalizedText
+	Description LocalizedText
+	References  []Reference
+
+	// Atributos de variáveis
+	DataType  NodeID
+	ValueRank int32 // -1 escalar, 1 array unidimensional
+	Value     DataValue
+	ValueFunc func() interface{} // Valor calculado a cada leitura (ex.: CurrentTime)
+
+	// Atributos de tipos
+	IsAbstract  bool
+	Symmetric   bool
+	InverseName LocalizedText
+}
+
+// AddressSpace armazena os nós publicados pelo servidor
+type AddressSpace struct {
+	mutex sync.RWMutex
+	nodes map[NodeID]*Node
+}
+
+// newAddressSpace cria um espaço de endereçamento vazio
+func newAddressSpace() *AddressSpace {
+	return &AddressSpace{nodes: make(map[NodeID]*Node)}
+}
+
+// add insere um nó no espaço de endereçamento
+func (a *AddressSpace) add(node *Node) *Node {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if node.DisplayName.Text == "" {
+		node.DisplayName.Text = node.BrowseName.Name
+	}
+	if node.Class == NodeClassVariable && node.ValueRank == 0 {
+		node.ValueRank = -1
+	}
+	a.nodes[node.ID] = node
+	return node
+}
+
+// addReference cria uma referência de source para target e a inversa correspondente
+func (a *AddressSpace) addReference(source NodeID, refType uint32, target NodeID) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	typeID := ns0(refType)
+	if node, ok := a.nodes[source]; ok {
+		node.References = append(node.References, Reference{TypeID: typeID, Target: target, IsForward: true})
+	}
+	if node, ok := a.nodes[target]; ok {
+		node.References = append(node.References, Reference{TypeID: typeID, Target: source, IsForward: false})
+	}
+}
+
+// addObject cria um objeto ligado ao pai pelo tipo de referência informado
+func (a *AddressSpace) addObject(id NodeID, name string, parent NodeID, refType uint32, typeDef uint32) *Node {
+	node := a.add(&Node{
+		ID:         id,
+		Class:      NodeClassObject,
+		BrowseName: QualifiedName{Namespace: id.Namespace, Name: name},
+	})
+	a.addReference(parent, refType, id)
+	a.addReference(id, idHasTypeDefinition, ns0(typeDef))
+	return node
+}
+
+// addVariable cria uma variável ligada ao pai pelo tipo de referência informado
+func (a *AddressSpace) addVariable(id NodeID, name string, parent NodeID, refType uint32, typeDef uint32, dataType uint32, value interface{}) *Node {
+	node := a.add(&Node{
+		ID:         id,
+		Class:      NodeClassVariable,
+		BrowseName: QualifiedName{Namespace: id.Namespace, Name: name},
+		DataType:   ns0(dataType),
+		Value:      DataValue{Value: value, HasValue: true, SourceTimestamp: time.Now()},
+	})
+	a.addReference(parent, refType, id)
+	a.addReference(id, idHasTypeDefinition, ns0(typeDef))
+	return node
+}
+
+// addProperty cria uma propriedade (HasProperty / PropertyType)
+func (a *AddressSpace) addProperty(id NodeID, name string, parent NodeID, dataType uint32, value interface{}) *Node {
+	return a.addVariable(id, name, parent, idHasProperty, idPropertyType, dataType, value)
+}
+
+// node retorna um nó pelo NodeId
+func (a *AddressSpace) node(id NodeID) (*Node, bool) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	node, ok := a.nodes[id]
+	return node, ok
+}
+
+// setValue atualiza o valor de uma variável. Retorna true se o valor ou o status mudou.
+func (a *AddressSpace) setValue(id NodeID, value interface{}, status uint32, timestamp time.Time) bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	node, ok := a.nodes[id]
+	if !ok {
+		return false
+	}
+
+	changed := !node.Value.HasValue || node.Value.Status != status || !reflect.DeepEqual(node.Value.Value, value)
+	node.Value = DataValue{Value: value, HasValue: true, Status: status, SourceTimestamp: timestamp}
+	return changed
+}
+
+// readValue retorna o valor atual de uma variável com timestamp do servidor
+func (a *AddressSpace) readValue(id NodeID) DataValue {
+	return a.readAttribute(id, attrValue)
+}
+
+// readAttribute lê um atributo de um nó
+func (a *AddressSpace) readAttribute(id NodeID, attr uint32) DataValue {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	node, ok := a.nodes[id]
+	if !ok {
+		return DataValue{Status: StatusBadNodeIDUnknown}
+	}
+
+	value := func(v interface{}) DataValue {
+		return DataValue{Value: v, HasValue: true}
+	}
+	isVariable := node.Class == NodeClassVariable || node.Class == NodeClassVariableType
+	isType := node.Class == NodeClassObjectType || node.Class == NodeClassVariableType ||
+		node.Class == NodeClassReferenceType || node.Class == NodeClassDataType
+
+	switch attr {
+	case attrNodeID:
+		return value(node.ID)
+	case attrNodeClass:
+		return value(int32(node.Class))
+	case attrBrowseName:
+		return value(node.BrowseName)
+	case attrDisplayName:
+		return value(node.DisplayName)
+	case attrDescription:
+		return value(node.Description)
+	case attrWriteMask, attrUserWriteMask:
+		return value(uint32(0))
+	case attrEventNotifier:
+		if node.Class == NodeClassObject || node.Class == NodeClassView {
+			return value(byte(0))
+		}
+	case attrIsAbstract:
+		if isType {
+			return value(node.IsAbstract)
+		}
+	case attrSymmetric:
+		if node.Class == NodeClassReferenceType {
+			return value(node.Symmetric)
+		}
+	case attrInverseName:
+		if node.Class == NodeClassReferenceType {
+			return value(node.InverseName)
+		}
+	case attrValue:
+		if node.Class == NodeClassVariable {
+			dv := node.Value
+			if node.ValueFunc != nil {
+				dv = DataValue{Value: node.ValueFunc(), HasValue: true, SourceTimestamp: time.Now()}
+			}
+			dv.ServerTimestamp = time.Now()
+			return dv
+		}
+		if node.Class == NodeClassVariableType {
+			return DataValue{HasValue: true}
+		}
+	case attrDataType:
+		if isVariable {
+			return value(node.DataType)
+		}
+	case attrValueRank:
+		if isVariable {
+			return value(node.ValueRank)
+		}
+	case attrArrayDimensions:
+		if isVariable {
+			if node.ValueRank > 0 {
+				return value([]uint32{0})
+			}
+			return DataValue{HasValue: true}
+		}
+	case attrAccessLevel, attrUserAccessLevel:
+		if node.Class == NodeClassVariable {
+			return value(accessCurrentRead)
+		}
+	case attrMinimumSamplingInterval:
+		if node.Class == NodeClassVariable {
+			return value(float64(0))
+		}
+	case attrHistorizing:
+		if node.Class == NodeClassVariable {
+			return value(false)
+		}
+	}
+
+	return DataValue{Status: StatusBadAttributeIDInvalid}
+}
+
+// references retorna uma cópia das referências de um nó
+func (a *AddressSpace) references(id NodeID) ([]Reference, bool) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	node, ok := a.nodes[id]
+	if !ok {
+		return nil, false
+	}
+	return append([]Reference(nil), node.References...), true
+}
+
+// isSubtypeOf verifica se refType é base ou um subtipo de base (via HasSubtype inverso)
+func (a *AddressSpace) isSubtypeOf(refType, base NodeID) bool {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	current := refType
+	for depth := 0; depth < 16; depth++ {
+		if current == base {
+			return true
+		}
+		node, ok := a.nodes[current]
+		if !ok {
+			return false
+		}
+		parent := NodeID{}
+		for _, ref := range node.References {
+			if !ref.IsForward && ref.TypeID == ns0(idHasSubtype) {
+				parent = ref.Target
+				break
+			}
+		}
+		if parent.IsNull() {
+			return false
+		}
+		current = parent
+	}
+	return false
+}
+
+// typeDefinition retorna o tipo (HasTypeDefinition) de um objeto ou variável
+func (a *AddressSpace) typeDefinition(id NodeID) NodeID {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	node, ok := a.nodes[id]
+	if !ok {
+		return NodeID{}
+	}
+	for _, ref := range node.References {
+		if ref.IsForward && ref.TypeID == ns0(idHasTypeDefinition) {
+			return ref.Target
+		}
+	}
+	return NodeID{}
+}
+
+// standardNode descreve um nó do namespace 0 criado na inicialização
+type standardNode struct {
+	id       uint32
+	class    uint32
+	name     string
+	parent   uint32 // Nó pai (0 = sem pai)
+	refType  uint32 // Referência do pai para o nó
+	abstract bool
+	inverse  string // Nome inverso (tipos de referência)
+}
+
+// standardNodes é o subconjunto do namespace 0 necessário para navegação pelos clientes
+var standardNodes = []standardNode{
+	{idRootFolder, NodeClassObject, "Root", 0, 0, false, ""},
+	{idObjectsFolder, NodeClassObject, "Objects", idRootFolder, idOrganizes, false, ""},
+	{idTypesFolder, NodeClassObject, "Types", idRootFolder, idOrganizes, false, ""},
+	{idViewsFolder, NodeClassObject, "Views", idRootFolder, idOrganizes, false, ""},
+	{idObjectTypesFolder, NodeClassObject, "ObjectTypes", idTypesFolder, idOrganizes, false, ""},
+	{idVariableTypesFolder, NodeClassObject, "VariableTypes", idTypesFolder, idOrganizes, false, ""},
+	{idDataTypesFolder, NodeClassObject, "DataTypes", idTypesFolder, idOrganizes, false, ""},
+	{idReferenceTypesFolder, NodeClassObject, "ReferenceTypes", idTypesFolder, idOrganizes, false, ""},
+
+	// Tipos de referência
+	{idReferences, NodeClassReferenceType, "References", idReferenceTypesFolder, idOrganizes, true, ""},
+	{idHierarchicalReferences, NodeClassReferenceType, "HierarchicalReferences", idReferences, idHasSubtype, true, ""},
+	{idNonHierarchicalReferences, NodeClassReferenceType, "NonHierarchicalReferences", idReferences, idHasSubtype, true, ""},
+	{idHasChild, NodeClassReferenceType, "HasChild", idHierarchicalReferences, idHasSubtype, true, ""},
+	{idOrganizes, NodeClassReferenceType, "Organizes", idHierarchicalReferences, idHasSubtype, false, "OrganizedBy"},
+	{idAggregates, NodeClassReferenceType, "Aggregates", idHasChild, idHasSubtype, true, ""},
+	{idHasSubtype, NodeClassReferenceType, "HasSubtype", idHasChild, idHasSubtype, false, "SubtypeOf"},
+	{idHasComponent, NodeClassReferenceType, "HasComponent", idAggregates, idHasSubtype, false, "ComponentOf"},
+	{idHasProperty, NodeClassReferenceType, "HasProperty", idAggregates, idHasSubtype, false, "PropertyOf"},
+	{idHasTypeDefinition, NodeClassReferenceType, "HasTypeDefinition", idNonHierarchicalReferences, idHasSubtype, false, "TypeDefinitionOf"},
+
+	// Tipos de objeto
+	{idBaseObjectType, NodeClassObjectType, "BaseObjectType", idObjectTypesFolder, idOrganizes, false, ""},
+	{idFolderType, NodeClassObjectType, "FolderType", idBaseObjectType, idHasSubtype, false, ""},
+	{idServerType, NodeClassObjectType, "ServerType", idBaseObjectType, idHasSubtype, false, ""},
+
+	// Tipos de variável
+	{idBaseVariableType, NodeClassVariableType, "BaseVariableType", idVariableTypesFolder, idOrganizes, true, ""},
+	{idBaseDataVariableType, NodeClassVariableType, "BaseDataVariableType", idBaseVariableType, idHasSubtype, false, ""},
+	{idPropertyType, NodeClassVariableType, "PropertyType", idBaseVariableType, idHasSubtype, false, ""},
+	{idServerStatusType, NodeClassVariableType, "ServerStatusType", idBaseDataVariableType, idHasSubtype, false, ""},
+	{idDataItemType, NodeClassVariableType, "DataItemType", idBaseDataVariableType, idHasSubtype, false, ""},
+	{idAnalogItemType, NodeClassVariableType, "AnalogItemType", idDataItemType, idHasSubtype, false, ""},
+
+	// Tipos de dados
+	{idBaseDataType, NodeClassDataType, "BaseDataType", idDataTypesFolder, idOrganizes, true, ""},
+	{idBoolean, NodeClassDataType, "Boolean", idBaseDataType, idHasSubtype, false, ""},
+	{idNumber, NodeClassDataType, "Number", idBaseDataType, idHasSubtype, true, ""},
+	{idByte, NodeClassDataType, "Byte", idNumber, idHasSubtype, false, ""},
+	{idInt32, NodeClassDataType, "Int32", idNumber, idHasSubtype, false, ""},
+	{idUInt32, NodeClassDataType, "UInt32", idNumber, idHasSubtype, false, ""},
+	{idDouble, NodeClassDataType, "Double", idNumber, idHasSubtype, false, ""},
+	{idString, NodeClassDataType, "String", idBaseDataType, idHasSubtype, false, ""},
+	{idDateTime, NodeClassDataType, "DateTime", idBaseDataType, idHasSubtype, false, ""},
+	{idLocalizedText, NodeClassDataType, "LocalizedText", idBaseDataType, idHasSubtype, false, ""},
+	{idStructure, NodeClassDataType, "Structure", idBaseDataType, idHasSubtype, true, ""},
+	{idEnumeration, NodeClassDataType, "Enumeration", idBaseDataType, idHasSubtype, true, ""},
+	{idServerState, NodeClassDataType, "ServerState", idEnumeration, idHasSubtype, false, ""},
+	{idServerStatusDataType, NodeClassDataType, "ServerStatusDataType", idStructure, idHasSubtype, false, ""},
+	{idRange, NodeClassDataType, "Range", idStructure, idHasSubtype, false, ""},
+	{idEUInformation, NodeClassDataType, "EUInformation", idStructure, idHasSubtype, false, ""},
+}
+
+// addStandardNodes cria o subconjunto do namespace 0
+func (a *AddressSpace) addStandardNodes() {
+	for _, def := range standardNodes {
+		node := &Node{
+			ID:         ns0(def.id),
+			Class:      def.class,
+			BrowseName: QualifiedName{Name: def.name},
+			IsAbstract: def.abstract,
+		}
+		if def.inverse != "" {
+			node.InverseName = LocalizedText{Text: def.inverse}
+		}
+		if def.class == NodeClassVariableType {
+			node.DataType = ns0(idBaseDataType)
+			node.ValueRank = -2
+		}
+		a.add(node)
+
+		if def.parent != 0 {
+			a.addReference(ns0(def.parent), def.refType, node.ID)
+		}
+		if def.class == NodeClassObject {
+			a.addReference(node.ID, idHasTypeDefinition, ns0(idFolderType))
+		}
+	}
+}
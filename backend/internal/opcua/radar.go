@@ -0,0 +1,262 @@
+package opcua
+
+import (
+	"fmt"
+	"time"
+
+	"radar_go/internal/config"
+	"radar_go/internal/models"
+)
+
+// Número de canais do radar (posições/velocidades)
+const channelCount = 7
+
+// Códigos UNECE das unidades de engenharia
+const (
+	unitMetrePerSecond int32 = 0x4D5453 // "MTS"
+	unitMetre          int32 = 0x4D5452 // "MTR"
+)
+
+// Estados do servidor (enumeração ServerState)
+const (
+	serverStateRunning  int32 = 0
+	serverStateShutdown int32 = 4
+)
+
+// radarNodes agrupa os NodeIds das variáveis atualizadas a cada ciclo
+type radarNodes struct {
+	velocities  [channelCount]NodeID
+	positions   [channelCount]NodeID
+	status      NodeID
+	statusCode  NodeID
+	connected   NodeID
+	lastUpdate  NodeID
+	obstructed  NodeID
+	noData      NodeID
+	commFailure NodeID
+	alarmActive NodeID
+}
+
+// radarNodeID cria um NodeId string no namespace do radar
+func radarNodeID(path string) NodeID {
+	return NewStringNodeID(1, path)
+}
+
+// buildAddressSpace cria o espaço de endereçamento completo: namespace 0, objeto
+// Server e a árvore Objects/Radar.
+func buildAddressSpace(cfg config.OPCUAConfig, version string, startTime time.Time, state func() int32) (*AddressSpace, *radarNodes) {
+	space := newAddressSpace()
+	space.addStandardNodes()
+	addServerObject(space, cfg, version, startTime, state)
+	nodes := addRadarObject(space, cfg, version)
+	return space, nodes
+}
+
+// addServerObject cria o objeto Server com NamespaceArray e ServerStatus
+func addServerObject(space *AddressSpace, cfg config.OPCUAConfig, version string, startTime time.Time, state func() int32) {
+	objects := ns0(idObjectsFolder)
+	server := space.addObject(ns0(idServer), "Server", objects, idOrganizes, idServerType)
+	server.Description = LocalizedText{Text: "Servidor OPC UA do radar"}
+
+	namespaces := space.addProperty(ns0(idNamespaceArray), "NamespaceArray", server.ID, idString,
+		[]string{standardNamespaceURI, cfg.NamespaceURI})
+	namespaces.ValueRank = 1
+
+	servers := space.addProperty(ns0(idServerArray), "ServerArray", server.ID, idString,
+		[]string{cfg.ApplicationURI})
+	servers.ValueRank = 1
+
+	status := space.addVariable(ns0(idServerStatus), "ServerStatus", server.ID, idHasComponent,
+		idServerStatusType, idServerStatusDataType, nil)
+	status.ValueFunc = func() interface{} {
+		return encodeServerStatus(cfg, version, startTime, state())
+	}
+
+	space.addVariable(ns0(idServerStatusStartTime), "StartTime", status.ID, idHasComponent,
+		idBaseDataVariableType, idDateTime, startTime)
+	current := space.addVariable(ns0(idServerStatusCurrentTime), "CurrentTime", status.ID, idHasComponent,
+		idBaseDataVariableType, idDateTime, nil)
+	current.ValueFunc = func() interface{} { return time.Now() }
+	serverState := space.addVariable(ns0(idServerStatusState), "State", status.ID, idHasComponent,
+		idBaseDataVariableType, idServerState, nil)
+	serverState.ValueFunc = func() interface{} { return state() }
+
+	space.addProperty(ns0(idServiceLevel), "ServiceLevel", server.ID, idByte, byte(255))
+}
+
+// encodeServerStatus codifica a estrutura ServerStatusDataType
+func encodeServerStatus(cfg config.OPCUAConfig, version string, startTime time.Time, state int32) *ExtensionObject {
+	e := &encoder{}
+	e.dateTime(startTime)
+	e.dateTime(time.Now())
+	e.int32(state)
+	// BuildInfo
+	e.string(cfg.ApplicationURI)
+	e.string("radar_go")
+	e.string("Radar SICK OPC UA Server")
+	e.string(version)
+	e.string(version)
+	e.dateTime(startTime)
+	// SecondsTillShutdown e ShutdownReason
+	e.uint32(0)
+	e.localizedText(LocalizedText{})
+	return &ExtensionObject{TypeID: ns0(idServerStatusDataTypeEncoding), Body: e.bytes()}
+}
+
+// encodeEUInformation codifica a estrutura EUInformation de uma unidade UNECE
+func encodeEUInformation(unitID int32, symbol, description string) *ExtensionObject {
+	e := &encoder{}
+	e.string(unitsNamespaceURI)
+	e.int32(unitID)
+	e.localizedText(LocalizedText{Locale: "en", Text: symbol})
+	e.localizedText(LocalizedText{Locale: "en", Text: description})
+	return &ExtensionObject{TypeID: ns0(idEUInformationEncoding), Body: e.bytes()}
+}
+
+// encodeRange codifica a estrutura Range (EURange)
+func encodeRange(r config.OPCUARange) *ExtensionObject {
+	e := &encoder{}
+	e.double(r.Low)
+	e.double(r.High)
+	return &ExtensionObject{TypeID: ns0(idRangeEncoding), Body: e.bytes()}
+}
+
+// addRadarObject cria a árvore Objects/Radar com informações do dispositivo,
+// canais, status e alarmes
+func addRadarObject(space *AddressSpace, cfg config.OPCUAConfig, version string) *radarNodes {
+	nodes := &radarNodes{}
+
+	radar := space.addObject(radarNodeID("Radar"), "Radar", ns0(idObjectsFolder), idOrganizes, idBaseObjectType)
+	radar.Description = LocalizedText{Text: "Radar SICK de medição de velocidade e posição"}
+
+	// Informações do dispositivo
+	device := space.addObject(radarNodeID("Radar.DeviceInfo"), "DeviceInfo", radar.ID, idHasComponent, idBaseObjectType)
+	space.addProperty(radarNodeID("Radar.DeviceInfo.Manufacturer"), "Manufacturer", device.ID, idString, cfg.Manufacturer)
+	space.addProperty(radarNodeID("Radar.DeviceInfo.Model"), "Model", device.ID, idString, cfg.Model)
+	space.addProperty(radarNodeID("Radar.DeviceInfo.SoftwareVersion"), "SoftwareVersion", device.ID, idString, version)
+	space.addProperty(radarNodeID("Radar.DeviceInfo.ChannelCount"), "ChannelCount", device.ID, idUInt32, uint32(channelCount))
+
+	// Status
+	nodes.status = space.addVariable(radarNodeID("Radar.RadarStatus"), "RadarStatus", radar.ID, idHasComponent,
+		idBaseDataVariableType, idString, "").ID
+	nodes.statusCode = space.addVariable(radarNodeID("Radar.StatusCode"), "StatusCode", radar.ID, idHasComponent,
+		idBaseDataVariableType, idInt32, int32(0)).ID
+	nodes.connected = space.addVariable(radarNodeID("Radar.Connected"), "Connected", radar.ID, idHasComponent,
+		idBaseDataVariableType, idBoolean, false).ID
+	nodes.lastUpdate = space.addVariable(radarNodeID("Radar.LastUpdate"), "LastUpdate", radar.ID, idHasComponent,
+		idBaseDataVariableType, idDateTime, time.Time{}).ID
+
+	// Canais
+	channels := space.addObject(radarNodeID("Radar.Channels"), "Channels", radar.ID, idHasComponent, idFolderType)
+	velocityUnit := encodeEUInformation(unitMetrePerSecond, "m/s", "metre per second")
+	positionUnit := encodeEUInformation(unitMetre, "m", "metre")
+	for i := 0; i < channelCount; i++ {
+		name := fmt.Sprintf("Channel%d", i+1)
+		path := "Radar.Channels." + name
+		channel := space.addObject(radarNodeID(path), name, channels.ID, idOrganizes, idBaseObjectType)
+
+		nodes.velocities[i] = addAnalogItem(space, path+".Velocity", "Velocity", channel.ID,
+			velocityUnit, encodeRange(cfg.VelocityRange))
+		nodes.positions[i] = addAnalogItem(space, path+".Position", "Position", channel.ID,
+			positionUnit, encodeRange(cfg.PositionRange))
+	}
+
+	// Alarmes
+	alarms := space.addObject(radarNodeID("Radar.Alarms"), "Alarms", radar.ID, idHasComponent, idBaseObjectType)
+	addAlarm := func(name, description string, initial bool) NodeID {
+		node := space.addVariable(radarNodeID("Radar.Alarms."+name), name, alarms.ID, idHasComponent,
+			idBaseDataVariableType, idBoolean, initial)
+		node.Description = LocalizedText{Text: description}
+		return node.ID
+	}
+	// Até a primeira métrica chegar, o alarme NoData permanece ativo
+	nodes.alarmActive = addAlarm("Active", "Algum alarme do radar está ativo", true)
+	nodes.obstructed = addAlarm("Obstructed", "Radar possivelmente obstruído", false)
+	nodes.noData = addAlarm("NoData", "Nenhuma métrica recebida dentro do tempo limite", true)
+	nodes.commFailure = addAlarm("CommunicationFailure", "Falha de comunicação com o radar", false)
+
+	return nodes
+}
+
+// addAnalogItem cria uma variável AnalogItemType (Double) com EngineeringUnits e EURange
+func addAnalogItem(space *AddressSpace, path, name string, parent NodeID, unit, euRange *ExtensionObject) NodeID {
+	node := space.addVariable(radarNodeID(path), name, parent, idHasComponent, idAnalogItemType, idDouble, float64(0))
+	space.addProperty(radarNodeID(path+".EngineeringUnits"), "EngineeringUnits", node.ID, idEUInformation, unit)
+	space.addProperty(radarNodeID(path+".EURange"), "EURange", node.ID, idRange, euRange)
+	return node.ID
+}
+
+// statusCode converte o status textual do radar para o código numérico
+func statusCode(status string) int32 {
+	switch status {
+	case "ok":
+		return 1
+	case "obstruido":
+		return 2
+	case "falha_comunicacao":
+		return 3
+	default:
+		return 0
+	}
+}
+
+// applyMetrics atualiza as variáveis do radar e retorna os nós que mudaram
+func applyMetrics(space *AddressSpace, nodes *radarNodes, metrics models.RadarMetrics) []NodeID {
+	var changed []NodeID
+	timestamp := metrics.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	set := func(id NodeID, value interface{}) {
+		if space.setValue(id, value, StatusGood, timestamp) {
+			changed = append(changed, id)
+		}
+	}
+
+	for i := 0; i < channelCount; i++ {
+		set(nodes.velocities[i], metrics.Velocities[i])
+		set(nodes.positions[i], metrics.Positions[i])
+	}
+
+	set(nodes.status, metrics.Status)
+	set(nodes.statusCode, statusCode(metrics.Status))
+	set(nodes.connected, metrics.Status != "falha_comunicacao")
+	set(nodes.lastUpdate, timestamp)
+
+	obstructed := metrics.Status == "obstruido"
+	commFailure := metrics.Status == "falha_comunicacao"
+	set(nodes.obstructed, obstructed)
+	set(nodes.commFailure, commFailure)
+	set(nodes.noData, false)
+	set(nodes.alarmActive, obstructed || commFailure)
+
+	return changed
+}
+
+// applyStale sinaliza o alarme NoData e marca os valores dos canais como incertos
+func applyStale(space *AddressSpace, nodes *radarNodes) []NodeID {
+	var changed []NodeID
+	now := time.Now()
+
+	if space.setValue(nodes.noData, true, StatusGood, now) {
+		changed = append(changed, nodes.noData)
+	}
+	if space.setValue(nodes.alarmActive, true, StatusGood, now) {
+		changed = append(changed, nodes.alarmActive)
+	}
+
+	// Manter o último valor, mas com status UncertainLastUsableValue
+	for _, ids := range [][channelCount]NodeID{nodes.velocities, nodes.positions} {
+		for _, id := range ids {
+			current := space.readValue(id)
+			if current.Status == StatusUncertainLastUsableValue {
+				continue
+			}
+			if space.setValue(id, current.Value, StatusUncertainLastUsableValue, current.SourceTimestamp) {
+				changed = append(changed, id)
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,400 @@
+// Package opcua implementa um servidor OPC UA (UA TCP binário, SecurityPolicy
+// None, autenticação anônima) que publica as métricas do radar em um espaço de
+// endereçamento navegável. Suporta os serviços de descoberta, sessão, Browse,
+// Read, TranslateBrowsePaths e assinaturas com itens monitorados.
+package opcua
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"radar_go/internal/config"
+	"radar_go/internal/models"
+	"radar_go/pkg/logger"
+)
+
+// Service publica as métricas do radar via OPC UA
+type Service struct {
+	config      config.OPCUAConfig
+	endpointURL string
+	version     string
+	startTime   time.Time
+
+	space *AddressSpace
+	nodes *radarNodes
+
+	listener   net.Listener
+	channels   map[*secureChannel]struct{}
+	running    bool
+	mutex      sync.RWMutex
+	wg         sync.WaitGroup
+	stopChan   chan struct{}
+	channelSeq uint32
+
+	// Sessões e assinaturas (protegidas por sessionMutex)
+	sessionMutex    sync.Mutex
+	sessions        map[NodeID]*session
+	sessionSeq      uint32
+	subscriptionSeq uint32
+
+	// Controle de dados antigos
+	lastUpdate time.Time
+	stale      bool
+	dataMutex  sync.Mutex
+}
+
+// NewService cria um novo serviço OPC UA
+func NewService(cfg config.OPCUAConfig, version string) *Service {
+	hostname := cfg.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+		if hostname == "" {
+			hostname = "localhost"
+		}
+	}
+
+	service := &Service{
+		config:      cfg,
+		endpointURL: fmt.Sprintf("opc.tcp://%s", net.JoinHostPort(hostname, strconv.Itoa(cfg.Port))),
+		version:     version,
+		startTime:   time.Now(),
+		channels:    make(map[*secureChannel]struct{}),
+		sessions:    make(map[NodeID]*session),
+	}
+	service.space, service.nodes = buildAddressSpace(cfg, version, service.startTime, service.serverState)
+
+	return service
+}
+
+// EndpointURL retorna a URL anunciada aos clientes
+func (s *Service) EndpointURL() string {
+	return s.endpointURL
+}
+
+// Start inicia o servidor OPC UA
+func (s *Service) Start() error {
+	if !s.config.Enabled {
+		logger.Info("Serviço OPC UA desabilitado por configuração")
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.running {
+		return nil
+	}
+
+	addr := net.JoinHostPort("", strconv.Itoa(s.config.Port))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar servidor OPC UA em %s: %w", addr, err)
+	}
+
+	s.listener = listener
+	s.stopChan = make(chan struct{})
+	s.running = true
+
+	s.wg.Add(2)
+	go s.acceptLoop(listener)
+	go s.maintenanceLoop(s.stopChan)
+
+	logger.Infof("Servidor OPC UA iniciado em %s", s.endpointURL)
+	return nil
+}
+
+// Stop para o servidor OPC UA, encerrando sessões e conexões
+func (s *Service) Stop() {
+	s.mutex.Lock()
+	if !s.running {
+		s.mutex.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stopChan)
+	s.listener.Close()
+	channels := make([]*secureChannel, 0, len(s.channels))
+	for ch := range s.channels {
+		channels = append(channels, ch)
+	}
+	s.mutex.Unlock()
+
+	s.sessionMutex.Lock()
+	for token, sess := range s.sessions {
+		s.closeSessionLocked(token, sess)
+	}
+	s.sessionMutex.Unlock()
+
+	for _, ch := range channels {
+		ch.close()
+	}
+
+	s.wg.Wait()
+	logger.Info("Serviço OPC UA parado")
+}
+
+// IsRunning verifica se o serviço está em execução
+func (s *Service) IsRunning() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.running
+}
+
+// SessionCount retorna o número de sessões abertas
+func (s *Service) SessionCount() int {
+	s.sessionMutex.Lock()
+	defer s.sessionMutex.Unlock()
+	return len(s.sessions)
+}
+
+// UpdateMetrics atualiza as variáveis do radar e notifica as assinaturas.
+// Compatível com radar.MetricsHandler.
+func (s *Service) UpdateMetrics(metrics models.RadarMetrics) {
+	if !s.config.Enabled {
+		return
+	}
+
+	s.dataMutex.Lock()
+	s.lastUpdate = time.Now()
+	s.stale = false
+	s.dataMutex.Unlock()
+
+	changed := applyMetrics(s.space, s.nodes, metrics)
+	s.notifyDataChange(changed)
+}
+
+// serverState retorna o estado publicado em ServerStatus.State
+func (s *Service) serverState() int32 {
+	if s.IsRunning() {
+		return serverStateRunning
+	}
+	return serverStateShutdown
+}
+
+// nextChannelID gera um identificador de canal seguro
+func (s *Service) nextChannelID() uint32 {
+	return atomic.AddUint32(&s.channelSeq, 1)
+}
+
+// acceptLoop aceita conexões de clientes OPC UA
+func (s *Service) acceptLoop(listener net.Listener) {
+	defer s.wg.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !s.IsRunning() {
+				return
+			}
+			logger.Warnf("Erro ao aceitar conexão OPC UA: %v", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		ch := &secureChannel{
+			service: s,
+			conn:    conn,
+			chunks:  make(map[uint32][]byte),
+		}
+
+		s.mutex.Lock()
+		s.channels[ch] = struct{}{}
+		s.mutex.Unlock()
+
+		logger.Infof("Cliente OPC UA conectado: %s", conn.RemoteAddr())
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			ch.serve()
+		}()
+	}
+}
+
+// channelClosed remove o canal e descarta requisições Publish pendentes nele
+func (s *Service) channelClosed(ch *secureChannel) {
+	s.mutex.Lock()
+	delete(s.channels, ch)
+	s.mutex.Unlock()
+
+	s.sessionMutex.Lock()
+	defer s.sessionMutex.Unlock()
+	for _, sess := range s.sessions {
+		if sess.channel == ch {
+			sess.channel = nil
+		}
+		pending := sess.publishQueue[:0]
+		for _, req := range sess.publishQueue {
+			if req.channel != ch {
+				pending = append(pending, req)
+			}
+		}
+		sess.publishQueue = pending
+	}
+}
+
+// maintenanceLoop verifica dados antigos, timeouts de sessão e ciclos de publicação
+func (s *Service) maintenanceLoop(stop chan struct{}) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(minPublishingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			s.checkStale(now)
+			s.expireSessions(now)
+			s.publishCycle(now)
+		}
+	}
+}
+
+// checkStale sinaliza o alarme NoData quando não há métricas dentro de StaleTimeout
+func (s *Service) checkStale(now time.Time) {
+	if s.config.StaleTimeout <= 0 {
+		return
+	}
+
+	s.dataMutex.Lock()
+	if s.stale || s.lastUpdate.IsZero() || now.Sub(s.lastUpdate) < s.config.StaleTimeout {
+		s.dataMutex.Unlock()
+		return
+	}
+	s.stale = true
+	s.dataMutex.Unlock()
+
+	logger.Warnf("OPC UA: nenhuma métrica do radar há mais de %v", s.config.StaleTimeout)
+	s.notifyDataChange(applyStale(s.space, s.nodes))
+}
+
+// dispatch decodifica uma requisição de serviço e retorna a resposta codificada.
+// Retorna nil quando a resposta será enviada depois (Publish).
+func (s *Service) dispatch(ch *secureChannel, requestID uint32, payload []byte) []byte {
+	d := newDecoder(payload)
+	typeID := d.expandedNodeID()
+	header := decodeRequestHeader(d)
+	if d.err != nil {
+		return serviceFault(header.handle, StatusBadDecodingError)
+	}
+
+	if typeID.Namespace != 0 || typeID.Kind != kindNumeric {
+		return serviceFault(header.handle, StatusBadServiceUnsupported)
+	}
+
+	// Serviços sem sessão
+	switch typeID.Numeric {
+	case idCloseSecureChannelRequest:
+		return nil
+	case idGetEndpointsRequest:
+		return s.handleGetEndpoints(header, d)
+	case idFindServersRequest:
+		return s.handleFindServers(header, d)
+	case idCreateSessionRequest:
+		return s.handleCreateSession(ch, header, d)
+	case idActivateSessionRequest:
+		return s.handleActivateSession(ch, header, d)
+	}
+
+	sess, status := s.lookupSession(header.authToken)
+	if status != StatusGood {
+		return serviceFault(header.handle, status)
+	}
+
+	switch typeID.Numeric {
+	case idCloseSessionRequest:
+		return s.handleCloseSession(header, d)
+	case idBrowseRequest:
+		return s.handleBrowse(sess, header, d)
+	case idBrowseNextRequest:
+		return s.handleBrowseNext(sess, header, d)
+	case idTranslateBrowsePathsRequest:
+		return s.handleTranslateBrowsePaths(header, d)
+	case idReadRequest:
+		return s.handleRead(header, d)
+	case idWriteRequest:
+		return s.handleWrite(header, d)
+	case idCreateSubscriptionRequest:
+		return s.handleCreateSubscription(sess, header, d)
+	case idModifySubscriptionRequest:
+		return s.handleModifySubscription(sess, header, d)
+	case idSetPublishingModeRequest:
+		return s.handleSetPublishingMode(sess, header, d)
+	case idDeleteSubscriptionsRequest:
+		return s.handleDeleteSubscriptions(sess, header, d)
+	case idCreateMonitoredItemsRequest:
+		return s.handleCreateMonitoredItems(sess, header, d)
+	case idModifyMonitoredItemsRequest:
+		return s.handleModifyMonitoredItems(sess, header, d)
+	case idSetMonitoringModeRequest:
+		return s.handleSetMonitoringMode(sess, header, d)
+	case idDeleteMonitoredItemsRequest:
+		return s.handleDeleteMonitoredItems(sess, header, d)
+	case idPublishRequest:
+		return s.handlePublish(ch, sess, requestID, header, d)
+	case idRepublishRequest:
+		return s.handleRepublish(sess, header, d)
+	default:
+		logger.Debugf("Serviço OPC UA não suportado: %s", typeID)
+		return serviceFault(header.handle, StatusBadServiceUnsupported)
+	}
+}
+
+// applicationDescription codifica a descrição da aplicação servidora
+func (s *Service) applicationDescription(e *encoder) {
+	e.string(s.config.ApplicationURI)
+	e.string("urn:radar_go")
+	e.localizedText(LocalizedText{Text: "Radar SICK OPC UA Server"})
+	e.uint32(0) // ApplicationType: Server
+	e.nullString()
+	e.nullString()
+	e.stringArray([]string{s.endpointURL})
+}
+
+// endpointDescriptions codifica a lista de endpoints (apenas None/anônimo)
+func (s *Service) endpointDescriptions(e *encoder) {
+	e.int32(1)
+	e.string(s.endpointURL)
+	s.applicationDescription(e)
+	e.byteString(nil) // ServerCertificate
+	e.uint32(1)       // MessageSecurityMode: None
+	e.string(securityPolicyNone)
+	// UserIdentityTokens
+	e.int32(1)
+	e.string("anonymous")
+	e.uint32(0) // UserTokenType: Anonymous
+	e.nullString()
+	e.nullString()
+	e.nullString()
+	e.string(transportProfileURI)
+	e.byte(0) // SecurityLevel
+}
+
+// handleGetEndpoints responde GetEndpoints
+func (s *Service) handleGetEndpoints(header requestHeader, d *decoder) []byte {
+	d.string()      // EndpointUrl
+	d.stringArray() // LocaleIds
+	d.stringArray() // ProfileUris
+	if d.err != nil {
+		return serviceFault(header.handle, StatusBadDecodingError)
+	}
+
+	e := newResponse(idGetEndpointsResponse, header.handle)
+	s.endpointDescriptions(e)
+	return e.bytes()
+}
+
+// handleFindServers responde FindServers com a própria aplicação
+func (s *Service) handleFindServers(header requestHeader, d *decoder) []byte {
+	e := newResponse(idFindServersResponse, header.handle)
+	e.int32(1)
+	s.applicationDescription(e)
+	return e.bytes()
+}
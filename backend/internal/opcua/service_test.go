@@ -0,0 +1,550 @@
+package opcua
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"radar_go/internal/config"
+	"radar_go/internal/models"
+)
+
+// testClient é um cliente UA TCP mínimo (SecurityPolicy None) para os testes
+type testClient struct {
+	t         *testing.T
+	conn      net.Conn
+	channelID uint32
+	tokenID   uint32
+	sequence  uint32
+	requestID uint32
+	handle    uint32
+	authToken NodeID
+}
+
+// startTestService inicia o servidor OPC UA em uma porta livre
+func startTestService(t *testing.T) *Service {
+	t.Helper()
+
+	service := NewService(config.OPCUAConfig{
+		Enabled:        true,
+		Port:           0,
+		Hostname:       "localhost",
+		ApplicationURI: "urn:radar_go:server",
+		NamespaceURI:   "urn:radar_go:radar",
+		Manufacturer:   "SICK",
+		Model:          "RMS1000",
+		MaxSessions:    2,
+		SessionTimeout: time.Minute,
+		VelocityRange:  config.OPCUARange{Low: -50, High: 50},
+		PositionRange:  config.OPCUARange{Low: 0, High: 200},
+	}, "teste")
+	if err := service.Start(); err != nil {
+		t.Fatalf("erro ao iniciar o servidor OPC UA: %v", err)
+	}
+	t.Cleanup(service.Stop)
+	return service
+}
+
+// dialTestClient conecta ao servidor e conclui Hello e OpenSecureChannel
+func dialTestClient(t *testing.T, service *Service) *testClient {
+	t.Helper()
+
+	service.mutex.RLock()
+	_, port, _ := net.SplitHostPort(service.listener.Addr().String())
+	service.mutex.RUnlock()
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort("127.0.0.1", port), time.Second)
+	if err != nil {
+		t.Fatalf("erro ao conectar ao servidor OPC UA: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	c := &testClient{t: t, conn: conn}
+	c.hello(service.EndpointURL())
+	c.openSecureChannel()
+	return c
+}
+
+// writeFrame envia uma mensagem com cabeçalho UA TCP
+func (c *testClient) writeFrame(kind string, body []byte) {
+	c.t.Helper()
+
+	frame := make([]byte, headerSize, headerSize+len(body))
+	copy(frame, kind)
+	binary.LittleEndian.PutUint32(frame[4:], uint32(headerSize+len(body)))
+	if _, err := c.conn.Write(append(frame, body...)); err != nil {
+		c.t.Fatalf("erro ao enviar %s: %v", kind, err)
+	}
+}
+
+// readFrame lê uma mensagem e retorna o tipo (ex.: "MSGF") e o corpo
+func (c *testClient) readFrame() (string, []byte) {
+	c.t.Helper()
+
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(c.conn, header); err != nil {
+		c.t.Fatalf("erro ao ler resposta: %v", err)
+	}
+	body := make([]byte, binary.LittleEndian.Uint32(header[4:])-headerSize)
+	if _, err := io.ReadFull(c.conn, body); err != nil {
+		c.t.Fatalf("erro ao ler resposta: %v", err)
+	}
+	return string(header[:4]), body
+}
+
+// hello envia HEL e valida o ACK
+func (c *testClient) hello(endpointURL string) {
+	c.t.Helper()
+
+	e := &encoder{}
+	e.uint32(0)     // ProtocolVersion
+	e.uint32(65535) // ReceiveBufferSize
+	e.uint32(65535) // SendBufferSize
+	e.uint32(0)     // MaxMessageSize
+	e.uint32(0)     // MaxChunkCount
+	e.string(endpointURL)
+	c.writeFrame("HELF", e.bytes())
+
+	kind, body := c.readFrame()
+	if kind != "ACKF" {
+		c.t.Fatalf("resposta ao HEL = %s, esperado ACKF", kind)
+	}
+	d := newDecoder(body)
+	d.uint32() // ProtocolVersion
+	if receive := d.uint32(); receive != bufferSize {
+		c.t.Errorf("ReceiveBufferSize do servidor = %d, esperado %d", receive, bufferSize)
+	}
+	if send := d.uint32(); send != 65535 {
+		c.t.Errorf("SendBufferSize do servidor = %d, esperado 65535", send)
+	}
+}
+
+// encodeRequestHeader codifica um RequestHeader com o token da sessão
+func (c *testClient) encodeRequestHeader(e *encoder) {
+	c.handle++
+	e.nodeID(c.authToken)
+	e.dateTime(time.Now())
+	e.uint32(c.handle)
+	e.uint32(0) // ReturnDiagnostics
+	e.nullString()
+	e.uint32(5000) // TimeoutHint
+	e.extensionObject(nil)
+}
+
+// openSecureChannel emite o token do canal seguro
+func (c *testClient) openSecureChannel() {
+	c.t.Helper()
+
+	c.requestID++
+	c.sequence++
+	e := &encoder{}
+	e.uint32(0) // ChannelId
+	e.string(securityPolicyNone)
+	e.byteString(nil) // SenderCertificate
+	e.byteString(nil) // ReceiverCertificateThumbprint
+	e.uint32(c.sequence)
+	e.uint32(c.requestID)
+	e.nodeID(ns0(idOpenSecureChannelRequest))
+	c.encodeRequestHeader(e)
+	e.uint32(0)       // ClientProtocolVersion
+	e.uint32(0)       // RequestType: Issue
+	e.uint32(1)       // SecurityMode: None
+	e.byteString(nil) // ClientNonce
+	e.uint32(60000)   // RequestedLifetime
+	c.writeFrame("OPNF", e.bytes())
+
+	kind, body := c.readFrame()
+	if kind != "OPNF" {
+		c.t.Fatalf("resposta ao OPN = %s, esperado OPNF", kind)
+	}
+	d := newDecoder(body)
+	d.uint32() // ChannelId
+	if policy := d.string(); policy != securityPolicyNone {
+		c.t.Errorf("SecurityPolicyUri = %q", policy)
+	}
+	d.byteString()
+	d.byteString()
+	d.uint32() // SequenceNumber
+	if requestID := d.uint32(); requestID != c.requestID {
+		c.t.Errorf("RequestId da resposta = %d, esperado %d", requestID, c.requestID)
+	}
+	c.decodeResponseHeader(d, idOpenSecureChannelResponse)
+	d.uint32() // ServerProtocolVersion
+	c.channelID = d.uint32()
+	c.tokenID = d.uint32()
+	d.dateTime()
+	if lifetime := d.uint32(); lifetime != 60000 {
+		c.t.Errorf("RevisedLifetime = %d, esperado 60000", lifetime)
+	}
+	if d.err != nil || c.channelID == 0 {
+		c.t.Fatalf("OpenSecureChannelResponse inválida (canal %d): %v", c.channelID, d.err)
+	}
+}
+
+// decodeResponseHeader valida o tipo da resposta e retorna o ServiceResult
+func (c *testClient) decodeResponseHeader(d *decoder, typeID uint32) uint32 {
+	c.t.Helper()
+
+	if got := d.nodeID(); got != ns0(typeID) {
+		c.t.Fatalf("resposta do tipo %s, esperado %s", got, ns0(typeID))
+	}
+	d.dateTime()
+	if handle := d.uint32(); handle != c.handle {
+		c.t.Errorf("RequestHandle da resposta = %d, esperado %d", handle, c.handle)
+	}
+	result := d.uint32()
+	d.diagnosticInfo()
+	d.stringArray()
+	d.extensionObject()
+	return result
+}
+
+// call envia uma requisição de serviço e retorna o decodificador da resposta,
+// já posicionado após o ResponseHeader
+func (c *testClient) call(requestType, responseType uint32, body func(e *encoder)) (*decoder, uint32) {
+	c.t.Helper()
+
+	c.requestID++
+	c.sequence++
+	e := &encoder{}
+	e.uint32(c.channelID)
+	e.uint32(c.tokenID)
+	e.uint32(c.sequence)
+	e.uint32(c.requestID)
+	e.nodeID(ns0(requestType))
+	c.encodeRequestHeader(e)
+	body(e)
+	c.writeFrame("MSGF", e.bytes())
+
+	// Remontar a resposta, que pode vir em vários chunks
+	var payload []byte
+	for {
+		kind, frame := c.readFrame()
+		d := newDecoder(frame)
+		if channelID := d.uint32(); channelID != c.channelID {
+			c.t.Fatalf("resposta no canal %d, esperado %d", channelID, c.channelID)
+		}
+		d.uint32() // TokenId
+		d.uint32() // SequenceNumber
+		if requestID := d.uint32(); requestID != c.requestID {
+			c.t.Fatalf("RequestId da resposta = %d, esperado %d", requestID, c.requestID)
+		}
+		payload = append(payload, d.remaining()...)
+		if kind == "MSGF" {
+			break
+		}
+		if kind != "MSGC" {
+			c.t.Fatalf("resposta do tipo %s", kind)
+		}
+	}
+
+	d := newDecoder(payload)
+	// Falhas de serviço chegam como ServiceFault
+	if mask := payload[0]; mask == 0x01 && binary.LittleEndian.Uint16(payload[2:]) == uint16(idServiceFault) {
+		return d, c.decodeResponseHeader(d, idServiceFault)
+	}
+	return d, c.decodeResponseHeader(d, responseType)
+}
+
+// createSession cria uma sessão anônima e, se activate, a ativa
+func (c *testClient) createSession(name string, activate bool) {
+	c.t.Helper()
+
+	d, result := c.call(idCreateSessionRequest, idCreateSessionResponse, func(e *encoder) {
+		// ClientDescription
+		e.string("urn:radar_go:test")
+		e.string("urn:radar_go")
+		e.localizedText(LocalizedText{Text: "Cliente de teste"})
+		e.uint32(1) // ApplicationType: Client
+		e.nullString()
+		e.nullString()
+		e.emptyArray()
+
+		e.nullString() // ServerUri
+		e.string("opc.tcp://localhost")
+		e.string(name)
+		e.byteString(make([]byte, 32)) // ClientNonce
+		e.byteString(nil)              // ClientCertificate
+		e.double(30000)                // RequestedSessionTimeout
+		e.uint32(0)                    // MaxResponseMessageSize
+	})
+	if result != StatusGood {
+		c.t.Fatalf("CreateSession = 0x%08X", result)
+	}
+
+	sessionID := d.nodeID()
+	c.authToken = d.nodeID()
+	if timeout := d.double(); timeout != 30000 {
+		c.t.Errorf("RevisedSessionTimeout = %v, esperado 30000", timeout)
+	}
+	if d.err != nil || sessionID.IsNull() || c.authToken.IsNull() {
+		c.t.Fatalf("CreateSessionResponse inválida: %v", d.err)
+	}
+
+	if !activate {
+		return
+	}
+
+	_, result = c.call(idActivateSessionRequest, idActivateSessionResponse, func(e *encoder) {
+		// ClientSignature
+		e.nullString()
+		e.byteString(nil)
+		e.emptyArray() // ClientSoftwareCertificates
+		e.emptyArray() // LocaleIds
+		token := &encoder{}
+		token.string("anonymous")
+		e.extensionObject(&ExtensionObject{TypeID: ns0(idAnonymousIdentityToken), Body: token.bytes()})
+		// UserTokenSignature
+		e.nullString()
+		e.byteString(nil)
+	})
+	if result != StatusGood {
+		c.t.Fatalf("ActivateSession = 0x%08X", result)
+	}
+}
+
+// browseResult é uma referência retornada pelo Browse
+type browseResult struct {
+	refType    NodeID
+	isForward  bool
+	target     NodeID
+	browseName QualifiedName
+	nodeClass  uint32
+	typeDef    NodeID
+}
+
+// browse navega as referências hierárquicas de um nó
+func (c *testClient) browse(node NodeID) (uint32, []browseResult) {
+	c.t.Helper()
+
+	d, result := c.call(idBrowseRequest, idBrowseResponse, func(e *encoder) {
+		// ViewDescription
+		e.nodeID(NodeID{})
+		e.dateTime(time.Time{})
+		e.uint32(0)
+		e.uint32(0) // RequestedMaxReferencesPerNode
+		e.int32(1)
+		e.nodeID(node)
+		e.uint32(browseForward)
+		e.nodeID(ns0(idHierarchicalReferences))
+		e.boolean(true) // IncludeSubtypes
+		e.uint32(0)     // NodeClassMask
+		e.uint32(0x3F)  // ResultMask
+	})
+	if result != StatusGood {
+		c.t.Fatalf("Browse = 0x%08X", result)
+	}
+
+	if n := d.arrayLength(); n != 1 {
+		c.t.Fatalf("Browse retornou %d resultados, esperado 1", n)
+	}
+	status := d.uint32()
+	d.byteString() // ContinuationPoint
+	var refs []browseResult
+	for i, n := 0, d.arrayLength(); i < n && d.err == nil; i++ {
+		var ref browseResult
+		ref.refType = d.nodeID()
+		ref.isForward = d.boolean()
+		ref.target = d.expandedNodeID()
+		ref.browseName = d.qualifiedName()
+		d.localizedText()
+		ref.nodeClass = d.uint32()
+		ref.typeDef = d.expandedNodeID()
+		refs = append(refs, ref)
+	}
+	d.arrayLength() // DiagnosticInfos
+	if d.err != nil {
+		c.t.Fatalf("BrowseResponse inválida: %v", d.err)
+	}
+	return status, refs
+}
+
+// read lê atributos; retorna o ServiceResult e os valores
+func (c *testClient) read(attribute uint32, nodes ...NodeID) (uint32, []DataValue) {
+	c.t.Helper()
+
+	d, result := c.call(idReadRequest, idReadResponse, func(e *encoder) {
+		e.double(0) // MaxAge
+		e.uint32(timestampsBoth)
+		e.int32(int32(len(nodes)))
+		for _, node := range nodes {
+			e.nodeID(node)
+			e.uint32(attribute)
+			e.nullString()                   // IndexRange
+			e.qualifiedName(QualifiedName{}) // DataEncoding
+		}
+	})
+	if result != StatusGood {
+		return result, nil
+	}
+
+	values := make([]DataValue, d.arrayLength())
+	for i := range values {
+		values[i] = d.dataValue()
+	}
+	if d.err != nil {
+		c.t.Fatalf("ReadResponse inválida: %v", d.err)
+	}
+	return result, values
+}
+
+func TestServiceSessionBrowseRead(t *testing.T) {
+	service := startTestService(t)
+	service.UpdateMetrics(models.RadarMetrics{
+		Velocities: [7]float64{12.5, -3},
+		Positions:  [7]float64{40.25},
+		Status:     "obstruido",
+	})
+
+	client := dialTestClient(t, service)
+	client.createSession("teste", true)
+	if n := service.SessionCount(); n != 1 {
+		t.Errorf("%d sessões abertas, esperada 1", n)
+	}
+
+	// Objects organiza o objeto Server e a árvore do radar
+	status, refs := client.browse(ns0(idObjectsFolder))
+	if status != StatusGood {
+		t.Fatalf("Browse(Objects) = 0x%08X", status)
+	}
+	found := map[NodeID]browseResult{}
+	for _, ref := range refs {
+		found[ref.target] = ref
+	}
+	radar, ok := found[radarNodeID("Radar")]
+	if !ok {
+		t.Fatalf("Objects sem referência ao Radar: %+v", refs)
+	}
+	if radar.refType != ns0(idOrganizes) || !radar.isForward || radar.nodeClass != NodeClassObject ||
+		radar.browseName != (QualifiedName{Namespace: 1, Name: "Radar"}) || radar.typeDef != ns0(idBaseObjectType) {
+		t.Errorf("referência ao Radar = %+v", radar)
+	}
+	if _, ok := found[ns0(idServer)]; !ok {
+		t.Error("Objects sem referência ao Server")
+	}
+
+	// Canal 1: velocidade e posição, com propriedades de engenharia
+	status, refs = client.browse(radarNodeID("Radar.Channels.Channel1"))
+	if status != StatusGood || len(refs) != 2 {
+		t.Fatalf("Browse(Channel1) = 0x%08X, %d referências", status, len(refs))
+	}
+	for _, ref := range refs {
+		if ref.nodeClass != NodeClassVariable || ref.typeDef != ns0(idAnalogItemType) {
+			t.Errorf("referência de Channel1 = %+v", ref)
+		}
+	}
+
+	if status, _ := client.browse(radarNodeID("Inexistente")); status != StatusBadNodeIDUnknown {
+		t.Errorf("Browse de nó inexistente = 0x%08X", status)
+	}
+
+	// Valores atuais das métricas
+	result, values := client.read(attrValue,
+		radarNodeID("Radar.Channels.Channel1.Velocity"),
+		radarNodeID("Radar.Channels.Channel2.Velocity"),
+		radarNodeID("Radar.Channels.Channel1.Position"),
+		radarNodeID("Radar.RadarStatus"),
+		radarNodeID("Radar.StatusCode"),
+		radarNodeID("Radar.Alarms.Obstructed"),
+		radarNodeID("Inexistente"),
+	)
+	if result != StatusGood || len(values) != 7 {
+		t.Fatalf("Read = 0x%08X, %d valores", result, len(values))
+	}
+	want := []interface{}{12.5, -3.0, 40.25, "obstruido", int32(2), true}
+	for i, value := range want {
+		if !values[i].HasValue || values[i].Value != value || values[i].Status != StatusGood {
+			t.Errorf("valor %d = %+v, esperado %v", i, values[i], value)
+		}
+		if values[i].SourceTimestamp.IsZero() || values[i].ServerTimestamp.IsZero() {
+			t.Errorf("valor %d sem timestamps: %+v", i, values[i])
+		}
+	}
+	if values[6].Status != StatusBadNodeIDUnknown {
+		t.Errorf("leitura de nó inexistente = 0x%08X", values[6].Status)
+	}
+
+	// Outros atributos
+	_, values = client.read(attrBrowseName, radarNodeID("Radar"))
+	if len(values) != 1 || values[0].Value != (QualifiedName{Namespace: 1, Name: "Radar"}) {
+		t.Errorf("BrowseName do Radar = %+v", values)
+	}
+	_, values = client.read(attrDataType, radarNodeID("Radar.Channels.Channel1.Velocity"))
+	if len(values) != 1 || values[0].Value != ns0(idDouble) {
+		t.Errorf("DataType da velocidade = %+v", values)
+	}
+}
+
+func TestServiceRequiresActivatedSession(t *testing.T) {
+	service := startTestService(t)
+	client := dialTestClient(t, service)
+
+	// Sem sessão
+	if result, _ := client.read(attrValue, radarNodeID("Radar.RadarStatus")); result != StatusBadSessionIDInvalid {
+		t.Errorf("Read sem sessão = 0x%08X, esperado BadSessionIdInvalid", result)
+	}
+
+	// Sessão criada, mas não ativada
+	client.createSession("teste", false)
+	if result, _ := client.read(attrValue, radarNodeID("Radar.RadarStatus")); result != StatusBadSessionNotActivated {
+		t.Errorf("Read com sessão não ativada = 0x%08X, esperado BadSessionNotActivated", result)
+	}
+}
+
+func TestServiceRejectsInvalidHello(t *testing.T) {
+	service := startTestService(t)
+
+	service.mutex.RLock()
+	addr := service.listener.Addr().String()
+	service.mutex.RUnlock()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := &testClient{t: t, conn: conn}
+
+	// Mensagem antes do HEL
+	client.writeFrame("MSGF", make([]byte, 16))
+	kind, body := client.readFrame()
+	if kind != "ERRF" {
+		t.Fatalf("resposta = %s, esperado ERRF", kind)
+	}
+	if status := binary.LittleEndian.Uint32(body); status != StatusBadTCPMessageTypeInvalid {
+		t.Errorf("erro = 0x%08X, esperado BadTcpMessageTypeInvalid", status)
+	}
+}
+
+func TestServiceMaxSessions(t *testing.T) {
+	service := startTestService(t)
+
+	for i := 0; i < 2; i++ {
+		dialTestClient(t, service).createSession(fmt.Sprintf("sessão %d", i), true)
+	}
+
+	client := dialTestClient(t, service)
+	_, result := client.call(idCreateSessionRequest, idCreateSessionResponse, func(e *encoder) {
+		e.nullString()
+		e.nullString()
+		e.localizedText(LocalizedText{})
+		e.uint32(1)
+		e.nullString()
+		e.nullString()
+		e.emptyArray()
+		e.nullString()
+		e.nullString()
+		e.string("excedente")
+		e.byteString(nil)
+		e.byteString(nil)
+		e.double(30000)
+		e.uint32(0)
+	})
+	if result != StatusBadTooManySessions {
+		t.Errorf("CreateSession além do limite = 0x%08X, esperado BadTooManySessions", result)
+	}
+}
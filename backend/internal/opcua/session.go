@@ -0,0 +1,204 @@
+package opcua
+
+import (
+	"crypto/rand"
+	"time"
+
+	"radar_go/pkg/logger"
+)
+
+// Limites de sessão
+const (
+	minSessionTimeout     = 10 * time.Second
+	maxContinuationPoints = 10
+)
+
+// session representa uma sessão OPC UA criada por um cliente
+type session struct {
+	id        NodeID
+	authToken NodeID
+	name      string
+	timeout   time.Duration
+	lastSeen  time.Time
+	activated bool
+	channel   *secureChannel
+
+	subscriptions      map[uint32]*subscription
+	publishQueue       []*publishRequest
+	continuationPoints map[string]browseContinuation
+}
+
+// randomBytes gera um nonce aleatório
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+// lookupSession localiza uma sessão ativada pelo token de autenticação
+func (s *Service) lookupSession(token NodeID) (*session, uint32) {
+	s.sessionMutex.Lock()
+	defer s.sessionMutex.Unlock()
+
+	sess, ok := s.sessions[token]
+	if !ok {
+		return nil, StatusBadSessionIDInvalid
+	}
+	if !sess.activated {
+		return nil, StatusBadSessionNotActivated
+	}
+	sess.lastSeen = time.Now()
+	return sess, StatusGood
+}
+
+// handleCreateSession cria uma sessão ainda não ativada
+func (s *Service) handleCreateSession(ch *secureChannel, header requestHeader, d *decoder) []byte {
+	// ClientDescription (ApplicationDescription)
+	d.string()
+	d.string()
+	d.localizedText()
+	d.uint32()
+	d.string()
+	d.string()
+	d.stringArray()
+
+	d.string() // ServerUri
+	d.string() // EndpointUrl
+	name := d.string()
+	d.byteString() // ClientNonce
+	d.byteString() // ClientCertificate
+	requestedTimeout := d.double()
+	d.uint32() // MaxResponseMessageSize
+	if d.err != nil {
+		return serviceFault(header.handle, StatusBadDecodingError)
+	}
+
+	timeout := time.Duration(requestedTimeout * float64(time.Millisecond))
+	if timeout < minSessionTimeout {
+		timeout = minSessionTimeout
+	}
+	if s.config.SessionTimeout > 0 && timeout > s.config.SessionTimeout {
+		timeout = s.config.SessionTimeout
+	}
+
+	s.sessionMutex.Lock()
+	if s.config.MaxSessions > 0 && len(s.sessions) >= s.config.MaxSessions {
+		s.sessionMutex.Unlock()
+		logger.Warnf("Sessão OPC UA recusada: limite de %d sessões atingido", s.config.MaxSessions)
+		return serviceFault(header.handle, StatusBadTooManySessions)
+	}
+
+	s.sessionSeq++
+	sess := &session{
+		id:                 NewNumericNodeID(1, 1000000+s.sessionSeq),
+		authToken:          NodeID{Namespace: 0, Kind: kindOpaque, Text: string(randomBytes(16))},
+		name:               name,
+		timeout:            timeout,
+		lastSeen:           time.Now(),
+		channel:            ch,
+		subscriptions:      make(map[uint32]*subscription),
+		continuationPoints: make(map[string]browseContinuation),
+	}
+	s.sessions[sess.authToken] = sess
+	s.sessionMutex.Unlock()
+
+	logger.Infof("Sessão OPC UA criada: %q (%s, timeout %v)", name, sess.id, timeout)
+
+	e := newResponse(idCreateSessionResponse, header.handle)
+	e.nodeID(sess.id)
+	e.nodeID(sess.authToken)
+	e.double(float64(timeout / time.Millisecond))
+	e.byteString(randomBytes(32)) // ServerNonce
+	e.byteString(nil)             // ServerCertificate
+	s.endpointDescriptions(e)
+	e.emptyArray() // ServerSoftwareCertificates
+	// ServerSignature (SignatureData vazio)
+	e.nullString()
+	e.byteString(nil)
+	e.uint32(maxMessageSize) // MaxRequestMessageSize
+	return e.bytes()
+}
+
+// handleActivateSession ativa a sessão com token anônimo e a associa ao canal
+func (s *Service) handleActivateSession(ch *secureChannel, header requestHeader, d *decoder) []byte {
+	// ClientSignature
+	d.string()
+	d.byteString()
+	// ClientSoftwareCertificates
+	for i, n := 0, d.arrayLength(); i < n && d.err == nil; i++ {
+		d.byteString()
+		d.byteString()
+	}
+	d.stringArray() // LocaleIds
+	identity := d.extensionObject()
+	if d.err != nil {
+		return serviceFault(header.handle, StatusBadDecodingError)
+	}
+
+	if identity != nil && identity.TypeID != ns0(idAnonymousIdentityToken) {
+		logger.Warnf("Sessão OPC UA recusada: token de identidade não suportado (%s)", identity.TypeID)
+		return serviceFault(header.handle, StatusBadIdentityTokenInvalid)
+	}
+
+	s.sessionMutex.Lock()
+	sess, ok := s.sessions[header.authToken]
+	if !ok {
+		s.sessionMutex.Unlock()
+		return serviceFault(header.handle, StatusBadSessionIDInvalid)
+	}
+	sess.activated = true
+	sess.channel = ch
+	sess.lastSeen = time.Now()
+	s.sessionMutex.Unlock()
+
+	logger.Debugf("Sessão OPC UA ativada: %s", sess.id)
+
+	e := newResponse(idActivateSessionResponse, header.handle)
+	e.byteString(randomBytes(32)) // ServerNonce
+	e.emptyArray()                // Results
+	e.emptyArray()                // DiagnosticInfos
+	return e.bytes()
+}
+
+// handleCloseSession encerra a sessão e suas assinaturas
+func (s *Service) handleCloseSession(header requestHeader, d *decoder) []byte {
+	d.boolean() // DeleteSubscriptions (sempre removidas: não há transferência)
+
+	s.sessionMutex.Lock()
+	if sess, ok := s.sessions[header.authToken]; ok {
+		s.closeSessionLocked(header.authToken, sess)
+		logger.Infof("Sessão OPC UA encerrada: %s", sess.id)
+	}
+	s.sessionMutex.Unlock()
+
+	return newResponse(idCloseSessionResponse, header.handle).bytes()
+}
+
+// closeSessionLocked remove a sessão e responde requisições Publish pendentes.
+// Deve ser chamada com sessionMutex adquirido.
+func (s *Service) closeSessionLocked(token NodeID, sess *session) {
+	delete(s.sessions, token)
+	for _, req := range sess.publishQueue {
+		req.fail(StatusBadSessionClosed)
+	}
+	sess.publishQueue = nil
+	sess.subscriptions = make(map[uint32]*subscription)
+}
+
+// expireSessions remove sessões sem atividade por mais que o timeout negociado
+func (s *Service) expireSessions(now time.Time) {
+	s.sessionMutex.Lock()
+	defer s.sessionMutex.Unlock()
+
+	for token, sess := range s.sessions {
+		// Requisições Publish pendentes mantêm a sessão viva
+		if len(sess.publishQueue) > 0 {
+			sess.lastSeen = now
+			continue
+		}
+		if now.Sub(sess.lastSeen) > sess.timeout {
+			logger.Infof("Sessão OPC UA expirada: %s", sess.id)
+			s.closeSessionLocked(token, sess)
+		}
+	}
+}
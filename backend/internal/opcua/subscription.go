@@ -0,0 +1,801 @@
+package opcua
+
+import (
+	"sort"
+	"time"
+
+	"radar_go/pkg/logger"
+)
+
+// Limites de assinaturas e itens monitorados
+const (
+	minPublishingInterval = 100 * time.Millisecond
+	maxPublishingInterval = time.Minute
+	defaultKeepAliveCount = 10
+	maxQueueSize          = 100
+	maxPublishRequests    = 10
+	maxRetransmitQueue    = 20
+)
+
+// Modos de monitoramento
+const (
+	monitoringDisabled  uint32 = 0
+	monitoringSampling  uint32 = 1
+	monitoringReporting uint32 = 2
+)
+
+// monitoredItem é um atributo monitorado dentro de uma assinatura
+type monitoredItem struct {
+	id            uint32
+	item          readValueID
+	clientHandle  uint32
+	mode          uint32
+	queueSize     uint32
+	discardOldest bool
+	timestamps    uint32
+	queue         []DataValue
+}
+
+// enqueue adiciona uma notificação respeitando o tamanho da fila
+func (m *monitoredItem) enqueue(dv DataValue) {
+	if m.mode == monitoringDisabled {
+		return
+	}
+	dv = filterTimestamps(dv, m.timestamps)
+	if uint32(len(m.queue)) >= m.queueSize {
+		if m.discardOldest {
+			m.queue = m.queue[1:]
+		} else {
+			m.queue[len(m.queue)-1] = dv
+			return
+		}
+	}
+	m.queue = append(m.queue, dv)
+}
+
+// subscription agrupa itens monitorados publicados em um intervalo fixo
+type subscription struct {
+	id                 uint32
+	publishingInterval time.Duration
+	lifetimeCount      uint32
+	maxKeepAliveCount  uint32
+	maxNotifications   uint32
+	enabled            bool
+
+	items      map[uint32]*monitoredItem
+	itemSeq    uint32
+	sequence   uint32 // Próximo número de sequência
+	nextCycle  time.Time
+	keepAlive  uint32 // Ciclos desde a última mensagem enviada
+	lifetime   uint32 // Ciclos sem requisição Publish disponível
+	retransmit map[uint32][]byte
+}
+
+// hasNotifications verifica se algum item em modo Reporting tem valores na fila
+func (sub *subscription) hasNotifications() bool {
+	if !sub.enabled {
+		return false
+	}
+	for _, item := range sub.items {
+		if item.mode == monitoringReporting && len(item.queue) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// publishRequest é uma requisição Publish aguardando notificações
+type publishRequest struct {
+	channel   *secureChannel
+	requestID uint32
+	handle    uint32
+	deadline  time.Time
+	results   []uint32 // Resultados dos acknowledgements
+}
+
+// fail responde a requisição Publish com um ServiceFault
+func (r *publishRequest) fail(status uint32) {
+	if r.channel == nil {
+		return
+	}
+	if err := r.channel.sendResponse(r.requestID, serviceFault(r.handle, status)); err != nil {
+		logger.Debugf("Erro ao responder Publish OPC UA: %v", err)
+	}
+}
+
+// reviseSubscription ajusta os parâmetros solicitados aos limites do servidor
+func reviseSubscription(interval float64, lifetime, keepAlive uint32) (time.Duration, uint32, uint32) {
+	revisedInterval := time.Duration(interval * float64(time.Millisecond))
+	if revisedInterval < minPublishingInterval {
+		revisedInterval = minPublishingInterval
+	}
+	if revisedInterval > maxPublishingInterval {
+		revisedInterval = maxPublishingInterval
+	}
+	if keepAlive == 0 {
+		keepAlive = defaultKeepAliveCount
+	}
+	if lifetime < keepAlive*3 {
+		lifetime = keepAlive * 3
+	}
+	return revisedInterval, lifetime, keepAlive
+}
+
+// handleCreateSubscription cria uma assinatura
+func (s *Service) handleCreateSubscription(sess *session, header requestHeader, d *decoder) []byte {
+	interval := d.double()
+	lifetime := d.uint32()
+	keepAlive := d.uint32()
+	maxNotifications := d.uint32()
+	enabled := d.boolean()
+	d.byte() // Priority
+	if d.err != nil {
+		return serviceFault(header.handle, StatusBadDecodingError)
+	}
+
+	revisedInterval, lifetime, keepAlive := reviseSubscription(interval, lifetime, keepAlive)
+
+	s.sessionMutex.Lock()
+	s.subscriptionSeq++
+	sub := &subscription{
+		id:                 s.subscriptionSeq,
+		publishingInterval: revisedInterval,
+		lifetimeCount:      lifetime,
+		maxKeepAliveCount:  keepAlive,
+		maxNotifications:   maxNotifications,
+		enabled:            enabled,
+		items:              make(map[uint32]*monitoredItem),
+		sequence:           1,
+		nextCycle:          time.Now().Add(revisedInterval),
+		keepAlive:          keepAlive, // Primeiro ciclo envia keep-alive
+		retransmit:         make(map[uint32][]byte),
+	}
+	sess.subscriptions[sub.id] = sub
+	s.sessionMutex.Unlock()
+
+	logger.Debugf("Assinatura OPC UA %d criada (intervalo %v)", sub.id, revisedInterval)
+
+	e := newResponse(idCreateSubscriptionResponse, header.handle)
+	e.uint32(sub.id)
+	e.double(float64(revisedInterval / time.Millisecond))
+	e.uint32(lifetime)
+	e.uint32(keepAlive)
+	return e.bytes()
+}
+
+// handleModifySubscription altera os parâmetros de uma assinatura
+func (s *Service) handleModifySubscription(sess *session, header requestHeader, d *decoder) []byte {
+	id := d.uint32()
+	interval := d.double()
+	lifetime := d.uint32()
+	keepAlive := d.uint32()
+	maxNotifications := d.uint32()
+	d.byte() // Priority
+	if d.err != nil {
+		return serviceFault(header.handle, StatusBadDecodingError)
+	}
+
+	revisedInterval, lifetime, keepAlive := reviseSubscription(interval, lifetime, keepAlive)
+
+	s.sessionMutex.Lock()
+	sub, ok := sess.subscriptions[id]
+	if ok {
+		sub.publishingInterval = revisedInterval
+		sub.lifetimeCount = lifetime
+		sub.maxKeepAliveCount = keepAlive
+		sub.maxNotifications = maxNotifications
+	}
+	s.sessionMutex.Unlock()
+
+	if !ok {
+		return serviceFault(header.handle, StatusBadSubscriptionIDInvalid)
+	}
+
+	e := newResponse(idModifySubscriptionResponse, header.handle)
+	e.double(float64(revisedInterval / time.Millisecond))
+	e.uint32(lifetime)
+	e.uint32(keepAlive)
+	return e.bytes()
+}
+
+// handleSetPublishingMode habilita ou desabilita a publicação de assinaturas
+func (s *Service) handleSetPublishingMode(sess *session, header requestHeader, d *decoder) []byte {
+	enabled := d.boolean()
+	ids := d.uint32Array()
+	if d.err != nil {
+		return serviceFault(header.handle, StatusBadDecodingError)
+	}
+	if len(ids) == 0 {
+		return serviceFault(header.handle, StatusBadNothingToDo)
+	}
+
+	results := make([]uint32, len(ids))
+	s.sessionMutex.Lock()
+	for i, id := range ids {
+		if sub, ok := sess.subscriptions[id]; ok {
+			sub.enabled = enabled
+		} else {
+			results[i] = StatusBadSubscriptionIDInvalid
+		}
+	}
+	s.sessionMutex.Unlock()
+
+	e := newResponse(idSetPublishingModeResponse, header.handle)
+	e.statusCodeArray(results)
+	e.emptyArray() // DiagnosticInfos
+	return e.bytes()
+}
+
+// handleDeleteSubscriptions remove assinaturas
+func (s *Service) handleDeleteSubscriptions(sess *session, header requestHeader, d *decoder) []byte {
+	ids := d.uint32Array()
+	if d.err != nil {
+		return serviceFault(header.handle, StatusBadDecodingError)
+	}
+	if len(ids) == 0 {
+		return serviceFault(header.handle, StatusBadNothingToDo)
+	}
+
+	results := make([]uint32, len(ids))
+	s.sessionMutex.Lock()
+	for i, id := range ids {
+		if _, ok := sess.subscriptions[id]; ok {
+			delete(sess.subscriptions, id)
+		} else {
+			results[i] = StatusBadSubscriptionIDInvalid
+		}
+	}
+
+	// Sem assinaturas, requisições Publish pendentes não serão atendidas
+	var orphaned []*publishRequest
+	if len(sess.subscriptions) == 0 {
+		orphaned = sess.publishQueue
+		sess.publishQueue = nil
+	}
+	s.sessionMutex.Unlock()
+
+	for _, req := range orphaned {
+		req.fail(StatusBadNoSubscription)
+	}
+
+	e := newResponse(idDeleteSubscriptionsResponse, header.handle)
+	e.statusCodeArray(results)
+	e.emptyArray() // DiagnosticInfos
+	return e.bytes()
+}
+
+// monitoringParameters são os parâmetros solicitados para um item monitorado
+type monitoringParameters struct {
+	clientHandle     uint32
+	samplingInterval float64
+	filter           *ExtensionObject
+	queueSize        uint32
+	discardOldest    bool
+}
+
+// decodeMonitoringParameters lê MonitoringParameters
+func decodeMonitoringParameters(d *decoder) monitoringParameters {
+	return monitoringParameters{
+		clientHandle:     d.uint32(),
+		samplingInterval: d.double(),
+		filter:           d.extensionObject(),
+		queueSize:        d.uint32(),
+		discardOldest:    d.boolean(),
+	}
+}
+
+// revise aplica os limites do servidor aos parâmetros de monitoramento
+func (p monitoringParameters) revise(sub *subscription) (float64, uint32) {
+	// Os valores são atualizados pelo radar (por exceção), não por amostragem
+	sampling := p.samplingInterval
+	if sampling < 0 {
+		sampling = float64(sub.publishingInterval / time.Millisecond)
+	}
+	queueSize := p.queueSize
+	if queueSize == 0 {
+		queueSize = 1
+	}
+	if queueSize > maxQueueSize {
+		queueSize = maxQueueSize
+	}
+	return sampling, queueSize
+}
+
+// filterSupported verifica se o filtro do item é aceito (nenhum ou DataChangeFilter)
+func filterSupported(filter *ExtensionObject) bool {
+	return filter == nil || filter.TypeID == ns0(idDataChangeFilter)
+}
+
+// handleCreateMonitoredItems cria itens monitorados e enfileira o valor inicial
+func (s *Service) handleCreateMonitoredItems(sess *session, header requestHeader, d *decoder) []byte {
+	subID := d.uint32()
+	timestamps := d.uint32()
+
+	type createRequest struct {
+		item   readValueID
+		mode   uint32
+		params monitoringParameters
+	}
+	n := d.arrayLength()
+	requests := make([]createRequest, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		requests = append(requests, createRequest{
+			item:   decodeReadValueID(d),
+			mode:   d.uint32(),
+			params: decodeMonitoringParameters(d),
+		})
+	}
+	if d.err != nil {
+		return serviceFault(header.handle, StatusBadDecodingError)
+	}
+	if len(requests) == 0 {
+		return serviceFault(header.handle, StatusBadNothingToDo)
+	}
+
+	s.sessionMutex.Lock()
+	defer s.sessionMutex.Unlock()
+
+	sub, ok := sess.subscriptions[subID]
+	if !ok {
+		return serviceFault(header.handle, StatusBadSubscriptionIDInvalid)
+	}
+
+	e := newResponse(idCreateMonitoredItemsResponse, header.handle)
+	e.int32(int32(len(requests)))
+	for _, req := range requests {
+		initial := s.read(req.item)
+		status := StatusGood
+		switch {
+		case initial.Status == StatusBadNodeIDUnknown || initial.Status == StatusBadAttributeIDInvalid ||
+			initial.Status == StatusBadIndexRangeInvalid:
+			status = initial.Status
+		case req.mode > monitoringReporting:
+			status = StatusBadMonitoringModeInvalid
+		case !filterSupported(req.params.filter):
+			status = StatusBadFilterNotAllowed
+		}
+
+		if status != StatusGood {
+			e.uint32(status)
+			e.uint32(0)
+			e.double(0)
+			e.uint32(0)
+			e.extensionObject(nil)
+			continue
+		}
+
+		sampling, queueSize := req.params.revise(sub)
+		sub.itemSeq++
+		item := &monitoredItem{
+			id:            sub.itemSeq,
+			item:          req.item,
+			clientHandle:  req.params.clientHandle,
+			mode:          req.mode,
+			queueSize:     queueSize,
+			discardOldest: req.params.discardOldest,
+			timestamps:    timestamps,
+		}
+		item.enqueue(initial)
+		sub.items[item.id] = item
+
+		e.uint32(StatusGood)
+		e.uint32(item.id)
+		e.double(sampling)
+		e.uint32(queueSize)
+		e.extensionObject(nil) // FilterResult
+	}
+	e.emptyArray() // DiagnosticInfos
+	return e.bytes()
+}
+
+// handleModifyMonitoredItems altera parâmetros de itens monitorados
+func (s *Service) handleModifyMonitoredItems(sess *session, header requestHeader, d *decoder) []byte {
+	subID := d.uint32()
+	timestamps := d.uint32()
+
+	type modifyRequest struct {
+		id     uint32
+		params monitoringParameters
+	}
+	n := d.arrayLength()
+	requests := make([]modifyRequest, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		requests = append(requests, modifyRequest{id: d.uint32(), params: decodeMonitoringParameters(d)})
+	}
+	if d.err != nil {
+		return serviceFault(header.handle, StatusBadDecodingError)
+	}
+	if len(requests) == 0 {
+		return serviceFault(header.handle, StatusBadNothingToDo)
+	}
+
+	s.sessionMutex.Lock()
+	defer s.sessionMutex.Unlock()
+
+	sub, ok := sess.subscriptions[subID]
+	if !ok {
+		return serviceFault(header.handle, StatusBadSubscriptionIDInvalid)
+	}
+
+	e := newResponse(idModifyMonitoredItemsResponse, header.handle)
+	e.int32(int32(len(requests)))
+	for _, req := range requests {
+		item, ok := sub.items[req.id]
+		status := StatusGood
+		if !ok {
+			status = StatusBadMonitoredItemIDInvalid
+		} else if !filterSupported(req.params.filter) {
+			status = StatusBadFilterNotAllowed
+		}
+
+		if status != StatusGood {
+			e.uint32(status)
+			e.double(0)
+			e.uint32(0)
+			e.extensionObject(nil)
+			continue
+		}
+
+		sampling, queueSize := req.params.revise(sub)
+		item.clientHandle = req.params.clientHandle
+		item.queueSize = queueSize
+		item.discardOldest = req.params.discardOldest
+		item.timestamps = timestamps
+		if uint32(len(item.queue)) > queueSize {
+			item.queue = item.queue[uint32(len(item.queue))-queueSize:]
+		}
+
+		e.uint32(StatusGood)
+		e.double(sampling)
+		e.uint32(queueSize)
+		e.extensionObject(nil)
+	}
+	e.emptyArray() // DiagnosticInfos
+	return e.bytes()
+}
+
+// handleSetMonitoringMode altera o modo de itens monitorados
+func (s *Service) handleSetMonitoringMode(sess *session, header requestHeader, d *decoder) []byte {
+	subID := d.uint32()
+	mode := d.uint32()
+	ids := d.uint32Array()
+	if d.err != nil {
+		return serviceFault(header.handle, StatusBadDecodingError)
+	}
+	if len(ids) == 0 {
+		return serviceFault(header.handle, StatusBadNothingToDo)
+	}
+	if mode > monitoringReporting {
+		return serviceFault(header.handle, StatusBadMonitoringModeInvalid)
+	}
+
+	s.sessionMutex.Lock()
+	defer s.sessionMutex.Unlock()
+
+	sub, ok := sess.subscriptions[subID]
+	if !ok {
+		return serviceFault(header.handle, StatusBadSubscriptionIDInvalid)
+	}
+
+	results := make([]uint32, len(ids))
+	for i, id := range ids {
+		item, ok := sub.items[id]
+		if !ok {
+			results[i] = StatusBadMonitoredItemIDInvalid
+			continue
+		}
+		wasDisabled := item.mode == monitoringDisabled
+		item.mode = mode
+		if mode == monitoringDisabled {
+			item.queue = nil
+		} else if wasDisabled {
+			item.enqueue(s.read(item.item))
+		}
+	}
+
+	e := newResponse(idSetMonitoringModeResponse, header.handle)
+	e.statusCodeArray(results)
+	e.emptyArray() // DiagnosticInfos
+	return e.bytes()
+}
+
+// handleDeleteMonitoredItems remove itens monitorados
+func (s *Service) handleDeleteMonitoredItems(sess *session, header requestHeader, d *decoder) []byte {
+	subID := d.uint32()
+	ids := d.uint32Array()
+	if d.err != nil {
+		return serviceFault(header.handle, StatusBadDecodingError)
+	}
+	if len(ids) == 0 {
+		return serviceFault(header.handle, StatusBadNothingToDo)
+	}
+
+	s.sessionMutex.Lock()
+	defer s.sessionMutex.Unlock()
+
+	sub, ok := sess.subscriptions[subID]
+	if !ok {
+		return serviceFault(header.handle, StatusBadSubscriptionIDInvalid)
+	}
+
+	results := make([]uint32, len(ids))
+	for i, id := range ids {
+		if _, ok := sub.items[id]; ok {
+			delete(sub.items, id)
+		} else {
+			results[i] = StatusBadMonitoredItemIDInvalid
+		}
+	}
+
+	e := newResponse(idDeleteMonitoredItemsResponse, header.handle)
+	e.statusCodeArray(results)
+	e.emptyArray() // DiagnosticInfos
+	return e.bytes()
+}
+
+// handlePublish processa acknowledgements e enfileira a requisição até haver notificações
+func (s *Service) handlePublish(ch *secureChannel, sess *session, requestID uint32, header requestHeader, d *decoder) []byte {
+	type ack struct{ subID, seq uint32 }
+	n := d.arrayLength()
+	acks := make([]ack, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		acks = append(acks, ack{subID: d.uint32(), seq: d.uint32()})
+	}
+	if d.err != nil {
+		return serviceFault(header.handle, StatusBadDecodingError)
+	}
+
+	req := &publishRequest{
+		channel:   ch,
+		requestID: requestID,
+		handle:    header.handle,
+		results:   make([]uint32, len(acks)),
+	}
+	if header.timeoutHint > 0 {
+		req.deadline = time.Now().Add(time.Duration(header.timeoutHint) * time.Millisecond)
+	}
+
+	s.sessionMutex.Lock()
+	for i, a := range acks {
+		sub, ok := sess.subscriptions[a.subID]
+		switch {
+		case !ok:
+			req.results[i] = StatusBadSubscriptionIDInvalid
+		case sub.retransmit[a.seq] == nil:
+			req.results[i] = StatusBadSequenceNumberUnknown
+		default:
+			delete(sub.retransmit, a.seq)
+		}
+	}
+
+	if len(sess.subscriptions) == 0 {
+		s.sessionMutex.Unlock()
+		return serviceFault(header.handle, StatusBadNoSubscription)
+	}
+
+	var rejected *publishRequest
+	if len(sess.publishQueue) >= maxPublishRequests {
+		rejected = sess.publishQueue[0]
+		sess.publishQueue = sess.publishQueue[1:]
+	}
+	sess.publishQueue = append(sess.publishQueue, req)
+
+	// Responder imediatamente se alguma assinatura já tem notificações atrasadas
+	for _, sub := range sortedSubscriptions(sess) {
+		if sub.hasNotifications() && !sub.nextCycle.After(time.Now()) {
+			s.publishLocked(sess, sub, time.Now())
+			break
+		}
+	}
+	s.sessionMutex.Unlock()
+
+	if rejected != nil {
+		rejected.fail(StatusBadTooManyPublishRequests)
+	}
+	return nil
+}
+
+// handleRepublish reenvia uma mensagem de notificação ainda não confirmada
+func (s *Service) handleRepublish(sess *session, header requestHeader, d *decoder) []byte {
+	subID := d.uint32()
+	seq := d.uint32()
+	if d.err != nil {
+		return serviceFault(header.handle, StatusBadDecodingError)
+	}
+
+	s.sessionMutex.Lock()
+	sub, ok := sess.subscriptions[subID]
+	var message []byte
+	if ok {
+		message = sub.retransmit[seq]
+	}
+	s.sessionMutex.Unlock()
+
+	if !ok {
+		return serviceFault(header.handle, StatusBadSubscriptionIDInvalid)
+	}
+	if message == nil {
+		return serviceFault(header.handle, StatusBadMessageNotAvailable)
+	}
+
+	e := newResponse(idRepublishResponse, header.handle)
+	e.buf = append(e.buf, message...)
+	return e.bytes()
+}
+
+// sortedSubscriptions retorna as assinaturas da sessão em ordem de ID
+func sortedSubscriptions(sess *session) []*subscription {
+	subs := make([]*subscription, 0, len(sess.subscriptions))
+	for _, sub := range sess.subscriptions {
+		subs = append(subs, sub)
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].id < subs[j].id })
+	return subs
+}
+
+// notifyDataChange enfileira os novos valores nos itens que monitoram os nós alterados
+func (s *Service) notifyDataChange(changed []NodeID) {
+	if len(changed) == 0 {
+		return
+	}
+
+	set := make(map[NodeID]struct{}, len(changed))
+	for _, id := range changed {
+		set[id] = struct{}{}
+	}
+
+	s.sessionMutex.Lock()
+	defer s.sessionMutex.Unlock()
+
+	for _, sess := range s.sessions {
+		for _, sub := range sess.subscriptions {
+			for _, item := range sub.items {
+				if item.item.attribute != attrValue {
+					continue
+				}
+				if _, ok := set[item.item.nodeID]; ok {
+					item.enqueue(s.space.readValue(item.item.nodeID))
+				}
+			}
+		}
+	}
+}
+
+// publishCycle executa o ciclo de publicação das assinaturas cujo intervalo expirou
+func (s *Service) publishCycle(now time.Time) {
+	s.sessionMutex.Lock()
+	defer s.sessionMutex.Unlock()
+
+	for _, sess := range s.sessions {
+		// Requisições Publish expiradas
+		pending := sess.publishQueue[:0]
+		for _, req := range sess.publishQueue {
+			if !req.deadline.IsZero() && now.After(req.deadline) {
+				req.fail(StatusBadTimeout)
+				continue
+			}
+			pending = append(pending, req)
+		}
+		sess.publishQueue = pending
+
+		for _, sub := range sortedSubscriptions(sess) {
+			if now.Before(sub.nextCycle) {
+				continue
+			}
+			sub.nextCycle = now.Add(sub.publishingInterval)
+
+			if len(sess.publishQueue) == 0 {
+				sub.lifetime++
+				if sub.lifetime > sub.lifetimeCount {
+					logger.Infof("Assinatura OPC UA %d expirada (sessão %s)", sub.id, sess.id)
+					delete(sess.subscriptions, sub.id)
+				}
+				continue
+			}
+			sub.lifetime = 0
+
+			if sub.hasNotifications() {
+				s.publishLocked(sess, sub, now)
+				continue
+			}
+
+			sub.keepAlive++
+			if sub.keepAlive >= sub.maxKeepAliveCount {
+				s.publishLocked(sess, sub, now)
+			}
+		}
+	}
+}
+
+// publishLocked responde a requisição Publish mais antiga da sessão com as
+// notificações da assinatura (ou keep-alive). Deve ser chamada com sessionMutex adquirido.
+func (s *Service) publishLocked(sess *session, sub *subscription, now time.Time) {
+	if len(sess.publishQueue) == 0 {
+		return
+	}
+	req := sess.publishQueue[0]
+	sess.publishQueue = sess.publishQueue[1:]
+
+	// Coletar notificações dos itens em modo Reporting
+	type notification struct {
+		handle uint32
+		value  DataValue
+	}
+	var notifications []notification
+	more := false
+	if sub.enabled {
+		ids := make([]uint32, 0, len(sub.items))
+		for id := range sub.items {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		for _, id := range ids {
+			item := sub.items[id]
+			if item.mode != monitoringReporting {
+				continue
+			}
+			for len(item.queue) > 0 {
+				if sub.maxNotifications > 0 && uint32(len(notifications)) >= sub.maxNotifications {
+					more = true
+					break
+				}
+				notifications = append(notifications, notification{item.clientHandle, item.queue[0]})
+				item.queue = item.queue[1:]
+			}
+		}
+	}
+
+	// NotificationMessage
+	message := &encoder{}
+	if len(notifications) == 0 {
+		// Keep-alive: próximo número de sequência, sem consumi-lo
+		message.uint32(sub.sequence)
+		message.dateTime(now)
+		message.emptyArray()
+	} else {
+		body := &encoder{}
+		body.int32(int32(len(notifications)))
+		for _, n := range notifications {
+			body.uint32(n.handle)
+			body.dataValue(n.value)
+		}
+		body.emptyArray() // DiagnosticInfos
+
+		message.uint32(sub.sequence)
+		message.dateTime(now)
+		message.int32(1)
+		message.extensionObject(&ExtensionObject{TypeID: ns0(idDataChangeNotification), Body: body.bytes()})
+
+		sub.retransmit[sub.sequence] = message.bytes()
+		if len(sub.retransmit) > maxRetransmitQueue {
+			oldest := sub.sequence
+			for seq := range sub.retransmit {
+				if seq < oldest {
+					oldest = seq
+				}
+			}
+			delete(sub.retransmit, oldest)
+		}
+		sub.sequence++
+	}
+	sub.keepAlive = 0
+
+	available := make([]uint32, 0, len(sub.retransmit))
+	for seq := range sub.retransmit {
+		available = append(available, seq)
+	}
+	sort.Slice(available, func(i, j int) bool { return available[i] < available[j] })
+
+	e := newResponse(idPublishResponse, req.handle)
+	e.uint32(sub.id)
+	e.uint32Array(available)
+	e.boolean(more)
+	e.buf = append(e.buf, message.bytes()...)
+	e.statusCodeArray(req.results)
+	e.emptyArray() // DiagnosticInfos
+
+	if err := req.channel.sendResponse(req.requestID, e.bytes()); err != nil {
+		logger.Debugf("Erro ao enviar Publish OPC UA: %v", err)
+	}
+}
@@ -0,0 +1,434 @@
+package opcua
+
+import "time"
+
+// Bits do ResultMask do Browse
+const (
+	resultMaskReferenceType  uint32 = 0x01
+	resultMaskIsForward      uint32 = 0x02
+	resultMaskNodeClass      uint32 = 0x04
+	resultMaskBrowseName     uint32 = 0x08
+	resultMaskDisplayName    uint32 = 0x10
+	resultMaskTypeDefinition uint32 = 0x20
+)
+
+// Direções de navegação
+const (
+	browseForward uint32 = 0
+	browseInverse uint32 = 1
+	browseBoth    uint32 = 2
+)
+
+// Limite de operações por requisição
+const maxNodesPerRequest = 1000
+
+// referenceDescription é o resultado de navegação de uma referência
+type referenceDescription struct {
+	refType     NodeID
+	isForward   bool
+	target      NodeID
+	browseName  QualifiedName
+	displayName LocalizedText
+	nodeClass   uint32
+	typeDef     NodeID
+	resultMask  uint32
+}
+
+// encode codifica a ReferenceDescription respeitando o ResultMask
+func (r referenceDescription) encode(e *encoder) {
+	if r.resultMask&resultMaskReferenceType != 0 {
+		e.nodeID(r.refType)
+	} else {
+		e.nodeID(NodeID{})
+	}
+	e.boolean(r.isForward)
+	e.expandedNodeID(r.target)
+	if r.resultMask&resultMaskBrowseName != 0 {
+		e.qualifiedName(r.browseName)
+	} else {
+		e.qualifiedName(QualifiedName{})
+	}
+	if r.resultMask&resultMaskDisplayName != 0 {
+		e.localizedText(r.displayName)
+	} else {
+		e.localizedText(LocalizedText{})
+	}
+	if r.resultMask&resultMaskNodeClass != 0 {
+		e.uint32(r.nodeClass)
+	} else {
+		e.uint32(0)
+	}
+	if r.resultMask&resultMaskTypeDefinition != 0 {
+		e.expandedNodeID(r.typeDef)
+	} else {
+		e.expandedNodeID(NodeID{})
+	}
+}
+
+// browseContinuation guarda as referências restantes de uma navegação paginada
+type browseContinuation struct {
+	refs    []referenceDescription
+	maxRefs uint32
+}
+
+// browseDescription contém os parâmetros de navegação de um nó
+type browseDescription struct {
+	nodeID          NodeID
+	direction       uint32
+	refType         NodeID
+	includeSubtypes bool
+	nodeClassMask   uint32
+	resultMask      uint32
+}
+
+// browseNode retorna as referências de um nó que satisfazem os filtros
+func (s *Service) browseNode(desc browseDescription) ([]referenceDescription, uint32) {
+	if desc.direction > browseBoth {
+		return nil, StatusBadBrowseDirectionInvalid
+	}
+
+	refs, ok := s.space.references(desc.nodeID)
+	if !ok {
+		return nil, StatusBadNodeIDUnknown
+	}
+
+	var results []referenceDescription
+	for _, ref := range refs {
+		if desc.direction == browseForward && !ref.IsForward {
+			continue
+		}
+		if desc.direction == browseInverse && ref.IsForward {
+			continue
+		}
+		if !desc.refType.IsNull() {
+			if desc.includeSubtypes {
+				if !s.space.isSubtypeOf(ref.TypeID, desc.refType) {
+					continue
+				}
+			} else if ref.TypeID != desc.refType {
+				continue
+			}
+		}
+
+		target, ok := s.space.node(ref.Target)
+		if !ok {
+			continue
+		}
+		if desc.nodeClassMask != 0 && desc.nodeClassMask&target.Class == 0 {
+			continue
+		}
+
+		results = append(results, referenceDescription{
+			refType:     ref.TypeID,
+			isForward:   ref.IsForward,
+			target:      target.ID,
+			browseName:  target.BrowseName,
+			displayName: target.DisplayName,
+			nodeClass:   target.Class,
+			typeDef:     s.space.typeDefinition(target.ID),
+			resultMask:  desc.resultMask,
+		})
+	}
+
+	return results, StatusGood
+}
+
+// encodeBrowseResult codifica um BrowseResult, guardando o excedente em um continuation point
+func (s *Service) encodeBrowseResult(e *encoder, sess *session, refs []referenceDescription, status uint32, maxRefs uint32) {
+	e.uint32(status)
+
+	var continuation []byte
+	if maxRefs > 0 && uint32(len(refs)) > maxRefs {
+		s.sessionMutex.Lock()
+		if len(sess.continuationPoints) < maxContinuationPoints {
+			continuation = randomBytes(8)
+			sess.continuationPoints[string(continuation)] = browseContinuation{refs: refs[maxRefs:], maxRefs: maxRefs}
+			refs = refs[:maxRefs]
+		}
+		s.sessionMutex.Unlock()
+	}
+	e.byteString(continuation)
+
+	e.int32(int32(len(refs)))
+	for _, ref := range refs {
+		ref.encode(e)
+	}
+}
+
+// handleBrowse responde Browse
+func (s *Service) handleBrowse(sess *session, header requestHeader, d *decoder) []byte {
+	// ViewDescription
+	d.nodeID()
+	d.dateTime()
+	d.uint32()
+	maxRefs := d.uint32()
+
+	n := d.arrayLength()
+	descs := make([]browseDescription, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		descs = append(descs, browseDescription{
+			nodeID:          d.nodeID(),
+			direction:       d.uint32(),
+			refType:         d.nodeID(),
+			includeSubtypes: d.boolean(),
+			nodeClassMask:   d.uint32(),
+			resultMask:      d.uint32(),
+		})
+	}
+	if d.err != nil {
+		return serviceFault(header.handle, StatusBadDecodingError)
+	}
+	if len(descs) == 0 {
+		return serviceFault(header.handle, StatusBadNothingToDo)
+	}
+	if len(descs) > maxNodesPerRequest {
+		return serviceFault(header.handle, StatusBadTooManyOperations)
+	}
+
+	e := newResponse(idBrowseResponse, header.handle)
+	e.int32(int32(len(descs)))
+	for _, desc := range descs {
+		refs, status := s.browseNode(desc)
+		s.encodeBrowseResult(e, sess, refs, status, maxRefs)
+	}
+	e.emptyArray() // DiagnosticInfos
+	return e.bytes()
+}
+
+// handleBrowseNext continua uma navegação a partir de continuation points
+func (s *Service) handleBrowseNext(sess *session, header requestHeader, d *decoder) []byte {
+	release := d.boolean()
+	n := d.arrayLength()
+	points := make([][]byte, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		points = append(points, d.byteString())
+	}
+	if d.err != nil {
+		return serviceFault(header.handle, StatusBadDecodingError)
+	}
+	if len(points) == 0 {
+		return serviceFault(header.handle, StatusBadNothingToDo)
+	}
+
+	e := newResponse(idBrowseNextResponse, header.handle)
+	e.int32(int32(len(points)))
+	for _, point := range points {
+		s.sessionMutex.Lock()
+		next, ok := sess.continuationPoints[string(point)]
+		delete(sess.continuationPoints, string(point))
+		s.sessionMutex.Unlock()
+
+		switch {
+		case !ok:
+			s.encodeBrowseResult(e, sess, nil, StatusBadContinuationPointInvalid, 0)
+		case release:
+			s.encodeBrowseResult(e, sess, nil, StatusGood, 0)
+		default:
+			// Mantém o mesmo tamanho de página da requisição original
+			s.encodeBrowseResult(e, sess, next.refs, StatusGood, next.maxRefs)
+		}
+	}
+	e.emptyArray() // DiagnosticInfos
+	return e.bytes()
+}
+
+// handleTranslateBrowsePaths resolve caminhos relativos de nomes para NodeIds
+func (s *Service) handleTranslateBrowsePaths(header requestHeader, d *decoder) []byte {
+	type pathElement struct {
+		refType         NodeID
+		isInverse       bool
+		includeSubtypes bool
+		target          QualifiedName
+	}
+	type browsePath struct {
+		start    NodeID
+		elements []pathElement
+	}
+
+	n := d.arrayLength()
+	paths := make([]browsePath, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		path := browsePath{start: d.nodeID()}
+		for j, m := 0, d.arrayLength(); j < m && d.err == nil; j++ {
+			path.elements = append(path.elements, pathElement{
+				refType:         d.nodeID(),
+				isInverse:       d.boolean(),
+				includeSubtypes: d.boolean(),
+				target:          d.qualifiedName(),
+			})
+		}
+		paths = append(paths, path)
+	}
+	if d.err != nil {
+		return serviceFault(header.handle, StatusBadDecodingError)
+	}
+	if len(paths) == 0 {
+		return serviceFault(header.handle, StatusBadNothingToDo)
+	}
+
+	e := newResponse(idTranslateBrowsePathsResponse, header.handle)
+	e.int32(int32(len(paths)))
+	for _, path := range paths {
+		if _, ok := s.space.node(path.start); !ok {
+			e.uint32(StatusBadNodeIDUnknown)
+			e.emptyArray()
+			continue
+		}
+		if len(path.elements) == 0 {
+			e.uint32(StatusBadNothingToDo)
+			e.emptyArray()
+			continue
+		}
+
+		current := []NodeID{path.start}
+		for _, element := range path.elements {
+			direction := browseForward
+			if element.isInverse {
+				direction = browseInverse
+			}
+			refType := element.refType
+			if refType.IsNull() {
+				refType = ns0(idHierarchicalReferences)
+			}
+
+			var next []NodeID
+			for _, id := range current {
+				refs, _ := s.browseNode(browseDescription{
+					nodeID:          id,
+					direction:       direction,
+					refType:         refType,
+					includeSubtypes: element.includeSubtypes,
+				})
+				for _, ref := range refs {
+					if ref.browseName == element.target {
+						next = append(next, ref.target)
+					}
+				}
+			}
+			current = next
+			if len(current) == 0 {
+				break
+			}
+		}
+
+		if len(current) == 0 {
+			e.uint32(StatusBadNoMatch)
+			e.emptyArray()
+			continue
+		}
+
+		e.uint32(StatusGood)
+		e.int32(int32(len(current)))
+		for _, id := range current {
+			e.expandedNodeID(id)
+			e.uint32(0xFFFFFFFF) // RemainingPathIndex: caminho resolvido por completo
+		}
+	}
+	e.emptyArray() // DiagnosticInfos
+	return e.bytes()
+}
+
+// Opções de TimestampsToReturn
+const (
+	timestampsSource  uint32 = 0
+	timestampsServer  uint32 = 1
+	timestampsBoth    uint32 = 2
+	timestampsNeither uint32 = 3
+)
+
+// filterTimestamps remove os timestamps não solicitados
+func filterTimestamps(dv DataValue, which uint32) DataValue {
+	switch which {
+	case timestampsSource:
+		dv.ServerTimestamp = time.Time{}
+	case timestampsServer:
+		dv.SourceTimestamp = time.Time{}
+	case timestampsNeither:
+		dv.SourceTimestamp = time.Time{}
+		dv.ServerTimestamp = time.Time{}
+	}
+	return dv
+}
+
+// readValueID identifica um atributo a ser lido ou monitorado
+type readValueID struct {
+	nodeID     NodeID
+	attribute  uint32
+	indexRange string
+}
+
+// decodeReadValueID lê um ReadValueId
+func decodeReadValueID(d *decoder) readValueID {
+	r := readValueID{
+		nodeID:     d.nodeID(),
+		attribute:  d.uint32(),
+		indexRange: d.string(),
+	}
+	d.qualifiedName() // DataEncoding
+	return r
+}
+
+// read lê um atributo aplicando as restrições suportadas
+func (s *Service) read(r readValueID) DataValue {
+	if r.indexRange != "" {
+		return DataValue{Status: StatusBadIndexRangeInvalid}
+	}
+	return s.space.readAttribute(r.nodeID, r.attribute)
+}
+
+// handleRead responde Read
+func (s *Service) handleRead(header requestHeader, d *decoder) []byte {
+	d.double() // MaxAge (valores sempre atuais)
+	timestamps := d.uint32()
+	n := d.arrayLength()
+	nodes := make([]readValueID, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		nodes = append(nodes, decodeReadValueID(d))
+	}
+	if d.err != nil {
+		return serviceFault(header.handle, StatusBadDecodingError)
+	}
+	if len(nodes) == 0 {
+		return serviceFault(header.handle, StatusBadNothingToDo)
+	}
+	if len(nodes) > maxNodesPerRequest {
+		return serviceFault(header.handle, StatusBadTooManyOperations)
+	}
+
+	e := newResponse(idReadResponse, header.handle)
+	e.int32(int32(len(nodes)))
+	for _, node := range nodes {
+		e.dataValue(filterTimestamps(s.read(node), timestamps))
+	}
+	e.emptyArray() // DiagnosticInfos
+	return e.bytes()
+}
+
+// handleWrite responde Write: todas as variáveis publicadas são somente leitura
+func (s *Service) handleWrite(header requestHeader, d *decoder) []byte {
+	n := d.arrayLength()
+	results := make([]uint32, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		id := d.nodeID()
+		d.uint32() // AttributeId
+		d.string() // IndexRange
+		d.dataValue()
+
+		if _, ok := s.space.node(id); ok {
+			results = append(results, StatusBadNotWritable)
+		} else {
+			results = append(results, StatusBadNodeIDUnknown)
+		}
+	}
+	if d.err != nil {
+		return serviceFault(header.handle, StatusBadDecodingError)
+	}
+	if len(results) == 0 {
+		return serviceFault(header.handle, StatusBadNothingToDo)
+	}
+
+	e := newResponse(idWriteResponse, header.handle)
+	e.statusCodeArray(results)
+	e.emptyArray() // DiagnosticInfos
+	return e.bytes()
+}
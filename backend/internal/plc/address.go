@@ -0,0 +1,254 @@
+package plc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Area identifica a área de memória do PLC (códigos do protocolo S7)
+type Area int
+
+const (
+	// AreaInput entradas de processo (I / E)
+	AreaInput Area = 0x81
+	// AreaOutput saídas de processo (Q / A)
+	AreaOutput Area = 0x82
+	// AreaMerker memória interna (M)
+	AreaMerker Area = 0x83
+	// AreaDB blocos de dados (DB)
+	AreaDB Area = 0x84
+	// AreaCounter contadores (C / Z)
+	AreaCounter Area = 0x1C
+	// AreaTimer temporizadores (T)
+	AreaTimer Area = 0x1D
+)
+
+// String retorna o mnemônico da área
+func (a Area) String() string {
+	switch a {
+	case AreaInput:
+		return "I"
+	case AreaOutput:
+		return "Q"
+	case AreaMerker:
+		return "M"
+	case AreaDB:
+		return "DB"
+	case AreaCounter:
+		return "C"
+	case AreaTimer:
+		return "T"
+	default:
+		return fmt.Sprintf("Area(0x%02X)", int(a))
+	}
+}
+
+// Size identifica a largura do acesso em um endereço S7
+type Size int
+
+const (
+	// SizeBit acesso a um único bit (X)
+	SizeBit Size = iota
+	// SizeByte acesso a um byte (B)
+	SizeByte
+	// SizeWord acesso a uma palavra de 16 bits (W)
+	SizeWord
+	// SizeDWord acesso a uma palavra dupla de 32 bits (D)
+	SizeDWord
+)
+
+// Address representa um endereço S7 simbólico já decodificado
+type Address struct {
+	Area     Area
+	DBNumber int  // Número do DB (somente para AreaDB)
+	Offset   int  // Offset em bytes, ou número do temporizador/contador
+	Bit      int  // Índice do bit (somente para SizeBit)
+	Size     Size // Largura do acesso
+}
+
+// ParseAddress decodifica um endereço na notação S7 usada no TIA Portal/STEP 7.
+//
+// Formatos aceitos (mnemônicos ingleses e alemães, sem diferenciar maiúsculas):
+//
+//	DB10.DBX56.0  DB10.DBB4  DB10.DBW4  DB10.DBD4
+//	M0.1  MB20  MW20  MD20
+//	I2.0  IB2  IW2  ID2   (E2.0, EB2, ...)
+//	Q4.3  QB4  QW4  QD8   (A4.3, AB4, ...)
+//	T5  C3  (Z3)
+func ParseAddress(address string) (Address, error) {
+	s := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(address), " ", ""))
+	if s == "" {
+		return Address{}, fmt.Errorf("endereço S7 vazio")
+	}
+
+	if strings.HasPrefix(s, "DB") {
+		return parseDBAddress(s)
+	}
+
+	var area Area
+	switch s[0] {
+	case 'I', 'E':
+		area = AreaInput
+	case 'Q', 'A':
+		area = AreaOutput
+	case 'M':
+		area = AreaMerker
+	case 'T':
+		return parseTimerCounter(s, AreaTimer)
+	case 'C', 'Z':
+		return parseTimerCounter(s, AreaCounter)
+	default:
+		return Address{}, fmt.Errorf("área desconhecida no endereço S7 %q", address)
+	}
+
+	addr, err := parseSizedOffset(s[1:])
+	if err != nil {
+		return Address{}, fmt.Errorf("endereço S7 inválido %q: %w", address, err)
+	}
+	addr.Area = area
+	return addr, nil
+}
+
+// MustParseAddress é como ParseAddress, mas entra em pânico se o endereço for inválido.
+// Destinado a mapeamentos fixos definidos no código.
+func MustParseAddress(address string) Address {
+	addr, err := ParseAddress(address)
+	if err != nil {
+		panic(err)
+	}
+	return addr
+}
+
+// parseDBAddress decodifica endereços de bloco de dados (DBn.DBXo.b, DBn.DBBo, ...)
+func parseDBAddress(s string) (Address, error) {
+	parts := strings.SplitN(s, ".", 2)
+	if len(parts) != 2 {
+		return Address{}, fmt.Errorf("endereço de DB incompleto %q (esperado DBn.DBXo.b, DBn.DBBo, DBn.DBWo ou DBn.DBDo)", s)
+	}
+
+	dbNumber, err := strconv.Atoi(parts[0][2:])
+	if err != nil || dbNumber < 1 || dbNumber > 65535 {
+		return Address{}, fmt.Errorf("número de DB inválido em %q", s)
+	}
+
+	if !strings.HasPrefix(parts[1], "DB") {
+		return Address{}, fmt.Errorf("tipo de acesso ao DB inválido em %q", s)
+	}
+
+	addr, err := parseSizedOffset(parts[1][2:])
+	if err != nil {
+		return Address{}, fmt.Errorf("endereço de DB inválido %q: %w", s, err)
+	}
+	addr.Area = AreaDB
+	addr.DBNumber = dbNumber
+	return addr, nil
+}
+
+// parseSizedOffset decodifica a parte "X56.0", "B4", "W4", "D4" ou "2.0" de um endereço
+func parseSizedOffset(s string) (Address, error) {
+	if s == "" {
+		return Address{}, fmt.Errorf("offset ausente")
+	}
+
+	var addr Address
+	explicitBit := false
+	switch s[0] {
+	case 'X':
+		explicitBit = true
+		s = s[1:]
+	case 'B':
+		addr.Size = SizeByte
+		s = s[1:]
+	case 'W':
+		addr.Size = SizeWord
+		s = s[1:]
+	case 'D':
+		addr.Size = SizeDWord
+		s = s[1:]
+	}
+
+	// Acessos de byte, palavra e palavra dupla não têm índice de bit
+	if addr.Size != SizeBit && strings.Contains(s, ".") {
+		return Address{}, fmt.Errorf("índice de bit não permitido em acesso B, W ou D")
+	}
+
+	if explicitBit || (s != "" && s[0] >= '0' && s[0] <= '9' && strings.Contains(s, ".")) {
+		byteStr, bitStr, ok := strings.Cut(s, ".")
+		if !ok {
+			return Address{}, fmt.Errorf("índice de bit ausente")
+		}
+		offset, err := strconv.Atoi(byteStr)
+		if err != nil || offset < 0 {
+			return Address{}, fmt.Errorf("offset inválido %q", byteStr)
+		}
+		bit, err := strconv.Atoi(bitStr)
+		if err != nil || bit < 0 || bit > 7 {
+			return Address{}, fmt.Errorf("índice de bit inválido %q (deve ser 0-7)", bitStr)
+		}
+		addr.Size = SizeBit
+		addr.Offset = offset
+		addr.Bit = bit
+		return addr, nil
+	}
+
+	if addr.Size == SizeBit {
+		return Address{}, fmt.Errorf("largura de acesso ausente (use X, B, W ou D)")
+	}
+
+	offset, err := strconv.Atoi(s)
+	if err != nil || offset < 0 {
+		return Address{}, fmt.Errorf("offset inválido %q", s)
+	}
+	addr.Offset = offset
+	return addr, nil
+}
+
+// parseTimerCounter decodifica endereços de temporizador (T5) ou contador (C3)
+func parseTimerCounter(s string, area Area) (Address, error) {
+	number, err := strconv.Atoi(s[1:])
+	if err != nil || number < 0 {
+		return Address{}, fmt.Errorf("número de %s inválido em %q", area, s)
+	}
+	return Address{Area: area, Offset: number, Size: SizeWord}, nil
+}
+
+// ByteLength retorna quantos bytes o endereço ocupa no PLC
+func (a Address) ByteLength() int {
+	switch a.Size {
+	case SizeWord:
+		return 2
+	case SizeDWord:
+		return 4
+	default:
+		return 1
+	}
+}
+
+// String formata o endereço na notação S7 canônica (mnemônicos ingleses)
+func (a Address) String() string {
+	if a.Area == AreaTimer || a.Area == AreaCounter {
+		return fmt.Sprintf("%s%d", a.Area, a.Offset)
+	}
+
+	var prefix string
+	if a.Area == AreaDB {
+		prefix = fmt.Sprintf("DB%d.DB", a.DBNumber)
+	} else {
+		prefix = a.Area.String()
+	}
+
+	switch a.Size {
+	case SizeBit:
+		if a.Area == AreaDB {
+			return fmt.Sprintf("%sX%d.%d", prefix, a.Offset, a.Bit)
+		}
+		return fmt.Sprintf("%s%d.%d", prefix, a.Offset, a.Bit)
+	case SizeByte:
+		return fmt.Sprintf("%sB%d", prefix, a.Offset)
+	case SizeWord:
+		return fmt.Sprintf("%sW%d", prefix, a.Offset)
+	default:
+		return fmt.Sprintf("%sD%d", prefix, a.Offset)
+	}
+}
@@ -0,0 +1,90 @@
+package plc
+
+import "testing"
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Address
+	}{
+		{"DB10.DBX56.0", Address{Area: AreaDB, DBNumber: 10, Offset: 56, Bit: 0, Size: SizeBit}},
+		{"db10.dbx56.7", Address{Area: AreaDB, DBNumber: 10, Offset: 56, Bit: 7, Size: SizeBit}},
+		{"DB10.DBB4", Address{Area: AreaDB, DBNumber: 10, Offset: 4, Size: SizeByte}},
+		{"DB10.DBW4", Address{Area: AreaDB, DBNumber: 10, Offset: 4, Size: SizeWord}},
+		{"DB10.DBD4", Address{Area: AreaDB, DBNumber: 10, Offset: 4, Size: SizeDWord}},
+		{"M0.1", Address{Area: AreaMerker, Offset: 0, Bit: 1, Size: SizeBit}},
+		{"MB20", Address{Area: AreaMerker, Offset: 20, Size: SizeByte}},
+		{"MW20", Address{Area: AreaMerker, Offset: 20, Size: SizeWord}},
+		{"MD20", Address{Area: AreaMerker, Offset: 20, Size: SizeDWord}},
+		{"I2.0", Address{Area: AreaInput, Offset: 2, Bit: 0, Size: SizeBit}},
+		{"E2.0", Address{Area: AreaInput, Offset: 2, Bit: 0, Size: SizeBit}},
+		{"IW2", Address{Area: AreaInput, Offset: 2, Size: SizeWord}},
+		{"Q4.3", Address{Area: AreaOutput, Offset: 4, Bit: 3, Size: SizeBit}},
+		{"AB4", Address{Area: AreaOutput, Offset: 4, Size: SizeByte}},
+		{"QD8", Address{Area: AreaOutput, Offset: 8, Size: SizeDWord}},
+		{" db 1 . dbw 2 ", Address{Area: AreaDB, DBNumber: 1, Offset: 2, Size: SizeWord}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAddress(tt.input)
+		if err != nil {
+			t.Errorf("ParseAddress(%q): erro inesperado: %v", tt.input, err)
+			continue
+		}
+		if got.Area != tt.want.Area || got.DBNumber != tt.want.DBNumber ||
+			got.Offset != tt.want.Offset || got.Bit != tt.want.Bit || got.Size != tt.want.Size {
+			t.Errorf("ParseAddress(%q) = %+v, esperado %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseTimerCounter(t *testing.T) {
+	for input, area := range map[string]Area{"T5": AreaTimer, "C3": AreaCounter, "Z3": AreaCounter} {
+		got, err := ParseAddress(input)
+		if err != nil {
+			t.Errorf("ParseAddress(%q): erro inesperado: %v", input, err)
+			continue
+		}
+		if got.Area != area {
+			t.Errorf("ParseAddress(%q).Area = %v, esperado %v", input, got.Area, area)
+		}
+	}
+}
+
+func TestParseAddressInvalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"X1.0",
+		"DB10",
+		"DB0.DBW4",
+		"DB10.W4",
+		"DB10.DBX56",
+		"DB10.DBX56.8",
+		"DB10.DBW",
+		"M",
+		"M1",
+		"MW-2",
+
+		// Índice de bit em acesso de byte, palavra ou palavra dupla
+		"MB2.0",
+		"MW2.1",
+		"ID4.0",
+		"DB1.DBW4.1",
+		"DB1.DBB4.0",
+		"DB1.DBD4.7",
+	}
+
+	for _, input := range invalid {
+		if got, err := ParseAddress(input); err == nil {
+			t.Errorf("ParseAddress(%q) = %+v, esperado erro", input, got)
+		}
+	}
+}
+
+func TestAddressString(t *testing.T) {
+	for _, input := range []string{"DB10.DBX56.0", "DB10.DBW4", "M0.1", "MB20", "IW2", "QD8"} {
+		if got := MustParseAddress(input).String(); got != input {
+			t.Errorf("String() de %q = %q", input, got)
+		}
+	}
+}
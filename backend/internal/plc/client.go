@@ -140,6 +140,159 @@ func (c *S7Client) WriteDataBlock(dbNumber int, startOffset int, data []byte) er
 	return nil
 }
 
+// ReadArea lê bytes de qualquer área de memória do PLC (DB, M, I, Q, T, C)
+func (c *S7Client) ReadArea(area Area, dbNumber int, start int, size int) ([]byte, error) {
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+
+	buffer := make([]byte, size)
+	var err error
+	switch area {
+	case AreaDB:
+		err = c.client.AGReadDB(dbNumber, start, size, buffer)
+	case AreaMerker:
+		err = c.client.AGReadMB(start, size, buffer)
+	case AreaInput:
+		err = c.client.AGReadEB(start, size, buffer)
+	case AreaOutput:
+		err = c.client.AGReadAB(start, size, buffer)
+	case AreaTimer:
+		// Cada temporizador ocupa 2 bytes
+		buffer = make([]byte, size*2)
+		err = c.client.AGReadTM(start, size, buffer)
+	case AreaCounter:
+		// Cada contador ocupa 2 bytes
+		buffer = make([]byte, size*2)
+		err = c.client.AGReadCT(start, size, buffer)
+	default:
+		return nil, fmt.Errorf("área do PLC não suportada: %s", area)
+	}
+
+	if err != nil {
+		c.connected = false
+		return nil, fmt.Errorf("erro ao ler %s: %w", Address{Area: area, DBNumber: dbNumber, Offset: start, Size: SizeByte}, err)
+	}
+
+	return buffer, nil
+}
+
+// WriteArea escreve bytes em qualquer área de memória do PLC (DB, M, I, Q, T, C)
+func (c *S7Client) WriteArea(area Area, dbNumber int, start int, data []byte) error {
+	if err := c.ensureConnected(); err != nil {
+		return err
+	}
+
+	var err error
+	switch area {
+	case AreaDB:
+		err = c.client.AGWriteDB(dbNumber, start, len(data), data)
+	case AreaMerker:
+		err = c.client.AGWriteMB(start, len(data), data)
+	case AreaInput:
+		err = c.client.AGWriteEB(start, len(data), data)
+	case AreaOutput:
+		err = c.client.AGWriteAB(start, len(data), data)
+	case AreaTimer:
+		err = c.client.AGWriteTM(start, len(data)/2, data)
+	case AreaCounter:
+		err = c.client.AGWriteCT(start, len(data)/2, data)
+	default:
+		return fmt.Errorf("área do PLC não suportada: %s", area)
+	}
+
+	if err != nil {
+		c.connected = false
+		return fmt.Errorf("erro ao escrever %s: %w", Address{Area: area, DBNumber: dbNumber, Offset: start, Size: SizeByte}, err)
+	}
+
+	return nil
+}
+
+// ReadAddress lê os bytes de um endereço simbólico S7 (ex.: "DB10.DBD4", "MW20", "I2.0")
+func (c *S7Client) ReadAddress(address string) ([]byte, error) {
+	addr, err := ParseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	size := addr.ByteLength()
+	if addr.Area == AreaTimer || addr.Area == AreaCounter {
+		size = 1 // Um temporizador/contador
+	}
+
+	return c.ReadArea(addr.Area, addr.DBNumber, addr.Offset, size)
+}
+
+// WriteAddress escreve bytes em um endereço simbólico S7.
+// Endereços de bit são escritos como bit único (0 ou 1 no primeiro byte).
+func (c *S7Client) WriteAddress(address string, data []byte) error {
+	addr, err := ParseAddress(address)
+	if err != nil {
+		return err
+	}
+
+	if addr.Size == SizeBit {
+		if len(data) != 1 {
+			return fmt.Errorf("escrita em %s requer exatamente 1 byte (0 ou 1)", addr)
+		}
+		return c.writeBitAt(addr, data[0] != 0)
+	}
+
+	if len(data) != addr.ByteLength() {
+		return fmt.Errorf("escrita em %s requer %d bytes, recebidos %d", addr, addr.ByteLength(), len(data))
+	}
+
+	return c.WriteArea(addr.Area, addr.DBNumber, addr.Offset, data)
+}
+
+// ReadValue lê um endereço simbólico e converte para o tipo informado
+// ("bool", "byte", "word", "int", "dword", "dint", "float"). Com tipo vazio,
+// o tipo é deduzido da largura do endereço.
+func (c *S7Client) ReadValue(address string, dataType string) (interface{}, error) {
+	addr, err := ParseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.ReadAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeValue(addr, dataType, data)
+}
+
+// WriteValue converte um valor para o tipo informado e o escreve no endereço simbólico
+func (c *S7Client) WriteValue(address string, dataType string, value interface{}) error {
+	addr, err := ParseAddress(address)
+	if err != nil {
+		return err
+	}
+
+	data, err := EncodeValue(addr, dataType, value)
+	if err != nil {
+		return err
+	}
+
+	return c.WriteAddress(address, data)
+}
+
+// writeBitAt escreve um único bit com uma escrita de bit real (WriteVar com
+// transporte BIT), sem tocar nos demais bits do byte
+func (c *S7Client) writeBitAt(addr Address, value bool) error {
+	item := DataItem{Address: addr, Data: []byte{0}}
+	if value {
+		item.Data[0] = 1
+	}
+
+	items := []DataItem{item}
+	if err := c.WriteMulti(items); err != nil {
+		return err
+	}
+	return items[0].Err
+}
+
 // ReadFloat lê um valor float (REAL) do PLC
 func (c *S7Client) ReadFloat(dbNumber int, offset int) (float32, error) {
 	data, err := c.ReadDataBlock(dbNumber, offset, 4)
@@ -217,20 +370,13 @@ func (c *S7Client) WriteBool(dbNumber int, offset int, bitIndex int, value bool)
 		return fmt.Errorf("índice de bit inválido: %d (deve ser 0-7)", bitIndex)
 	}
 
-	data, err := c.ReadDataBlock(dbNumber, offset, 1)
-	if err != nil {
-		return err
-	}
-
-	if value {
-		// Definir bit
-		data[0] |= (1 << bitIndex)
-	} else {
-		// Limpar bit
-		data[0] &= ^(1 << bitIndex)
-	}
-
-	return c.WriteDataBlock(dbNumber, offset, data)
+	return c.writeBitAt(Address{
+		Area:     AreaDB,
+		DBNumber: dbNumber,
+		Offset:   offset,
+		Bit:      bitIndex,
+		Size:     SizeBit,
+	}, value)
 }
 
 // ensureConnected garante que o cliente está conectado
@@ -0,0 +1,420 @@
+package plc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync/atomic"
+)
+
+// Constantes do protocolo S7 para ReadVar/WriteVar com múltiplos itens
+const (
+	// Número máximo de itens por requisição (mesmo limite usado pelo Snap7)
+	maxItemsPerRequest = 20
+
+	// Tamanho do cabeçalho TPKT + COTP
+	isoHeaderSize = 7
+
+	// Tamanho do cabeçalho S7 de requisição (Job) e de resposta (Ack-Data)
+	s7RequestHeaderSize  = 10
+	s7ResponseHeaderSize = 12
+
+	// Tamanho de cada especificação de variável nos parâmetros
+	s7ItemSpecSize = 12
+
+	// Tamanho do cabeçalho de cada item na seção de dados
+	s7DataItemHeaderSize = 4
+
+	// Códigos de função
+	s7FuncReadVar  = 0x04
+	s7FuncWriteVar = 0x05
+
+	// Tamanhos de transporte na especificação de variável
+	s7TransportBit     = 0x01
+	s7TransportByte    = 0x02
+	s7TransportCounter = 0x1C
+	s7TransportTimer   = 0x1D
+
+	// Tamanhos de transporte na seção de dados
+	s7DataBit   = 0x03
+	s7DataBytes = 0x04
+	s7DataOctet = 0x09
+
+	// Código de retorno de item bem-sucedido
+	s7ItemSuccess = 0xFF
+
+	// PDU mínima garantida por qualquer CPU S7
+	minPDULength = 240
+)
+
+// DataItem representa um item de leitura ou escrita em lote
+type DataItem struct {
+	Address Address // Endereço decodificado
+	Data    []byte  // Leitura: preenchido com o valor lido; escrita: valor a escrever
+	Err     error   // Erro específico do item, se houver
+}
+
+// NewDataItem cria um item a partir de um endereço simbólico
+func NewDataItem(address string) (DataItem, error) {
+	addr, err := ParseAddress(address)
+	if err != nil {
+		return DataItem{}, err
+	}
+	return DataItem{Address: addr}, nil
+}
+
+// s7PDURef gera referências de PDU para correlacionar requisição e resposta
+var s7PDURef uint32
+
+// itemDataLength retorna quantos bytes de dados o item ocupa no telegrama
+func itemDataLength(addr Address) int {
+	if addr.Area == AreaTimer || addr.Area == AreaCounter {
+		return 2
+	}
+	return addr.ByteLength()
+}
+
+// paddedLength arredonda o tamanho para número par (exigido entre itens de dados)
+func paddedLength(n int) int {
+	return n + n%2
+}
+
+// ReadMulti lê vários endereços com o menor número possível de requisições,
+// agrupando os itens conforme o tamanho de PDU negociado com o PLC.
+// Erros de comunicação são retornados; erros de itens individuais ficam em DataItem.Err.
+func (c *S7Client) ReadMulti(items []DataItem) error {
+	if err := c.ensureConnected(); err != nil {
+		return err
+	}
+
+	pduLength := c.pduLength()
+	// Cabeçalho de parâmetros: função + contagem de itens
+	reqBase := s7RequestHeaderSize + 2
+	respBase := s7ResponseHeaderSize + 2
+
+	start := 0
+	for start < len(items) {
+		reqSize, respSize := reqBase, respBase
+		end := start
+
+		for end < len(items) && end-start < maxItemsPerRequest {
+			itemResp := s7DataItemHeaderSize + paddedLength(itemDataLength(items[end].Address))
+			if reqSize+s7ItemSpecSize > pduLength || respSize+itemResp > pduLength {
+				break
+			}
+			reqSize += s7ItemSpecSize
+			respSize += itemResp
+			end++
+		}
+
+		// Item maior que a PDU: usar leitura por área, que fragmenta a transferência
+		if end == start {
+			items[start].Data, items[start].Err = c.ReadArea(items[start].Address.Area,
+				items[start].Address.DBNumber, items[start].Address.Offset, itemDataLength(items[start].Address))
+			start++
+			continue
+		}
+
+		if err := c.readVar(items[start:end]); err != nil {
+			c.connected = false
+			return err
+		}
+		start = end
+	}
+
+	return nil
+}
+
+// WriteMulti escreve vários endereços com o menor número possível de requisições.
+// Endereços de bit são escritos como bits reais (sem leitura-modificação-escrita do byte).
+func (c *S7Client) WriteMulti(items []DataItem) error {
+	if err := c.ensureConnected(); err != nil {
+		return err
+	}
+
+	for i := range items {
+		if len(items[i].Data) != itemDataLength(items[i].Address) {
+			return fmt.Errorf("escrita em %s requer %d bytes, recebidos %d",
+				items[i].Address, itemDataLength(items[i].Address), len(items[i].Data))
+		}
+	}
+
+	pduLength := c.pduLength()
+	reqBase := s7RequestHeaderSize + 2
+	respBase := s7ResponseHeaderSize + 2
+
+	start := 0
+	for start < len(items) {
+		reqSize, respSize := reqBase, respBase
+		end := start
+
+		for end < len(items) && end-start < maxItemsPerRequest {
+			itemReq := s7ItemSpecSize + s7DataItemHeaderSize + paddedLength(len(items[end].Data))
+			if reqSize+itemReq > pduLength || respSize+1 > pduLength {
+				break
+			}
+			reqSize += itemReq
+			respSize++
+			end++
+		}
+
+		if end == start {
+			addr := items[start].Address
+			items[start].Err = c.WriteArea(addr.Area, addr.DBNumber, addr.Offset, items[start].Data)
+			start++
+			continue
+		}
+
+		if err := c.writeVar(items[start:end]); err != nil {
+			c.connected = false
+			return err
+		}
+		start = end
+	}
+
+	return nil
+}
+
+// pduLength retorna o tamanho de PDU negociado na conexão
+func (c *S7Client) pduLength() int {
+	if c.handler == nil || c.handler.PDULength < minPDULength {
+		return minPDULength
+	}
+	return c.handler.PDULength
+}
+
+// readVar envia uma única requisição ReadVar com todos os itens informados
+func (c *S7Client) readVar(items []DataItem) error {
+	params := make([]byte, 2, 2+len(items)*s7ItemSpecSize)
+	params[0] = s7FuncReadVar
+	params[1] = byte(len(items))
+	for _, item := range items {
+		params = append(params, encodeItemSpec(item.Address)...)
+	}
+
+	response, err := c.sendS7(params, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := checkItemCount(response, s7FuncReadVar, len(items)); err != nil {
+		return err
+	}
+
+	offset := 2
+	for i := range items {
+		if offset+s7DataItemHeaderSize > len(response) {
+			return fmt.Errorf("resposta ReadVar truncada no item %d", i)
+		}
+
+		returnCode := response[offset]
+		transport := response[offset+1]
+		length := int(binary.BigEndian.Uint16(response[offset+2:]))
+		offset += s7DataItemHeaderSize
+
+		if returnCode != s7ItemSuccess {
+			items[i].Err = fmt.Errorf("erro ao ler %s: %s", items[i].Address, itemErrorText(returnCode))
+			continue
+		}
+
+		// Para transporte em bytes/palavras o tamanho é informado em bits
+		if transport == s7DataBytes {
+			length /= 8
+		}
+
+		if offset+length > len(response) {
+			return fmt.Errorf("resposta ReadVar truncada no item %d", i)
+		}
+
+		items[i].Data = append([]byte(nil), response[offset:offset+length]...)
+		items[i].Err = nil
+
+		// Bits lidos como bit retornam 0/1; converter para a máscara do byte
+		if items[i].Address.Size == SizeBit && len(items[i].Data) == 1 && items[i].Data[0] != 0 {
+			items[i].Data[0] = 1 << items[i].Address.Bit
+		}
+
+		offset += length
+		if i < len(items)-1 {
+			offset += length % 2
+		}
+	}
+
+	return nil
+}
+
+// writeVar envia uma única requisição WriteVar com todos os itens informados
+func (c *S7Client) writeVar(items []DataItem) error {
+	params := make([]byte, 2, 2+len(items)*s7ItemSpecSize)
+	params[0] = s7FuncWriteVar
+	params[1] = byte(len(items))
+	var data []byte
+
+	for i, item := range items {
+		params = append(params, encodeItemSpec(item.Address)...)
+
+		header := make([]byte, s7DataItemHeaderSize)
+		switch {
+		case item.Address.Size == SizeBit:
+			header[1] = s7DataBit
+			binary.BigEndian.PutUint16(header[2:], 1)
+		case item.Address.Area == AreaTimer || item.Address.Area == AreaCounter:
+			header[1] = s7DataOctet
+			binary.BigEndian.PutUint16(header[2:], uint16(len(item.Data)))
+		default:
+			header[1] = s7DataBytes
+			binary.BigEndian.PutUint16(header[2:], uint16(len(item.Data)*8))
+		}
+
+		payload := item.Data
+		if item.Address.Size == SizeBit {
+			// O valor do bit é transmitido como 0x00/0x01
+			payload = []byte{0}
+			if item.Data[0] != 0 {
+				payload[0] = 1
+			}
+		}
+
+		data = append(data, header...)
+		data = append(data, payload...)
+		if i < len(items)-1 && len(payload)%2 != 0 {
+			data = append(data, 0)
+		}
+	}
+
+	response, err := c.sendS7(params, data)
+	if err != nil {
+		return err
+	}
+
+	if err := checkItemCount(response, s7FuncWriteVar, len(items)); err != nil {
+		return err
+	}
+
+	if len(response) < 2+len(items) {
+		return fmt.Errorf("resposta WriteVar truncada")
+	}
+
+	for i := range items {
+		if code := response[2+i]; code != s7ItemSuccess {
+			items[i].Err = fmt.Errorf("erro ao escrever %s: %s", items[i].Address, itemErrorText(code))
+		} else {
+			items[i].Err = nil
+		}
+	}
+
+	return nil
+}
+
+// encodeItemSpec monta a especificação de variável (12 bytes) de um endereço
+func encodeItemSpec(addr Address) []byte {
+	spec := make([]byte, s7ItemSpecSize)
+	spec[0] = 0x12 // Especificação de variável
+	spec[1] = 0x0A // Tamanho do restante da especificação
+	spec[2] = 0x10 // Syntax ID: S7ANY
+
+	var bitAddress int
+	switch {
+	case addr.Area == AreaTimer:
+		spec[3] = s7TransportTimer
+		binary.BigEndian.PutUint16(spec[4:], 1)
+		bitAddress = addr.Offset
+	case addr.Area == AreaCounter:
+		spec[3] = s7TransportCounter
+		binary.BigEndian.PutUint16(spec[4:], 1)
+		bitAddress = addr.Offset
+	case addr.Size == SizeBit:
+		spec[3] = s7TransportBit
+		binary.BigEndian.PutUint16(spec[4:], 1)
+		bitAddress = addr.Offset*8 + addr.Bit
+	default:
+		spec[3] = s7TransportByte
+		binary.BigEndian.PutUint16(spec[4:], uint16(addr.ByteLength()))
+		bitAddress = addr.Offset * 8
+	}
+
+	if addr.Area == AreaDB {
+		binary.BigEndian.PutUint16(spec[6:], uint16(addr.DBNumber))
+	}
+	spec[8] = byte(addr.Area)
+	spec[9] = byte(bitAddress >> 16)
+	spec[10] = byte(bitAddress >> 8)
+	spec[11] = byte(bitAddress)
+
+	return spec
+}
+
+// sendS7 monta o telegrama ISO-on-TCP com os parâmetros e dados informados,
+// envia ao PLC e retorna a parte da resposta a partir dos parâmetros
+func (c *S7Client) sendS7(params []byte, data []byte) ([]byte, error) {
+	total := isoHeaderSize + s7RequestHeaderSize + len(params) + len(data)
+	request := make([]byte, 0, total)
+
+	// TPKT (RFC 1006) + COTP DT
+	request = append(request, 0x03, 0x00, byte(total>>8), byte(total), 0x02, 0xF0, 0x80)
+
+	// Cabeçalho S7 (Job)
+	ref := uint16(atomic.AddUint32(&s7PDURef, 1))
+	request = append(request,
+		0x32, 0x01, 0x00, 0x00,
+		byte(ref>>8), byte(ref),
+		byte(len(params)>>8), byte(len(params)),
+		byte(len(data)>>8), byte(len(data)))
+	request = append(request, params...)
+	request = append(request, data...)
+
+	response, err := c.handler.Send(request)
+	if err != nil {
+		return nil, fmt.Errorf("erro de comunicação com o PLC: %w", err)
+	}
+
+	if len(response) < isoHeaderSize+s7ResponseHeaderSize {
+		return nil, fmt.Errorf("resposta S7 muito curta (%d bytes)", len(response))
+	}
+
+	header := response[isoHeaderSize:]
+	if header[0] != 0x32 || header[1] != 0x03 {
+		return nil, fmt.Errorf("resposta S7 inesperada (ROSCTR 0x%02X)", header[1])
+	}
+	if binary.BigEndian.Uint16(header[4:]) != ref {
+		return nil, fmt.Errorf("referência de PDU da resposta não corresponde à requisição")
+	}
+	if header[10] != 0 || header[11] != 0 {
+		return nil, fmt.Errorf("PLC retornou erro 0x%02X%02X", header[10], header[11])
+	}
+
+	return response[isoHeaderSize+s7ResponseHeaderSize:], nil
+}
+
+// checkItemCount valida a função e a quantidade de itens de uma resposta
+func checkItemCount(response []byte, function byte, count int) error {
+	if len(response) < 2 {
+		return fmt.Errorf("resposta S7 sem parâmetros")
+	}
+	if response[0] != function {
+		return fmt.Errorf("resposta S7 com função 0x%02X, esperada 0x%02X", response[0], function)
+	}
+	if int(response[1]) != count {
+		return fmt.Errorf("PLC respondeu %d itens, esperados %d", response[1], count)
+	}
+	return nil
+}
+
+// itemErrorText descreve os códigos de retorno de item do protocolo S7
+func itemErrorText(code byte) string {
+	switch code {
+	case 0x01:
+		return "erro de hardware"
+	case 0x03:
+		return "acesso ao objeto não permitido"
+	case 0x05:
+		return "endereço fora do intervalo"
+	case 0x06:
+		return "tipo de dados não suportado"
+	case 0x07:
+		return "tipo de dados inconsistente"
+	case 0x0A:
+		return "objeto inexistente"
+	default:
+		return fmt.Sprintf("código 0x%02X", code)
+	}
+}
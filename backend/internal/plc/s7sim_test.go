@@ -0,0 +1,366 @@
+package plc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"radar_go/internal/config"
+	"radar_go/internal/models"
+	"radar_go/internal/s7sim"
+)
+
+// Tamanho do DB10 no simulador: métricas (0-57) e área livre para os testes
+const testDB10Size = 64
+
+// startSimulator inicia um PLC simulado em uma porta local com DB10 e DB11
+func startSimulator(t *testing.T) (*s7sim.Server, config.PLCConfig) {
+	t.Helper()
+
+	sim := s7sim.NewServer()
+	sim.Memory().AddDB(10, testDB10Size)
+	sim.Memory().AddDB(11, 64)
+	if err := sim.Listen("127.0.0.1:0"); err != nil {
+		t.Fatalf("erro ao iniciar o simulador: %v", err)
+	}
+	t.Cleanup(func() { sim.Close() })
+
+	cfg := config.PLCConfig{
+		Enabled:      true,
+		Host:         sim.Addr(),
+		Rack:         0,
+		Slot:         1,
+		UpdateRate:   20 * time.Millisecond,
+		ReadTimeout:  time.Second,
+		WriteTimeout: time.Second,
+	}
+	return sim, cfg
+}
+
+// newTestClient conecta um cliente S7 ao simulador
+func newTestClient(t *testing.T, cfg config.PLCConfig) *S7Client {
+	t.Helper()
+
+	client := NewS7Client(cfg)
+	if err := client.Connect(); err != nil {
+		t.Fatalf("erro ao conectar ao simulador: %v", err)
+	}
+	t.Cleanup(client.Disconnect)
+	return client
+}
+
+// readDB lê bytes da memória do simulador
+func readDB(t *testing.T, sim *s7sim.Server, db, offset, size int) []byte {
+	t.Helper()
+
+	data, err := sim.Memory().Read(s7sim.AreaDB, db, offset, size)
+	if err != nil {
+		t.Fatalf("erro ao ler DB%d do simulador: %v", db, err)
+	}
+	return data
+}
+
+// waitFor aguarda a condição ser satisfeita ou falha após o tempo limite
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("tempo esgotado aguardando %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// metricsImage monta o conteúdo esperado de DB10.DBB0-57 para as métricas:
+// velocidades em DBD0-24, posições em DBD28-52 (REAL) e status em DBW56 (INT)
+func metricsImage(metrics models.RadarMetrics, status int16) []byte {
+	image := make([]byte, 58)
+	for i := 0; i < 7; i++ {
+		binary.BigEndian.PutUint32(image[i*4:], math.Float32bits(float32(metrics.Velocities[i])))
+		binary.BigEndian.PutUint32(image[28+i*4:], math.Float32bits(float32(metrics.Positions[i])))
+	}
+	binary.BigEndian.PutUint16(image[56:], uint16(status))
+	return image
+}
+
+func TestServiceWritesMetricsToDB10(t *testing.T) {
+	sim, cfg := startSimulator(t)
+
+	service := NewPLCService(cfg)
+	if err := service.Start(); err != nil {
+		t.Fatalf("erro ao iniciar o serviço: %v", err)
+	}
+	defer service.Stop()
+
+	metrics := models.RadarMetrics{
+		Positions:  [7]float64{1.5, 2.25, 3, 4.75, 5, 6.5, 7.125},
+		Velocities: [7]float64{-0.5, 0.25, 1, -1.75, 2, 0, 12.5},
+		Status:     "obstruido",
+	}
+	service.UpdateMetrics(metrics)
+
+	want := metricsImage(metrics, 2)
+	waitFor(t, 2*time.Second, "métricas no DB10", func() bool {
+		return bytes.Equal(readDB(t, sim, 10, 0, len(want)), want)
+	})
+
+	// Os bytes além do mapeamento não são tocados
+	if rest := readDB(t, sim, 10, len(want), testDB10Size-len(want)); !bytes.Equal(rest, make([]byte, len(rest))) {
+		t.Errorf("bytes fora do mapeamento alterados: % X", rest)
+	}
+
+	// Escritas em lote: todos os itens no DB10, sem escritas de bit
+	for _, write := range sim.Writes() {
+		if write.Area != s7sim.AreaDB || write.DBNumber != 10 || write.Bit != -1 {
+			t.Errorf("escrita inesperada: %+v", write)
+		}
+	}
+}
+
+func TestWriteSingleBit(t *testing.T) {
+	sim, cfg := startSimulator(t)
+	client := newTestClient(t, cfg)
+
+	if err := sim.Memory().Write(s7sim.AreaDB, 10, 60, []byte{0xA5}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Liga o bit 1 e desliga o bit 7; os demais bits do byte são preservados
+	if err := client.WriteBool(10, 60, 1, true); err != nil {
+		t.Fatalf("WriteBool: %v", err)
+	}
+	if err := client.WriteValue("DB10.DBX60.7", TypeBool, false); err != nil {
+		t.Fatalf("WriteValue: %v", err)
+	}
+	if got := readDB(t, sim, 10, 60, 1)[0]; got != 0x27 {
+		t.Errorf("DB10.DBB60 = 0x%02X, esperado 0x27", got)
+	}
+
+	// Cada escrita é uma escrita de bit real, sem leitura-modificação-escrita
+	writes := sim.Writes()
+	if len(writes) != 2 {
+		t.Fatalf("%d escritas recebidas, esperadas 2: %+v", len(writes), writes)
+	}
+	for i, want := range []struct {
+		bit   int
+		value byte
+	}{{1, 1}, {7, 0}} {
+		if writes[i].Offset != 60 || writes[i].Bit != want.bit || !bytes.Equal(writes[i].Data, []byte{want.value}) {
+			t.Errorf("escrita %d = %+v, esperado bit %d = %d", i, writes[i], want.bit, want.value)
+		}
+	}
+
+	// Bit em merker
+	if err := client.WriteAddress("M3.4", []byte{1}); err != nil {
+		t.Fatalf("WriteAddress(M3.4): %v", err)
+	}
+	if on, _ := sim.Memory().ReadBit(s7sim.AreaMerker, 0, 3, 4); !on {
+		t.Error("M3.4 não foi ligado")
+	}
+
+	value, err := client.ReadValue("DB10.DBX60.1", TypeBool)
+	if err != nil || value != true {
+		t.Errorf("ReadValue(DB10.DBX60.1) = %v, %v; esperado true", value, err)
+	}
+}
+
+func TestMultiReadWrite(t *testing.T) {
+	sim, cfg := startSimulator(t)
+	// PDU mínima: o lote abaixo precisa ser dividido em várias requisições
+	sim.SetPDULength(minPDULength)
+	client := newTestClient(t, cfg)
+
+	addresses := []string{"DB10.DBW2", "DB10.DBD4", "DB11.DBB1", "MW10", "Q1.2", "DB10.DBX8.3"}
+	var items []DataItem
+	for round := 0; round < 4; round++ {
+		for i, address := range addresses {
+			item, err := NewDataItem(address)
+			if err != nil {
+				t.Fatal(err)
+			}
+			item.Address.Offset += round * 12
+			if item.Address.Size == SizeBit {
+				item.Data = []byte{1}
+			} else {
+				item.Data = bytes.Repeat([]byte{byte(round*16 + i + 1)}, item.Address.ByteLength())
+			}
+			items = append(items, item)
+		}
+	}
+
+	requests := sim.RequestCount()
+	if err := client.WriteMulti(items); err != nil {
+		t.Fatalf("WriteMulti: %v", err)
+	}
+	if sim.RequestCount()-requests < 2 {
+		t.Errorf("lote de %d itens enviado em uma requisição com PDU de %d bytes", len(items), minPDULength)
+	}
+	for _, item := range items {
+		if item.Err != nil {
+			t.Errorf("escrita de %s: %v", item.Address, item.Err)
+		}
+	}
+
+	read := make([]DataItem, len(items))
+	for i, item := range items {
+		read[i] = DataItem{Address: item.Address}
+	}
+	if err := client.ReadMulti(read); err != nil {
+		t.Fatalf("ReadMulti: %v", err)
+	}
+	for i, item := range read {
+		want := items[i].Data
+		if item.Address.Size == SizeBit {
+			want = []byte{1 << item.Address.Bit}
+		}
+		if item.Err != nil || !bytes.Equal(item.Data, want) {
+			t.Errorf("leitura de %s = % X (%v), esperado % X", item.Address, item.Data, item.Err, want)
+		}
+	}
+}
+
+func TestMultiItemErrors(t *testing.T) {
+	_, cfg := startSimulator(t)
+	client := newTestClient(t, cfg)
+
+	items := make([]DataItem, 3)
+	for i, address := range []string{"DB10.DBW0", "DB99.DBW0", "DB11.DBD62"} {
+		item, err := NewDataItem(address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items[i] = item
+	}
+
+	if err := client.ReadMulti(items); err != nil {
+		t.Fatalf("ReadMulti: %v", err)
+	}
+	if items[0].Err != nil {
+		t.Errorf("leitura de DB10.DBW0: %v", items[0].Err)
+	}
+	if items[1].Err == nil {
+		t.Error("leitura de DB99 inexistente sem erro")
+	}
+	if items[2].Err == nil {
+		t.Error("leitura além do fim do DB11 sem erro")
+	}
+}
+
+func TestServiceReconnectsAfterDrop(t *testing.T) {
+	sim, cfg := startSimulator(t)
+
+	service := NewPLCService(cfg)
+	if err := service.Start(); err != nil {
+		t.Fatalf("erro ao iniciar o serviço: %v", err)
+	}
+	defer service.Stop()
+
+	first := models.RadarMetrics{Velocities: [7]float64{1}, Status: "ok"}
+	service.UpdateMetrics(first)
+	waitFor(t, 2*time.Second, "primeiras métricas", func() bool {
+		return bytes.Equal(readDB(t, sim, 10, 0, 58), metricsImage(first, 1))
+	})
+
+	// Queda da conexão; o serviço deve reconectar e continuar enviando
+	sim.DropConnections()
+
+	second := models.RadarMetrics{Velocities: [7]float64{2}, Positions: [7]float64{3}, Status: "falha_comunicacao"}
+	service.UpdateMetrics(second)
+	waitFor(t, 5*time.Second, "métricas após a reconexão", func() bool {
+		return bytes.Equal(readDB(t, sim, 10, 0, 58), metricsImage(second, 3))
+	})
+
+	if n := sim.ConnectionCount(); n < 2 {
+		t.Errorf("%d conexões aceitas, esperada uma reconexão", n)
+	}
+}
+
+func TestClientReconnectsAfterDrop(t *testing.T) {
+	sim, cfg := startSimulator(t)
+	client := newTestClient(t, cfg)
+
+	if err := client.WriteInt(10, 0, 1234); err != nil {
+		t.Fatalf("WriteInt: %v", err)
+	}
+
+	sim.DropConnections()
+
+	// A primeira operação após a queda pode falhar; a seguinte reconecta
+	var value int16
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		if value, err = client.ReadInt(10, 0); err == nil {
+			break
+		}
+	}
+	if err != nil || value != 1234 {
+		t.Fatalf("ReadInt após a queda = %d, %v; esperado 1234", value, err)
+	}
+	if !client.IsConnected() {
+		t.Error("cliente não reconectado")
+	}
+	if n := sim.ConnectionCount(); n != 2 {
+		t.Errorf("%d conexões aceitas, esperadas 2", n)
+	}
+}
+
+// readHeartbeat lê o contador de heartbeat em DB10.DBW58
+func readHeartbeat(t *testing.T, sim *s7sim.Server) uint16 {
+	t.Helper()
+	return binary.BigEndian.Uint16(readDB(t, sim, 10, 58, 2))
+}
+
+func TestServiceWritesHeartbeat(t *testing.T) {
+	sim, cfg := startSimulator(t)
+	cfg.Heartbeat = "DB10.DBW58"
+
+	service := NewPLCService(cfg)
+	if err := service.Start(); err != nil {
+		t.Fatalf("erro ao iniciar o serviço: %v", err)
+	}
+	defer service.Stop()
+
+	// Sem métricas, apenas o contador é escrito, e ele avança a cada ciclo
+	waitFor(t, 2*time.Second, "heartbeat sem métricas", func() bool { return readHeartbeat(t, sim) >= 3 })
+	if image := readDB(t, sim, 10, 0, 58); !bytes.Equal(image, make([]byte, 58)) {
+		t.Errorf("métricas escritas antes de recebidas: % X", image)
+	}
+
+	// Com métricas, o heartbeat segue no mesmo lote
+	metrics := models.RadarMetrics{Velocities: [7]float64{1}, Status: "ok"}
+	service.UpdateMetrics(metrics)
+	waitFor(t, 2*time.Second, "métricas no DB10", func() bool {
+		return bytes.Equal(readDB(t, sim, 10, 0, 58), metricsImage(metrics, 1))
+	})
+	before := readHeartbeat(t, sim)
+	waitFor(t, 2*time.Second, "heartbeat com métricas", func() bool { return readHeartbeat(t, sim) > before })
+
+	for _, write := range sim.Writes() {
+		if write.Area != s7sim.AreaDB || write.DBNumber != 10 || write.Offset+len(write.Data) > 60 {
+			t.Errorf("escrita fora do mapeamento: %+v", write)
+		}
+	}
+
+	// Após a queda da conexão o contador volta a avançar
+	sim.DropConnections()
+	before = readHeartbeat(t, sim)
+	waitFor(t, 5*time.Second, "heartbeat após a reconexão", func() bool { return readHeartbeat(t, sim) > before+2 })
+}
+
+func TestServiceInvalidHeartbeat(t *testing.T) {
+	sim, cfg := startSimulator(t)
+	cfg.Heartbeat = "DB10.XYZ"
+
+	service := NewPLCService(cfg)
+	if err := service.Start(); err == nil {
+		service.Stop()
+		t.Fatal("endereço de heartbeat inválido aceito")
+	}
+	if service.IsRunning() || sim.ConnectionCount() != 0 {
+		t.Error("serviço conectado com heartbeat inválido")
+	}
+}
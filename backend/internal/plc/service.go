@@ -2,19 +2,20 @@ package plc
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
 	"radar_go/internal/config"
 	"radar_go/internal/models"
+	"radar_go/internal/telemetry"
 	"radar_go/pkg/logger"
 )
 
 // MapPoint representa um ponto de mapeamento entre o radar e o PLC
 type MapPoint struct {
-	DBNumber    int    // Número do bloco de dados
-	ByteOffset  int    // Offset em bytes
-	DataType    string // Tipo de dados: "float", "int", "bool"
+	Address     string // Endereço S7 simbólico (ex.: "DB10.DBD0", "M0.1", "QW4")
+	DataType    string // Tipo de dados: "float", "int", "bool", ...
 	Description string // Descrição do ponto
 }
 
@@ -27,11 +28,15 @@ type PLCService struct {
 	velocityMapping  []MapPoint // Mapeamento das velocidades para o PLC
 	positionMapping  []MapPoint // Mapeamento das posições para o PLC
 	statusMapping    MapPoint   // Mapeamento do status do radar
+	heartbeatMapping MapPoint   // Contador de heartbeat (vazio = desabilitado)
+	heartbeat        uint16     // Valor do contador, incrementado a cada ciclo
 	updateFrequency  time.Duration
 	lastMetrics      *models.RadarMetrics
 	metricsSubscribe chan models.RadarMetrics
 	mutex            sync.RWMutex
 	running          bool
+	wg               sync.WaitGroup // Loop de atualização em execução
+	log              *logger.Logger // Campos component e plc em cada linha
 }
 
 // NewPLCService cria um novo serviço de PLC
@@ -46,13 +51,19 @@ func NewPLCService(cfg config.PLCConfig) *PLCService {
 		updateFrequency:  cfg.UpdateRate,
 		metricsSubscribe: make(chan models.RadarMetrics, 10),
 		running:          false,
+		log:              newLogger(cfg),
 	}
 }
 
+// newLogger cria o logger do serviço com a identificação do PLC
+func newLogger(cfg config.PLCConfig) *logger.Logger {
+	return logger.With("component", "plc", "plc", cfg.Host, "rack", cfg.Rack, "slot", cfg.Slot)
+}
+
 // Start inicia o serviço de comunicação com o PLC
 func (s *PLCService) Start() error {
 	if !s.config.Enabled {
-		logger.Info("Serviço PLC desabilitado por configuração")
+		s.log.Info("Serviço PLC desabilitado por configuração")
 		return nil
 	}
 
@@ -63,6 +74,12 @@ func (s *PLCService) Start() error {
 		return nil
 	}
 
+	if s.config.Heartbeat != "" {
+		if _, err := ParseAddress(s.config.Heartbeat); err != nil {
+			return fmt.Errorf("endereço de heartbeat inválido: %w", err)
+		}
+	}
+
 	// Iniciar conexão com o PLC
 	if err := s.client.Connect(); err != nil {
 		return err
@@ -72,10 +89,11 @@ func (s *PLCService) Start() error {
 	s.configureDefaultMapping()
 
 	// Iniciar goroutine para atualização contínua
+	s.wg.Add(1)
 	go s.runUpdateLoop()
 
 	s.running = true
-	logger.Info("Serviço PLC iniciado")
+	s.log.Info("Serviço PLC iniciado")
 	return nil
 }
 
@@ -91,7 +109,26 @@ func (s *PLCService) Stop() {
 	s.cancel()
 	s.client.Disconnect()
 	s.running = false
-	logger.Info("Serviço PLC parado")
+	s.log.Info("Serviço PLC parado")
+}
+
+// Reconfigure aplica uma nova configuração: para o loop de atualização,
+// troca o cliente S7 e reinicia o serviço se estiver habilitado
+func (s *PLCService) Reconfigure(cfg config.PLCConfig) error {
+	s.Stop()
+	s.wg.Wait()
+
+	s.mutex.Lock()
+	s.client = NewS7Client(cfg)
+	s.config = cfg
+	s.log = newLogger(cfg)
+	s.updateFrequency = cfg.UpdateRate
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.lastMetrics = nil
+	s.mutex.Unlock()
+
+	s.log.Infof("Serviço PLC reconfigurado (host: %s, rack: %d, slot: %d)", cfg.Host, cfg.Rack, cfg.Slot)
+	return s.Start()
 }
 
 // IsRunning verifica se o serviço está em execução
@@ -103,7 +140,11 @@ func (s *PLCService) IsRunning() bool {
 
 // UpdateMetrics atualiza as métricas no PLC
 func (s *PLCService) UpdateMetrics(metrics models.RadarMetrics) {
-	if !s.config.Enabled || !s.running {
+	// Só executa com o serviço habilitado
+	s.mutex.RLock()
+	running, log := s.running, s.log
+	s.mutex.RUnlock()
+	if !running {
 		return
 	}
 
@@ -113,7 +154,7 @@ func (s *PLCService) UpdateMetrics(metrics models.RadarMetrics) {
 		// Enviado com sucesso
 	default:
 		// Canal cheio, descartar mensagem
-		logger.Warn("Canal de métricas para PLC está cheio, descartando atualização")
+		log.Warn("Canal de métricas para PLC está cheio, descartando atualização")
 	}
 }
 
@@ -123,10 +164,9 @@ func (s *PLCService) configureDefaultMapping() {
 	s.velocityMapping = make([]MapPoint, 7)
 	for i := 0; i < 7; i++ {
 		s.velocityMapping[i] = MapPoint{
-			DBNumber:    10,           // DB10
-			ByteOffset:  i * 4,        // 0, 4, 8, 12, 16, 20, 24
-			DataType:    "float",      // Float (REAL)
-			Description: "Velocidade", // Descrição
+			Address:     fmt.Sprintf("DB10.DBD%d", i*4), // DB10.DBD0 ... DB10.DBD24
+			DataType:    TypeFloat,                      // Float (REAL)
+			Description: "Velocidade",                   // Descrição
 		}
 	}
 
@@ -134,24 +174,31 @@ func (s *PLCService) configureDefaultMapping() {
 	s.positionMapping = make([]MapPoint, 7)
 	for i := 0; i < 7; i++ {
 		s.positionMapping[i] = MapPoint{
-			DBNumber:    10,        // DB10
-			ByteOffset:  28 + i*4,  // 28, 32, 36, 40, 44, 48, 52
-			DataType:    "float",   // Float (REAL)
-			Description: "Posição", // Descrição
+			Address:     fmt.Sprintf("DB10.DBD%d", 28+i*4), // DB10.DBD28 ... DB10.DBD52
+			DataType:    TypeFloat,                         // Float (REAL)
+			Description: "Posição",                         // Descrição
 		}
 	}
 
 	// Mapeamento de status (exemplo)
 	s.statusMapping = MapPoint{
-		DBNumber:    10,       // DB10
-		ByteOffset:  56,       // Byte 56
-		DataType:    "int",    // INT
-		Description: "Status", // Descrição
+		Address:     "DB10.DBW56", // Word 56
+		DataType:    TypeInt,      // INT
+		Description: "Status",     // Descrição
+	}
+
+	// Contador de heartbeat: o PLC detecta a queda do gateway quando ele para de mudar
+	s.heartbeatMapping = MapPoint{
+		Address:     s.config.Heartbeat,
+		DataType:    TypeWord,
+		Description: "Heartbeat",
 	}
 }
 
 // runUpdateLoop executa o loop de atualização contínua para o PLC
 func (s *PLCService) runUpdateLoop() {
+	defer s.wg.Done()
+
 	ticker := time.NewTicker(s.updateFrequency)
 	defer ticker.Stop()
 
@@ -172,34 +219,121 @@ func (s *PLCService) runUpdateLoop() {
 			metrics := s.lastMetrics
 			s.mutex.RUnlock()
 
-			if metrics != nil {
-				s.sendMetricsToPLC(*metrics)
+			// O heartbeat é escrito mesmo antes das primeiras métricas
+			if metrics != nil || s.heartbeatMapping.Address != "" {
+				s.sendMetricsToPLC(metrics)
 			}
 		}
 	}
 }
 
-// sendMetricsToPLC envia as métricas para o PLC
-func (s *PLCService) sendMetricsToPLC(metrics models.RadarMetrics) {
+// sendMetricsToPLC envia as métricas e o heartbeat para o PLC em lote
+// (ReadVar/WriteVar multi-item); sem métricas, envia apenas o heartbeat
+func (s *PLCService) sendMetricsToPLC(metrics *models.RadarMetrics) {
 	// Verificar conexão
 	if !s.client.IsConnected() {
 		if err := s.client.Connect(); err != nil {
-			logger.Error("Falha ao reconectar ao PLC", err)
+			telemetry.PLCWriteFailures.WithLabelValues("connect").Inc()
+			s.log.Error("Falha ao reconectar ao PLC", err)
 			return
 		}
 	}
 
-	// Implementar lógica para enviar dados para o PLC
-	// Por exemplo, converter velocidades para o formato correto e enviar para os endereços mapeados
-	logger.Debug("Enviando métricas para o PLC")
+	start := time.Now()
+	items := make([]DataItem, 0, len(s.velocityMapping)+len(s.positionMapping)+2)
+
+	if metrics != nil {
+		for i, mapping := range s.velocityMapping {
+			if i < len(metrics.Velocities) {
+				items = appendMappedItem(items, mapping, metrics.Velocities[i])
+			}
+		}
+		for i, mapping := range s.positionMapping {
+			if i < len(metrics.Positions) {
+				items = appendMappedItem(items, mapping, metrics.Positions[i])
+			}
+		}
+		if s.statusMapping.Address != "" {
+			items = appendMappedItem(items, s.statusMapping, statusCode(metrics.Status))
+		}
+	}
+	if s.heartbeatMapping.Address != "" {
+		s.heartbeat++
+		items = appendMappedItem(items, s.heartbeatMapping, s.heartbeat)
+	}
 
-	// Lógica de envio seria implementada aqui
-	// Exemplo (pseudocódigo):
-	// for i, velocity := range metrics.Velocities {
-	//     mapping := s.velocityMapping[i]
-	//     data := floatToBytes(velocity)
-	//     s.client.WriteDataBlock(mapping.DBNumber, mapping.ByteOffset, data)
-	// }
+	err := s.client.WriteMulti(items)
+	telemetry.PLCWriteDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		telemetry.PLCWriteFailures.WithLabelValues("write").Inc()
+		s.log.Errorf("Erro ao enviar métricas para o PLC: %v", err)
+		return
+	}
+
+	for _, item := range items {
+		if item.Err != nil {
+			telemetry.PLCWriteFailures.WithLabelValues("item").Inc()
+			s.log.Warnf("Falha ao escrever %s no PLC: %v", item.Address, item.Err)
+		}
+	}
+
+	s.log.Debugf("Métricas enviadas para o PLC (%d itens em %v)", len(items), time.Since(start))
+}
+
+// appendMappedItem converte um valor para o tipo do mapeamento e o adiciona ao lote
+func appendMappedItem(items []DataItem, mapping MapPoint, value interface{}) []DataItem {
+	addr, err := ParseAddress(mapping.Address)
+	if err != nil {
+		logger.Warnf("Mapeamento PLC inválido (%s): %v", mapping.Description, err)
+		return items
+	}
+
+	data, err := EncodeValue(addr, mapping.DataType, value)
+	if err != nil {
+		logger.Warnf("Erro ao converter %s para %s: %v", mapping.Description, addr, err)
+		return items
+	}
+
+	return append(items, DataItem{Address: addr, Data: data})
+}
+
+// statusCode converte o status textual do radar para o código numérico enviado ao PLC
+func statusCode(status string) int {
+	switch status {
+	case "ok":
+		return 1
+	case "obstruido":
+		return 2
+	case "falha_comunicacao":
+		return 3
+	default:
+		return 0
+	}
+}
+
+// ReadAddress lê um endereço simbólico S7 para diagnóstico
+func (s *PLCService) ReadAddress(address string, dataType string) (interface{}, error) {
+	client, enabled := s.currentClient()
+	if !enabled {
+		return nil, fmt.Errorf("serviço PLC desabilitado")
+	}
+	return client.ReadValue(address, dataType)
+}
+
+// WriteAddress escreve um valor em um endereço simbólico S7
+func (s *PLCService) WriteAddress(address string, dataType string, value interface{}) error {
+	client, enabled := s.currentClient()
+	if !enabled {
+		return fmt.Errorf("serviço PLC desabilitado")
+	}
+	return client.WriteValue(address, dataType, value)
+}
+
+// currentClient retorna o cliente S7 atual (trocado por Reconfigure)
+func (s *PLCService) currentClient() (*S7Client, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.client, s.config.Enabled
 }
 
 // Shutdown encerra graciosamente o serviço
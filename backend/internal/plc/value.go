@@ -0,0 +1,194 @@
+package plc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Tipos de dados aceitos nos mapeamentos e nas leituras por endereço simbólico
+const (
+	TypeBool  = "bool"
+	TypeByte  = "byte"
+	TypeWord  = "word"
+	TypeInt   = "int"
+	TypeDWord = "dword"
+	TypeDInt  = "dint"
+	TypeFloat = "float"
+)
+
+// defaultType deduz o tipo de dados a partir da largura do endereço
+func defaultType(addr Address) string {
+	if addr.Area == AreaTimer || addr.Area == AreaCounter {
+		return TypeWord
+	}
+
+	switch addr.Size {
+	case SizeBit:
+		return TypeBool
+	case SizeByte:
+		return TypeByte
+	case SizeWord:
+		return TypeWord
+	default:
+		return TypeDWord
+	}
+}
+
+// typeSize retorna o tamanho em bytes de um tipo de dados
+func typeSize(dataType string) int {
+	switch dataType {
+	case TypeBool, TypeByte:
+		return 1
+	case TypeWord, TypeInt:
+		return 2
+	case TypeDWord, TypeDInt, TypeFloat:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// checkType valida se o tipo de dados é compatível com a largura do endereço
+func checkType(addr Address, dataType string) (string, error) {
+	dataType = strings.ToLower(dataType)
+	if dataType == "" {
+		return defaultType(addr), nil
+	}
+
+	size := typeSize(dataType)
+	if size == 0 {
+		return "", fmt.Errorf("tipo de dados desconhecido: %s", dataType)
+	}
+
+	if addr.Size == SizeBit {
+		if dataType != TypeBool {
+			return "", fmt.Errorf("endereço de bit %s só aceita o tipo bool", addr)
+		}
+		return dataType, nil
+	}
+
+	if dataType == TypeBool || size != addr.ByteLength() {
+		return "", fmt.Errorf("tipo %s incompatível com o endereço %s (%d bytes)", dataType, addr, addr.ByteLength())
+	}
+
+	return dataType, nil
+}
+
+// DecodeValue converte os bytes lidos de um endereço para o tipo informado
+func DecodeValue(addr Address, dataType string, data []byte) (interface{}, error) {
+	dataType, err := checkType(addr, dataType)
+	if err != nil {
+		return nil, err
+	}
+
+	if addr.Size == SizeBit {
+		if len(data) < 1 {
+			return nil, fmt.Errorf("dados insuficientes para %s", addr)
+		}
+		return (data[0] & (1 << addr.Bit)) != 0, nil
+	}
+
+	if len(data) < typeSize(dataType) {
+		return nil, fmt.Errorf("dados insuficientes para %s: esperado %d bytes, recebido %d",
+			addr, typeSize(dataType), len(data))
+	}
+
+	switch dataType {
+	case TypeByte:
+		return data[0], nil
+	case TypeWord:
+		return binary.BigEndian.Uint16(data), nil
+	case TypeInt:
+		return int16(binary.BigEndian.Uint16(data)), nil
+	case TypeDWord:
+		return binary.BigEndian.Uint32(data), nil
+	case TypeDInt:
+		return int32(binary.BigEndian.Uint32(data)), nil
+	default:
+		return math.Float32frombits(binary.BigEndian.Uint32(data)), nil
+	}
+}
+
+// EncodeValue converte um valor para os bytes do tipo informado.
+// Para endereços de bit, retorna um único byte 0 ou 1.
+func EncodeValue(addr Address, dataType string, value interface{}) ([]byte, error) {
+	dataType, err := checkType(addr, dataType)
+	if err != nil {
+		return nil, err
+	}
+
+	if dataType == TypeBool {
+		b, ok := value.(bool)
+		if !ok {
+			num, isNum := toFloat64(value)
+			if !isNum {
+				return nil, fmt.Errorf("valor %v não pode ser convertido para bool", value)
+			}
+			b = num != 0
+		}
+		if b {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	}
+
+	num, ok := toFloat64(value)
+	if !ok {
+		return nil, fmt.Errorf("valor %v não pode ser convertido para %s", value, dataType)
+	}
+
+	data := make([]byte, typeSize(dataType))
+	switch dataType {
+	case TypeByte:
+		data[0] = byte(num)
+	case TypeWord:
+		binary.BigEndian.PutUint16(data, uint16(num))
+	case TypeInt:
+		binary.BigEndian.PutUint16(data, uint16(int16(num)))
+	case TypeDWord:
+		binary.BigEndian.PutUint32(data, uint32(num))
+	case TypeDInt:
+		binary.BigEndian.PutUint32(data, uint32(int32(num)))
+	default:
+		binary.BigEndian.PutUint32(data, math.Float32bits(float32(num)))
+	}
+
+	return data, nil
+}
+
+// toFloat64 converte valores numéricos (incluindo os decodificados de JSON) para float64
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,40 @@
+package radar
+
+import (
+	"math"
+
+	"radar_go/internal/models"
+)
+
+// MinVelocityChange é a mudança mínima de velocidade registrada (m/s)
+const MinVelocityChange = 0.01
+
+// Obstructed indica se o radar está possivelmente obstruído (todas as
+// posições zero)
+func Obstructed(metrics *models.RadarMetrics) bool {
+	for _, pos := range metrics.Positions {
+		if pos != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// DetectVelocityChanges preenche metrics.VelocityChanges com as velocidades
+// que variaram ao menos MinVelocityChange em relação a last
+func DetectVelocityChanges(metrics *models.RadarMetrics, last [7]float64) {
+	metrics.VelocityChanges = []models.VelocityChange{}
+
+	for i := 0; i < 7; i++ {
+		change := metrics.Velocities[i] - last[i]
+		if math.Abs(change) >= MinVelocityChange {
+			metrics.VelocityChanges = append(metrics.VelocityChanges, models.VelocityChange{
+				Index:       i,
+				OldValue:    last[i],
+				NewValue:    metrics.Velocities[i],
+				ChangeValue: change,
+				Timestamp:   metrics.Timestamp,
+			})
+		}
+	}
+}
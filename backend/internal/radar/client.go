@@ -3,6 +3,7 @@ package radar
 import (
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -11,6 +12,9 @@ import (
 	"radar_go/pkg/logger"
 )
 
+// DataCommand solicita ao radar o telegrama com posições e velocidades
+const DataCommand = "sRN LMDradardata"
+
 // RadarClient gerencia a comunicação com o radar
 type RadarClient struct {
 	conn      net.Conn
@@ -34,12 +38,22 @@ func NewRadarClient(host string, port int, protocol string) *RadarClient {
 func (r *RadarClient) Connect() error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
+	return r.connectLocked()
+}
 
+// connectLocked conecta ao radar; o chamador deve manter r.mutex
+func (r *RadarClient) connectLocked() error {
 	if r.connected {
 		return nil
 	}
 
-	addr := fmt.Sprintf("%s:%d", r.host, r.port)
+	// Descartar a conexão anterior, que falhou
+	if r.conn != nil {
+		r.conn.Close()
+		r.conn = nil
+	}
+
+	addr := net.JoinHostPort(r.host, strconv.Itoa(r.port))
 	logger.Infof("Tentando conectar ao radar em %s...", addr)
 
 	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
@@ -59,7 +73,7 @@ func (r *RadarClient) SendCommand(cmd string) (string, error) {
 	defer r.mutex.Unlock()
 
 	if !r.connected {
-		if err := r.Connect(); err != nil {
+		if err := r.connectLocked(); err != nil {
 			return "", err
 		}
 	}
@@ -86,20 +100,7 @@ func (r *RadarClient) SendCommand(cmd string) (string, error) {
 
 // DecodeValues decodifica a resposta do radar em métricas
 func (r *RadarClient) DecodeValues(response string) (*models.RadarMetrics, error) {
-	metrics := &models.RadarMetrics{
-		Timestamp: time.Now(),
-		Status:    "ok",
-	}
-
-	// Implementar a lógica de decodificação específica para o protocolo (ASCII ou binário)
-	switch r.protocol {
-	case "ascii":
-		return r.decodeASCII(response, metrics)
-	case "binary":
-		return r.decodeBinary(response, metrics)
-	default:
-		return nil, fmt.Errorf("protocolo não suportado: %s", r.protocol)
-	}
+	return Decode(response, r.protocol)
 }
 
 // SetConnected define o estado de conexão
@@ -1,35 +1,93 @@
 package radar
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"radar_go/internal/models"
+	"radar_go/internal/telemetry"
 	"radar_go/pkg/logger"
 )
 
-// decodeASCII decodifica a resposta no formato ASCII
-func (r *RadarClient) decodeASCII(response string, metrics *models.RadarMetrics) (*models.RadarMetrics, error) {
+// Número de objetos (posições/velocidades) por telegrama
+const maxObjects = 7
+
+// Erros de decodificação, identificados por tipo em radar_decode_errors_total
+var (
+	ErrEmptyResponse       = errors.New("resposta vazia do radar")
+	ErrUnsupportedProtocol = errors.New("protocolo não suportado")
+)
+
+// Block é um bloco de valores do telegrama ASCII (P3DX1 ou V3DX1)
+type Block struct {
+	Name    string    // "P3DX1" (posições) ou "V3DX1" (velocidades)
+	Scale   float32   // Fator de escala (IEEE-754 em hexadecimal no telegrama)
+	Hex     []string  // Valores brutos
+	Decimal []int     // Valores brutos convertidos (com sinal nas velocidades)
+	Values  []float64 // Valores em unidades de engenharia (m ou m/s)
+}
+
+// Telegram é a resposta ASCII do radar decodificada, com os valores brutos de
+// cada bloco. Blocos ausentes ficam nil.
+type Telegram struct {
+	Positions  *Block
+	Velocities *Block
+}
+
+// Decode decodifica a resposta do radar em métricas. É o decodificador único,
+// usado pelo serviço e pelas ferramentas de linha de comando.
+func Decode(response string, protocol string) (*models.RadarMetrics, error) {
+	switch strings.ToLower(protocol) {
+	case "ascii":
+		telegram, err := ParseASCII(response)
+		if err != nil {
+			return nil, err
+		}
+		if telegram.Positions == nil {
+			telemetry.RadarDecodeErrors.WithLabelValues("missing_position_block").Inc()
+			logger.Warn("Erro ao processar bloco de posições: bloco de posição (P3DX1) não encontrado ou formato inesperado")
+		}
+		if telegram.Velocities == nil {
+			telemetry.RadarDecodeErrors.WithLabelValues("missing_velocity_block").Inc()
+			logger.Warn("Erro ao processar bloco de velocidades: bloco de velocidade (V3DX1) não encontrado ou formato inesperado")
+		}
+		return telegram.Metrics(), nil
+	case "binary":
+		// Atualmente, apenas o modo ASCII é suportado
+		return nil, fmt.Errorf("%w: protocolo binário ainda não implementado", ErrUnsupportedProtocol)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedProtocol, protocol)
+	}
+}
+
+// DecodeErrorType classifica um erro de Decode para o label "type" das métricas
+func DecodeErrorType(err error) string {
+	switch {
+	case errors.Is(err, ErrEmptyResponse):
+		return "empty_response"
+	case errors.Is(err, ErrUnsupportedProtocol):
+		return "unsupported_protocol"
+	default:
+		return "other"
+	}
+}
+
+// ParseASCII extrai os blocos de posições e velocidades da resposta ASCII
+func ParseASCII(response string) (*Telegram, error) {
 	if len(response) == 0 {
-		return nil, fmt.Errorf("resposta vazia do radar")
+		return nil, ErrEmptyResponse
 	}
 
 	// Exibir resposta para depuração
 	if logger.IsDebugEnabled() {
 		logger.Debug("Resposta ASCII do radar:")
 		logger.Debug(response)
-
-		// Converter para hexadecimal para depuração
-		hexDump := ""
-		for i, c := range response {
-			if i < 50 { // Limita para os primeiros 50 caracteres
-				hexDump += fmt.Sprintf("%02X ", c)
-			}
-		}
 		logger.Debug("Hex dump dos primeiros 50 bytes:")
-		logger.Debug(hexDump)
+		logger.Debug(HexDump(response, 50))
 	}
 
 	// Remove caracteres de controle e divide em tokens
@@ -42,135 +100,107 @@ func (r *RadarClient) decodeASCII(response string, metrics *models.RadarMetrics)
 
 	tokens := strings.Fields(cleanedResponse)
 
-	// Processar o bloco de posições (P3DX1)
-	if err := r.processPositionBlock(tokens, metrics); err != nil {
-		logger.Warnf("Erro ao processar bloco de posições: %v", err)
-		// Continuar mesmo com erro, para tentar processar velocidades
+	// Posições em mm na escala do bloco; velocidades com sinal (16 bits)
+	telegram := &Telegram{
+		Positions:  parseBlock(tokens, "P3DX1", false, 1000),
+		Velocities: parseBlock(tokens, "V3DX1", true, 1),
 	}
 
-	// Processar o bloco de velocidades (V3DX1)
-	if err := r.processVelocityBlock(tokens, metrics); err != nil {
-		logger.Warnf("Erro ao processar bloco de velocidades: %v", err)
-		// Continuar mesmo com erro, métricas podem estar parcialmente preenchidas
+	if logger.IsDebugEnabled() {
+		telegram.logValues()
 	}
-
-	return metrics, nil
-}
-
-// decodeBinary decodifica a resposta no formato binário
-func (r *RadarClient) decodeBinary(response string, metrics *models.RadarMetrics) (*models.RadarMetrics, error) {
-	// Implementar decodificação binária se necessário
-	// Atualmente, apenas o modo ASCII é suportado
-	return nil, fmt.Errorf("protocolo binário ainda não implementado")
+	return telegram, nil
 }
 
-// processPositionBlock processa o bloco de posições na resposta
-func (r *RadarClient) processPositionBlock(tokens []string, metrics *models.RadarMetrics) error {
-	// Procura o bloco de posições (P3DX1)
-	posIdx := -1
+// parseBlock encontra o bloco name e converte seus valores. O bloco tem o
+// formato "<name> <escala> <não usado> <quantidade> <valor>..."; divisor
+// converte para a unidade final.
+func parseBlock(tokens []string, name string, signed bool, divisor float64) *Block {
+	idx := -1
 	for i, token := range tokens {
-		if token == "P3DX1" {
-			posIdx = i
+		if token == name {
+			idx = i
 			break
 		}
 	}
-
-	if posIdx == -1 || posIdx+3 >= len(tokens) {
-		return fmt.Errorf("bloco de posição (P3DX1) não encontrado ou formato inesperado")
+	if idx == -1 || idx+3 >= len(tokens) {
+		return nil
 	}
 
-	// Extrai a escala em formato float
-	scaleHex := tokens[posIdx+1]
-	scale := hexStringToFloat32(scaleHex)
+	block := &Block{
+		Name:  name,
+		Scale: hexStringToFloat32(tokens[idx+1]),
+	}
 
 	// O terceiro token (após o token não utilizado) indica o número de valores que seguem
-	numValues := 7 // Padrão para 7 posições
-	if posIdx+3 < len(tokens) {
-		if valCount, err := strconv.Atoi(tokens[posIdx+3]); err == nil {
-			numValues = valCount
-			if numValues > 7 {
-				numValues = 7 // Limitamos a 7 para manter a compatibilidade
-			}
+	numValues := maxObjects
+	if valCount, err := strconv.Atoi(tokens[idx+3]); err == nil {
+		numValues = valCount
+		if numValues > maxObjects {
+			numValues = maxObjects // Limitamos a 7 para manter a compatibilidade
 		}
 	}
 
-	logger.Debugf("Bloco de Posição (P3DX1) encontrado. Escala: %f", scale)
-
-	// Processa os valores de posição (começando após o contador de valores)
-	for i := 0; i < numValues && posIdx+i+4 < len(tokens); i++ {
-		valHex := tokens[posIdx+i+4]
-
-		// Converte valor hexadecimal para decimal
+	for i := 0; i < numValues && idx+i+4 < len(tokens); i++ {
+		valHex := tokens[idx+i+4]
 		decimalValue := smallHexToInt(valHex)
 
-		// Aplica a escala correta (divide por 1000 para ter metros)
-		posMeters := float64(decimalValue) * float64(scale) / 1000.0
-
-		if i < 7 { // Garante que não exceda o array
-			metrics.Positions[i] = posMeters
+		// Velocidades são valores de 16 bits com sinal
+		if signed && decimalValue > 32767 {
+			decimalValue -= 65536
 		}
 
-		logger.Debugf("  pos%d: HEX=%s -> DEC=%d -> %.3fm", i+1, valHex, decimalValue, posMeters)
+		block.Hex = append(block.Hex, valHex)
+		block.Decimal = append(block.Decimal, decimalValue)
+		block.Values = append(block.Values, float64(decimalValue)*float64(block.Scale)/divisor)
 	}
 
-	return nil
+	return block
 }
 
-// processVelocityBlock processa o bloco de velocidades na resposta
-func (r *RadarClient) processVelocityBlock(tokens []string, metrics *models.RadarMetrics) error {
-	// Procura o bloco de velocidades (V3DX1)
-	velIdx := -1
-	for i, token := range tokens {
-		if token == "V3DX1" {
-			velIdx = i
-			break
-		}
+// Metrics converte o telegrama em métricas; blocos ausentes ficam zerados
+func (t *Telegram) Metrics() *models.RadarMetrics {
+	metrics := &models.RadarMetrics{
+		Timestamp: time.Now(),
+		Status:    "ok",
 	}
-
-	if velIdx == -1 || velIdx+3 >= len(tokens) {
-		return fmt.Errorf("bloco de velocidade (V3DX1) não encontrado ou formato inesperado")
+	if t.Positions != nil {
+		copy(metrics.Positions[:], t.Positions.Values)
 	}
+	if t.Velocities != nil {
+		copy(metrics.Velocities[:], t.Velocities.Values)
+	}
+	return metrics
+}
 
-	// Extrai a escala em formato float
-	scaleHex := tokens[velIdx+1]
-	scale := hexStringToFloat32(scaleHex)
-
-	// O terceiro token (após o token não utilizado) indica o número de valores que seguem
-	numValues := 7 // Padrão para 7 velocidades
-	if velIdx+3 < len(tokens) {
-		if valCount, err := strconv.Atoi(tokens[velIdx+3]); err == nil {
-			numValues = valCount
-			if numValues > 7 {
-				numValues = 7 // Limitamos a 7 para manter a compatibilidade
-			}
+// logValues registra os valores de cada bloco em nível DEBUG
+func (t *Telegram) logValues() {
+	if t.Positions != nil {
+		logger.Debugf("Bloco de Posição (P3DX1) encontrado. Escala: %f", t.Positions.Scale)
+		for i := range t.Positions.Values {
+			logger.Debugf("  pos%d: HEX=%s -> DEC=%d -> %.3fm",
+				i+1, t.Positions.Hex[i], t.Positions.Decimal[i], t.Positions.Values[i])
 		}
 	}
-
-	logger.Debugf("Bloco de Velocidade (V3DX1) encontrado. Escala: %f", scale)
-
-	// Processa os valores de velocidade (começando após o contador de valores)
-	for i := 0; i < numValues && velIdx+i+4 < len(tokens); i++ {
-		valHex := tokens[velIdx+i+4]
-
-		// Converte valor hexadecimal para decimal
-		decimalValue := smallHexToInt(valHex)
-
-		// Para valores de velocidade, pode ser necessário interpretar como valor com sinal
-		if decimalValue > 32767 {
-			decimalValue -= 65536
+	if t.Velocities != nil {
+		logger.Debugf("Bloco de Velocidade (V3DX1) encontrado. Escala: %f", t.Velocities.Scale)
+		for i := range t.Velocities.Values {
+			logger.Debugf("  vel%d: HEX=%s -> DEC=%d -> %.3fm/s",
+				i+1, t.Velocities.Hex[i], t.Velocities.Decimal[i], t.Velocities.Values[i])
 		}
+	}
+}
 
-		// Aplica a escala (sem divisão por 1000)
-		velMS := float64(decimalValue) * float64(scale)
-
-		if i < 7 { // Garante que não exceda o array
-			metrics.Velocities[i] = velMS
+// HexDump formata os primeiros limit caracteres da resposta em hexadecimal
+func HexDump(response string, limit int) string {
+	var b strings.Builder
+	for i, c := range response {
+		if i >= limit {
+			break
 		}
-
-		logger.Debugf("  vel%d: HEX=%s -> DEC=%d -> %.3fm/s", i+1, valHex, decimalValue, velMS)
+		fmt.Fprintf(&b, "%02X ", c)
 	}
-
-	return nil
+	return b.String()
 }
 
 // hexStringToFloat32 converte uma string hexadecimal IEEE-754 para float32
@@ -2,14 +2,18 @@ package radar
 
 import (
 	"context"
-	"math"
+	"fmt"
+	"net"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"radar_go/internal/config"
+	"radar_go/internal/events"
 	"radar_go/internal/models"
 	"radar_go/internal/redis"
+	"radar_go/internal/telemetry"
 	"radar_go/internal/websocket"
 	"radar_go/pkg/logger"
 )
@@ -27,6 +31,7 @@ type Service struct {
 	cancel            context.CancelFunc
 	running           bool
 	mutex             sync.RWMutex
+	wg                sync.WaitGroup // Goroutines de coleta e estatísticas
 	status            models.RadarStatus
 	lastVelocities    [7]float64
 	metricsHandlers   []MetricsHandler
@@ -34,6 +39,9 @@ type Service struct {
 	consecutiveErrors int
 	lastErrorMsg      string
 	lastMetrics       *models.RadarMetrics
+	log               *logger.Logger // Campos component, radarId e radar em cada linha
+	journal           *events.Journal
+	linked            bool // Comunicação estabelecida (para radar_connected/radar_lost)
 
 	// Estatísticas de desempenho
 	stats struct {
@@ -64,6 +72,7 @@ func NewService(cfg config.RadarConfig, redisService *redis.Service, wsHub *webs
 		config:         cfg,
 		redisService:   redisService,
 		wsHub:          wsHub,
+		log:            newLogger(cfg),
 		ctx:            ctx,
 		cancel:         cancel,
 		running:        false,
@@ -91,15 +100,16 @@ func (s *Service) Start() error {
 		return nil
 	}
 
-	logger.Infof("Iniciando serviço do radar (host: %s, porta: %d)", s.config.Host, s.config.Port)
+	s.log.Infof("Iniciando serviço do radar (host: %s, porta: %d)", s.config.Host, s.config.Port)
 
 	// Tentar conectar ao radar
 	if err := s.client.Connect(); err != nil {
-		logger.Warnf("Erro na conexão inicial com o radar: %v. Tentando novamente no ciclo de coleta.", err)
+		s.log.Warnf("Erro na conexão inicial com o radar: %v. Tentando novamente no ciclo de coleta.", err)
 		// Não retornar erro aqui, deixar o loop de coleta tentar reconectar
 	}
 
 	// Iniciar goroutine para coletar dados
+	s.wg.Add(2)
 	go s.collectData()
 
 	// Iniciar goroutine para monitorar estatísticas
@@ -118,12 +128,42 @@ func (s *Service) Stop() {
 		return
 	}
 
-	logger.Info("Parando serviço do radar")
+	s.log.Info("Parando serviço do radar")
 	s.cancel()
 	s.client.Close()
 	s.running = false
 }
 
+// Reconfigure aplica uma nova configuração: para a coleta, troca o cliente
+// do radar e reinicia se o serviço estava em execução
+func (s *Service) Reconfigure(cfg config.RadarConfig) error {
+	wasRunning := s.IsRunning()
+	s.Stop()
+	s.wg.Wait()
+
+	s.mutex.Lock()
+	s.client = NewRadarClient(cfg.Host, cfg.Port, cfg.Protocol)
+	s.config = cfg
+	s.log = newLogger(cfg)
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.consecutiveErrors = 0
+	s.linked = false
+	s.mutex.Unlock()
+	telemetry.RadarConsecutiveErrors.Set(0)
+
+	s.log.Infof("Serviço do radar reconfigurado (host: %s, porta: %d, amostragem: %v)", cfg.Host, cfg.Port, cfg.SampleRate)
+	if !wasRunning {
+		return nil
+	}
+	return s.Start()
+}
+
+// newLogger cria o logger do serviço com a identificação do radar
+func newLogger(cfg config.RadarConfig) *logger.Logger {
+	return logger.With("component", "radar", "radarId", cfg.ID,
+		"radar", net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)))
+}
+
 // IsRunning verifica se o serviço está em execução
 func (s *Service) IsRunning() bool {
 	s.mutex.RLock()
@@ -152,6 +192,45 @@ func (s *Service) GetLastMetrics() *models.RadarMetrics {
 	return s.lastMetrics
 }
 
+// GetVelocityHistory retorna o histórico de uma velocidade (índice 1-7) armazenado no Redis
+func (s *Service) GetVelocityHistory(index int) ([]models.HistoryPoint, error) {
+	if s.redisService == nil || !s.redisService.IsConnected() {
+		return nil, fmt.Errorf("histórico indisponível: Redis não conectado")
+	}
+	return s.redisService.GetVelocityHistory(index)
+}
+
+// GetSamples retorna amostras completas armazenadas no Redis, para o replay histórico
+func (s *Service) GetSamples(from, to time.Time, limit int) ([]models.RadarMetrics, error) {
+	if s.redisService == nil || !s.redisService.IsConnected() {
+		return nil, fmt.Errorf("histórico indisponível: Redis não conectado")
+	}
+	return s.redisService.GetSamples(from, to, limit)
+}
+
+// GetVelocityChangesRange retorna as mudanças de velocidade armazenadas no Redis em um intervalo
+func (s *Service) GetVelocityChangesRange(from, to time.Time) ([]models.VelocityChange, error) {
+	if s.redisService == nil || !s.redisService.IsConnected() {
+		return nil, fmt.Errorf("histórico indisponível: Redis não conectado")
+	}
+	return s.redisService.GetVelocityChangesRange(from, to)
+}
+
+// SetJournal define o registro de eventos de conexão e status. Deve ser
+// chamado antes de Start.
+func (s *Service) SetJournal(journal *events.Journal) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.journal = journal
+}
+
+// getJournal retorna o registro de eventos, ou nil se desabilitado
+func (s *Service) getJournal() *events.Journal {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.journal
+}
+
 // SetAsyncRedis configura o envio assíncrono para o Redis
 func (s *Service) SetAsyncRedis(async bool) {
 	s.asyncRedis = async
@@ -164,6 +243,8 @@ func (s *Service) SetThrottleOutput(throttle bool) {
 
 // collectData executa o loop principal de coleta de dados do radar
 func (s *Service) collectData() {
+	defer s.wg.Done()
+
 	ticker := time.NewTicker(s.config.SampleRate)
 	defer ticker.Stop()
 
@@ -184,6 +265,7 @@ func (s *Service) collectData() {
 
 			// Registrar duração do ciclo
 			cycleDuration := time.Since(s.stats.cycleStartTime)
+			telemetry.RadarCycleDuration.Observe(cycleDuration.Seconds())
 			s.statsLock.Lock()
 			atomic.AddInt64(&s.stats.totalCycles, 1)
 
@@ -209,7 +291,7 @@ func (s *Service) collectData() {
 // processTick processa um ciclo de coleta de dados
 func (s *Service) processTick() {
 	// Enviar comando para o radar
-	response, err := s.client.SendCommand("sRN LMDradardata")
+	response, err := s.client.SendCommand(DataCommand)
 	if err != nil {
 		s.handleConnectionError(err)
 		return
@@ -217,31 +299,35 @@ func (s *Service) processTick() {
 
 	// Resetar contador de erros se comunicação bem sucedida
 	if s.consecutiveErrors > 0 {
-		logger.Infof("Comunicação com o radar restaurada após %d tentativas", s.consecutiveErrors)
+		s.log.Infof("Comunicação com o radar restaurada após %d tentativas", s.consecutiveErrors)
 		s.consecutiveErrors = 0
+		telemetry.RadarConsecutiveErrors.Set(0)
 		s.updateStatus("ok", "")
 	}
 
+	if !s.linked {
+		s.linked = true
+		s.getJournal().Record(models.Event{
+			Type:    models.EventRadarConnected,
+			Message: fmt.Sprintf("Comunicação com o radar %s estabelecida", net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))),
+			Data:    map[string]interface{}{"host": s.config.Host, "port": s.config.Port},
+		})
+	}
+
 	// Decodificar a resposta
 	metrics, err := s.client.DecodeValues(response)
 	if err != nil {
-		logger.Errorf("Erro ao decodificar valores: %v", err)
+		errorType := DecodeErrorType(err)
+		telemetry.RadarDecodeErrors.WithLabelValues(errorType).Inc()
+		s.log.With("errorType", errorType).Errorf("Erro ao decodificar valores: %v", err)
 		return
 	}
 
 	if metrics != nil {
 		// Verificar se o radar está obstruído (todas posições zero)
-		allZero := true
-		for _, pos := range metrics.Positions {
-			if pos != 0 {
-				allZero = false
-				break
-			}
-		}
-
-		if allZero {
+		if Obstructed(metrics) {
 			metrics.Status = "obstruido"
-			logger.Warn("ALERTA: Radar possivelmente obstruído - todas as posições são zero!")
+			s.log.Warn("ALERTA: Radar possivelmente obstruído - todas as posições são zero!")
 		}
 
 		// Detectar mudanças nas velocidades
@@ -268,68 +354,50 @@ func (s *Service) processTick() {
 		if s.redisService != nil && s.redisService.IsConnected() {
 			if s.asyncRedis {
 				// Usar goroutine para não bloquear o ciclo de coleta
+				log := s.log
 				go func(m *models.RadarMetrics) {
 					if err := s.redisService.WriteMetrics(m); err != nil {
-						logger.Errorf("Erro ao escrever métricas no Redis: %v", err)
+						log.Errorf("Erro ao escrever métricas no Redis: %v", err)
 					}
 
 					// Se houver mudanças de velocidade, registrar separadamente
 					if len(m.VelocityChanges) > 0 {
 						if err := s.redisService.WriteVelocityChanges(m.VelocityChanges); err != nil {
-							logger.Errorf("Erro ao escrever mudanças de velocidade no Redis: %v", err)
+							log.Errorf("Erro ao escrever mudanças de velocidade no Redis: %v", err)
 						}
 					}
 				}(metrics)
 			} else {
 				// Versão síncrona (bloqueia até concluir)
 				if err := s.redisService.WriteMetrics(metrics); err != nil {
-					logger.Errorf("Erro ao escrever métricas no Redis: %v", err)
+					s.log.Errorf("Erro ao escrever métricas no Redis: %v", err)
 				}
 
 				if len(metrics.VelocityChanges) > 0 {
 					if err := s.redisService.WriteVelocityChanges(metrics.VelocityChanges); err != nil {
-						logger.Errorf("Erro ao escrever mudanças de velocidade no Redis: %v", err)
+						s.log.Errorf("Erro ao escrever mudanças de velocidade no Redis: %v", err)
 					}
 				}
 			}
 		}
 	} else {
-		logger.Warn("Nenhuma métrica válida extraída da resposta")
+		s.log.Warn("Nenhuma métrica válida extraída da resposta")
 	}
 }
 
 // detectVelocityChanges detecta mudanças nas velocidades
 func (s *Service) detectVelocityChanges(metrics *models.RadarMetrics) {
-	// Limiar mínimo para considerar uma mudança (configurável)
-	const minVelocityChange = 0.01
-
-	// Limpa o array de mudanças
-	metrics.VelocityChanges = []models.VelocityChange{}
-
 	// Obter últimas velocidades
 	s.mutex.RLock()
 	lastVelocities := s.lastVelocities
 	s.mutex.RUnlock()
 
-	// Verifica cada velocidade individualmente
-	for i := 0; i < 7; i++ {
-		// Calcula a diferença
-		change := metrics.Velocities[i] - lastVelocities[i]
-
-		// Se a mudança for significativa (maior que o limiar), registra
-		if math.Abs(change) >= minVelocityChange {
-			metrics.VelocityChanges = append(metrics.VelocityChanges, models.VelocityChange{
-				Index:       i,
-				OldValue:    lastVelocities[i],
-				NewValue:    metrics.Velocities[i],
-				ChangeValue: change,
-				Timestamp:   metrics.Timestamp,
-			})
+	DetectVelocityChanges(metrics, lastVelocities)
 
-			if s.config.Debug && !s.throttleOutput {
-				logger.Debugf("Mudança detectada na velocidade %d: %.3f -> %.3f (Δ%.3f)",
-					i+1, lastVelocities[i], metrics.Velocities[i], change)
-			}
+	if s.config.Debug && !s.throttleOutput {
+		for _, change := range metrics.VelocityChanges {
+			s.log.Debugf("Mudança detectada na velocidade %d: %.3f -> %.3f (Δ%.3f)",
+				change.Index+1, change.OldValue, change.NewValue, change.ChangeValue)
 		}
 	}
 
@@ -343,15 +411,25 @@ func (s *Service) detectVelocityChanges(metrics *models.RadarMetrics) {
 func (s *Service) handleConnectionError(err error) {
 	s.consecutiveErrors++
 	s.lastErrorMsg = err.Error()
+	telemetry.RadarCommunicationErrors.Inc()
+	telemetry.RadarConsecutiveErrors.Set(float64(s.consecutiveErrors))
 
-	logger.Errorf("Erro ao comunicar com o radar: %v. Tentativa %d",
-		err, s.consecutiveErrors)
+	// Tentativa como campo: a mensagem se repete igual e é resumida pelo logger
+	s.log.With("attempt", s.consecutiveErrors).Errorf("Erro ao comunicar com o radar: %v", err)
 
 	// Marcar cliente como desconectado
 	s.client.SetConnected(false)
 
 	// Se exceder o número máximo de tentativas, atualizar status
 	if s.consecutiveErrors > s.config.MaxConsecutiveErrors {
+		if s.consecutiveErrors == s.config.MaxConsecutiveErrors+1 {
+			s.linked = false
+			s.getJournal().Record(models.Event{
+				Type:    models.EventRadarLost,
+				Message: fmt.Sprintf("Comunicação com o radar %s perdida após %d tentativas", net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port)), s.consecutiveErrors),
+				Data:    map[string]interface{}{"host": s.config.Host, "port": s.config.Port, "error": s.lastErrorMsg},
+			})
+		}
 		s.updateStatus("falha_comunicacao", s.lastErrorMsg)
 
 		// Esperar antes da próxima tentativa
@@ -364,6 +442,7 @@ func (s *Service) updateStatus(status string, errorMsg string) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	previous := s.status.Status
 	s.status = models.RadarStatus{
 		Status:     status,
 		Timestamp:  time.Now(),
@@ -381,11 +460,19 @@ func (s *Service) updateStatus(status string, errorMsg string) {
 		s.wsHub.BroadcastStatus(s.status)
 	}
 
+	if previous != status {
+		s.journal.Record(models.Event{
+			Type:    models.EventStatusChanged,
+			Message: fmt.Sprintf("Status do radar alterado de %s para %s", previous, status),
+			Data:    map[string]interface{}{"from": previous, "to": status, "error": errorMsg},
+		})
+	}
+
 	// Log
 	if status != "ok" {
-		logger.Warnf("Status do radar alterado para %s: %s", status, errorMsg)
+		s.log.Warnf("Status do radar alterado para %s: %s", status, errorMsg)
 	} else if s.consecutiveErrors > 0 {
-		logger.Info("Status do radar restaurado para 'ok'")
+		s.log.Info("Status do radar restaurado para 'ok'")
 	}
 }
 
@@ -397,6 +484,11 @@ func (s *Service) updateMetrics(metrics models.RadarMetrics) {
 	// Criar cópia das métricas
 	metricsCopy := metrics
 	s.lastMetrics = &metricsCopy
+
+	for i := range metrics.Velocities {
+		telemetry.RadarVelocity.WithLabelValues(telemetry.Channel(i)).Set(metrics.Velocities[i])
+		telemetry.RadarPosition.WithLabelValues(telemetry.Channel(i)).Set(metrics.Positions[i])
+	}
 }
 
 // notifyMetricsHandlers notifica todos os handlers registrados
@@ -423,6 +515,8 @@ func (s *Service) notifyMetricsHandlersAsync(metrics models.RadarMetrics) {
 
 // monitorStats monitora estatísticas de desempenho
 func (s *Service) monitorStats() {
+	defer s.wg.Done()
+
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
 
@@ -455,7 +549,7 @@ func (s *Service) logPerformanceStats() {
 	}
 
 	// Registrar estatísticas
-	logger.Infof("Estatísticas de desempenho: %d ciclos totais, duração média: %v",
+	s.log.Infof("Estatísticas de desempenho: %d ciclos totais, duração média: %v",
 		totalCycles, avgDuration)
 
 	// Limpar histórico de durações para não consumir muita memória
@@ -0,0 +1,90 @@
+package redis
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"radar_go/internal/models"
+	"radar_go/internal/telemetry"
+)
+
+// WriteEvent acrescenta o evento ao stream de eventos, mantendo
+// aproximadamente os maxLen mais recentes (0 = sem limite). Retorna o id
+// atribuído pelo Redis.
+func (s *Service) WriteEvent(event models.Event, maxLen int64) (string, error) {
+	s.reload.RLock()
+	defer s.reload.RUnlock()
+
+	s.mutex.RLock()
+	if !s.connected || !s.config.Enabled {
+		s.mutex.RUnlock()
+		return "", fmt.Errorf("Redis não conectado ou desabilitado")
+	}
+	s.mutex.RUnlock()
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("erro ao serializar evento: %w", err)
+	}
+
+	start := time.Now()
+	id, err := s.client.XAdd(s.ctx, &redis.XAddArgs{
+		Stream: fmt.Sprintf("%s:events", s.prefix),
+		MaxLen: maxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"type":  string(event.Type),
+			"event": string(data),
+		},
+	}).Result()
+	telemetry.RedisPipelineDuration.WithLabelValues("events").Observe(time.Since(start).Seconds())
+	if err != nil {
+		telemetry.RedisPipelineFailures.WithLabelValues("events").Inc()
+		s.mutex.Lock()
+		s.connected = false
+		s.mutex.Unlock()
+		return "", fmt.Errorf("erro ao escrever evento no Redis: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetEvents obtém os eventos do stream no intervalo [from, to], em ordem cronológica
+func (s *Service) GetEvents(from, to time.Time) ([]models.Event, error) {
+	s.reload.RLock()
+	defer s.reload.RUnlock()
+
+	s.mutex.RLock()
+	if !s.connected || !s.config.Enabled {
+		s.mutex.RUnlock()
+		return nil, fmt.Errorf("Redis não conectado ou desabilitado")
+	}
+	s.mutex.RUnlock()
+
+	// Os ids do stream começam pelo timestamp em milissegundos
+	messagesCmd := s.client.XRange(s.ctx, fmt.Sprintf("%s:events", s.prefix),
+		strconv.FormatInt(from.UnixMilli(), 10), strconv.FormatInt(to.UnixMilli(), 10))
+	if messagesCmd.Err() != nil {
+		return nil, fmt.Errorf("erro ao obter eventos: %w", messagesCmd.Err())
+	}
+
+	events := make([]models.Event, 0, len(messagesCmd.Val()))
+	for _, message := range messagesCmd.Val() {
+		data, ok := message.Values["event"].(string)
+		if !ok {
+			continue
+		}
+		var event models.Event
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			continue
+		}
+		event.ID = message.ID
+		events = append(events, event)
+	}
+
+	return events, nil
+}
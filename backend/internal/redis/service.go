@@ -12,9 +12,18 @@ import (
 
 	"radar_go/internal/config"
 	"radar_go/internal/models"
+	"radar_go/internal/telemetry"
 	"radar_go/pkg/logger"
 )
 
+// storedSample é uma amostra completa armazenada para o replay histórico
+type storedSample struct {
+	Timestamp  int64      `json:"t"` // Milissegundos
+	Status     string     `json:"s"`
+	Positions  [7]float64 `json:"p"`
+	Velocities [7]float64 `json:"v"`
+}
+
 // Service gerencia a conexão e operações com o Redis
 type Service struct {
 	client    *redis.Client
@@ -25,6 +34,10 @@ type Service struct {
 	connected bool
 	mutex     sync.RWMutex
 
+	// Exclusivo durante Reconfigure: as operações usam client, ctx e prefix
+	// sem o mutex, então a troca espera que terminem
+	reload sync.RWMutex
+
 	// Constantes específicas do serviço
 	maxVelocityHistorySize int
 	minVelocityChange      float64
@@ -45,19 +58,9 @@ func NewService(cfg config.RedisConfig) (*Service, error) {
 	// Criar contexto cancelável
 	ctx, cancel := context.WithCancel(context.Background())
 
-	// Configurar endereço
-	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
-
-	// Criar cliente Redis
-	client := redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: cfg.Password,
-		DB:       cfg.DB,
-	})
-
 	// Criar serviço
 	service := &Service{
-		client:                 client,
+		client:                 newClient(cfg),
 		ctx:                    ctx,
 		cancel:                 cancel,
 		prefix:                 cfg.Prefix,
@@ -89,7 +92,51 @@ func (s *Service) TestConnection() error {
 	}
 
 	logger.Infof("Conexão com o Redis estabelecida. Resposta: %s", result)
+	s.mutex.Lock()
 	s.connected = true
+	s.mutex.Unlock()
+	return nil
+}
+
+// newClient cria o cliente Redis para a configuração
+func newClient(cfg config.RedisConfig) *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+}
+
+// Reconfigure aplica uma nova configuração (host, prefixo, retenção...),
+// fechando a conexão atual e reconectando. Sem conexão, o serviço segue em
+// modo offline e o erro é retornado.
+func (s *Service) Reconfigure(cfg config.RedisConfig) error {
+	s.reload.Lock()
+	defer s.reload.Unlock()
+
+	s.closeClient()
+
+	s.mutex.Lock()
+	s.config = cfg
+	s.prefix = cfg.Prefix
+	s.client = nil
+	s.connected = false
+	if cfg.Enabled {
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+		s.client = newClient(cfg)
+	}
+	s.mutex.Unlock()
+
+	if !cfg.Enabled {
+		logger.Info("Serviço Redis desabilitado por configuração")
+		return nil
+	}
+
+	logger.Infof("Serviço Redis reconfigurado (%s:%d, prefixo: %s)", cfg.Host, cfg.Port, cfg.Prefix)
+	if err := s.TestConnection(); err != nil {
+		logger.Warnf("Aviso: %v. O Redis será utilizado em modo offline.", err)
+		return err
+	}
 	return nil
 }
 
@@ -102,6 +149,9 @@ func (s *Service) IsConnected() bool {
 
 // WriteMetrics escreve métricas no Redis
 func (s *Service) WriteMetrics(metrics *models.RadarMetrics) error {
+	s.reload.RLock()
+	defer s.reload.RUnlock()
+
 	s.mutex.RLock()
 	if !s.connected || !s.config.Enabled {
 		s.mutex.RUnlock()
@@ -153,8 +203,26 @@ func (s *Service) WriteMetrics(metrics *models.RadarMetrics) error {
 		pipe.ZRemRangeByRank(s.ctx, histKey, 0, -1001)
 	}
 
+	// Amostra completa para o replay histórico. Os históricos por canal usam o
+	// valor como membro e perdem valores repetidos; aqui o membro inclui o timestamp.
+	if s.config.SampleRetention > 0 {
+		sample, err := json.Marshal(storedSample{
+			Timestamp:  timestamp,
+			Status:     metrics.Status,
+			Positions:  metrics.Positions,
+			Velocities: metrics.Velocities,
+		})
+		if err == nil {
+			samplesKey := fmt.Sprintf("%s:samples", s.prefix)
+			pipe.ZAdd(s.ctx, samplesKey, &redis.Z{Score: float64(timestamp), Member: string(sample)})
+
+			oldest := timestamp - s.config.SampleRetention.Milliseconds()
+			pipe.ZRemRangeByScore(s.ctx, samplesKey, "-inf", fmt.Sprintf("(%d", oldest))
+		}
+	}
+
 	// Executa a pipeline
-	_, err := pipe.Exec(s.ctx)
+	err := s.execPipeline("metrics", pipe)
 	if err != nil {
 		s.mutex.Lock()
 		s.connected = false
@@ -167,6 +235,9 @@ func (s *Service) WriteMetrics(metrics *models.RadarMetrics) error {
 
 // WriteVelocityChanges escreve as mudanças de velocidade no Redis
 func (s *Service) WriteVelocityChanges(changes []models.VelocityChange) error {
+	s.reload.RLock()
+	defer s.reload.RUnlock()
+
 	s.mutex.RLock()
 	if !s.connected || !s.config.Enabled || len(changes) == 0 {
 		s.mutex.RUnlock()
@@ -237,7 +308,7 @@ func (s *Service) WriteVelocityChanges(changes []models.VelocityChange) error {
 	pipe.Set(s.ctx, latestDataKey, string(jsonData), 0)
 
 	// Executa a pipeline
-	_, err := pipe.Exec(s.ctx)
+	err := s.execPipeline("velocity_changes", pipe)
 	if err != nil {
 		s.mutex.Lock()
 		s.connected = false
@@ -251,6 +322,9 @@ func (s *Service) WriteVelocityChanges(changes []models.VelocityChange) error {
 
 // WriteStatus escreve o status do radar no Redis
 func (s *Service) WriteStatus(status models.RadarStatus) error {
+	s.reload.RLock()
+	defer s.reload.RUnlock()
+
 	s.mutex.RLock()
 	if !s.connected || !s.config.Enabled {
 		s.mutex.RUnlock()
@@ -276,7 +350,7 @@ func (s *Service) WriteStatus(status models.RadarStatus) error {
 	}
 
 	// Executar pipeline
-	_, err := pipe.Exec(s.ctx)
+	err := s.execPipeline("status", pipe)
 	if err != nil {
 		s.mutex.Lock()
 		s.connected = false
@@ -287,8 +361,22 @@ func (s *Service) WriteStatus(status models.RadarStatus) error {
 	return nil
 }
 
+// execPipeline executa a pipeline registrando duração e falhas por operação
+func (s *Service) execPipeline(operation string, pipe redis.Pipeliner) error {
+	start := time.Now()
+	_, err := pipe.Exec(s.ctx)
+	telemetry.RedisPipelineDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		telemetry.RedisPipelineFailures.WithLabelValues(operation).Inc()
+	}
+	return err
+}
+
 // GetStatus obtém o status atual do Redis
 func (s *Service) GetStatus() (*models.RadarStatus, error) {
+	s.reload.RLock()
+	defer s.reload.RUnlock()
+
 	s.mutex.RLock()
 	if !s.connected || !s.config.Enabled {
 		s.mutex.RUnlock()
@@ -343,6 +431,9 @@ func (s *Service) GetStatus() (*models.RadarStatus, error) {
 
 // GetCurrentData obtém os dados atuais do radar do Redis
 func (s *Service) GetCurrentData() (*models.RadarMetrics, error) {
+	s.reload.RLock()
+	defer s.reload.RUnlock()
+
 	s.mutex.RLock()
 	if !s.connected || !s.config.Enabled {
 		s.mutex.RUnlock()
@@ -398,6 +489,9 @@ func (s *Service) GetCurrentData() (*models.RadarMetrics, error) {
 
 // GetVelocityChanges obtém as mudanças recentes de velocidade
 func (s *Service) GetVelocityChanges() ([]models.VelocityChange, error) {
+	s.reload.RLock()
+	defer s.reload.RUnlock()
+
 	s.mutex.RLock()
 	if !s.connected || !s.config.Enabled {
 		s.mutex.RUnlock()
@@ -422,36 +516,130 @@ func (s *Service) GetVelocityChanges() ([]models.VelocityChange, error) {
 			continue
 		}
 
-		var changeData map[string]interface{}
-		if err := json.Unmarshal([]byte(dataCmd.Val()), &changeData); err != nil {
+		change, ok := parseVelocityChange(dataCmd.Val())
+		if !ok {
 			continue
 		}
+		changes = append(changes, change)
+	}
 
-		// Converter para o modelo VelocityChange
-		change := models.VelocityChange{}
+	return changes, nil
+}
 
-		// Índice
-		if idx, ok := changeData["index"].(float64); ok {
-			change.Index = int(idx)
-		}
+// parseVelocityChange converte os detalhes de uma mudança armazenada em JSON
+func parseVelocityChange(data string) (models.VelocityChange, bool) {
+	var changeData map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &changeData); err != nil {
+		return models.VelocityChange{}, false
+	}
 
-		// Valores
-		if val, ok := changeData["old_value"].(float64); ok {
-			change.OldValue = val
-		}
-		if val, ok := changeData["new_value"].(float64); ok {
-			change.NewValue = val
-		}
-		if val, ok := changeData["change_value"].(float64); ok {
-			change.ChangeValue = val
-		}
+	// Converter para o modelo VelocityChange
+	change := models.VelocityChange{}
 
-		// Timestamp
-		if ts, ok := changeData["timestamp"].(float64); ok {
-			change.Timestamp = time.Unix(0, int64(ts)*int64(time.Millisecond))
+	// Índice
+	if idx, ok := changeData["index"].(float64); ok {
+		change.Index = int(idx)
+	}
+
+	// Valores
+	if val, ok := changeData["old_value"].(float64); ok {
+		change.OldValue = val
+	}
+	if val, ok := changeData["new_value"].(float64); ok {
+		change.NewValue = val
+	}
+	if val, ok := changeData["change_value"].(float64); ok {
+		change.ChangeValue = val
+	}
+
+	// Timestamp
+	if ts, ok := changeData["timestamp"].(float64); ok {
+		change.Timestamp = time.Unix(0, int64(ts)*int64(time.Millisecond))
+	}
+
+	return change, true
+}
+
+// GetSamples obtém até limit amostras completas no intervalo [from, to], em ordem cronológica
+func (s *Service) GetSamples(from, to time.Time, limit int) ([]models.RadarMetrics, error) {
+	s.reload.RLock()
+	defer s.reload.RUnlock()
+
+	s.mutex.RLock()
+	if !s.connected || !s.config.Enabled {
+		s.mutex.RUnlock()
+		return nil, fmt.Errorf("Redis não conectado ou desabilitado")
+	}
+	s.mutex.RUnlock()
+
+	samplesKey := fmt.Sprintf("%s:samples", s.prefix)
+	dataCmd := s.client.ZRangeByScore(s.ctx, samplesKey, &redis.ZRangeBy{
+		Min:   strconv.FormatInt(from.UnixMilli(), 10),
+		Max:   strconv.FormatInt(to.UnixMilli(), 10),
+		Count: int64(limit),
+	})
+	if dataCmd.Err() != nil {
+		return nil, fmt.Errorf("erro ao obter amostras: %w", dataCmd.Err())
+	}
+
+	samples := make([]models.RadarMetrics, 0, len(dataCmd.Val()))
+	for _, member := range dataCmd.Val() {
+		var sample storedSample
+		if err := json.Unmarshal([]byte(member), &sample); err != nil {
+			continue
 		}
+		samples = append(samples, models.RadarMetrics{
+			Timestamp:  time.UnixMilli(sample.Timestamp),
+			Status:     sample.Status,
+			Positions:  sample.Positions,
+			Velocities: sample.Velocities,
+		})
+	}
 
-		changes = append(changes, change)
+	return samples, nil
+}
+
+// GetVelocityChangesRange obtém as mudanças de velocidade no intervalo [from, to], em ordem cronológica
+func (s *Service) GetVelocityChangesRange(from, to time.Time) ([]models.VelocityChange, error) {
+	s.reload.RLock()
+	defer s.reload.RUnlock()
+
+	s.mutex.RLock()
+	if !s.connected || !s.config.Enabled {
+		s.mutex.RUnlock()
+		return nil, fmt.Errorf("Redis não conectado ou desabilitado")
+	}
+	s.mutex.RUnlock()
+
+	changesKey := fmt.Sprintf("%s:velocity_changes", s.prefix)
+	keysCmd := s.client.ZRangeByScore(s.ctx, changesKey, &redis.ZRangeBy{
+		Min: strconv.FormatInt(from.UnixMilli(), 10),
+		Max: strconv.FormatInt(to.UnixMilli(), 10),
+	})
+	if keysCmd.Err() != nil {
+		return nil, fmt.Errorf("erro ao obter mudanças de velocidade: %w", keysCmd.Err())
+	}
+
+	keys := keysCmd.Val()
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	// Obter os detalhes de todas as mudanças de uma vez
+	valuesCmd := s.client.MGet(s.ctx, keys...)
+	if valuesCmd.Err() != nil {
+		return nil, fmt.Errorf("erro ao obter mudanças de velocidade: %w", valuesCmd.Err())
+	}
+
+	changes := make([]models.VelocityChange, 0, len(keys))
+	for _, value := range valuesCmd.Val() {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		if change, ok := parseVelocityChange(data); ok {
+			changes = append(changes, change)
+		}
 	}
 
 	return changes, nil
@@ -459,6 +647,9 @@ func (s *Service) GetVelocityChanges() ([]models.VelocityChange, error) {
 
 // GetVelocityHistory obtém o histórico de uma velocidade específica
 func (s *Service) GetVelocityHistory(index int) ([]models.HistoryPoint, error) {
+	s.reload.RLock()
+	defer s.reload.RUnlock()
+
 	s.mutex.RLock()
 	if !s.connected || !s.config.Enabled {
 		s.mutex.RUnlock()
@@ -507,10 +698,20 @@ func (s *Service) GetVelocityHistory(index int) ([]models.HistoryPoint, error) {
 
 // Shutdown encerra graciosamente o serviço Redis
 func (s *Service) Shutdown() {
+	s.reload.Lock()
+	defer s.reload.Unlock()
+
+	s.closeClient()
+}
+
+// closeClient cancela o contexto e fecha a conexão atual, se houver
+func (s *Service) closeClient() {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	s.cancel()
+	if s.cancel != nil {
+		s.cancel()
+	}
 
 	if s.client != nil {
 		if err := s.client.Close(); err != nil {
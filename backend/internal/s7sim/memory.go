@@ -0,0 +1,169 @@
+package s7sim
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Area identifica a área de memória simulada (códigos do protocolo S7)
+type Area byte
+
+const (
+	// AreaInput entradas de processo (I / E)
+	AreaInput Area = 0x81
+	// AreaOutput saídas de processo (Q / A)
+	AreaOutput Area = 0x82
+	// AreaMerker memória interna (M)
+	AreaMerker Area = 0x83
+	// AreaDB blocos de dados (DB)
+	AreaDB Area = 0x84
+	// AreaCounter contadores (C / Z), 2 bytes cada
+	AreaCounter Area = 0x1C
+	// AreaTimer temporizadores (T), 2 bytes cada
+	AreaTimer Area = 0x1D
+)
+
+// Tamanhos padrão das áreas simuladas
+const (
+	defaultProcessAreaSize = 1024 // Bytes de I, Q e M
+	defaultTimerCount      = 256  // Temporizadores/contadores
+)
+
+// WriteRecord registra uma escrita recebida pelo simulador
+type WriteRecord struct {
+	Time     time.Time
+	Area     Area
+	DBNumber int
+	Offset   int    // Offset em bytes (ou número do temporizador/contador)
+	Bit      int    // Índice do bit para escritas de bit, -1 para escritas de bytes
+	Data     []byte // Bytes escritos (para bit: 0 ou 1)
+}
+
+// Memory armazena as áreas de memória do PLC simulado
+type Memory struct {
+	mu       sync.RWMutex
+	dbs      map[int][]byte
+	inputs   []byte
+	outputs  []byte
+	merkers  []byte
+	timers   []byte
+	counters []byte
+}
+
+// NewMemory cria uma memória com áreas I/Q/M, temporizadores e contadores vazios
+func NewMemory() *Memory {
+	return &Memory{
+		dbs:      make(map[int][]byte),
+		inputs:   make([]byte, defaultProcessAreaSize),
+		outputs:  make([]byte, defaultProcessAreaSize),
+		merkers:  make([]byte, defaultProcessAreaSize),
+		timers:   make([]byte, defaultTimerCount*2),
+		counters: make([]byte, defaultTimerCount*2),
+	}
+}
+
+// AddDB cria (ou recria) um bloco de dados com o tamanho informado
+func (m *Memory) AddDB(number int, size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dbs[number] = make([]byte, size)
+}
+
+// Read lê bytes de uma área. Para temporizadores e contadores, offset é o número do elemento.
+func (m *Memory) Read(area Area, dbNumber int, offset int, size int) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	buf, start, err := m.slice(area, dbNumber, offset, size)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, size)
+	copy(data, buf[start:start+size])
+	return data, nil
+}
+
+// Write escreve bytes em uma área
+func (m *Memory) Write(area Area, dbNumber int, offset int, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	buf, start, err := m.slice(area, dbNumber, offset, len(data))
+	if err != nil {
+		return err
+	}
+
+	copy(buf[start:], data)
+	return nil
+}
+
+// ReadBit lê um único bit
+func (m *Memory) ReadBit(area Area, dbNumber int, offset int, bit int) (bool, error) {
+	data, err := m.Read(area, dbNumber, offset, 1)
+	if err != nil {
+		return false, err
+	}
+	return data[0]&(1<<bit) != 0, nil
+}
+
+// WriteBit altera um único bit, preservando os demais bits do byte
+func (m *Memory) WriteBit(area Area, dbNumber int, offset int, bit int, value bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	buf, start, err := m.slice(area, dbNumber, offset, 1)
+	if err != nil {
+		return err
+	}
+
+	if value {
+		buf[start] |= 1 << bit
+	} else {
+		buf[start] &^= 1 << bit
+	}
+	return nil
+}
+
+// errAddressOutOfRange indica um acesso fora dos limites da área
+var errAddressOutOfRange = fmt.Errorf("endereço fora do intervalo")
+
+// errObjectNotFound indica um DB inexistente
+var errObjectNotFound = fmt.Errorf("objeto inexistente")
+
+// slice retorna o buffer da área e o offset inicial em bytes, validando os limites.
+// Deve ser chamada com o mutex adquirido.
+func (m *Memory) slice(area Area, dbNumber int, offset int, size int) ([]byte, int, error) {
+	var buf []byte
+	start := offset
+
+	switch area {
+	case AreaDB:
+		db, ok := m.dbs[dbNumber]
+		if !ok {
+			return nil, 0, errObjectNotFound
+		}
+		buf = db
+	case AreaInput:
+		buf = m.inputs
+	case AreaOutput:
+		buf = m.outputs
+	case AreaMerker:
+		buf = m.merkers
+	case AreaTimer:
+		buf = m.timers
+		start = offset * 2
+	case AreaCounter:
+		buf = m.counters
+		start = offset * 2
+	default:
+		return nil, 0, fmt.Errorf("área 0x%02X não suportada", byte(area))
+	}
+
+	if start < 0 || size < 0 || start+size > len(buf) {
+		return nil, 0, errAddressOutOfRange
+	}
+
+	return buf, start, nil
+}
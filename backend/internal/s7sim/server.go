@@ -0,0 +1,535 @@
+// Package s7sim implementa um servidor ISO-on-TCP/S7 mínimo em memória, usado
+// como substituto de um PLC S7-1500 em testes de integração e no cmd/s7sim.
+//
+// São suportados o handshake COTP, a negociação de PDU e as funções ReadVar e
+// WriteVar (com um ou vários itens) sobre DBs, merkers, entradas, saídas,
+// temporizadores e contadores.
+package s7sim
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Constantes do protocolo
+const (
+	// Tamanho máximo de PDU oferecido pelo simulador
+	DefaultPDULength = 480
+
+	isoHeaderSize = 7
+
+	cotpConnectionRequest = 0xE0
+	cotpConnectionConfirm = 0xD0
+	cotpData              = 0xF0
+
+	rosctrJob     = 0x01
+	rosctrAckData = 0x03
+
+	funcSetupCommunication = 0xF0
+	funcReadVar            = 0x04
+	funcWriteVar           = 0x05
+
+	transportBit     = 0x01
+	transportCounter = 0x1C
+	transportTimer   = 0x1D
+
+	dataBit   = 0x03
+	dataBytes = 0x04
+	dataOctet = 0x09
+
+	itemSuccess          = 0xFF
+	itemOutOfRange       = 0x05
+	itemTypeNotSupported = 0x06
+	itemObjectNotFound   = 0x0A
+)
+
+// Server é um PLC S7 simulado que atende conexões ISO-on-TCP
+type Server struct {
+	memory    *Memory
+	pduLength int
+
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	offline  bool
+
+	writes   []WriteRecord
+	writesMu sync.Mutex
+	onWrite  func(WriteRecord)
+
+	requests    int64
+	connections int64
+}
+
+// NewServer cria um simulador com memória vazia e PDU padrão
+func NewServer() *Server {
+	return &Server{
+		memory:    NewMemory(),
+		pduLength: DefaultPDULength,
+		conns:     make(map[net.Conn]struct{}),
+	}
+}
+
+// Memory retorna a memória simulada, para preparar dados ou verificar resultados
+func (s *Server) Memory() *Memory {
+	return s.memory
+}
+
+// SetPDULength define o tamanho máximo de PDU negociado com os clientes
+func (s *Server) SetPDULength(length int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pduLength = length
+}
+
+// OnWrite registra uma função chamada a cada escrita recebida
+func (s *Server) OnWrite(fn func(WriteRecord)) {
+	s.writesMu.Lock()
+	defer s.writesMu.Unlock()
+	s.onWrite = fn
+}
+
+// Listen começa a aceitar conexões no endereço informado (ex.: "127.0.0.1:0")
+func (s *Server) Listen(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("erro ao escutar em %s: %w", addr, err)
+	}
+
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go s.acceptLoop(listener)
+	return nil
+}
+
+// Addr retorna o endereço em que o simulador está escutando
+func (s *Server) Addr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
+
+// Close encerra o listener e todas as conexões abertas
+func (s *Server) Close() error {
+	s.mu.Lock()
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+		s.listener = nil
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+// DropConnections fecha as conexões atuais, simulando uma queda de rede
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+// SetOffline faz o simulador recusar (true) ou voltar a aceitar (false) conexões.
+// Ao ficar offline, as conexões atuais também são fechadas.
+func (s *Server) SetOffline(offline bool) {
+	s.mu.Lock()
+	s.offline = offline
+	s.mu.Unlock()
+
+	if offline {
+		s.DropConnections()
+	}
+}
+
+// Writes retorna uma cópia das escritas recebidas desde o último ClearWrites
+func (s *Server) Writes() []WriteRecord {
+	s.writesMu.Lock()
+	defer s.writesMu.Unlock()
+	return append([]WriteRecord(nil), s.writes...)
+}
+
+// ClearWrites descarta o histórico de escritas
+func (s *Server) ClearWrites() {
+	s.writesMu.Lock()
+	defer s.writesMu.Unlock()
+	s.writes = nil
+}
+
+// RequestCount retorna o número de requisições ReadVar/WriteVar atendidas
+func (s *Server) RequestCount() int64 {
+	return atomic.LoadInt64(&s.requests)
+}
+
+// ConnectionCount retorna o número de conexões aceitas desde o início
+func (s *Server) ConnectionCount() int64 {
+	return atomic.LoadInt64(&s.connections)
+}
+
+// acceptLoop aceita conexões até o listener ser fechado
+func (s *Server) acceptLoop(listener net.Listener) {
+	defer s.wg.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.offline {
+			s.mu.Unlock()
+			conn.Close()
+			continue
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		atomic.AddInt64(&s.connections, 1)
+		s.wg.Add(1)
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn processa telegramas de uma conexão até ela ser encerrada
+func (s *Server) serveConn(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+		s.wg.Done()
+	}()
+
+	for {
+		frame, err := readFrame(conn)
+		if err != nil {
+			return
+		}
+
+		response, err := s.handleFrame(frame)
+		if err != nil {
+			return
+		}
+		if response == nil {
+			continue
+		}
+
+		if _, err := conn.Write(response); err != nil {
+			return
+		}
+	}
+}
+
+// readFrame lê um telegrama TPKT completo
+func readFrame(r io.Reader) ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if header[0] != 0x03 {
+		return nil, fmt.Errorf("versão TPKT inválida: %d", header[0])
+	}
+
+	length := int(binary.BigEndian.Uint16(header[2:]))
+	if length < isoHeaderSize {
+		return nil, fmt.Errorf("telegrama TPKT muito curto: %d", length)
+	}
+
+	frame := make([]byte, length)
+	copy(frame, header)
+	if _, err := io.ReadFull(r, frame[4:]); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// handleFrame processa um telegrama e retorna a resposta (ou nil, se não houver)
+func (s *Server) handleFrame(frame []byte) ([]byte, error) {
+	switch frame[5] {
+	case cotpConnectionRequest:
+		return connectionConfirm(frame), nil
+	case cotpData:
+		return s.handleS7(frame[isoHeaderSize:])
+	default:
+		return nil, fmt.Errorf("tipo de PDU COTP não suportado: 0x%02X", frame[5])
+	}
+}
+
+// connectionConfirm responde a um Connection Request COTP com os mesmos TSAPs
+func connectionConfirm(request []byte) []byte {
+	response := append([]byte(nil), request...)
+	response[5] = cotpConnectionConfirm
+	if len(response) >= 10 {
+		// Referência de destino = referência de origem do pedido
+		response[6], response[7] = request[8], request[9]
+		response[8], response[9] = 0x00, 0x01
+	}
+	return response
+}
+
+// handleS7 processa um PDU S7 (Job) e monta a resposta Ack-Data
+func (s *Server) handleS7(pdu []byte) ([]byte, error) {
+	if len(pdu) < 10 || pdu[0] != 0x32 || pdu[1] != rosctrJob {
+		return nil, fmt.Errorf("PDU S7 inválido")
+	}
+
+	ref := binary.BigEndian.Uint16(pdu[4:])
+	paramLen := int(binary.BigEndian.Uint16(pdu[6:]))
+	dataLen := int(binary.BigEndian.Uint16(pdu[8:]))
+	if len(pdu) < 10+paramLen+dataLen || paramLen < 1 {
+		return nil, fmt.Errorf("PDU S7 truncado")
+	}
+
+	params := pdu[10 : 10+paramLen]
+	data := pdu[10+paramLen : 10+paramLen+dataLen]
+
+	switch params[0] {
+	case funcSetupCommunication:
+		return s.setupCommunication(ref, params), nil
+	case funcReadVar:
+		atomic.AddInt64(&s.requests, 1)
+		return s.readVar(ref, params)
+	case funcWriteVar:
+		atomic.AddInt64(&s.requests, 1)
+		return s.writeVar(ref, params, data)
+	default:
+		// Função não suportada: erro de classe 0x81 (aplicação)
+		return ackData(ref, 0x81, 0x04, params[:1], nil), nil
+	}
+}
+
+// setupCommunication negocia o tamanho de PDU
+func (s *Server) setupCommunication(ref uint16, params []byte) []byte {
+	s.mu.Lock()
+	pduLength := s.pduLength
+	s.mu.Unlock()
+
+	if len(params) >= 8 {
+		if requested := int(binary.BigEndian.Uint16(params[6:])); requested > 0 && requested < pduLength {
+			pduLength = requested
+		}
+	}
+
+	response := make([]byte, 8)
+	copy(response, params)
+	response[0] = funcSetupCommunication
+	binary.BigEndian.PutUint16(response[6:], uint16(pduLength))
+	return ackData(ref, 0, 0, response, nil)
+}
+
+// item representa uma especificação de variável decodificada
+type item struct {
+	transport byte
+	count     int
+	dbNumber  int
+	area      Area
+	address   int // Endereço em bits (ou número do temporizador/contador)
+}
+
+// byteLength retorna quantos bytes o item ocupa
+func (it item) byteLength() int {
+	switch it.transport {
+	case transportBit:
+		return 1
+	case 0x04, 0x05, transportCounter, transportTimer: // WORD, INT, COUNTER, TIMER
+		return it.count * 2
+	case 0x06, 0x07, 0x08: // DWORD, DINT, REAL
+		return it.count * 4
+	default: // BYTE, CHAR
+		return it.count
+	}
+}
+
+// byteOffset retorna o offset inicial em bytes (ou o número do temporizador/contador)
+func (it item) byteOffset() int {
+	if it.area == AreaTimer || it.area == AreaCounter {
+		return it.address
+	}
+	return it.address >> 3
+}
+
+// parseItems decodifica as especificações de variável dos parâmetros
+func parseItems(params []byte) ([]item, error) {
+	if len(params) < 2 {
+		return nil, fmt.Errorf("parâmetros S7 truncados")
+	}
+
+	count := int(params[1])
+	items := make([]item, 0, count)
+	offset := 2
+	for i := 0; i < count; i++ {
+		if offset+12 > len(params) || params[offset] != 0x12 {
+			return nil, fmt.Errorf("especificação de variável %d inválida", i)
+		}
+		spec := params[offset : offset+12]
+		items = append(items, item{
+			transport: spec[3],
+			count:     int(binary.BigEndian.Uint16(spec[4:])),
+			dbNumber:  int(binary.BigEndian.Uint16(spec[6:])),
+			area:      Area(spec[8]),
+			address:   int(spec[9])<<16 | int(spec[10])<<8 | int(spec[11]),
+		})
+		offset += 12
+	}
+	return items, nil
+}
+
+// readVar atende uma requisição ReadVar
+func (s *Server) readVar(ref uint16, params []byte) ([]byte, error) {
+	items, err := parseItems(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	for i, it := range items {
+		var value []byte
+		var transport byte
+		var length int
+
+		if it.transport == transportBit {
+			bit, readErr := s.memory.ReadBit(it.area, it.dbNumber, it.address>>3, it.address&7)
+			err = readErr
+			value = []byte{0}
+			if bit {
+				value[0] = 1
+			}
+			transport, length = dataBit, 1
+		} else {
+			value, err = s.memory.Read(it.area, it.dbNumber, it.byteOffset(), it.byteLength())
+			if it.area == AreaTimer || it.area == AreaCounter {
+				transport, length = dataOctet, len(value)
+			} else {
+				transport, length = dataBytes, len(value)*8
+			}
+		}
+
+		if err != nil {
+			data = append(data, itemReturnCode(err), 0x00, 0x00, 0x00)
+			continue
+		}
+
+		data = append(data, itemSuccess, transport, byte(length>>8), byte(length))
+		data = append(data, value...)
+		if i < len(items)-1 && len(value)%2 != 0 {
+			data = append(data, 0x00)
+		}
+	}
+
+	return ackData(ref, 0, 0, []byte{funcReadVar, byte(len(items))}, data), nil
+}
+
+// writeVar atende uma requisição WriteVar
+func (s *Server) writeVar(ref uint16, params []byte, data []byte) ([]byte, error) {
+	items, err := parseItems(params)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]byte, 0, len(items))
+	offset := 0
+	for i, it := range items {
+		if offset+4 > len(data) {
+			return nil, fmt.Errorf("dados do item %d truncados", i)
+		}
+
+		// O tamanho efetivo é dado pela especificação do item; o campo de
+		// tamanho da seção de dados varia entre clientes (bits ou bytes)
+		size := it.byteLength()
+		offset += 4
+		if offset+size > len(data) {
+			return nil, fmt.Errorf("dados do item %d truncados", i)
+		}
+		value := append([]byte(nil), data[offset:offset+size]...)
+		offset += size
+		if i < len(items)-1 && size%2 != 0 {
+			offset++
+		}
+
+		record := WriteRecord{
+			Time:     time.Now(),
+			Area:     it.area,
+			DBNumber: it.dbNumber,
+			Offset:   it.byteOffset(),
+			Bit:      -1,
+			Data:     value,
+		}
+
+		if it.transport == transportBit {
+			record.Bit = it.address & 7
+			err = s.memory.WriteBit(it.area, it.dbNumber, record.Offset, record.Bit, value[0] != 0)
+		} else {
+			err = s.memory.Write(it.area, it.dbNumber, record.Offset, value)
+		}
+
+		if err != nil {
+			results = append(results, itemReturnCode(err))
+			continue
+		}
+
+		results = append(results, itemSuccess)
+		s.recordWrite(record)
+	}
+
+	params = []byte{funcWriteVar, byte(len(items))}
+	return ackData(ref, 0, 0, append(params, results...), nil), nil
+}
+
+// recordWrite guarda a escrita no histórico e notifica o observador
+func (s *Server) recordWrite(record WriteRecord) {
+	s.writesMu.Lock()
+	s.writes = append(s.writes, record)
+	fn := s.onWrite
+	s.writesMu.Unlock()
+
+	if fn != nil {
+		fn(record)
+	}
+}
+
+// itemReturnCode converte erros de memória nos códigos de retorno S7
+func itemReturnCode(err error) byte {
+	switch {
+	case errors.Is(err, errAddressOutOfRange):
+		return itemOutOfRange
+	case errors.Is(err, errObjectNotFound):
+		return itemObjectNotFound
+	default:
+		return itemTypeNotSupported
+	}
+}
+
+// ackData monta um telegrama Ack-Data completo (TPKT + COTP + S7)
+func ackData(ref uint16, errClass, errCode byte, params []byte, data []byte) []byte {
+	total := isoHeaderSize + 12 + len(params) + len(data)
+	frame := make([]byte, 0, total)
+	frame = append(frame, 0x03, 0x00, byte(total>>8), byte(total), 0x02, cotpData, 0x80)
+	frame = append(frame,
+		0x32, rosctrAckData, 0x00, 0x00,
+		byte(ref>>8), byte(ref),
+		byte(len(params)>>8), byte(len(params)),
+		byte(len(data)>>8), byte(len(data)),
+		errClass, errCode)
+	frame = append(frame, params...)
+	frame = append(frame, data...)
+	return frame
+}
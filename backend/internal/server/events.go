@@ -0,0 +1,155 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"radar_go/internal/auth"
+	"radar_go/internal/events"
+	"radar_go/internal/models"
+	"radar_go/internal/websocket"
+)
+
+// Limites da consulta de eventos
+const (
+	defaultEventsWindow = 24 * time.Hour
+	defaultEventsLimit  = 100
+	maxEventsLimit      = 1000
+)
+
+// eventsHandler consulta o registro de eventos. Parâmetros: type (lista
+// separada por vírgulas), from e to (RFC 3339; padrão: últimas 24 horas) e
+// limit (padrão 100, máximo 1000; mantém os mais recentes).
+// Uso: GET /api/events?type=radar_lost,alarm_raised&from=2024-05-01T00:00:00Z
+func (s *Server) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Método não permitido"})
+		return
+	}
+
+	if s.journal == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Registro de eventos desabilitado"})
+		return
+	}
+
+	query, err := parseEventsQuery(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	found, err := s.journal.Query(query)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if found == nil {
+		found = []models.Event{}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":   query.From,
+		"to":     query.To,
+		"count":  len(found),
+		"events": found,
+	})
+}
+
+// parseEventsQuery lê os filtros de /api/events
+func parseEventsQuery(r *http.Request) (events.Query, error) {
+	params := r.URL.Query()
+	query := events.Query{To: time.Now(), Limit: defaultEventsLimit}
+
+	for _, value := range params["type"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			eventType, err := events.ParseType(name)
+			if err != nil {
+				return query, err
+			}
+			query.Types = append(query.Types, eventType)
+		}
+	}
+
+	if value := params.Get("to"); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return query, errors.New("Data final inválida (use RFC 3339)")
+		}
+		query.To = to
+	}
+
+	query.From = query.To.Add(-defaultEventsWindow)
+	if value := params.Get("from"); value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return query, errors.New("Data inicial inválida (use RFC 3339)")
+		}
+		query.From = from
+	}
+	if query.From.After(query.To) {
+		return query, errors.New("A data inicial deve ser anterior à final")
+	}
+
+	if value := params.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxEventsLimit {
+			return query, errors.New("Limite inválido (use 1-1000)")
+		}
+		query.Limit = limit
+	}
+
+	return query, nil
+}
+
+// alarmAckHandler reconhece um alarme ativo, registrando quem o reconheceu
+// Uso: POST /api/alarms/ack {"alarm": "obstructed", "comment": "..."}
+func (s *Server) alarmAckHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Método não permitido"})
+		return
+	}
+
+	var request struct {
+		Alarm   string `json:"alarm"`
+		Comment string `json:"comment"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "JSON inválido: " + err.Error()})
+		return
+	}
+
+	actor := auth.FromContext(r.Context()).String()
+	if err := s.wsHub.AcknowledgeAlarm(request.Alarm, actor, request.Comment); err != nil {
+		switch {
+		case errors.Is(err, websocket.ErrAlarmInactive):
+			w.WriteHeader(http.StatusConflict)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"alarm":        request.Alarm,
+		"acknowledged": true,
+		"by":           actor,
+	})
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"radar_go/internal/telemetry"
+)
+
+// Rotas de conexão contínua: contadas, mas fora do histograma de latência,
+// já que a "duração" é o tempo de conexão do cliente
+var streamingRoutes = map[string]bool{
+	"/ws":         true,
+	"/api/stream": true,
+}
+
+// statusRecorder guarda o código de status da resposta, mantendo Flusher
+// (SSE) e Hijacker (upgrade do WebSocket) do ResponseWriter original
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("ResponseWriter não suporta hijack")
+	}
+	if r.status == 0 {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap permite ao http.ResponseController acessar o ResponseWriter original
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// instrument registra contagem e latência das requisições por rota. A rota é
+// o padrão registrado em routes (não o caminho), para limitar os labels.
+func instrument(routes *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := routes.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		telemetry.HTTPRequests.WithLabelValues(route, r.Method, strconv.Itoa(status)).Inc()
+		if !streamingRoutes[route] {
+			telemetry.HTTPRequestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"radar_go/internal/config"
+	"radar_go/pkg/logger"
+)
+
+// Prefixo dos arquivos de log (radar.log e radar_error.log)
+const logFilePrefix = "radar"
+
+// ConfigureLogging aplica nível, formato, supressão de repetições e arquivos
+// de log. Usado na inicialização e nas recargas que alteram a seção log.
+func ConfigureLogging(cfg config.LogConfig) error {
+	level, err := logger.ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+	format, err := logger.ParseFormat(cfg.Format)
+	if err != nil {
+		return err
+	}
+	logger.SetLevel(level)
+	logger.SetFormat(format)
+	logger.SetDedupWindow(cfg.DedupWindow)
+
+	if cfg.Dir == "" {
+		logger.DisableFileLogging()
+		return nil
+	}
+	return logger.EnableFileLogging(cfg.Dir, logFilePrefix, logger.Rotation{
+		MaxSize:    int64(cfg.MaxSizeMB) * 1024 * 1024,
+		Daily:      cfg.Daily,
+		MaxBackups: cfg.MaxFiles,
+		MaxAge:     cfg.MaxAge,
+		Compress:   cfg.Compress,
+	})
+}
+
+// adminLogLevelHandler exibe e altera o nível de log em tempo de execução.
+// A alteração não é gravada: a próxima recarga da seção log ou reinício
+// volta ao nível configurado.
+// Uso: POST /api/admin/log-level {"level": "debug"}
+func (s *Server) adminLogLevelHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var request struct {
+			Level string `json:"level"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "JSON inválido: " + err.Error()})
+			return
+		}
+		level, err := logger.ParseLevel(request.Level)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		logger.SetLevel(level)
+		logger.Warnf("Nível de log alterado para %s via API", level)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Método não permitido"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"level":      logger.GetLevel().String(),
+		"configured": s.currentConfig().Log.Level,
+	})
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"radar_go/internal/config"
+	"radar_go/internal/models"
+	"radar_go/pkg/logger"
+)
+
+// Origens de uma recarga da configuração
+const (
+	ReloadFile   = "file"   // Arquivo de configuração alterado
+	ReloadSignal = "sighup" // SIGHUP recebido
+	ReloadAPI    = "api"    // POST /api/admin/config
+)
+
+// ReloadResult descreve o resultado de uma recarga da configuração
+type ReloadResult struct {
+	Time      time.Time `json:"time"`
+	Trigger   string    `json:"trigger"`
+	Success   bool      `json:"success"`
+	Changed   []string  `json:"changed"`   // Campos alterados (ex.: "redis.prefix")
+	Restarted []string  `json:"restarted"` // Serviços reiniciados com a nova configuração
+
+	// Seções alteradas que só valem após reiniciar o processo; até lá, a
+	// configuração ativa mantém os valores anteriores
+	RequiresRestart []string `json:"requiresRestart,omitempty"`
+
+	Error    string              `json:"error,omitempty"`
+	Problems []config.FieldError `json:"problems,omitempty"` // Erros de validação
+}
+
+// Reload lê novamente a configuração (arquivo, variáveis de ambiente e flags) e
+// reinicia apenas os serviços afetados: radar, Redis e PLC. Uma configuração
+// inválida é rejeitada e a atual continua em uso.
+func (s *Server) Reload(trigger string) ReloadResult {
+	return s.reload(trigger, "")
+}
+
+// reload executa a recarga; actor identifica quem a solicitou pela API
+func (s *Server) reload(trigger, actor string) ReloadResult {
+	s.reloadMutex.Lock()
+	defer s.reloadMutex.Unlock()
+
+	result := ReloadResult{Time: time.Now(), Trigger: trigger}
+
+	cfg, err := config.Load()
+	if err != nil {
+		result.Error = err.Error()
+		var problems config.ValidationErrors
+		if errors.As(err, &problems) {
+			result.Problems = problems
+		}
+		logger.Errorf("Recarga da configuração (%s) rejeitada, mantendo a atual: %v", trigger, err)
+		s.setLastReload(result)
+		return result
+	}
+
+	current := s.currentConfig()
+	result.Changed = config.Diff(current, cfg)
+	if len(result.Changed) == 0 {
+		result.Success = true
+		logger.Infof("Recarga da configuração (%s): nenhuma alteração", trigger)
+		s.setLastReload(result)
+		return result
+	}
+
+	logger.Infof("Recarga da configuração (%s): %s", trigger, strings.Join(result.Changed, ", "))
+
+	var failures []string
+	for _, section := range config.Sections(result.Changed) {
+		var err error
+		switch section {
+		case "radar":
+			s.wsHub.SetRadarID(cfg.Radar.ID)
+			s.journal.SetRadarID(cfg.Radar.ID)
+			err = s.radarService.Reconfigure(cfg.Radar)
+		case "redis":
+			err = s.redisService.Reconfigure(cfg.Redis)
+		case "plc":
+			err = s.plcService.Reconfigure(cfg.PLC)
+		case "log":
+			err = ConfigureLogging(cfg.Log)
+		default:
+			result.RequiresRestart = append(result.RequiresRestart, section)
+			cfg.RestoreSection(current, section)
+			continue
+		}
+
+		result.Restarted = append(result.Restarted, section)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", section, err))
+		}
+	}
+
+	if len(result.RequiresRestart) > 0 {
+		logger.Warnf("Alterações em %s só terão efeito após reiniciar o serviço",
+			strings.Join(result.RequiresRestart, ", "))
+	}
+
+	// Os serviços reiniciados já usam a nova configuração, mesmo com falha de conexão
+	s.mutex.Lock()
+	s.config = cfg
+	s.mutex.Unlock()
+
+	result.Success = len(failures) == 0
+	if !result.Success {
+		result.Error = strings.Join(failures, "; ")
+		logger.Errorf("Recarga da configuração (%s) com falhas: %s", trigger, result.Error)
+	}
+	s.setLastReload(result)
+
+	data := map[string]interface{}{
+		"trigger":   trigger,
+		"success":   result.Success,
+		"changed":   result.Changed,
+		"restarted": result.Restarted,
+	}
+	if len(result.RequiresRestart) > 0 {
+		data["requiresRestart"] = result.RequiresRestart
+	}
+	if result.Error != "" {
+		data["error"] = result.Error
+	}
+	s.journal.Record(models.Event{
+		Type:    models.EventConfigReloaded,
+		Actor:   actor,
+		Message: fmt.Sprintf("Configuração recarregada (%s): %s", trigger, strings.Join(result.Changed, ", ")),
+		Data:    data,
+	})
+	return result
+}
+
+// LastReload retorna o resultado da última recarga, ou nil se não houve nenhuma
+func (s *Server) LastReload() *ReloadResult {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.lastReload
+}
+
+// setLastReload guarda o resultado da recarga para /api/admin/config
+func (s *Server) setLastReload(result ReloadResult) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastReload = &result
+}
+
+// currentConfig retorna a configuração ativa (substituída a cada recarga)
+func (s *Server) currentConfig() *config.Config {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.config
+}
+
+// watchConfigFile verifica o arquivo de configuração a cada interval e
+// recarrega quando o conteúdo muda. Só recarrega depois de duas leituras
+// iguais, para não pegar o arquivo no meio de uma gravação.
+func (s *Server) watchConfigFile(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	loaded := fileChecksum(config.File())
+	pending := loaded
+
+	for {
+		select {
+		case <-s.watchStop:
+			return
+		case <-ticker.C:
+			sum := fileChecksum(config.File())
+			if sum == loaded {
+				pending = sum
+				continue
+			}
+			if sum != pending {
+				pending = sum
+				continue
+			}
+
+			loaded = sum
+			logger.Infof("%s alterado, recarregando configuração", config.File())
+			s.Reload(ReloadFile)
+		}
+	}
+}
+
+// fileChecksum retorna o SHA-256 do conteúdo do arquivo, ou "" se não existir
+func fileChecksum(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return string(sum[:])
+}
@@ -3,9 +3,15 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
 
 	"radar_go/internal/api"
+	"radar_go/internal/auth"
+	"radar_go/internal/config"
+	"radar_go/internal/models"
+	"radar_go/internal/plc"
+	"radar_go/internal/telemetry"
 	"radar_go/internal/websocket"
 	"radar_go/pkg/logger"
 )
@@ -14,6 +20,7 @@ import (
 func (s *Server) setupRoutes() {
 	// Criar handlers
 	wsHandler := websocket.NewHandler(s.wsHub)
+	wsHandler.SetOriginCheck(s.authenticator.AllowOrigin)
 	apiHandler := api.NewHandler(s.radarService, s.redisService)
 
 	// Endpoint de saúde
@@ -22,6 +29,9 @@ func (s *Server) setupRoutes() {
 	// Endpoint de informações do servidor
 	s.router.HandleFunc("/info", s.infoHandler)
 
+	// Métricas no formato texto do Prometheus (requer credencial, ver requiresAuth)
+	s.router.Handle("/metrics", telemetry.Handler())
+
 	// Endpoints de descoberta
 	s.router.HandleFunc("/api/discover", s.discoverHandler)
 
@@ -29,6 +39,9 @@ func (s *Server) setupRoutes() {
 	s.router.Handle("/ws", wsHandler)
 	s.router.HandleFunc("/ws/health", wsHandler.GetHealthHandler())
 
+	// Server-Sent Events, alternativa ao WebSocket alimentada pelo mesmo hub
+	s.router.HandleFunc("/api/stream", wsHandler.HandleStream)
+
 	// API REST
 	s.router.HandleFunc("/api/status", apiHandler.GetStatus)
 	s.router.HandleFunc("/api/current", apiHandler.GetCurrentData)
@@ -37,17 +50,34 @@ func (s *Server) setupRoutes() {
 	s.router.HandleFunc("/api/latest-update", apiHandler.GetLatestUpdate)
 	s.router.HandleFunc("/api/server-info", s.serverInfoHandler)
 
+	// Identidade da credencial usada na requisição
+	s.router.HandleFunc("/api/auth/whoami", s.whoamiHandler)
+
+	// Registro de eventos operacionais e reconhecimento de alarmes
+	s.router.HandleFunc("/api/events", s.eventsHandler)
+	s.router.HandleFunc("/api/alarms/ack", auth.RequireRole(auth.RoleOperator, s.alarmAckHandler))
+
+	// Diagnóstico do PLC (endereços simbólicos S7, ex.: DB10.DBD4, MW20, I2.0)
+	s.router.HandleFunc("/api/plc/read", auth.RequireRole(auth.RoleEngineer, s.plcReadHandler))
+
+	// Configuração ativa e recarga sem reiniciar o serviço
+	s.router.HandleFunc("/api/admin/config", auth.RequireRole(auth.RoleAdmin, s.adminConfigHandler))
+
+	// Nível de log em tempo de execução, sem recarregar a configuração
+	s.router.HandleFunc("/api/admin/log-level", auth.RequireRole(auth.RoleAdmin, s.adminLogLevelHandler))
+
 	// Static assets (opcional)
 	fs := http.FileServer(http.Dir("./static"))
 	s.router.Handle("/", fs)
 
-	// Middleware para logging e CORS
+	// Middleware para logging, CORS e autenticação
 	s.wrapWithMiddleware()
 }
 
 // healthHandler responde com o status de saúde do servidor
 func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	cfg := s.currentConfig()
 
 	// Verificar status dos serviços
 	radarStatus := "ok"
@@ -56,7 +86,7 @@ func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	plcStatus := "disabled"
-	if s.config.PLC.Enabled {
+	if cfg.PLC.Enabled {
 		if s.plcService != nil && s.plcService.IsRunning() {
 			plcStatus = "ok"
 		} else {
@@ -64,6 +94,33 @@ func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	modbusStatus := "disabled"
+	if cfg.Modbus.Enabled {
+		if s.modbusService != nil && s.modbusService.IsRunning() {
+			modbusStatus = "ok"
+		} else {
+			modbusStatus = "offline"
+		}
+	}
+
+	opcuaStatus := "disabled"
+	if cfg.OPCUA.Enabled {
+		if s.opcuaService != nil && s.opcuaService.IsRunning() {
+			opcuaStatus = "ok"
+		} else {
+			opcuaStatus = "offline"
+		}
+	}
+
+	mqttStatus := "disabled"
+	if cfg.MQTT.Enabled {
+		if s.mqttService != nil && s.mqttService.IsConnected() {
+			mqttStatus = "ok"
+		} else {
+			mqttStatus = "offline"
+		}
+	}
+
 	redisStatus := "ok"
 	if s.redisService != nil && !s.redisService.IsConnected() {
 		redisStatus = "offline"
@@ -82,6 +139,9 @@ func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
 			"radar":     radarStatus,
 			"redis":     redisStatus,
 			"plc":       plcStatus,
+			"modbus":    modbusStatus,
+			"opcua":     opcuaStatus,
+			"mqtt":      mqttStatus,
 			"websocket": "ok",
 			"discovery": discoveryStatus,
 		},
@@ -126,6 +186,7 @@ func (s *Server) infoHandler(w http.ResponseWriter, r *http.Request) {
 // serverInfoHandler retorna informações completas sobre o servidor
 func (s *Server) serverInfoHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	cfg := s.currentConfig()
 
 	// Obter informações do servidor
 	info := s.GetServerInfo()
@@ -158,20 +219,28 @@ func (s *Server) serverInfoHandler(w http.ResponseWriter, r *http.Request) {
 		"services": map[string]interface{}{
 			"radar": map[string]interface{}{
 				"running": s.radarService != nil && s.radarService.IsRunning(),
-				"host":    s.config.Radar.Host,
-				"port":    s.config.Radar.Port,
+				"host":    cfg.Radar.Host,
+				"port":    cfg.Radar.Port,
 			},
 			"redis": map[string]interface{}{
-				"enabled":   s.config.Redis.Enabled,
+				"enabled":   cfg.Redis.Enabled,
 				"connected": s.redisService != nil && s.redisService.IsConnected(),
-				"host":      s.config.Redis.Host,
-				"port":      s.config.Redis.Port,
+				"host":      cfg.Redis.Host,
+				"port":      cfg.Redis.Port,
 			},
 			"plc": map[string]interface{}{
-				"enabled": s.config.PLC.Enabled,
+				"enabled": cfg.PLC.Enabled,
 				"running": s.plcService != nil && s.plcService.IsRunning(),
-				"host":    s.config.PLC.Host,
+				"host":    cfg.PLC.Host,
+			},
+			"modbus": map[string]interface{}{
+				"enabled":   cfg.Modbus.Enabled,
+				"running":   s.modbusService != nil && s.modbusService.IsRunning(),
+				"port":      cfg.Modbus.Port,
+				"wordOrder": cfg.Modbus.WordOrder,
 			},
+			"opcua": s.opcuaInfo(),
+			"mqtt":  s.mqttInfo(),
 		},
 	}
 
@@ -196,26 +265,110 @@ func (s *Server) discoverHandler(w http.ResponseWriter, r *http.Request) {
 		"version":     info.Version,
 		"wsEndpoint":  "/ws",
 		"apiEndpoint": "/api",
+		"tls":         info.TLS,
+	}
+	if info.TLS {
+		// Os aplicativos fixam o certificado autoassinado por esta impressão digital
+		response["certFingerprint"] = info.Fingerprint
+		response["mutualTls"] = s.tlsConfig.ClientCAs != nil
 	}
 
 	// Enviar resposta
 	json.NewEncoder(w).Encode(response)
 }
 
+// plcReadHandler lê um endereço simbólico do PLC para diagnóstico
+// Uso: GET /api/plc/read?address=DB10.DBD4&type=float
+func (s *Server) plcReadHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Método não permitido"})
+		return
+	}
+
+	if !s.currentConfig().PLC.Enabled {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Serviço PLC desabilitado"})
+		return
+	}
+
+	address := r.URL.Query().Get("address")
+	dataType := r.URL.Query().Get("type")
+
+	parsed, err := plc.ParseAddress(address)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	value, err := s.plcService.ReadAddress(address, dataType)
+
+	// Leituras de diagnóstico acessam o PLC diretamente e ficam no registro
+	data := map[string]interface{}{"command": "read", "address": parsed.String(), "type": dataType}
+	if err != nil {
+		data["error"] = err.Error()
+	} else {
+		data["value"] = value
+	}
+	s.journal.Record(models.Event{
+		Type:    models.EventPLCCommand,
+		Actor:   auth.FromContext(r.Context()).String(),
+		Message: "Leitura de " + parsed.String() + " no PLC",
+		Data:    data,
+	})
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	// Construir resposta
+	response := map[string]interface{}{
+		"address":   parsed.String(),
+		"area":      parsed.Area.String(),
+		"type":      dataType,
+		"value":     value,
+		"timestamp": time.Now(),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
 // wrapWithMiddleware adiciona middleware às rotas
 func (s *Server) wrapWithMiddleware() {
 	originalHandler := s.router
 
 	s.router = http.NewServeMux()
 
-	// Adicionar middleware a todas as rotas
-	s.router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	// Logging da requisição, já com a identidade autenticada
+	logged := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
+		if identity := auth.FromContext(r.Context()); identity != nil {
+			logger.Infof("%s %s %s (%s)", r.Method, r.URL.Path, r.RemoteAddr, identity)
+		} else {
+			logger.Infof("%s %s %s", r.Method, r.URL.Path, r.RemoteAddr)
+		}
+
+		// Processar requisição pelo handler original
+		originalHandler.ServeHTTP(w, r)
+
+		// Logging do tempo de resposta
+		duration := time.Since(start)
+		logger.Debugf("Requisição %s %s completada em %v", r.Method, r.URL.Path, duration)
+	})
+	authenticated := s.authenticator.Middleware(logged)
+
+	// Adicionar middleware a todas as rotas, com contagem e latência por rota
+	s.router.Handle("/", instrument(originalHandler, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Adicionar cabeçalhos CORS
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		s.authenticator.SetCORSHeaders(w, r)
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, Last-Event-ID")
 
 		// Se for uma requisição OPTIONS, retornar imediatamente
 		if r.Method == "OPTIONS" {
@@ -223,14 +376,98 @@ func (s *Server) wrapWithMiddleware() {
 			return
 		}
 
-		// Logging da requisição
-		logger.Infof("%s %s %s", r.Method, r.URL.Path, r.RemoteAddr)
+		if requiresAuth(r.URL.Path) {
+			authenticated.ServeHTTP(w, r)
+			return
+		}
+		logged.ServeHTTP(w, r)
+	})))
+}
 
-		// Processar requisição pelo handler original
-		originalHandler.ServeHTTP(w, r)
+// requiresAuth indica as rotas protegidas: /api/*, /ws* e /metrics (com
+// velocidades, posições e conexões ao vivo). A descoberta, usada pelos
+// aplicativos antes do login, e as verificações de saúde continuam abertas.
+func requiresAuth(path string) bool {
+	switch path {
+	case "/api/discover", "/ws/health":
+		return false
+	case "/ws", "/metrics":
+		return true
+	}
+	return strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/ws/")
+}
 
-		// Logging do tempo de resposta
-		duration := time.Since(start)
-		logger.Debugf("Requisição %s %s completada em %v", r.Method, r.URL.Path, duration)
+// whoamiHandler retorna a identidade da requisição
+func (s *Server) whoamiHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"identity":    auth.FromContext(r.Context()),
+		"authEnabled": s.authenticator.Enabled(),
+	})
+}
+
+// opcuaInfo retorna o estado do servidor OPC UA para /api/server-info
+func (s *Server) opcuaInfo() map[string]interface{} {
+	cfg := s.currentConfig()
+	info := map[string]interface{}{
+		"enabled": cfg.OPCUA.Enabled,
+		"running": false,
+	}
+	if s.opcuaService != nil {
+		info["running"] = s.opcuaService.IsRunning()
+		info["endpoint"] = s.opcuaService.EndpointURL()
+		info["sessions"] = s.opcuaService.SessionCount()
+	}
+	return info
+}
+
+// mqttInfo retorna o estado do publicador MQTT para /api/server-info
+func (s *Server) mqttInfo() map[string]interface{} {
+	cfg := s.currentConfig()
+	info := map[string]interface{}{
+		"enabled":   cfg.MQTT.Enabled,
+		"broker":    cfg.MQTT.Broker,
+		"sparkplug": cfg.MQTT.Sparkplug.Enabled,
+		"connected": false,
+	}
+	if s.mqttService != nil {
+		info["connected"] = s.mqttService.IsConnected()
+		info["buffered"] = s.mqttService.BufferedCount()
+	}
+	return info
+}
+
+// adminConfigHandler exibe a configuração ativa (segredos mascarados) e o
+// resultado da última recarga. POST recarrega a configuração.
+func (s *Server) adminConfigHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodPost {
+		result := s.reload(ReloadAPI, auth.FromContext(r.Context()).String())
+		if !result.Success {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Método não permitido"})
+		return
+	}
+
+	active, err := s.currentConfig().MarshalReadable()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"config":     json.RawMessage(active),
+		"file":       config.File(),
+		"lastReload": s.LastReload(),
 	})
 }
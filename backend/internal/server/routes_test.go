@@ -0,0 +1,28 @@
+package server
+
+import "testing"
+
+func TestRequiresAuth(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/health", false},
+		{"/info", false},
+		{"/ws/health", false},
+		{"/api/discover", false},
+		{"/", false},
+		{"/index.html", false},
+		{"/metrics", true},
+		{"/ws", true},
+		{"/ws/other", true},
+		{"/api/current", true},
+		{"/api/admin/config", true},
+	}
+
+	for _, tt := range tests {
+		if got := requiresAuth(tt.path); got != tt.want {
+			t.Errorf("requiresAuth(%q) = %v, esperado %v", tt.path, got, tt.want)
+		}
+	}
+}
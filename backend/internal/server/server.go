@@ -2,16 +2,24 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 
+	"radar_go/internal/auth"
 	"radar_go/internal/config"
 	"radar_go/internal/discovery"
+	"radar_go/internal/events"
+	"radar_go/internal/modbus"
+	"radar_go/internal/mqtt"
+	"radar_go/internal/opcua"
 	"radar_go/internal/plc"
 	"radar_go/internal/radar"
 	"radar_go/internal/redis"
+	"radar_go/internal/version"
 	"radar_go/internal/websocket"
 	"radar_go/pkg/logger"
 )
@@ -24,9 +32,21 @@ type Server struct {
 	radarService     *radar.Service
 	redisService     *redis.Service
 	plcService       *plc.PLCService
+	modbusService    *modbus.Service
+	opcuaService     *opcua.Service
+	mqttService      *mqtt.Service
 	wsHub            *websocket.Hub
 	discoveryService *discovery.DiscoveryService
+	journal          *events.Journal // nil com o registro de eventos desabilitado
+	authenticator    *auth.Authenticator
+	tlsConfig        *tls.Config
 	serverInfo       ServerInfo
+
+	// Recarga da configuração (ver reload.go)
+	mutex       sync.RWMutex // Protege config e lastReload
+	reloadMutex sync.Mutex   // Uma recarga por vez
+	lastReload  *ReloadResult
+	watchStop   chan struct{}
 }
 
 // ServerInfo contém informações sobre o servidor
@@ -38,17 +58,20 @@ type ServerInfo struct {
 	Version      string
 	WebSocketURL string
 	APIURL       string
+	TLS          bool
+	Fingerprint  string // SHA-256 do certificado TLS, para fixação pelos clientes
 }
 
 // NewServer cria uma nova instância do servidor
 func NewServer(cfg *config.Config) (*Server, error) {
 	// Criar instância do servidor
 	server := &Server{
-		config: cfg,
-		router: http.NewServeMux(),
+		config:    cfg,
+		router:    http.NewServeMux(),
+		watchStop: make(chan struct{}),
 		serverInfo: ServerInfo{
 			StartTime: time.Now(),
-			Version:   "1.0.0",
+			Version:   version.Version,
 			Port:      cfg.Server.Port,
 		},
 	}
@@ -60,9 +83,22 @@ func NewServer(cfg *config.Config) (*Server, error) {
 	}
 	server.serverInfo.IP = ip
 
+	// Certificado HTTPS/WSS (autoassinado no primeiro boot, se necessário)
+	wsScheme, httpScheme := "ws", "http"
+	if cfg.Server.TLS.Enabled {
+		tlsConfig, fingerprint, err := loadTLSConfig(cfg.Server.TLS, ip)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao configurar TLS: %w", err)
+		}
+		server.tlsConfig = tlsConfig
+		server.serverInfo.TLS = true
+		server.serverInfo.Fingerprint = fingerprint
+		wsScheme, httpScheme = "wss", "https"
+	}
+
 	// Configurar URLs
-	server.serverInfo.WebSocketURL = fmt.Sprintf("ws://%s:%d/ws", ip, cfg.Server.Port)
-	server.serverInfo.APIURL = fmt.Sprintf("http://%s:%d/api", ip, cfg.Server.Port)
+	server.serverInfo.WebSocketURL = fmt.Sprintf("%s://%s:%d/ws", wsScheme, ip, cfg.Server.Port)
+	server.serverInfo.APIURL = fmt.Sprintf("%s://%s:%d/api", httpScheme, ip, cfg.Server.Port)
 
 	// Inicializar componentes
 	if err := server.initComponents(); err != nil {
@@ -79,6 +115,7 @@ func NewServer(cfg *config.Config) (*Server, error) {
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 		IdleTimeout:  120 * time.Second,
+		TLSConfig:    server.tlsConfig,
 	}
 
 	return server, nil
@@ -86,8 +123,22 @@ func NewServer(cfg *config.Config) (*Server, error) {
 
 // initComponents inicializa todos os componentes do servidor
 func (s *Server) initComponents() error {
+	// Autenticação de /api e /ws
+	authenticator, err := auth.NewAuthenticator(s.config.Auth)
+	if err != nil {
+		return fmt.Errorf("configuração de autenticação inválida: %w", err)
+	}
+	s.authenticator = authenticator
+	if !authenticator.Enabled() {
+		logger.Warn("Autenticação desabilitada: /api e /ws aceitam qualquer cliente")
+	}
+
 	// Inicializar hub WebSocket
 	s.wsHub = websocket.NewHub()
+	if err := s.wsHub.Configure(s.config.WebSocket); err != nil {
+		return fmt.Errorf("configuração WebSocket inválida: %w", err)
+	}
+	s.wsHub.SetRadarID(s.config.Radar.ID)
 	go s.wsHub.Run()
 
 	// Inicializar serviço Redis
@@ -97,23 +148,78 @@ func (s *Server) initComponents() error {
 	}
 	s.redisService = redisService
 
+	// Inicializar registro de eventos (Redis Streams, com arquivo de fallback)
+	if s.config.Events.Enabled {
+		journal, err := events.NewJournal(s.config.Events, s.redisService)
+		if err != nil {
+			return fmt.Errorf("erro ao inicializar registro de eventos: %w", err)
+		}
+		s.journal = journal
+		s.journal.SetRadarID(s.config.Radar.ID)
+
+		// Eventos gravados são enviados no tópico "events" do WebSocket
+		s.journal.RegisterHandler(s.wsHub.BroadcastEvent)
+		s.wsHub.SetJournal(s.journal)
+	}
+
 	// Inicializar serviço do Radar
 	radarService, err := radar.NewService(s.config.Radar, s.redisService, s.wsHub)
 	if err != nil {
 		return fmt.Errorf("erro ao inicializar serviço do Radar: %w", err)
 	}
 	s.radarService = radarService
+	s.radarService.SetJournal(s.journal)
 
-	// Inicializar serviço do PLC (se habilitado)
-	if s.config.PLC.Enabled {
-		s.plcService = plc.NewPLCService(s.config.PLC)
+	// Respostas a get_history/get_status e snapshot inicial dos clientes WebSocket
+	s.wsHub.SetDataProvider(s.radarService)
 
-		// Registrar serviço PLC para receber atualizações do radar
-		s.radarService.RegisterMetricsHandler(s.plcService.UpdateMetrics)
+	// Inicializar serviço do PLC. Criado mesmo desabilitado, para que uma
+	// recarga da configuração possa habilitá-lo; só inicia se habilitado.
+	s.plcService = plc.NewPLCService(s.config.PLC)
+
+	// Registrar serviço PLC para receber atualizações do radar
+	s.radarService.RegisterMetricsHandler(s.plcService.UpdateMetrics)
+
+	// Inicializar servidor Modbus TCP (se habilitado)
+	if s.config.Modbus.Enabled {
+		modbusService, err := modbus.NewService(s.config.Modbus)
+		if err != nil {
+			return fmt.Errorf("erro ao inicializar serviço Modbus: %w", err)
+		}
+		s.modbusService = modbusService
+
+		// Mesmo hook usado pelo PLC
+		s.radarService.RegisterMetricsHandler(s.modbusService.UpdateMetrics)
+	}
+
+	// Inicializar servidor OPC UA (se habilitado)
+	if s.config.OPCUA.Enabled {
+		opcuaConfig := s.config.OPCUA
+		if opcuaConfig.Hostname == "" {
+			opcuaConfig.Hostname = s.serverInfo.IP
+		}
+		s.opcuaService = opcua.NewService(opcuaConfig, s.serverInfo.Version)
+
+		// Assinaturas OPC UA são alimentadas pelo mesmo hook do PLC
+		s.radarService.RegisterMetricsHandler(s.opcuaService.UpdateMetrics)
+	}
+
+	// Inicializar publicador MQTT (se habilitado)
+	if s.config.MQTT.Enabled {
+		mqttService, err := mqtt.NewService(s.config.MQTT)
+		if err != nil {
+			return fmt.Errorf("erro ao inicializar serviço MQTT: %w", err)
+		}
+		s.mqttService = mqttService
+
+		s.radarService.RegisterMetricsHandler(s.mqttService.UpdateMetrics)
 	}
 
 	// Inicializar serviço de descoberta
 	s.discoveryService = discovery.NewDiscoveryService(s.config.Server.Port)
+	if s.serverInfo.TLS {
+		s.discoveryService.SetTLSFingerprint(s.serverInfo.Fingerprint)
+	}
 
 	return nil
 }
@@ -126,6 +232,13 @@ func (s *Server) Start() error {
 		// Não abortar operação se falhar
 	}
 
+	// Iniciar registro de eventos antes dos serviços que o alimentam
+	if s.journal != nil {
+		if err := s.journal.Start(); err != nil {
+			return fmt.Errorf("erro ao iniciar registro de eventos: %w", err)
+		}
+	}
+
 	// Iniciar serviço do Radar
 	if err := s.radarService.Start(); err != nil {
 		return fmt.Errorf("erro ao iniciar serviço do Radar: %w", err)
@@ -139,12 +252,48 @@ func (s *Server) Start() error {
 		}
 	}
 
+	// Iniciar servidor Modbus (se habilitado)
+	if s.modbusService != nil {
+		if err := s.modbusService.Start(); err != nil {
+			logger.Errorf("Erro ao iniciar serviço Modbus: %v", err)
+			// Não abortar se o Modbus falhar
+		}
+	}
+
+	// Iniciar servidor OPC UA (se habilitado)
+	if s.opcuaService != nil {
+		if err := s.opcuaService.Start(); err != nil {
+			logger.Errorf("Erro ao iniciar serviço OPC UA: %v", err)
+			// Não abortar se o OPC UA falhar
+		}
+	}
+
+	// Iniciar publicador MQTT (se habilitado)
+	if s.mqttService != nil {
+		if err := s.mqttService.Start(); err != nil {
+			logger.Errorf("Erro ao iniciar serviço MQTT: %v", err)
+			// Não abortar se o MQTT falhar
+		}
+	}
+
+	// Recarregar a configuração quando o arquivo mudar
+	if s.config.Server.WatchInterval > 0 {
+		go s.watchConfigFile(s.config.Server.WatchInterval)
+	}
+
 	// Mostrar informações do servidor
 	s.logServerInfo()
 
-	// Iniciar servidor HTTP
-	logger.Infof("Iniciando servidor HTTP na porta %d", s.config.Server.Port)
-	if err := s.httpServer.ListenAndServe(); err != http.ErrServerClosed {
+	// Iniciar servidor HTTP (HTTPS com TLS habilitado; o certificado já está em TLSConfig)
+	var err error
+	if s.tlsConfig != nil {
+		logger.Infof("Iniciando servidor HTTPS na porta %d", s.config.Server.Port)
+		err = s.httpServer.ListenAndServeTLS("", "")
+	} else {
+		logger.Infof("Iniciando servidor HTTP na porta %d", s.config.Server.Port)
+		err = s.httpServer.ListenAndServe()
+	}
+	if err != http.ErrServerClosed {
 		return fmt.Errorf("erro ao iniciar servidor HTTP: %w", err)
 	}
 
@@ -160,6 +309,9 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		logger.Errorf("Erro ao encerrar servidor HTTP: %v", err)
 	}
 
+	// Parar a verificação do arquivo de configuração
+	close(s.watchStop)
+
 	// Encerrar serviço de descoberta
 	if s.discoveryService != nil {
 		s.discoveryService.Stop()
@@ -174,6 +326,21 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		s.plcService.Shutdown()
 	}
 
+	if s.modbusService != nil {
+		s.modbusService.Stop()
+	}
+
+	if s.opcuaService != nil {
+		s.opcuaService.Stop()
+	}
+
+	if s.mqttService != nil {
+		s.mqttService.Stop()
+	}
+
+	// Gravar os eventos pendentes antes de encerrar o hub e o Redis
+	s.journal.Stop()
+
 	if s.wsHub != nil {
 		s.wsHub.Shutdown()
 	}
@@ -222,6 +389,12 @@ func (s *Server) logServerInfo() {
 	logger.Infof("Porta HTTP: %d", s.serverInfo.Port)
 	logger.Infof("WebSocket URL: %s", s.serverInfo.WebSocketURL)
 	logger.Infof("API URL: %s", s.serverInfo.APIURL)
+	if s.serverInfo.TLS {
+		logger.Infof("Certificado TLS (SHA-256): %s", s.serverInfo.Fingerprint)
+		if s.tlsConfig.ClientCAs != nil {
+			logger.Info("TLS mútuo: certificado de cliente obrigatório")
+		}
+	}
 	logger.Infof("mDNS: %s.%s.%s",
 		s.discoveryService.GetInstanceName(),
 		discovery.ServiceType,
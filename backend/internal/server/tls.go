@@ -0,0 +1,156 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"radar_go/internal/config"
+	"radar_go/pkg/logger"
+)
+
+// Validade do certificado autoassinado
+const selfSignedValidity = 5 * 365 * 24 * time.Hour
+
+// loadTLSConfig carrega o certificado do servidor (gerando um autoassinado no
+// primeiro boot, se habilitado) e a CA de clientes para TLS mútuo. Retorna
+// também a impressão digital SHA-256 do certificado.
+func loadTLSConfig(cfg config.TLSConfig, ip string) (*tls.Config, string, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, "", fmt.Errorf("certFile e keyFile são obrigatórios com TLS habilitado")
+	}
+
+	if !fileExists(cfg.CertFile) || !fileExists(cfg.KeyFile) {
+		if !cfg.AutoGenerate {
+			return nil, "", fmt.Errorf("certificado TLS não encontrado: %s / %s", cfg.CertFile, cfg.KeyFile)
+		}
+		if err := generateSelfSigned(cfg.CertFile, cfg.KeyFile, certificateHosts(cfg.Hosts, ip)); err != nil {
+			return nil, "", fmt.Errorf("erro ao gerar certificado autoassinado: %w", err)
+		}
+		logger.Infof("Certificado TLS autoassinado gerado em %s", cfg.CertFile)
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("erro ao carregar certificado TLS: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.ClientCAFile != "" {
+		caPEM, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, "", fmt.Errorf("erro ao ler CA de clientes: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, "", fmt.Errorf("nenhum certificado válido em %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, certificateFingerprint(cert.Certificate[0]), nil
+}
+
+// certificateFingerprint formata o SHA-256 do certificado (DER) como
+// "AB:CD:...", o formato exibido pelos navegadores
+func certificateFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	hexSum := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	parts := make([]string, 0, len(sum))
+	for i := 0; i < len(hexSum); i += 2 {
+		parts = append(parts, hexSum[i:i+2])
+	}
+	return strings.Join(parts, ":")
+}
+
+// certificateHosts lista os nomes do certificado gerado: hostname, localhost,
+// IP local e os configurados
+func certificateHosts(extra []string, ip string) []string {
+	hosts := []string{"localhost", "127.0.0.1"}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		hosts = append(hosts, hostname, hostname+".local")
+	}
+	if ip != "" && ip != "localhost" {
+		hosts = append(hosts, ip)
+	}
+	return append(hosts, extra...)
+}
+
+// generateSelfSigned cria um certificado ECDSA P-256 autoassinado para hosts
+func generateSelfSigned(certFile, keyFile string, hosts []string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+
+	commonName, err := os.Hostname()
+	if err != nil || commonName == "" {
+		commonName = "radar_go"
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName, Organization: []string{"SICK Radar Monitor"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, host := range hosts {
+		if parsed := net.ParseIP(host); parsed != nil {
+			template.IPAddresses = append(template.IPAddresses, parsed)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range []string{certFile, keyFile} {
+		if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600)
+}
+
+// fileExists verifica se o arquivo existe
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
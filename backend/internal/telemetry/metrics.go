@@ -0,0 +1,189 @@
+// Package telemetry define as métricas Prometheus do serviço, expostas em
+// /metrics. Os serviços atualizam os coletores diretamente; o registro é
+// próprio (não o global do client_golang) para expor apenas o que é nosso.
+package telemetry
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prefixo de todas as métricas
+const namespace = "radar"
+
+// Buckets de operações rápidas (ciclo do radar, Redis, PLC): 0,5ms a ~2s
+var fastBuckets = prometheus.ExponentialBuckets(0.0005, 2, 13)
+
+// Registry contém todas as métricas do serviço
+var Registry = prometheus.NewRegistry()
+
+// Radar
+var (
+	// RadarCycleDuration mede cada ciclo de coleta (comando, decodificação e distribuição)
+	RadarCycleDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "cycle_duration_seconds",
+		Help:      "Duração do ciclo de coleta do radar.",
+		Buckets:   fastBuckets,
+	})
+
+	// RadarCommunicationErrors conta falhas de comunicação com o radar
+	RadarCommunicationErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "communication_errors_total",
+		Help:      "Falhas ao enviar o comando ou ler a resposta do radar.",
+	})
+
+	// RadarDecodeErrors conta erros de decodificação do telegrama por tipo
+	RadarDecodeErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "decode_errors_total",
+		Help:      "Erros de decodificação do telegrama, por tipo.",
+	}, []string{"type"})
+
+	// RadarConsecutiveErrors é o número atual de falhas consecutivas
+	RadarConsecutiveErrors = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "consecutive_errors",
+		Help:      "Falhas consecutivas de comunicação com o radar.",
+	})
+
+	// RadarVelocity é a última velocidade lida de cada canal (m/s)
+	RadarVelocity = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "velocity_meters_per_second",
+		Help:      "Última velocidade lida por canal.",
+	}, []string{"channel"})
+
+	// RadarPosition é a última posição lida de cada canal (m)
+	RadarPosition = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "position_meters",
+		Help:      "Última posição lida por canal.",
+	}, []string{"channel"})
+)
+
+// Redis
+var (
+	// RedisPipelineDuration mede a execução das pipelines de escrita, por operação
+	RedisPipelineDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "redis",
+		Name:      "pipeline_duration_seconds",
+		Help:      "Duração das pipelines de escrita no Redis.",
+		Buckets:   fastBuckets,
+	}, []string{"operation"})
+
+	// RedisPipelineFailures conta pipelines que falharam, por operação
+	RedisPipelineFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "redis",
+		Name:      "pipeline_failures_total",
+		Help:      "Pipelines de escrita no Redis que falharam.",
+	}, []string{"operation"})
+)
+
+// PLC
+var (
+	// PLCWriteDuration mede cada escrita em lote das métricas no PLC
+	PLCWriteDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "plc",
+		Name:      "write_duration_seconds",
+		Help:      "Duração da escrita em lote das métricas no PLC.",
+		Buckets:   fastBuckets,
+	})
+
+	// PLCWriteFailures conta falhas de escrita no PLC, por etapa
+	// ("connect", "write" ou "item")
+	PLCWriteFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "plc",
+		Name:      "write_failures_total",
+		Help:      "Falhas ao escrever as métricas no PLC, por etapa.",
+	}, []string{"stage"})
+)
+
+// WebSocket
+var (
+	// WebSocketClients é o número de clientes WebSocket conectados
+	WebSocketClients = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "websocket",
+		Name:      "clients",
+		Help:      "Clientes WebSocket conectados.",
+	})
+
+	// WebSocketMessagesSent conta mensagens escritas nas conexões
+	WebSocketMessagesSent = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "websocket",
+		Name:      "messages_sent_total",
+		Help:      "Mensagens escritas nas conexões WebSocket.",
+	})
+
+	// WebSocketMessagesDropped conta mensagens não entregues, por motivo
+	// ("queue_full", "coalesced" ou "downsampled")
+	WebSocketMessagesDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "websocket",
+		Name:      "messages_dropped_total",
+		Help:      "Mensagens descartadas pela política de backpressure, por motivo.",
+	}, []string{"reason"})
+)
+
+// HTTP
+var (
+	// HTTPRequests conta requisições por rota, método e código de status
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Requisições HTTP por rota, método e código de status.",
+	}, []string{"route", "method", "code"})
+
+	// HTTPRequestDuration mede o tempo de resposta por rota e método
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Tempo de resposta HTTP por rota e método (exceto conexões contínuas).",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		RadarCycleDuration,
+		RadarCommunicationErrors,
+		RadarDecodeErrors,
+		RadarConsecutiveErrors,
+		RadarVelocity,
+		RadarPosition,
+		RedisPipelineDuration,
+		RedisPipelineFailures,
+		PLCWriteDuration,
+		PLCWriteFailures,
+		WebSocketClients,
+		WebSocketMessagesSent,
+		WebSocketMessagesDropped,
+		HTTPRequests,
+		HTTPRequestDuration,
+	)
+}
+
+// Handler retorna o handler HTTP de /metrics (formato texto do Prometheus)
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Channel formata o índice (0-6) de um canal do radar como label ("1" a "7")
+func Channel(index int) string {
+	return strconv.Itoa(index + 1)
+}
@@ -0,0 +1,46 @@
+// Package version identifica a versão do binário
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Preenchidos na compilação, ex.:
+//
+//	go build -ldflags "-X radar_go/internal/version.Version=1.1.0 -X radar_go/internal/version.Commit=$(git rev-parse --short HEAD)"
+var (
+	Version   = "1.0.0"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// String retorna a versão com commit, data de compilação e plataforma
+func String() string {
+	commit, date := Commit, BuildDate
+
+	// Sem -ldflags, usar as informações de VCS gravadas pelo go build
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && commit == "":
+				commit = setting.Value
+				if len(commit) > 12 {
+					commit = commit[:12]
+				}
+			case setting.Key == "vcs.time" && date == "":
+				date = setting.Value
+			}
+		}
+	}
+
+	if commit == "" {
+		commit = "desconhecido"
+	}
+	if date == "" {
+		date = "desconhecida"
+	}
+	return fmt.Sprintf("%s (commit %s, compilado em %s, %s %s/%s)",
+		Version, commit, date, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
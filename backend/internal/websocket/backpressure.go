@@ -0,0 +1,255 @@
+package websocket
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"radar_go/internal/config"
+	"radar_go/internal/telemetry"
+)
+
+// Políticas aplicadas quando a fila de envio de um cliente enche
+const (
+	// Descarta a mensagem mais antiga da fila para abrir espaço
+	BackpressureDropOldest = "drop_oldest"
+	// Guarda apenas as métricas mais recentes, enviadas quando a fila esvaziar
+	BackpressureCoalesce = "coalesce"
+	// Com a fila acima da metade, entrega apenas 1 de cada N métricas
+	BackpressureDownsample = "downsample"
+)
+
+// validBackpressure verifica se a política é suportada
+func validBackpressure(policy string) bool {
+	switch policy {
+	case BackpressureDropOldest, BackpressureCoalesce, BackpressureDownsample:
+		return true
+	}
+	return false
+}
+
+// validateWebSocketConfig verifica as configurações de backpressure
+func validateWebSocketConfig(cfg config.WebSocketConfig) error {
+	if !validBackpressure(cfg.Backpressure) {
+		return fmt.Errorf("política de backpressure inválida: %q", cfg.Backpressure)
+	}
+	if cfg.SendBufferSize < 2 {
+		return fmt.Errorf("sendBufferSize deve ser no mínimo 2")
+	}
+	if cfg.DownsampleFactor < 1 {
+		return fmt.Errorf("downsampleFactor deve ser no mínimo 1")
+	}
+	if cfg.StallTimeout <= 0 {
+		return fmt.Errorf("stallTimeout deve ser positivo")
+	}
+	return nil
+}
+
+// QueueStats são as métricas da fila de envio de um cliente
+type QueueStats struct {
+	ClientID     string     `json:"clientId"`
+	IPAddress    string     `json:"ipAddress"`
+	Encoding     string     `json:"encoding"`
+	Policy       string     `json:"policy"`
+	Depth        int        `json:"depth"`    // Mensagens aguardando envio
+	Capacity     int        `json:"capacity"` // Tamanho da fila
+	MaxDepth     int        `json:"maxDepth"` // Maior profundidade observada
+	Sent         uint64     `json:"sent"`
+	Dropped      uint64     `json:"dropped"`
+	Coalesced    uint64     `json:"coalesced"`
+	Downsampled  uint64     `json:"downsampled"`
+	LastWrite    time.Time  `json:"lastWrite"`
+	StalledSince *time.Time `json:"stalledSince,omitempty"` // Fila parada desde, se houver
+}
+
+// sendQueue guarda a política e os contadores da fila de envio de um cliente.
+// É alterada pelo hub (enfileiramento), pelo writePump (escrita) e pelo
+// readPump (troca de política).
+type sendQueue struct {
+	mu sync.Mutex
+
+	policy string
+
+	// Métricas mais recentes aguardando a fila esvaziar (coalesce)
+	pending []byte
+
+	// Contador do downsample
+	skip int
+
+	// Houve descarte desde a última métrica compacta enviada
+	resync bool
+
+	maxDepth    int
+	sent        uint64
+	dropped     uint64
+	coalesced   uint64 // Métricas substituídas por outras mais recentes
+	downsampled uint64
+
+	// Última escrita concluída e início da espera da mensagem mais antiga
+	lastWrite    time.Time
+	waitingSince time.Time
+}
+
+// newSendQueue cria o estado da fila com a política informada
+func newSendQueue(policy string) *sendQueue {
+	return &sendQueue{policy: policy, lastWrite: time.Now()}
+}
+
+// getPolicy retorna a política atual
+func (q *sendQueue) getPolicy() string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.policy
+}
+
+// setPolicy altera a política; ao sair de coalesce as métricas pendentes
+// continuam sendo entregues
+func (q *sendQueue) setPolicy(policy string) error {
+	if !validBackpressure(policy) {
+		return fmt.Errorf("política de backpressure inválida: %q", policy)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.policy = policy
+	q.skip = 0
+	return nil
+}
+
+// enqueued registra uma mensagem enfileirada e a profundidade resultante
+func (q *sendQueue) enqueued(depth int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if depth > q.maxDepth {
+		q.maxDepth = depth
+	}
+	if q.waitingSince.IsZero() {
+		q.waitingSince = time.Now()
+	}
+}
+
+// drop registra mensagens descartadas
+func (q *sendQueue) drop(n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.dropped += uint64(n)
+	q.resync = true
+	telemetry.WebSocketMessagesDropped.WithLabelValues("queue_full").Add(float64(n))
+}
+
+// takeResync informa (e limpa) se houve descarte desde a última consulta
+func (q *sendQueue) takeResync() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	resync := q.resync
+	q.resync = false
+	return resync
+}
+
+// downsample decide se uma métrica deve ser descartada pelo downsample
+func (q *sendQueue) downsample(factor int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.skip++
+	if q.skip >= factor {
+		q.skip = 0
+		return false
+	}
+	q.downsampled++
+	telemetry.WebSocketMessagesDropped.WithLabelValues("downsampled").Inc()
+	return true
+}
+
+// setPending substitui as métricas pendentes; retorna se havia outras pendentes
+func (q *sendQueue) setPending(data []byte) (replaced bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	replaced = q.pending != nil
+	if replaced {
+		q.coalesced++
+		telemetry.WebSocketMessagesDropped.WithLabelValues("coalesced").Inc()
+	}
+	if q.waitingSince.IsZero() {
+		q.waitingSince = time.Now()
+	}
+	q.pending = data
+	return replaced
+}
+
+// hasPending verifica se há métricas pendentes
+func (q *sendQueue) hasPending() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pending != nil
+}
+
+// takePending retorna e limpa as métricas pendentes
+func (q *sendQueue) takePending() []byte {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	data := q.pending
+	q.pending = nil
+	return data
+}
+
+// written registra mensagens escritas na conexão e quantas ainda aguardam
+func (q *sendQueue) written(n int, remaining int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.sent += uint64(n)
+	telemetry.WebSocketMessagesSent.Add(float64(n))
+	q.lastWrite = time.Now()
+	if remaining == 0 && q.pending == nil {
+		q.waitingSince = time.Time{}
+	}
+}
+
+// stalledSince retorna desde quando há mensagens aguardando sem nenhuma
+// escrita concluída, ou zero se a fila está vazia
+func (q *sendQueue) stalledSince(depth int) time.Time {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if (depth == 0 && q.pending == nil) || q.waitingSince.IsZero() {
+		return time.Time{}
+	}
+	if q.lastWrite.After(q.waitingSince) {
+		return q.lastWrite
+	}
+	return q.waitingSince
+}
+
+// queueStats monta as métricas da fila de um cliente
+func (c *Client) queueStats(stallTimeout time.Duration) QueueStats {
+	depth := len(c.send)
+	stalled := c.queue.stalledSince(depth)
+
+	q := c.queue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := QueueStats{
+		ClientID:    c.id,
+		IPAddress:   c.ipAddress,
+		Encoding:    c.encoding,
+		Policy:      q.policy,
+		Depth:       depth,
+		Capacity:    cap(c.send),
+		MaxDepth:    q.maxDepth,
+		Sent:        q.sent,
+		Dropped:     q.dropped,
+		Coalesced:   q.coalesced,
+		Downsampled: q.downsampled,
+		LastWrite:   q.lastWrite,
+	}
+	// Só é considerada parada após um intervalo sem escrita maior que o normal
+	if !stalled.IsZero() && time.Since(stalled) > stallTimeout/10 {
+		stats.StalledSince = &stalled
+	}
+	return stats
+}
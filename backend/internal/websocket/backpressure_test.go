@@ -0,0 +1,333 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"radar_go/internal/models"
+)
+
+// stalledClient cria um cliente cuja fila ninguém esvazia, como um cliente
+// que parou de ler
+func stalledClient(h *Hub, policy string, capacity int) *Client {
+	client := newTestClient(h)
+	client.send = make(chan []byte, capacity)
+	client.queue = newSendQueue(policy)
+	return client
+}
+
+// testMessage monta uma mensagem do hub numerada
+func testMessage(topic string, n int) *hubMessage {
+	return &hubMessage{
+		topic:   topic,
+		data:    []byte(fmt.Sprintf(`{"type":%q,"n":%d}`, topic, n)),
+		payload: models.MetricsMessage{WebSocketMessage: models.WebSocketMessage{Type: topic}, Velocities: [7]float64{float64(n)}},
+	}
+}
+
+// deliverAll entrega as mensagens como o loop do hub
+func deliverAll(h *Hub, client *Client, topic string, from, to int) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for n := from; n <= to; n++ {
+		h.deliver(client, testMessage(topic, n))
+	}
+}
+
+// queued esvazia a fila do cliente, listando as mensagens como "tópico:n"
+func queued(t *testing.T, client *Client) []string {
+	t.Helper()
+
+	var got []string
+	for len(client.send) > 0 {
+		got = append(got, describe(t, <-client.send))
+	}
+	return got
+}
+
+// describe identifica uma mensagem JSON de teste como "tópico:n"
+func describe(t *testing.T, data []byte) string {
+	t.Helper()
+
+	var message struct {
+		Type string `json:"type"`
+		N    int    `json:"n"`
+	}
+	if err := json.Unmarshal(data, &message); err != nil {
+		t.Fatalf("mensagem inválida: %s", data)
+	}
+	return fmt.Sprintf("%s:%d", message.Type, message.N)
+}
+
+func TestBackpressureDropOldest(t *testing.T) {
+	h := NewHub()
+	client := stalledClient(h, BackpressureDropOldest, 4)
+
+	// Métricas também são descartadas pela mais antiga
+	deliverAll(h, client, TopicStatus, 0, 5)
+	deliverAll(h, client, TopicMetrics, 0, 3)
+
+	stats := client.queueStats(h.settings.StallTimeout)
+	if stats.Dropped != 6 || stats.Depth != 4 || stats.MaxDepth != 4 || stats.Coalesced != 0 {
+		t.Errorf("estatísticas = %+v, esperado 6 descartadas e fila com 4", stats)
+	}
+	want := []string{"metrics:0", "metrics:1", "metrics:2", "metrics:3"}
+	if got := queued(t, client); !reflect.DeepEqual(got, want) {
+		t.Errorf("fila = %v, esperado %v", got, want)
+	}
+}
+
+func TestBackpressureCoalesce(t *testing.T) {
+	h := NewHub()
+	client := stalledClient(h, BackpressureCoalesce, 4)
+
+	// Com espaço, métricas entram na fila normalmente
+	deliverAll(h, client, TopicMetrics, 0, 1)
+	deliverAll(h, client, TopicStatus, 0, 1)
+
+	// Fila cheia: cada métrica substitui a pendente, sem descartar a fila
+	deliverAll(h, client, TopicMetrics, 2, 6)
+	if got := string(client.queue.takePending()); !strings.Contains(got, `"n":6`) {
+		t.Errorf("métrica pendente = %s, esperado a mais recente (6)", got)
+	}
+	deliverAll(h, client, TopicMetrics, 7, 7)
+
+	// Outros tópicos com a fila cheia descartam a mais antiga
+	deliverAll(h, client, TopicStatus, 2, 2)
+
+	stats := client.queueStats(h.settings.StallTimeout)
+	if stats.Coalesced != 4 || stats.Dropped != 1 {
+		t.Errorf("estatísticas = %+v, esperado 4 agregadas e 1 descartada", stats)
+	}
+	want := []string{"metrics:1", "status:0", "status:1", "status:2"}
+	if got := queued(t, client); !reflect.DeepEqual(got, want) {
+		t.Errorf("fila = %v, esperado %v", got, want)
+	}
+	if got := string(client.queue.takePending()); !strings.Contains(got, `"n":7`) {
+		t.Errorf("métrica pendente = %s, esperado 7", got)
+	}
+
+	// O cliente é avisado de que há métricas pendentes
+	select {
+	case <-client.wake:
+	default:
+		t.Error("writePump não foi acordado para as métricas pendentes")
+	}
+}
+
+func TestBackpressureDownsample(t *testing.T) {
+	h := NewHub()
+	h.settings.DownsampleFactor = 4
+	client := stalledClient(h, BackpressureDownsample, 16)
+
+	// Abaixo da metade da fila todas as métricas passam
+	deliverAll(h, client, TopicMetrics, 0, 3)
+	deliverAll(h, client, TopicStatus, 0, 3)
+
+	// A partir da metade, 1 de cada 4 métricas; os demais tópicos passam
+	deliverAll(h, client, TopicMetrics, 4, 11)
+	deliverAll(h, client, TopicStatus, 4, 4)
+
+	stats := client.queueStats(h.settings.StallTimeout)
+	if stats.Downsampled != 6 || stats.Dropped != 0 {
+		t.Errorf("estatísticas = %+v, esperado 6 amostradas e nenhuma descartada", stats)
+	}
+	want := []string{
+		"metrics:0", "metrics:1", "metrics:2", "metrics:3",
+		"status:0", "status:1", "status:2", "status:3",
+		"metrics:7", "metrics:11", "status:4",
+	}
+	if got := queued(t, client); !reflect.DeepEqual(got, want) {
+		t.Errorf("fila = %v, esperado %v", got, want)
+	}
+}
+
+func TestBackpressureKeyframeAfterDrop(t *testing.T) {
+	h := NewHub()
+	client := stalledClient(h, BackpressureDropOldest, 2)
+	client.encoding = EncodingCBOR
+
+	frames := func() []compactMetrics {
+		var got []compactMetrics
+		for len(client.send) > 0 {
+			var frame compactMetrics
+			if err := cborDecMode.Unmarshal(<-client.send, &frame); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, frame)
+		}
+		return got
+	}
+
+	deliverAll(h, client, TopicMetrics, 0, 1)
+	if got := frames(); len(got) != 2 || !got[0].Keyframe || got[1].Keyframe {
+		t.Fatalf("frames iniciais = %+v, esperado keyframe seguido de delta", got)
+	}
+
+	// Com descarte, o delta perderia a referência: o frame seguinte é completo
+	deliverAll(h, client, TopicMetrics, 2, 5)
+	got := frames()
+	if len(got) != 2 || !got[1].Keyframe || got[1].Mask != fullValueMask {
+		t.Errorf("frames após descarte = %+v, esperado o último como keyframe", got)
+	}
+}
+
+// waitFor aguarda a condição ou falha após timeout
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("tempo esgotado aguardando %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// serverConn retorna o lado do servidor de uma conexão WebSocket real
+func serverConn(t *testing.T) *websocket.Conn {
+	t.Helper()
+
+	conns := make(chan *websocket.Conn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conns <- conn
+	}))
+	t.Cleanup(server.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	select {
+	case conn := <-conns:
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	case <-time.After(5 * time.Second):
+		t.Fatal("upgrade não concluído")
+	}
+	return nil
+}
+
+func TestBackpressureDisconnectStalled(t *testing.T) {
+	h := NewHub()
+	h.settings.StallTimeout = 50 * time.Millisecond
+
+	stalled := stalledClient(h, BackpressureDropOldest, 2)
+	stalled.id = "parado"
+	stalled.conn = serverConn(t)
+	idle := stalledClient(h, BackpressureDropOldest, 2)
+	idle.id = "ocioso"
+	reading := stalledClient(h, BackpressureDropOldest, 2)
+	reading.id = "lendo"
+
+	deliverAll(h, stalled, TopicStatus, 0, 3)
+	deliverAll(h, reading, TopicStatus, 0, 0)
+
+	// Antes do stallTimeout ninguém é desconectado
+	h.disconnectStalledClients()
+	if h.ClientCount() != 3 {
+		t.Fatalf("%d clientes após verificação imediata, esperado 3", h.ClientCount())
+	}
+
+	time.Sleep(2 * h.settings.StallTimeout)
+	reading.queue.written(0, 1) // Escrita recente: a fila anda
+
+	h.disconnectStalledClients()
+	if h.ClientCount() != 2 || h.StalledDisconnects() != 1 {
+		t.Fatalf("%d clientes e %d desconexões, esperado 2 e 1", h.ClientCount(), h.StalledDisconnects())
+	}
+	if h.getClientByID("parado") != nil {
+		t.Error("cliente parado continua registrado")
+	}
+	for len(stalled.send) > 0 {
+		<-stalled.send
+	}
+	if _, ok := <-stalled.send; ok {
+		t.Error("fila do cliente parado não foi fechada")
+	}
+
+	// A conexão do cliente parado é encerrada
+	stalled.conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := stalled.conn.ReadMessage(); err == nil || strings.Contains(err.Error(), "timeout") {
+		t.Errorf("leitura após desconexão: %v, esperado conexão fechada", err)
+	}
+}
+
+func TestBackpressureStalledConnection(t *testing.T) {
+	h := NewHub()
+	cfg := h.BackpressureSettings()
+	cfg.SendBufferSize = 4
+	cfg.StallTimeout = 200 * time.Millisecond
+	if err := h.Configure(cfg); err != nil {
+		t.Fatal(err)
+	}
+	server := startServer(t, h)
+
+	// Um cliente para de ler; o outro continua
+	stalled, _, err := dial(t, server, "backpressure=drop_oldest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	healthy, _, err := dial(t, server, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitWelcome(t, healthy, false)
+	waitFor(t, 5*time.Second, "registro dos clientes", func() bool { return h.ClientCount() == 2 })
+
+	// Mensagens grandes enchem os buffers do TCP e a escrita para
+	payload := strings.Repeat("x", 256*1024)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200 && h.ClientCount() == 2; i++ {
+			h.broadcast <- &hubMessage{topic: TopicStatus, data: []byte(`{"type":"status","lastError":"` + payload + `"}`)}
+			for {
+				healthy.SetReadDeadline(time.Now().Add(5 * time.Second))
+				_, data, err := healthy.ReadMessage()
+				if err != nil {
+					return
+				}
+				if strings.Contains(string(data), `"status"`) {
+					break
+				}
+			}
+		}
+	}()
+
+	waitFor(t, 10*time.Second, "desconexão do cliente parado", func() bool { return h.ClientCount() == 1 })
+	<-done
+
+	// O cliente parado encontra a conexão encerrada depois do que já estava em trânsito
+	stalled.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err := stalled.ReadMessage(); err != nil {
+			if strings.Contains(err.Error(), "timeout") {
+				t.Errorf("conexão do cliente parado não encerrada: %v", err)
+			}
+			break
+		}
+	}
+
+	// O cliente saudável continua recebendo
+	h.broadcast <- &hubMessage{topic: TopicStatus, data: []byte(`{"type":"status","n":1}`)}
+	healthy.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, data, err := healthy.ReadMessage(); err != nil || !strings.Contains(string(data), `"n":1`) {
+		t.Errorf("cliente saudável após a desconexão: %s, %v", data, err)
+	}
+}
@@ -1,19 +1,22 @@
 package websocket
 
 import (
-	"bytes"
 	"encoding/json"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 
+	"radar_go/internal/auth"
 	"radar_go/internal/models"
 	"radar_go/pkg/logger"
 )
 
 const (
-	// Tempo permitido para escrever uma mensagem para o peer.
+	// Tempo permitido para escrever um ping para o peer. Mensagens de dados
+	// usam o stallTimeout do backpressure.
 	writeWait = 10 * time.Second
 
 	// Tempo permitido para ler a próxima mensagem do peer.
@@ -24,9 +27,6 @@ const (
 
 	// Tamanho máximo da mensagem permitido.
 	maxMessageSize = 512 * 1024 // 512KB
-
-	// Tamanho do buffer de canal para mensagens de saída.
-	sendBufferSize = 256
 )
 
 // Client representa uma conexão WebSocket individual
@@ -39,33 +39,78 @@ type Client struct {
 	// Buffer de mensagens para envio.
 	send chan []byte
 
+	// Política de backpressure e métricas da fila de envio
+	queue *sendQueue
+
+	// Sinaliza ao writePump que há métricas agregadas pendentes
+	wake chan struct{}
+
 	// ID único do cliente
 	id string
 
+	// Logger com os campos clientId e remote
+	log *logger.Logger
+
 	// Informações do cliente (IP, agente, etc.)
 	userAgent string
 	ipAddress string
 
+	// Identidade autenticada na conexão
+	identity *auth.Identity
+
 	// Timestamp da conexão
 	connectedAt time.Time
+
+	// Tópicos assinados e limite de taxa
+	sub *subscription
+
+	// Versão do protocolo negociada no hello (alterada apenas pelo readPump)
+	protocolVersion int
+
+	// Codificação negociada na conexão e estado delta das métricas
+	// compactas (alterado apenas pelo loop do hub)
+	encoding string
+	delta    metricsDelta
+
+	// Replay histórico em andamento, se houver
+	replay   *replaySession
+	replayMu sync.Mutex
 }
 
 // newClient cria um novo cliente WebSocket
-func newClient(hub *Hub, conn *websocket.Conn, userAgent, ipAddress string) *Client {
+func newClient(hub *Hub, conn *websocket.Conn, identity *auth.Identity, userAgent, ipAddress, encoding, backpressure string) *Client {
+	id := uuid.New().String()
 	return &Client{
 		hub:         hub,
 		conn:        conn,
-		send:        make(chan []byte, sendBufferSize),
-		id:          uuid.New().String(),
+		send:        make(chan []byte, hub.settings.SendBufferSize),
+		queue:       newSendQueue(backpressure),
+		wake:        make(chan struct{}, 1),
+		id:          id,
+		log:         hub.getLog().With("clientId", id, "remote", ipAddress),
 		userAgent:   userAgent,
 		ipAddress:   ipAddress,
+		identity:    identity,
 		connectedAt: time.Now(),
+		sub:         newSubscription(),
+
+		protocolVersion: ProtocolVersion,
+		encoding:        encoding,
 	}
 }
 
+// actor identifica o cliente no registro de eventos
+func (c *Client) actor() string {
+	if c.identity == nil {
+		return ""
+	}
+	return c.identity.String()
+}
+
 // readPump bombeia mensagens do WebSocket para o hub.
 func (c *Client) readPump() {
 	defer func() {
+		c.stopReplay()
 		c.hub.unregister <- c
 		c.conn.Close()
 	}()
@@ -78,16 +123,25 @@ func (c *Client) readPump() {
 	})
 
 	for {
-		_, message, err := c.conn.ReadMessage()
+		messageType, message, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err,
 				websocket.CloseGoingAway,
 				websocket.CloseAbnormalClosure) {
-				logger.Errorf("Erro de leitura WebSocket: %v", err)
+				c.log.Errorf("Erro de leitura WebSocket: %v", err)
 			}
 			break
 		}
 
+		// Comandos binários são aceitos em CBOR
+		if messageType == websocket.BinaryMessage {
+			if message, err = decodeCommand(message); err != nil {
+				c.log.Warnf("Mensagem binária inválida do cliente %s: %v", c.id, err)
+				c.sendError(commandRequest{}, ErrCodeInvalidFormat, "Formato de mensagem inválido", "", nil)
+				continue
+			}
+		}
+
 		// Processar a mensagem recebida
 		c.processIncomingMessage(message)
 	}
@@ -95,6 +149,11 @@ func (c *Client) readPump() {
 
 // writePump bombeia mensagens do hub para a conexão WebSocket.
 func (c *Client) writePump() {
+	messageType := websocket.TextMessage
+	if c.encoding != EncodingJSON {
+		messageType = websocket.BinaryMessage
+	}
+
 	ticker := time.NewTicker(pingPeriod)
 	defer func() {
 		ticker.Stop()
@@ -104,28 +163,25 @@ func (c *Client) writePump() {
 	for {
 		select {
 		case message, ok := <-c.send:
-			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
 				// O hub fechou o canal.
+				c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
 				return
 			}
 
-			w, err := c.conn.NextWriter(websocket.TextMessage)
-			if err != nil {
+			if err := c.writeMessages(messageType, message); err != nil {
 				return
 			}
-			w.Write(message)
-
-			// Adicionar mensagens na fila ao escritor atual
-			n := len(c.send)
-			for i := 0; i < n; i++ {
-				w.Write([]byte{'\n'})
-				w.Write(<-c.send)
+		case <-c.wake:
+			// Métricas agregadas só passam à frente quando a fila esvazia
+			if len(c.send) > 0 {
+				continue
 			}
-
-			if err := w.Close(); err != nil {
-				return
+			if pending := c.queue.takePending(); pending != nil {
+				if err := c.writeMessages(messageType, pending); err != nil {
+					return
+				}
 			}
 		case <-ticker.C:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
@@ -136,47 +192,123 @@ func (c *Client) writePump() {
 	}
 }
 
-// processIncomingMessage processa uma mensagem recebida do cliente
+// writeMessages escreve uma mensagem e, em texto, as demais já enfileiradas no
+// mesmo frame (frames binários não podem ser concatenados). Com a fila vazia,
+// as métricas agregadas pendentes seguem em seguida.
+func (c *Client) writeMessages(messageType int, message []byte) error {
+	for message != nil {
+		c.conn.SetWriteDeadline(time.Now().Add(c.hub.settings.StallTimeout))
+
+		w, err := c.conn.NextWriter(messageType)
+		if err != nil {
+			return err
+		}
+		w.Write(message)
+		count := 1
+
+		if messageType == websocket.TextMessage {
+			n := len(c.send)
+			for i := 0; i < n; i++ {
+				w.Write([]byte{'\n'})
+				w.Write(<-c.send)
+			}
+			count += n
+		}
+
+		if err := w.Close(); err != nil {
+			return err
+		}
+		c.queue.written(count, len(c.send))
+
+		message = nil
+		if len(c.send) == 0 {
+			message = c.queue.takePending()
+		}
+	}
+	return nil
+}
+
+// processIncomingMessage valida uma mensagem recebida do cliente e executa o comando.
+// Campos desconhecidos são ignorados, para que versões diferentes do aplicativo
+// continuem compatíveis.
 func (c *Client) processIncomingMessage(message []byte) {
-	// Decodificar mensagem JSON
 	var cmd models.CommandMessage
-	decoder := json.NewDecoder(bytes.NewReader(message))
-	decoder.DisallowUnknownFields() // Rejeitar campos desconhecidos
+	if err := json.Unmarshal(message, &cmd); err != nil || cmd.Type == "" {
+		c.log.Warnf("Mensagem inválida do cliente %s: %v", c.id, err)
+		c.sendError(commandRequest{ID: cmd.ID, Type: cmd.Type}, ErrCodeInvalidFormat,
+			"Formato de mensagem inválido", "", nil)
+		return
+	}
 
-	if err := decoder.Decode(&cmd); err != nil {
-		logger.Errorf("Erro ao decodificar mensagem do cliente %s: %v", c.id, err)
-		c.sendErrorMessage("invalid_format", "Formato de mensagem inválido")
+	req := commandRequest{Type: cmd.Type, ID: cmd.ID, Time: cmd.Time}
+
+	if cmd.Version > ProtocolVersion {
+		c.sendError(req, ErrCodeUnsupportedVersion,
+			fmt.Sprintf("Versão de protocolo não suportada: %d", cmd.Version), "v",
+			map[string]int{"protocolVersion": ProtocolVersion, "minProtocolVersion": MinProtocolVersion})
 		return
 	}
 
-	// Processar com base no tipo de comando
-	switch cmd.Type {
-	case "ping":
-		// Responder com pong
-		c.handlePing(cmd)
-	case "get_history":
-		// Processar solicitação de histórico
-		c.handleGetHistory(cmd)
-	case "get_status":
-		// Processar solicitação de status
-		c.handleGetStatus(cmd)
-	default:
-		// Encaminhar comando para o hub processar
-		c.hub.commands <- models.ClientCommand{
-			Command:  cmd.Type,
-			Params:   cmd.Params,
-			ClientID: c.id,
-		}
+	spec, ok := commandSpecs[cmd.Type]
+	if !ok || spec.Since > c.protocolVersion {
+		c.sendError(req, ErrCodeUnknownCommand, fmt.Sprintf("Comando desconhecido: %s", cmd.Type), "",
+			map[string]interface{}{"commands": commandNames()})
+		return
+	}
+
+	if !c.identity.Role.Allows(spec.Role) {
+		c.log.Warnf("Comando %s negado ao cliente %s (%s): requer %s", cmd.Type, c.id, c.identity, spec.Role)
+		c.sendError(req, ErrCodeForbidden, "Permissão insuficiente: requer papel "+spec.Role.String(), "",
+			map[string]string{"role": c.identity.Role.String(), "required": spec.Role.String()})
+		return
+	}
+
+	// Parâmetros omitidos equivalem a um objeto vazio
+	params := cmd.Params
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	if err := spec.Params.validate(params, "params"); err != nil {
+		c.sendError(req, ErrCodeInvalidParams, err.Error(), err.field, map[string]interface{}{"schema": spec.Params})
+		return
+	}
+	req.Params = params.(map[string]interface{})
+
+	spec.handle(c, req)
+}
+
+// handleHello negocia a versão do protocolo: vale a menor entre a do cliente e a do servidor
+func (c *Client) handleHello(req commandRequest) {
+	version := int(req.Params["version"].(float64))
+	if version < MinProtocolVersion {
+		c.sendError(req, ErrCodeUnsupportedVersion,
+			fmt.Sprintf("Versão de protocolo não suportada: %d", version), "params.version",
+			map[string]int{"protocolVersion": ProtocolVersion, "minProtocolVersion": MinProtocolVersion})
+		return
+	}
+	if version > ProtocolVersion {
+		version = ProtocolVersion
 	}
+	c.protocolVersion = version
+
+	info := protocolInfo(false)
+	info["protocolVersion"] = version
+	c.reply(models.WebSocketMessage{Type: "hello", Timestamp: time.Now(), Data: info, ReplyTo: req.ID})
+}
+
+// handleGetCapabilities envia a descrição completa do protocolo
+func (c *Client) handleGetCapabilities(req commandRequest) {
+	info := protocolInfo(true)
+	info["protocolVersion"] = c.protocolVersion
+	c.reply(models.WebSocketMessage{Type: "capabilities", Timestamp: time.Now(), Data: info, ReplyTo: req.ID})
 }
 
 // handlePing processa comandos de ping e envia um pong
-func (c *Client) handlePing(cmd models.CommandMessage) {
-	var ping models.PingMessage
-	if params, ok := cmd.Params.(map[string]interface{}); ok {
-		if timeVal, ok := params["time"].(float64); ok {
-			ping.Time = int64(timeVal)
-		}
+func (c *Client) handlePing(req commandRequest) {
+	// O aplicativo envia "time" fora de params
+	pingTime := req.Time
+	if timeVal, ok := req.Params["time"].(float64); ok {
+		pingTime = int64(timeVal)
 	}
 
 	// Responder com pong
@@ -184,59 +316,167 @@ func (c *Client) handlePing(cmd models.CommandMessage) {
 		WebSocketMessage: models.WebSocketMessage{
 			Type:      "pong",
 			Timestamp: time.Now(),
+			ReplyTo:   req.ID,
 		},
-		Time:       ping.Time,
+		Time:       pingTime,
 		ServerTime: time.Now().UnixNano() / int64(time.Millisecond),
 	}
-
-	if jsonMsg, err := serializeMessage(pong); err == nil {
-		c.send <- jsonMsg
-	}
+	c.reply(pong)
 }
 
 // handleGetHistory processa solicitações de histórico
-func (c *Client) handleGetHistory(cmd models.CommandMessage) {
-	// Implementar lógica para obter e enviar histórico
-	var index int
-	if params, ok := cmd.Params.(map[string]interface{}); ok {
-		if indexVal, ok := params["index"].(float64); ok {
-			index = int(indexVal)
-		}
-	}
-
+func (c *Client) handleGetHistory(req commandRequest) {
 	// Encaminhar solicitação para o hub
 	c.hub.commands <- models.ClientCommand{
 		Command:  "get_history",
-		Params:   map[string]interface{}{"index": index, "requestId": cmd.ID},
+		Params:   map[string]interface{}{"index": int(req.Params["index"].(float64)), "replyTo": req.ID},
 		ClientID: c.id,
 	}
 }
 
 // handleGetStatus processa solicitações de status
-func (c *Client) handleGetStatus(cmd models.CommandMessage) {
+func (c *Client) handleGetStatus(req commandRequest) {
 	// Encaminhar solicitação para o hub
 	c.hub.commands <- models.ClientCommand{
 		Command:  "get_status",
-		Params:   map[string]interface{}{"requestId": cmd.ID},
+		Params:   map[string]interface{}{"replyTo": req.ID},
 		ClientID: c.id,
 	}
 }
 
-// sendErrorMessage envia uma mensagem de erro para o cliente
-func (c *Client) sendErrorMessage(code string, message string) {
-	errorMsg := models.WebSocketMessage{
-		Type:      "error",
-		Timestamp: time.Now(),
-		Error:     message,
-		Data:      map[string]string{"code": code},
+// subscriptionParams são os parâmetros de subscribe, unsubscribe e set_rate_limit
+type subscriptionParams struct {
+	Topics  []string `json:"topics"`
+	MaxRate *float64 `json:"maxRate"` // Mensagens por segundo de métricas/canais (0 = sem limite)
+}
+
+// handleSubscription altera as assinaturas do cliente e confirma o estado resultante
+func (c *Client) handleSubscription(req commandRequest) {
+	var params subscriptionParams
+	raw, _ := json.Marshal(req.Params)
+	if err := json.Unmarshal(raw, &params); err != nil {
+		c.sendError(req, ErrCodeInvalidParams, "Parâmetros de assinatura inválidos", "params", nil)
+		return
 	}
 
-	if jsonMsg, err := serializeMessage(errorMsg); err == nil {
-		c.send <- jsonMsg
+	var err error
+	switch req.Type {
+	case "subscribe":
+		err = c.sub.subscribe(params.Topics)
+	case "unsubscribe":
+		err = c.sub.unsubscribe(params.Topics)
 	}
+	if err == nil && params.MaxRate != nil {
+		err = c.sub.setMaxRate(*params.MaxRate)
+	}
+	if err != nil {
+		c.sendError(req, ErrCodeInvalidSubscription, err.Error(), "params.topics", nil)
+		return
+	}
+
+	topics, radars, maxRate := c.sub.snapshot()
+	c.log.Debugf("Cliente %s: tópicos %v, radares %v, taxa máxima %.1f/s", c.id, topics, radars, maxRate)
+
+	c.reply(models.SubscriptionMessage{
+		WebSocketMessage: models.WebSocketMessage{
+			Type:      "subscriptions",
+			Timestamp: time.Now(),
+			ReplyTo:   req.ID,
+		},
+		Topics:  topics,
+		Radars:  radars,
+		MaxRate: maxRate,
+	})
+}
+
+// handleReplay inicia o replay do histórico gravado no Redis
+func (c *Client) handleReplay(req commandRequest) {
+	provider := c.hub.getProvider()
+	if provider == nil {
+		c.sendError(req, ErrCodeUnavailable, "Histórico indisponível", "", nil)
+		return
+	}
+
+	from, err := time.Parse(time.RFC3339, req.Params["from"].(string))
+	if err != nil {
+		c.sendError(req, ErrCodeInvalidParams, "Data inicial inválida (use RFC 3339)", "params.from", nil)
+		return
+	}
+
+	to := time.Now()
+	if value, ok := req.Params["to"].(string); ok {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			c.sendError(req, ErrCodeInvalidParams, "Data final inválida (use RFC 3339)", "params.to", nil)
+			return
+		}
+	}
+	if !from.Before(to) {
+		c.sendError(req, ErrCodeInvalidParams, "A data inicial deve ser anterior à final", "params.from", nil)
+		return
+	}
+
+	speed := 1.0
+	if value, ok := req.Params["speed"].(float64); ok {
+		speed = value
+	}
+
+	c.startReplay(newReplaySession(c.hub, c, provider, from, to, speed), req.ID)
+}
+
+// handleReplayControl pausa, retoma, reposiciona ou interrompe o replay
+func (c *Client) handleReplayControl(req commandRequest) {
+	ctl := replayControl{replyTo: req.ID}
+
+	switch req.Type {
+	case "replay_pause":
+		ctl.action = "pause"
+	case "replay_resume":
+		ctl.action = "resume"
+	case "replay_stop":
+		ctl.action = "stop"
+	case "replay_seek":
+		position, err := time.Parse(time.RFC3339, req.Params["position"].(string))
+		if err != nil {
+			c.sendError(req, ErrCodeInvalidParams, "Posição inválida (use RFC 3339)", "params.position", nil)
+			return
+		}
+		ctl.action = "seek"
+		ctl.position = position
+	}
+
+	if !c.controlReplay(ctl) {
+		c.sendError(req, ErrCodeReplayNotActive, "Nenhum replay em andamento", "", nil)
+	}
+}
+
+// handleSetBackpressure altera a política de backpressure do cliente
+func (c *Client) handleSetBackpressure(req commandRequest) {
+	policy := req.Params["policy"].(string)
+	if err := c.queue.setPolicy(policy); err != nil {
+		c.sendError(req, ErrCodeInvalidParams, err.Error(), "params.policy", nil)
+		return
+	}
+
+	c.log.Debugf("Cliente %s: backpressure %s", c.id, policy)
+	c.reply(models.WebSocketMessage{
+		Type:      "backpressure",
+		Timestamp: time.Now(),
+		Data:      map[string]string{"policy": policy},
+		ReplyTo:   req.ID,
+	})
+}
+
+// reply envia a resposta de um comando (com replyTo já preenchido)
+func (c *Client) reply(message interface{}) {
+	c.hub.sendToClient(c, message)
 }
 
-// serializeMessage serializa uma estrutura para JSON
-func serializeMessage(v interface{}) ([]byte, error) {
-	return json.Marshal(v)
+// sendError envia um erro estruturado em resposta a um comando
+func (c *Client) sendError(req commandRequest, code, message, field string, details interface{}) {
+	errorMsg := NewErrorMessage(message, code)
+	errorMsg.ReplyTo = req.ID
+	errorMsg.Command = req.Type
+	errorMsg.Field = field
+	errorMsg.Details = details
+	c.reply(errorMsg)
 }
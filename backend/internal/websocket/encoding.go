@@ -0,0 +1,181 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"radar_go/internal/models"
+)
+
+// Codificações de mensagem negociadas na conexão, pelo subprotocolo
+// WebSocket ("radar.json" / "radar.cbor") ou pelo parâmetro ?encoding=
+const (
+	EncodingJSON = "json" // Padrão: mensagens de texto JSON
+	EncodingCBOR = "cbor" // Mensagens binárias CBOR, com métricas compactas
+
+	subprotocolPrefix = "radar."
+)
+
+// Subprotocolos aceitos, em ordem de preferência do servidor
+var subprotocols = []string{subprotocolPrefix + EncodingCBOR, subprotocolPrefix + EncodingJSON}
+
+// Um frame completo de métricas é enviado a cada keyframeInterval frames, para
+// que o cliente se recupere de qualquer divergência
+const keyframeInterval = 50
+
+// Máscara com todos os valores do frame compacto (7 velocidades + 7 posições)
+const fullValueMask = 1<<(2*channelCount) - 1
+
+var (
+	cborEncMode cbor.EncMode
+	cborDecMode cbor.DecMode
+)
+
+func init() {
+	var err error
+
+	// Floats são reduzidos a float16 quando não há perda de precisão
+	cborEncMode, err = cbor.EncOptions{
+		ShortestFloat: cbor.ShortestFloat16,
+		Time:          cbor.TimeRFC3339Nano,
+	}.EncMode()
+	if err != nil {
+		panic(err)
+	}
+
+	cborDecMode, err = cbor.DecOptions{
+		DefaultMapType: reflect.TypeOf(map[string]interface{}{}),
+	}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// validEncoding verifica se a codificação é suportada
+func validEncoding(encoding string) bool {
+	return encoding == EncodingJSON || encoding == EncodingCBOR
+}
+
+// encodingFromSubprotocol extrai a codificação de um subprotocolo negociado
+func encodingFromSubprotocol(subprotocol string) string {
+	if len(subprotocol) > len(subprotocolPrefix) && subprotocol[:len(subprotocolPrefix)] == subprotocolPrefix {
+		return subprotocol[len(subprotocolPrefix):]
+	}
+	return ""
+}
+
+// compactMetrics é o frame de métricas das codificações binárias, serializado
+// como array: [tipo, radarId, timestamp (ms), seq, keyframe, máscara, valores, status].
+//
+// Os bits 0-6 da máscara indicam as velocidades 1-7 e os bits 7-13 as posições
+// 1-7 presentes em valores (float32, na ordem dos bits). Fora dos keyframes
+// seguem apenas os valores que mudaram desde o frame anterior, e status vai
+// vazio quando não mudou.
+type compactMetrics struct {
+	_         struct{} `cbor:",toarray"`
+	Type      string
+	RadarID   string
+	Timestamp int64
+	Seq       uint32
+	Keyframe  bool
+	Mask      uint16
+	Values    []float32
+	Status    string
+}
+
+// metricsDelta é o estado da codificação delta de um cliente
+type metricsDelta struct {
+	values [2 * channelCount]float32
+	status string
+	seq    uint32
+
+	// Força um keyframe no próximo frame (após descarte pelo backpressure)
+	forceKeyframe bool
+}
+
+// frame monta o próximo frame compacto e o estado resultante. O estado só
+// deve ser aplicado se o frame for de fato enfileirado para o cliente.
+func (d metricsDelta) frame(m models.MetricsMessage) (compactMetrics, metricsDelta) {
+	var current [2 * channelCount]float32
+	for i := 0; i < channelCount; i++ {
+		current[i] = float32(m.Velocities[i])
+		current[channelCount+i] = float32(m.Positions[i])
+	}
+
+	frame := compactMetrics{
+		Type:      "metrics",
+		RadarID:   m.RadarID,
+		Timestamp: m.Timestamp.UnixMilli(),
+		Seq:       d.seq,
+		Keyframe:  d.seq%keyframeInterval == 0 || d.forceKeyframe,
+	}
+
+	if frame.Keyframe {
+		frame.Mask = fullValueMask
+	} else {
+		for i, value := range current {
+			if value != d.values[i] {
+				frame.Mask |= 1 << i
+			}
+		}
+	}
+
+	frame.Values = make([]float32, 0, bits.OnesCount16(frame.Mask))
+	for i, value := range current {
+		if frame.Mask&(1<<i) != 0 {
+			frame.Values = append(frame.Values, value)
+		}
+	}
+
+	if frame.Keyframe || m.Status != d.status {
+		frame.Status = m.Status
+	}
+
+	return frame, metricsDelta{values: current, status: m.Status, seq: d.seq + 1}
+}
+
+// encode serializa uma mensagem na codificação do cliente
+func (c *Client) encode(message interface{}) ([]byte, error) {
+	if c.encoding == EncodingCBOR {
+		return cborEncMode.Marshal(message)
+	}
+	return json.Marshal(message)
+}
+
+// encodeHubMessage serializa uma mensagem do hub para o cliente. Retorna
+// também a função que confirma o envio (avança o estado delta das métricas).
+func (c *Client) encodeHubMessage(msg *hubMessage) ([]byte, func(), error) {
+	if c.encoding == EncodingJSON {
+		return msg.data, nil, nil
+	}
+
+	if metrics, ok := msg.payload.(models.MetricsMessage); ok {
+		frame, next := c.delta.frame(metrics)
+		data, err := cborEncMode.Marshal(frame)
+		return data, func() { c.delta = next }, err
+	}
+
+	// Demais mensagens são iguais para todos os clientes CBOR
+	if msg.cbor == nil {
+		data, err := cborEncMode.Marshal(msg.payload)
+		if err != nil {
+			return nil, nil, err
+		}
+		msg.cbor = data
+	}
+	return msg.cbor, nil, nil
+}
+
+// decodeCommand converte um comando recebido em CBOR para JSON, reaproveitando
+// a validação dos comandos de texto
+func decodeCommand(data []byte) ([]byte, error) {
+	var command interface{}
+	if err := cborDecMode.Unmarshal(data, &command); err != nil {
+		return nil, fmt.Errorf("CBOR inválido: %w", err)
+	}
+	return json.Marshal(command)
+}
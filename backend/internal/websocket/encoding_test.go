@@ -0,0 +1,229 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"radar_go/internal/models"
+)
+
+// startServer inicia o hub e um servidor HTTP com o handler WebSocket
+func startServer(t *testing.T, h *Hub) *httptest.Server {
+	t.Helper()
+
+	startHub(t, h)
+	server := httptest.NewServer(NewHandler(h))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// dial conecta ao servidor de teste com os parâmetros e subprotocolos informados
+func dial(t *testing.T, server *httptest.Server, query string, subprotocols ...string) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	if query != "" {
+		url += "?" + query
+	}
+	dialer := websocket.Dialer{Subprotocols: subprotocols, HandshakeTimeout: 5 * time.Second}
+	conn, resp, err := dialer.Dial(url, nil)
+	if err == nil {
+		t.Cleanup(func() { conn.Close() })
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	}
+	return conn, resp, err
+}
+
+// readCBOR lê a próxima mensagem binária e a decodifica em v
+func readCBOR(t *testing.T, conn *websocket.Conn, v interface{}) {
+	t.Helper()
+
+	messageType, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("erro ao ler mensagem: %v", err)
+	}
+	if messageType != websocket.BinaryMessage {
+		t.Fatalf("mensagem de texto recebida em CBOR: %s", data)
+	}
+	if err := cborDecMode.Unmarshal(data, v); err != nil {
+		t.Fatalf("CBOR inválido (% X): %v", data, err)
+	}
+}
+
+// waitWelcome aguarda o registro do cliente e retorna a codificação anunciada
+func waitWelcome(t *testing.T, conn *websocket.Conn, binary bool) string {
+	t.Helper()
+
+	var welcome map[string]interface{}
+	if binary {
+		readCBOR(t, conn, &welcome)
+	} else {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("erro ao ler boas-vindas: %v", err)
+		}
+		if messageType != websocket.TextMessage {
+			t.Fatalf("boas-vindas binárias em JSON")
+		}
+		if err := json.Unmarshal(data, &welcome); err != nil {
+			t.Fatalf("boas-vindas inválidas: %s", data)
+		}
+	}
+	if welcome["type"] != "welcome" {
+		t.Fatalf("primeira mensagem %v, esperado welcome", welcome)
+	}
+	data, _ := welcome["data"].(map[string]interface{})
+	encoding, _ := data["encoding"].(string)
+	return encoding
+}
+
+func TestEncodingNegotiation(t *testing.T) {
+	server := startServer(t, NewHub())
+
+	tests := []struct {
+		name         string
+		query        string
+		subprotocols []string
+		subprotocol  string // Subprotocolo aceito pelo servidor
+		want         string
+	}{
+		{"padrão", "", nil, "", EncodingJSON},
+		{"subprotocolo CBOR", "", []string{"radar.cbor"}, "radar.cbor", EncodingCBOR},
+		{"subprotocolo JSON", "", []string{"radar.json"}, "radar.json", EncodingJSON},
+		{"preferência do servidor", "", []string{"radar.json", "radar.cbor"}, "radar.cbor", EncodingCBOR},
+		{"subprotocolo desconhecido", "", []string{"mqtt"}, "", EncodingJSON},
+		{"parâmetro", "encoding=cbor", nil, "", EncodingCBOR},
+		{"subprotocolo sobre o parâmetro", "encoding=cbor", []string{"radar.json"}, "radar.json", EncodingJSON},
+	}
+
+	for _, tt := range tests {
+		conn, _, err := dial(t, server, tt.query, tt.subprotocols...)
+		if err != nil {
+			t.Errorf("%s: erro ao conectar: %v", tt.name, err)
+			continue
+		}
+		if got := conn.Subprotocol(); got != tt.subprotocol {
+			t.Errorf("%s: subprotocolo %q, esperado %q", tt.name, got, tt.subprotocol)
+		}
+		if got := waitWelcome(t, conn, tt.want == EncodingCBOR); got != tt.want {
+			t.Errorf("%s: codificação anunciada %q, esperado %q", tt.name, got, tt.want)
+		}
+		conn.Close()
+	}
+
+	// Codificação desconhecida é recusada antes do upgrade
+	_, resp, err := dial(t, server, "encoding=msgpack")
+	if err == nil || resp == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("encoding=msgpack: erro %v, resposta %v; esperado 400", err, resp)
+	}
+}
+
+func TestCBORMetricsFrames(t *testing.T) {
+	h := NewHub()
+	h.SetRadarID("radar-1")
+	server := startServer(t, h)
+
+	conn, _, err := dial(t, server, "", "radar.cbor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitWelcome(t, conn, true)
+
+	// Comandos binários são aceitos em CBOR
+	command, err := cborEncMode.Marshal(map[string]interface{}{
+		"type": "subscribe", "id": "s1", "params": map[string]interface{}{"topics": []string{TopicMetrics}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.WriteMessage(websocket.BinaryMessage, command); err != nil {
+		t.Fatal(err)
+	}
+	var reply map[string]interface{}
+	readCBOR(t, conn, &reply)
+	if reply["type"] != "subscriptions" || reply["replyTo"] != "s1" {
+		t.Fatalf("resposta ao subscribe em CBOR: %v", reply)
+	}
+
+	samples := []models.RadarMetrics{
+		{Velocities: [7]float64{1.5, -0.25, 0, 0, 0, 0, 3}, Positions: [7]float64{10, 20, 30, 40, 50, 60, 70}, Status: "ok"},
+		{Velocities: [7]float64{1.5, -0.75, 0, 0, 0, 0, 3}, Positions: [7]float64{10, 20, 30, 40, 50, 60, 70}, Status: "ok"},
+		{Velocities: [7]float64{2.5, -0.75, 0, 0, 0, 0, 3}, Positions: [7]float64{10, 20, 30, 40, 50, 60, 72.5}, Status: "obstruido"},
+	}
+	want := []compactMetrics{
+		{Type: "metrics", RadarID: "radar-1", Seq: 0, Keyframe: true, Mask: fullValueMask,
+			Values: []float32{1.5, -0.25, 0, 0, 0, 0, 3, 10, 20, 30, 40, 50, 60, 70}, Status: "ok"},
+		{Type: "metrics", RadarID: "radar-1", Seq: 1, Mask: 1 << 1, Values: []float32{-0.75}},
+		{Type: "metrics", RadarID: "radar-1", Seq: 2, Mask: 1<<0 | 1<<13, Values: []float32{2.5, 72.5}, Status: "obstruido"},
+	}
+
+	for i, metrics := range samples {
+		h.BroadcastMetrics(metrics)
+
+		var frame compactMetrics
+		readCBOR(t, conn, &frame)
+		if frame.Timestamp == 0 {
+			t.Errorf("frame %d sem timestamp", i)
+		}
+		frame.Timestamp = 0
+		if !equalFrames(frame, want[i]) {
+			t.Errorf("frame %d = %+v, esperado %+v", i, frame, want[i])
+		}
+	}
+}
+
+func TestMetricsDeltaKeyframes(t *testing.T) {
+	var delta metricsDelta
+	metrics := models.MetricsMessage{Status: "ok"}
+
+	for i := 0; i <= keyframeInterval; i++ {
+		metrics.Velocities[0] = float64(i)
+		frame, next := delta.frame(metrics)
+
+		keyframe := i%keyframeInterval == 0
+		if frame.Keyframe != keyframe {
+			t.Fatalf("frame %d: keyframe %v, esperado %v", i, frame.Keyframe, keyframe)
+		}
+		if keyframe && (frame.Mask != fullValueMask || len(frame.Values) != 2*channelCount || frame.Status != "ok") {
+			t.Errorf("keyframe %d incompleto: %+v", i, frame)
+		}
+		if !keyframe && (frame.Mask != 1 || frame.Status != "") {
+			t.Errorf("frame %d: máscara %b, status %q", i, frame.Mask, frame.Status)
+		}
+		delta = next
+	}
+
+	// Após um descarte o próximo frame é completo, e a sequência continua
+	delta.forceKeyframe = true
+	frame, next := delta.frame(metrics)
+	if !frame.Keyframe || frame.Mask != fullValueMask || frame.Seq != keyframeInterval+1 {
+		t.Errorf("frame após descarte = %+v, esperado keyframe", frame)
+	}
+	if next.forceKeyframe {
+		t.Error("forceKeyframe mantido após o keyframe")
+	}
+
+	// Valores iguais: frame sem valores
+	if frame, _ := next.frame(metrics); frame.Mask != 0 || len(frame.Values) != 0 {
+		t.Errorf("frame sem mudanças = %+v", frame)
+	}
+}
+
+func equalFrames(a, b compactMetrics) bool {
+	if a.Type != b.Type || a.RadarID != b.RadarID || a.Timestamp != b.Timestamp || a.Seq != b.Seq ||
+		a.Keyframe != b.Keyframe || a.Mask != b.Mask || a.Status != b.Status || len(a.Values) != len(b.Values) {
+		return false
+	}
+	for i := range a.Values {
+		if a.Values[i] != b.Values[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -5,7 +5,7 @@ import (
 	"net/http"
 	"time"
 
-	"radar_go/pkg/logger"
+	"radar_go/internal/auth"
 
 	"github.com/gorilla/websocket"
 )
@@ -19,6 +19,8 @@ const (
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
+	// Subprotocolos que selecionam a codificação das mensagens
+	Subprotocols: subprotocols,
 	// CheckOrigin: Permite personalizar verificação de origem
 	CheckOrigin: checkOrigin,
 }
@@ -26,6 +28,9 @@ var upgrader = websocket.Upgrader{
 // Handler gerencia conexões WebSocket
 type Handler struct {
 	hub *Hub
+
+	// Verificação da origem no upgrade (nil = checkOrigin)
+	originCheck func(origin string) bool
 }
 
 // NewHandler cria um novo gerenciador de WebSocket
@@ -35,6 +40,11 @@ func NewHandler(hub *Hub) *Handler {
 	}
 }
 
+// SetOriginCheck define as origens aceitas no upgrade do WebSocket
+func (h *Handler) SetOriginCheck(check func(origin string) bool) {
+	h.originCheck = check
+}
+
 // ServeHTTP implementa a interface http.Handler
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.HandleWebSocket(w, r)
@@ -46,13 +56,53 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	upgrader.ReadBufferSize = 1024
 	upgrader.WriteBufferSize = 1024
 
+	// Codificação pedida por parâmetro, para clientes sem suporte a subprotocolos
+	encoding := r.URL.Query().Get("encoding")
+	if encoding != "" && !validEncoding(encoding) {
+		http.Error(w, "Codificação não suportada: "+encoding, http.StatusBadRequest)
+		return
+	}
+
+	// Política de backpressure do cliente (padrão da configuração)
+	backpressure := r.URL.Query().Get("backpressure")
+	if backpressure == "" {
+		backpressure = h.hub.settings.Backpressure
+	} else if !validBackpressure(backpressure) {
+		http.Error(w, "Política de backpressure não suportada: "+backpressure, http.StatusBadRequest)
+		return
+	}
+
+	// Identidade autenticada pelo middleware HTTP
+	identity := auth.FromContext(r.Context())
+	if identity == nil {
+		identity = &auth.Identity{Subject: "anonymous", Role: auth.RoleViewer, Method: auth.MethodNone}
+	}
+
 	// Fazer upgrade da conexão HTTP para WebSocket
-	conn, err := upgrader.Upgrade(w, r, nil)
+	connUpgrader := upgrader
+	if h.originCheck != nil {
+		connUpgrader.CheckOrigin = func(r *http.Request) bool {
+			if h.originCheck(r.Header.Get("Origin")) {
+				return true
+			}
+			h.hub.getLog().Warnf("Conexão WebSocket recusada para a origem %q (%s)", r.Header.Get("Origin"), getIPAddress(r))
+			return false
+		}
+	}
+	conn, err := connUpgrader.Upgrade(w, r, nil)
 	if err != nil {
-		logger.Errorf("Erro ao fazer upgrade para WebSocket: %v", err)
+		h.hub.getLog().Errorf("Erro ao fazer upgrade para WebSocket: %v", err)
 		return
 	}
 
+	// O subprotocolo negociado tem precedência sobre o parâmetro
+	if negotiated := encodingFromSubprotocol(conn.Subprotocol()); negotiated != "" {
+		encoding = negotiated
+	}
+	if encoding == "" {
+		encoding = EncodingJSON
+	}
+
 	// Configurar limites de tamanho de mensagem
 	conn.SetReadLimit(maxWebSocketMessageSize)
 
@@ -60,10 +110,11 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	userAgent := r.UserAgent()
 	ipAddress := getIPAddress(r)
 
-	logger.Infof("Nova conexão WebSocket de %s (%s)", ipAddress, userAgent)
+	h.hub.getLog().Infof("Nova conexão WebSocket de %s (%s) como %s, codificação %s, backpressure %s",
+		ipAddress, userAgent, identity, encoding, backpressure)
 
 	// Criar cliente
-	client := newClient(h.hub, conn, userAgent, ipAddress)
+	client := newClient(h.hub, conn, identity, userAgent, ipAddress, encoding, backpressure)
 
 	// Registrar cliente no hub
 	h.hub.register <- client
@@ -100,16 +151,64 @@ func (h *Handler) GetHealthHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
+		settings := h.hub.BackpressureSettings()
+		queues := h.hub.QueueStats()
+
+		// Totais das filas de envio
+		totals := struct {
+			Depth        int    `json:"depth"`
+			Sent         uint64 `json:"sent"`
+			Dropped      uint64 `json:"dropped"`
+			Coalesced    uint64 `json:"coalesced"`
+			Downsampled  uint64 `json:"downsampled"`
+			Stalled      int    `json:"stalled"`      // Clientes com a fila parada agora
+			Disconnected int64  `json:"disconnected"` // Desconectados por fila parada
+		}{Disconnected: h.hub.StalledDisconnects()}
+		for _, q := range queues {
+			totals.Depth += q.Depth
+			totals.Sent += q.Sent
+			totals.Dropped += q.Dropped
+			totals.Coalesced += q.Coalesced
+			totals.Downsampled += q.Downsampled
+			if q.StalledSince != nil {
+				totals.Stalled++
+			}
+		}
+
+		// Assinantes de /api/stream
+		sseClients, lastEventID, sseOverflows := h.hub.stream.stats()
+
 		// Preparar resposta de status
 		status := struct {
-			Status    string    `json:"status"`
-			Clients   int       `json:"clients"`
-			Timestamp time.Time `json:"timestamp"`
+			Status       string       `json:"status"`
+			Clients      int          `json:"clients"`
+			Backpressure interface{}  `json:"backpressure"`
+			Queues       interface{}  `json:"queues"`
+			ClientQueues []QueueStats `json:"clientQueues"`
+			Stream       interface{}  `json:"stream"`
+			Timestamp    time.Time    `json:"timestamp"`
 		}{
-			Status:    "ok",
-			Clients:   h.hub.ClientCount(),
+			Status:  "ok",
+			Clients: len(queues),
+			Backpressure: map[string]interface{}{
+				"defaultPolicy":    settings.Backpressure,
+				"sendBufferSize":   settings.SendBufferSize,
+				"downsampleFactor": settings.DownsampleFactor,
+				"stallTimeout":     settings.StallTimeout.String(),
+			},
+			Queues:       totals,
+			ClientQueues: queues,
+			Stream: map[string]interface{}{
+				"clients":     sseClients,
+				"lastEventId": lastEventID,
+				"bufferSize":  sseRingSize,
+				"overflows":   sseOverflows,
+			},
 			Timestamp: time.Now(),
 		}
+		if totals.Stalled > 0 {
+			status.Status = "degraded"
+		}
 
 		// Escrever resposta
 		w.WriteHeader(http.StatusOK)
@@ -2,13 +2,44 @@ package websocket
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
+	"radar_go/internal/config"
+	"radar_go/internal/events"
 	"radar_go/internal/models"
+	"radar_go/internal/telemetry"
 	"radar_go/pkg/logger"
 )
 
+// DataProvider fornece ao hub os dados solicitados pelos clientes
+// (implementado por radar.Service, com histórico vindo do Redis)
+type DataProvider interface {
+	GetStatus() models.RadarStatus
+	GetLastMetrics() *models.RadarMetrics
+	GetVelocityHistory(index int) ([]models.HistoryPoint, error)
+
+	// Amostras completas e mudanças de velocidade gravadas, para o replay
+	GetSamples(from, to time.Time, limit int) ([]models.RadarMetrics, error)
+	GetVelocityChangesRange(from, to time.Time) ([]models.VelocityChange, error)
+}
+
+// Nomes dos alarmes, como nos campos de models.AlarmsMessage
+var alarmNames = [...]string{"obstructed", "communicationFailure", "notOk"}
+
+// Erros de AcknowledgeAlarm
+var (
+	ErrUnknownAlarm  = errors.New("alarme desconhecido")
+	ErrAlarmInactive = errors.New("alarme não está ativo")
+)
+
+// alarmStates retorna o estado de cada alarme, na ordem de alarmNames
+func alarmStates(alarms models.AlarmsMessage) [len(alarmNames)]bool {
+	return [len(alarmNames)]bool{alarms.Obstructed, alarms.CommunicationFailure, alarms.NotOK}
+}
+
 // Hub gerencia todas as conexões WebSocket e distribuição de mensagens
 type Hub struct {
 	// Clientes registrados
@@ -20,8 +51,8 @@ type Hub struct {
 	// Canal para desregistrar clientes
 	unregister chan *Client
 
-	// Canal para mensagens de broadcast
-	broadcast chan []byte
+	// Canal para mensagens de broadcast, roteadas pelas assinaturas dos clientes
+	broadcast chan *hubMessage
 
 	// Comando recebido dos clientes
 	commands chan models.ClientCommand
@@ -29,15 +60,39 @@ type Hub struct {
 	// Mutex para operações concorrentes no mapa de clientes
 	mu sync.RWMutex
 
+	// Eventos recentes e assinantes de /api/stream (SSE)
+	stream *eventStream
+
+	// Tamanho das filas, política de backpressure padrão e tolerância a
+	// clientes parados (definidos antes de Run)
+	settings config.WebSocketConfig
+
 	// Última métrica enviada (para evitar duplicação)
 	lastMetrics     *models.RadarMetrics
 	lastMetricsTime time.Time
 	metricsLock     sync.RWMutex
 
+	// Fonte dos dados de get_history, get_status e do snapshot inicial
+	provider DataProvider
+
+	// Logger com os campos component e radarId (protegido por metricsLock)
+	log *logger.Logger
+
+	// Registro de conexões de clientes e alarmes (protegido por metricsLock)
+	journal *events.Journal
+
+	// Radar de origem das mensagens, estado atual dos alarmes e alarmes
+	// ativos já reconhecidos
+	radarID    string
+	alarms     models.AlarmsMessage
+	acked      map[string]bool
+	alarmsLock sync.Mutex
+
 	// Estatísticas
 	stats struct {
 		totalMessages      int64
 		totalClients       int64
+		stalledClients     int64
 		messagesPerSecond  float64
 		lastStatsReset     time.Time
 		messagesSinceReset int64
@@ -57,10 +112,19 @@ func NewHub() *Hub {
 		clients:    make(map[*Client]bool),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
-		broadcast:  make(chan []byte, 256), // Buffer aumentado para evitar bloqueios
+		broadcast:  make(chan *hubMessage, 256), // Buffer aumentado para evitar bloqueios
 		commands:   make(chan models.ClientCommand, 100),
-		ctx:        ctx,
-		cancel:     cancel,
+		stream:     newEventStream(),
+		acked:      make(map[string]bool),
+		log:        logger.With("component", "websocket"),
+		settings: config.WebSocketConfig{
+			SendBufferSize:   256,
+			Backpressure:     BackpressureCoalesce,
+			DownsampleFactor: 4,
+			StallTimeout:     30 * time.Second,
+		},
+		ctx:    ctx,
+		cancel: cancel,
 	}
 
 	h.stats.lastStatsReset = time.Now()
@@ -70,7 +134,7 @@ func NewHub() *Hub {
 
 // Run inicia o loop principal do hub para gerenciar clientes e mensagens
 func (h *Hub) Run() {
-	logger.Info("Iniciando WebSocket Hub")
+	h.getLog().Info("Iniciando WebSocket Hub")
 
 	// Ticker para estatísticas periódicas
 	statsTicker := time.NewTicker(30 * time.Second)
@@ -84,7 +148,7 @@ func (h *Hub) Run() {
 		select {
 		case <-h.ctx.Done():
 			// Contexto cancelado, encerrar o hub
-			logger.Info("Encerrando WebSocket Hub")
+			h.getLog().Info("Encerrando WebSocket Hub")
 			h.closeAllClients()
 			return
 
@@ -94,8 +158,20 @@ func (h *Hub) Run() {
 			h.clients[client] = true
 			clientCount := len(h.clients)
 			h.mu.Unlock()
-
-			logger.Infof("Novo cliente WebSocket conectado. ID: %s. Total: %d", client.id, clientCount)
+			telemetry.WebSocketClients.Set(float64(clientCount))
+
+			client.log.Infof("Novo cliente WebSocket conectado. ID: %s (%s). Total: %d", client.id, client.identity, clientCount)
+			h.getJournal().Record(models.Event{
+				Type:    models.EventClientConnected,
+				Actor:   client.actor(),
+				Message: fmt.Sprintf("Cliente %s conectado de %s", client.id, client.ipAddress),
+				Data: map[string]interface{}{
+					"clientId":  client.id,
+					"remote":    client.ipAddress,
+					"userAgent": client.userAgent,
+					"encoding":  client.encoding,
+				},
+			})
 
 			// Atualizar estatísticas
 			h.statsLock.Lock()
@@ -111,46 +187,46 @@ func (h *Hub) Run() {
 			if _, ok := h.clients[client]; ok {
 				delete(h.clients, client)
 				close(client.send)
-
-				logger.Infof("Cliente WebSocket desconectado. ID: %s. Total: %d", client.id, len(h.clients))
+				telemetry.WebSocketClients.Set(float64(len(h.clients)))
+
+				client.log.Infof("Cliente WebSocket desconectado. ID: %s. Total: %d", client.id, len(h.clients))
+				h.getJournal().Record(models.Event{
+					Type:    models.EventClientDisconnected,
+					Actor:   client.actor(),
+					Message: fmt.Sprintf("Cliente %s desconectado", client.id),
+					Data: map[string]interface{}{
+						"clientId": client.id,
+						"remote":   client.ipAddress,
+						"duration": time.Since(client.connectedAt).Round(time.Second).String(),
+					},
+				})
 			}
 			h.mu.Unlock()
 
 		case message := <-h.broadcast:
-			// Enviar mensagem para todos os clientes
-			h.mu.RLock()
-			clientCount := len(h.clients)
-
 			// Atualizar estatísticas
 			h.statsLock.Lock()
 			h.stats.totalMessages++
 			h.stats.messagesSinceReset++
 			h.statsLock.Unlock()
 
-			if clientCount == 0 {
-				h.mu.RUnlock()
-				continue // Nenhum cliente conectado, pular broadcast
+			// Mensagens de dados são numeradas e guardadas para o SSE, mesmo
+			// sem clientes conectados (retomada com Last-Event-ID)
+			now := time.Now()
+			if message.topic != "" {
+				h.stream.publish(message, now)
 			}
 
-			// Broadcast otimizado
-			deadClients := make([]*Client, 0, 4) // Pré-alocar para alguns clientes mortos
-
+			// Entregar apenas aos clientes que assinaram o tópico; filas cheias
+			// são tratadas pela política de backpressure de cada cliente
+			h.mu.RLock()
 			for client := range h.clients {
-				select {
-				case client.send <- message:
-					// Mensagem enviada com sucesso
-				default:
-					// Canal do cliente está cheio, marcar para desconexão
-					deadClients = append(deadClients, client)
+				if client.sub.accepts(message, now) {
+					h.deliver(client, message)
 				}
 			}
 			h.mu.RUnlock()
 
-			// Lidar com clientes mortos fora do lock para evitar contenção
-			for _, client := range deadClients {
-				h.unregister <- client
-			}
-
 		case cmd := <-h.commands:
 			// Processar comando de um cliente
 			go h.handleClientCommand(cmd)
@@ -177,18 +253,104 @@ func (h *Hub) Run() {
 			clientCount := len(h.clients)
 			h.mu.RUnlock()
 
-			logger.Infof("Estatísticas WebSocket: %d clientes, %.2f msgs/seg, total: %d mensagens",
+			h.getLog().Infof("Estatísticas WebSocket: %d clientes, %.2f msgs/seg, total: %d mensagens",
 				clientCount, mps, total)
 
 		case <-cleanupTicker.C:
 			// Enviar ping para todos os clientes para manter conexões ativas
 			h.sendPingToAllClients()
+
+			// Desconectar apenas clientes parados por mais que stallTimeout
+			h.disconnectStalledClients()
 		}
 	}
 }
 
+// Configure define o tamanho das filas e a política de backpressure.
+// Deve ser chamado antes de Run.
+func (h *Hub) Configure(cfg config.WebSocketConfig) error {
+	if err := validateWebSocketConfig(cfg); err != nil {
+		return err
+	}
+	h.settings = cfg
+	return nil
+}
+
+// SetRadarID define o identificador do radar incluído nas mensagens de dados
+func (h *Hub) SetRadarID(radarID string) {
+	h.metricsLock.Lock()
+	defer h.metricsLock.Unlock()
+	h.radarID = radarID
+	h.log = logger.With("component", "websocket", "radarId", radarID)
+}
+
+// SetDataProvider define a fonte de dados para respostas a comandos dos clientes
+func (h *Hub) SetDataProvider(provider DataProvider) {
+	h.metricsLock.Lock()
+	defer h.metricsLock.Unlock()
+	h.provider = provider
+}
+
+// SetJournal define o registro de eventos de conexões e alarmes
+func (h *Hub) SetJournal(journal *events.Journal) {
+	h.metricsLock.Lock()
+	defer h.metricsLock.Unlock()
+	h.journal = journal
+}
+
+// getJournal retorna o registro de eventos, ou nil se desabilitado
+func (h *Hub) getJournal() *events.Journal {
+	h.metricsLock.RLock()
+	defer h.metricsLock.RUnlock()
+	return h.journal
+}
+
+// getProvider retorna a fonte de dados, ou nil se não configurada
+func (h *Hub) getProvider() DataProvider {
+	h.metricsLock.RLock()
+	defer h.metricsLock.RUnlock()
+	return h.provider
+}
+
+// getLog retorna o logger do hub
+func (h *Hub) getLog() *logger.Logger {
+	h.metricsLock.RLock()
+	defer h.metricsLock.RUnlock()
+	return h.log
+}
+
+// getRadarID retorna o identificador do radar
+func (h *Hub) getRadarID() string {
+	h.metricsLock.RLock()
+	defer h.metricsLock.RUnlock()
+	return h.radarID
+}
+
+// publish serializa e enfileira uma mensagem de dados para roteamento
+func (h *Hub) publish(topic string, channel int, message interface{}) {
+	jsonMessage, err := SerializeMessage(message)
+	if err != nil {
+		h.getLog().Errorf("Erro ao serializar mensagem %s: %v", topic, err)
+		return
+	}
+
+	h.broadcast <- &hubMessage{
+		topic:   topic,
+		channel: channel,
+		radarID: h.getRadarID(),
+		data:    jsonMessage,
+		payload: message,
+	}
+}
+
 // BroadcastMetrics envia métricas do radar para todos os clientes
 func (h *Hub) BroadcastMetrics(metrics models.RadarMetrics) {
+	// Alarmes são atualizados mesmo quando a métrica é descartada pelo limite
+	h.updateAlarms(func(alarms *models.AlarmsMessage) {
+		alarms.Obstructed = metrics.Status == "obstruido"
+		alarms.NotOK = metrics.Status != "ok"
+	})
+
 	// Verificar se devemos limitar a taxa de envio
 	h.metricsLock.Lock()
 
@@ -226,23 +388,171 @@ func (h *Hub) BroadcastMetrics(metrics models.RadarMetrics) {
 		return
 	}
 
+	radarID := h.getRadarID()
+
 	// Criar mensagem
 	message := models.MetricsMessage{
 		WebSocketMessage: models.WebSocketMessage{
 			Type:      "metrics",
 			Timestamp: time.Now(),
+			RadarID:   radarID,
 		},
 		Positions:  metrics.Positions,
 		Velocities: metrics.Velocities,
 		Status:     metrics.Status,
 	}
+	h.publish(TopicMetrics, 0, message)
+
+	// Mensagens por canal apenas para canais com assinantes
+	for i := 0; i < channelCount; i++ {
+		if !h.channelSubscribed(i + 1) {
+			continue
+		}
+		h.publish(TopicChannel, i+1, models.ChannelMessage{
+			WebSocketMessage: models.WebSocketMessage{
+				Type:      "channel",
+				Timestamp: message.Timestamp,
+				RadarID:   radarID,
+			},
+			Channel:  i + 1,
+			Velocity: metrics.Velocities[i],
+			Position: metrics.Positions[i],
+			Status:   metrics.Status,
+		})
+	}
+}
+
+// channelSubscribed verifica se algum cliente assinou o canal (1-7)
+func (h *Hub) channelSubscribed(channel int) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.clients {
+		if client.sub.wantsChannel(channel) {
+			return true
+		}
+	}
+	return h.stream.wantsChannel(channel)
+}
+
+// updateAlarms aplica update ao estado dos alarmes, registra os alarmes
+// ativados e desativados e publica no tópico "alarms" quando algo muda
+func (h *Hub) updateAlarms(update func(alarms *models.AlarmsMessage)) {
+	h.alarmsLock.Lock()
+	current := h.alarms
+	next := current
+	update(&next)
+	next.Active = next.Obstructed || next.CommunicationFailure || next.NotOK
+
+	// Um alarme desativado perde o reconhecimento
+	var raised, cleared []string
+	before, after := alarmStates(current), alarmStates(next)
+	for i, name := range alarmNames {
+		switch {
+		case after[i] && !before[i]:
+			raised = append(raised, name)
+		case before[i] && !after[i]:
+			cleared = append(cleared, name)
+			delete(h.acked, name)
+		}
+	}
+	next.Acknowledged = h.acknowledgedLocked()
+	h.alarms = next
+	h.alarmsLock.Unlock()
+
+	journal := h.getJournal()
+	for _, name := range raised {
+		journal.Record(models.Event{
+			Type:    models.EventAlarmRaised,
+			Message: fmt.Sprintf("Alarme %s ativado", name),
+			Data:    map[string]interface{}{"alarm": name},
+		})
+	}
+	for _, name := range cleared {
+		journal.Record(models.Event{
+			Type:    models.EventAlarmCleared,
+			Message: fmt.Sprintf("Alarme %s desativado", name),
+			Data:    map[string]interface{}{"alarm": name},
+		})
+	}
+
+	if len(raised) == 0 && len(cleared) == 0 {
+		return
+	}
+	h.publishAlarms(next)
+}
+
+// AcknowledgeAlarm registra o reconhecimento de um alarme ativo por actor.
+// Reconhecer de novo um alarme já reconhecido não tem efeito.
+func (h *Hub) AcknowledgeAlarm(alarm, actor, comment string) error {
+	index := -1
+	for i, name := range alarmNames {
+		if name == alarm {
+			index = i
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("%w: %q (use obstructed, communicationFailure ou notOk)", ErrUnknownAlarm, alarm)
+	}
 
-	// Serializar e enviar a mensagem
-	if jsonMessage, err := SerializeMessage(message); err == nil {
-		h.broadcast <- jsonMessage
-	} else {
-		logger.Error("Erro ao serializar mensagem de métricas", err)
+	h.alarmsLock.Lock()
+	if !alarmStates(h.alarms)[index] {
+		h.alarmsLock.Unlock()
+		return fmt.Errorf("%w: %s", ErrAlarmInactive, alarm)
+	}
+	if h.acked[alarm] {
+		h.alarmsLock.Unlock()
+		return nil
+	}
+	h.acked[alarm] = true
+	next := h.alarms
+	next.Acknowledged = h.acknowledgedLocked()
+	h.alarms = next
+	h.alarmsLock.Unlock()
+
+	h.getLog().Infof("Alarme %s reconhecido por %s", alarm, actor)
+	h.getJournal().Record(models.Event{
+		Type:    models.EventAlarmAcked,
+		Actor:   actor,
+		Message: fmt.Sprintf("Alarme %s reconhecido", alarm),
+		Data:    map[string]interface{}{"alarm": alarm, "comment": comment},
+	})
+	h.publishAlarms(next)
+	return nil
+}
+
+// acknowledgedLocked lista os alarmes reconhecidos; requer alarmsLock
+func (h *Hub) acknowledgedLocked() []string {
+	var acked []string
+	for _, name := range alarmNames {
+		if h.acked[name] {
+			acked = append(acked, name)
+		}
+	}
+	return acked
+}
+
+// publishAlarms publica o estado dos alarmes no tópico "alarms"
+func (h *Hub) publishAlarms(alarms models.AlarmsMessage) {
+	alarms.WebSocketMessage = models.WebSocketMessage{
+		Type:      "alarms",
+		Timestamp: time.Now(),
+		RadarID:   h.getRadarID(),
 	}
+	h.publish(TopicAlarms, 0, alarms)
+}
+
+// BroadcastEvent envia um evento registrado aos clientes do tópico "events"
+func (h *Hub) BroadcastEvent(event models.Event) {
+	message := models.EventMessage{
+		WebSocketMessage: models.WebSocketMessage{
+			Type:      "event",
+			Timestamp: time.Now(),
+			RadarID:   event.RadarID,
+		},
+		Event: event,
+	}
+	h.publish(TopicEvents, 0, message)
 }
 
 // BroadcastVelocityChanges envia mudanças de velocidade para todos os clientes
@@ -255,16 +565,11 @@ func (h *Hub) BroadcastVelocityChanges(changes []models.VelocityChange) {
 		WebSocketMessage: models.WebSocketMessage{
 			Type:      "velocity_changes",
 			Timestamp: time.Now(),
+			RadarID:   h.getRadarID(),
 		},
 		Changes: changes,
 	}
-
-	// Serializar e enviar a mensagem
-	if jsonMessage, err := SerializeMessage(message); err == nil {
-		h.broadcast <- jsonMessage
-	} else {
-		logger.Error("Erro ao serializar mensagem de mudanças de velocidade", err)
-	}
+	h.publish(TopicVelocityChanges, 0, message)
 }
 
 // BroadcastStatus envia atualização de status para todos os clientes
@@ -273,51 +578,244 @@ func (h *Hub) BroadcastStatus(status models.RadarStatus) {
 		WebSocketMessage: models.WebSocketMessage{
 			Type:      "status",
 			Timestamp: time.Now(),
+			RadarID:   h.getRadarID(),
 		},
 		Status:     status.Status,
 		LastError:  status.LastError,
 		ErrorCount: status.ErrorCount,
 	}
+	h.publish(TopicStatus, 0, message)
 
-	// Serializar e enviar a mensagem
-	if jsonMessage, err := SerializeMessage(message); err == nil {
-		h.broadcast <- jsonMessage
-	} else {
-		logger.Error("Erro ao serializar mensagem de status", err)
-	}
+	h.updateAlarms(func(alarms *models.AlarmsMessage) {
+		alarms.CommunicationFailure = status.Status == "falha_comunicacao"
+	})
 }
 
 // handleClientCommand processa comandos recebidos dos clientes
 func (h *Hub) handleClientCommand(cmd models.ClientCommand) {
-	logger.Infof("Comando recebido do cliente %s: %s", cmd.ClientID, cmd.Command)
+	h.getLog().With("clientId", cmd.ClientID).Infof("Comando recebido do cliente %s: %s", cmd.ClientID, cmd.Command)
+
+	params, _ := cmd.Params.(map[string]interface{})
+	replyTo, _ := params["replyTo"].(string)
 
 	switch cmd.Command {
 	case "get_history":
-		if params, ok := cmd.Params.(map[string]interface{}); ok {
-			if indexFloat, ok := params["index"].(float64); ok {
-				index := int(indexFloat)
-				h.sendVelocityHistory(cmd.ClientID, index)
-			}
-		}
+		index, _ := params["index"].(int)
+		h.sendVelocityHistory(cmd.ClientID, index, replyTo)
 	case "get_status":
-		h.sendCurrentStatus(cmd.ClientID)
+		h.sendCurrentStatus(cmd.ClientID, replyTo)
 	case "ping":
 		h.sendPong(cmd.ClientID, cmd.Params)
 	default:
-		logger.Warnf("Comando desconhecido: %s", cmd.Command)
+		h.getLog().With("clientId", cmd.ClientID).Warnf("Comando desconhecido: %s", cmd.Command)
 	}
 }
 
 // sendVelocityHistory envia histórico de velocidade para um cliente específico
-func (h *Hub) sendVelocityHistory(clientID string, index int) {
-	// Implementar integração com o Redis para obter histórico
-	// e enviar apenas para o cliente solicitante
+func (h *Hub) sendVelocityHistory(clientID string, index int, replyTo string) {
+	client := h.getClientByID(clientID)
+	if client == nil {
+		return
+	}
+
+	if index < 1 || index > channelCount {
+		h.sendError(client, "get_history", replyTo, ErrCodeInvalidParams,
+			fmt.Sprintf("Índice de velocidade inválido: %d (use 1-%d)", index, channelCount))
+		return
+	}
+
+	provider := h.getProvider()
+	if provider == nil {
+		h.sendError(client, "get_history", replyTo, ErrCodeUnavailable, "Histórico indisponível")
+		return
+	}
+
+	history, err := provider.GetVelocityHistory(index)
+	if err != nil {
+		client.log.Warnf("Erro ao obter histórico da velocidade %d para o cliente %s: %v", index, clientID, err)
+		h.sendError(client, "get_history", replyTo, ErrCodeHistoryUnavailable, "Histórico indisponível")
+		return
+	}
+	if history == nil {
+		history = []models.HistoryPoint{}
+	}
+
+	message := NewHistoryMessage(index, history)
+	message.ReplyTo = replyTo
+	message.RadarID = h.getRadarID()
+	h.sendToClient(client, message)
 }
 
 // sendCurrentStatus envia status atual para um cliente específico
-func (h *Hub) sendCurrentStatus(clientID string) {
-	// Implementar integração com o serviço de radar para obter status atual
-	// e enviar apenas para o cliente solicitante
+func (h *Hub) sendCurrentStatus(clientID string, replyTo string) {
+	client := h.getClientByID(clientID)
+	if client == nil {
+		return
+	}
+
+	provider := h.getProvider()
+	if provider == nil {
+		h.sendError(client, "get_status", replyTo, ErrCodeUnavailable, "Status indisponível")
+		return
+	}
+
+	message := NewStatusMessage(provider.GetStatus())
+	message.ReplyTo = replyTo
+	message.RadarID = h.getRadarID()
+	h.sendToClient(client, message)
+}
+
+// sendToClient envia uma mensagem a um único cliente, se ele ainda estiver registrado
+func (h *Hub) sendToClient(client *Client, message interface{}) {
+	data, err := client.encode(message)
+	if err != nil {
+		client.log.Errorf("Erro ao serializar mensagem para o cliente %s: %v", client.id, err)
+		return
+	}
+
+	// O canal send é fechado no unregister sob h.mu
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if _, ok := h.clients[client]; !ok {
+		return
+	}
+	// Respostas nunca são agregadas nem amostradas: com a fila cheia, abrem
+	// espaço descartando a mensagem mais antiga
+	h.enqueue(client, data)
+}
+
+// deliver entrega uma mensagem do hub a um cliente aplicando a política de
+// backpressure. Deve ser chamado com h.mu travado para leitura.
+func (h *Hub) deliver(client *Client, message *hubMessage) {
+	policy := client.queue.getPolicy()
+	depth := len(client.send)
+	capacity := cap(client.send)
+	metrics := message.topic == TopicMetrics || message.topic == TopicChannel
+
+	// downsample: acima da metade da fila, apenas 1 de cada N métricas
+	if policy == BackpressureDownsample && metrics && depth >= capacity/2 &&
+		client.queue.downsample(h.settings.DownsampleFactor) {
+		return
+	}
+
+	// coalesce: com a fila cheia, as métricas aguardam fora dela e cada nova
+	// amostra substitui a anterior
+	coalesce := policy == BackpressureCoalesce && message.topic == TopicMetrics && depth >= capacity
+
+	// Descartes quebram a sequência delta das métricas compactas: a amostra
+	// seguinte a um descarte (ou que vai causar um) segue como keyframe
+	if client.queue.takeResync() || (depth >= capacity && (!coalesce || client.queue.hasPending())) {
+		client.delta.forceKeyframe = true
+	}
+
+	data, sent, err := client.encodeHubMessage(message)
+	if err != nil {
+		client.log.Errorf("Erro ao codificar mensagem %s para o cliente %s: %v", message.topic, client.id, err)
+		return
+	}
+	if sent != nil {
+		sent()
+	}
+
+	if coalesce {
+		client.queue.setPending(data)
+		select {
+		case client.wake <- struct{}{}:
+		default:
+		}
+		return
+	}
+
+	h.enqueue(client, data)
+}
+
+// enqueue coloca a mensagem na fila do cliente; se estiver cheia, descarta a
+// mais antiga. Deve ser chamado com h.mu travado para leitura.
+func (h *Hub) enqueue(client *Client, data []byte) {
+	for attempt := 0; attempt < 2; attempt++ {
+		select {
+		case client.send <- data:
+			client.queue.enqueued(len(client.send))
+			return
+		default:
+		}
+
+		// Fila cheia: descartar a mensagem mais antiga
+		select {
+		case <-client.send:
+			client.queue.drop(1)
+		default:
+		}
+	}
+
+	client.queue.drop(1)
+	client.log.Debugf("Fila do cliente %s cheia, mensagem descartada", client.id)
+}
+
+// disconnectStalledClients desconecta clientes cuja fila não anda há mais que stallTimeout
+func (h *Hub) disconnectStalledClients() {
+	now := time.Now()
+	var stalled []*Client
+
+	h.mu.RLock()
+	for client := range h.clients {
+		since := client.queue.stalledSince(len(client.send))
+		if !since.IsZero() && now.Sub(since) > h.settings.StallTimeout {
+			stalled = append(stalled, client)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, client := range stalled {
+		client.log.Warnf("Cliente WebSocket %s (%s) parado há mais de %v, desconectando",
+			client.id, client.ipAddress, h.settings.StallTimeout)
+
+		h.statsLock.Lock()
+		h.stats.stalledClients++
+		h.statsLock.Unlock()
+
+		h.mu.Lock()
+		if _, ok := h.clients[client]; ok {
+			delete(h.clients, client)
+			close(client.send)
+			telemetry.WebSocketClients.Set(float64(len(h.clients)))
+		}
+		h.mu.Unlock()
+		client.conn.Close()
+	}
+}
+
+// QueueStats retorna as métricas das filas de envio de todos os clientes
+func (h *Hub) QueueStats() []QueueStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	stats := make([]QueueStats, 0, len(h.clients))
+	for client := range h.clients {
+		stats = append(stats, client.queueStats(h.settings.StallTimeout))
+	}
+	return stats
+}
+
+// BackpressureSettings retorna a configuração das filas de envio
+func (h *Hub) BackpressureSettings() config.WebSocketConfig {
+	return h.settings
+}
+
+// StalledDisconnects retorna quantos clientes foram desconectados por fila parada
+func (h *Hub) StalledDisconnects() int64 {
+	h.statsLock.Lock()
+	defer h.statsLock.Unlock()
+	return h.stats.stalledClients
+}
+
+// sendError envia uma mensagem de erro correlacionada a uma requisição
+func (h *Hub) sendError(client *Client, command, replyTo, code, message string) {
+	errorMsg := NewErrorMessage(message, code)
+	errorMsg.ReplyTo = replyTo
+	errorMsg.Command = command
+	h.sendToClient(client, errorMsg)
 }
 
 // sendPong envia resposta de pong para um cliente específico
@@ -345,30 +843,44 @@ func (h *Hub) sendPong(clientID string, params interface{}) {
 		ServerTime: time.Now().UnixNano() / int64(time.Millisecond),
 	}
 
-	// Serializar e enviar apenas para o cliente solicitante
-	if jsonMsg, err := SerializeMessage(pong); err == nil {
-		client.send <- jsonMsg
-	}
+	// Enviar apenas para o cliente solicitante
+	h.sendToClient(client, pong)
 }
 
-// sendInitialDataToClient envia dados iniciais para um novo cliente
+// sendInitialDataToClient envia boas-vindas, status atual e últimas métricas
+// para um novo cliente, sem esperar o próximo ciclo do radar
 func (h *Hub) sendInitialDataToClient(client *Client) {
-	// Implementar lógica para enviar dados iniciais, como:
-	// - Status atual
-	// - Métricas atuais
-
 	// Enviar mensagem de boas-vindas
+	// Versão do protocolo, funcionalidades e comandos disponíveis; o cliente
+	// pode negociar a versão com "hello" e obter os schemas com "get_capabilities"
+	data := protocolInfo(false)
+	data["message"] = "Conectado ao servidor SICK Radar Monitor"
+	data["clientId"] = client.id
+	data["encoding"] = client.encoding
+	data["identity"] = client.identity
+
 	welcome := models.WebSocketMessage{
 		Type:      "welcome",
 		Timestamp: time.Now(),
-		Data: map[string]interface{}{
-			"message":  "Conectado ao servidor SICK Radar Monitor",
-			"clientId": client.id,
-		},
+		Data:      data,
+	}
+
+	h.sendToClient(client, welcome)
+
+	provider := h.getProvider()
+	if provider == nil {
+		return
 	}
 
-	if jsonMsg, err := SerializeMessage(welcome); err == nil {
-		client.send <- jsonMsg
+	status := NewStatusMessage(provider.GetStatus())
+	status.RadarID = h.getRadarID()
+	h.sendToClient(client, status)
+
+	if metrics := provider.GetLastMetrics(); metrics != nil {
+		message := NewMetricsMessage(*metrics)
+		message.Timestamp = metrics.Timestamp
+		message.RadarID = h.getRadarID()
+		h.sendToClient(client, message)
 	}
 }
 
@@ -384,11 +896,13 @@ func (h *Hub) closeAllClients() {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	logger.Info("Fechando todas as conexões de clientes WebSocket")
+	h.getLog().Info("Fechando todas as conexões de clientes WebSocket")
 	for client := range h.clients {
 		close(client.send)
 		delete(h.clients, client)
 	}
+	telemetry.WebSocketClients.Set(0)
+	h.stream.closeAll()
 }
 
 // ClientCount retorna o número atual de clientes conectados
@@ -411,7 +925,9 @@ func (h *Hub) getClientByID(clientID string) *Client {
 	return nil
 }
 
-// sendPingToAllClients envia ping para todos os clientes
+// sendPingToAllClients envia ping para todos os clientes. Chamado pelo loop
+// do hub: entrega direto nas filas dos clientes, pois enviar para h.broadcast
+// bloquearia o próprio loop com o canal cheio.
 func (h *Hub) sendPingToAllClients() {
 	ping := models.PingMessage{
 		WebSocketMessage: models.WebSocketMessage{
@@ -421,13 +937,18 @@ func (h *Hub) sendPingToAllClients() {
 		Time: time.Now().UnixNano() / int64(time.Millisecond),
 	}
 
-	if jsonMsg, err := SerializeMessage(ping); err == nil {
-		h.mu.RLock()
-		if len(h.clients) > 0 {
-			h.broadcast <- jsonMsg
-		}
-		h.mu.RUnlock()
+	jsonMsg, err := SerializeMessage(ping)
+	if err != nil {
+		return
+	}
+
+	// Mensagem de sistema: entregue a todos, independentemente das assinaturas
+	message := &hubMessage{data: jsonMsg, payload: ping}
+	h.mu.RLock()
+	for client := range h.clients {
+		h.deliver(client, message)
 	}
+	h.mu.RUnlock()
 }
 
 // abs retorna o valor absoluto de um float64
@@ -0,0 +1,303 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"radar_go/internal/models"
+)
+
+// newTestClient cria um cliente registrado no hub, sem conexão
+func newTestClient(h *Hub) *Client {
+	client := &Client{
+		hub:      h,
+		send:     make(chan []byte, 8),
+		queue:    newSendQueue(BackpressureDropOldest),
+		wake:     make(chan struct{}, 1),
+		id:       "teste",
+		log:      h.getLog(),
+		sub:      newSubscription(),
+		encoding: EncodingJSON,
+	}
+	h.clients[client] = true
+	return client
+}
+
+func TestPingWithFullBroadcastQueue(t *testing.T) {
+	h := NewHub()
+	client := newTestClient(h)
+
+	// Canal de broadcast cheio, como após uma rajada de métricas
+	for len(h.broadcast) < cap(h.broadcast) {
+		h.broadcast <- &hubMessage{topic: TopicMetrics, data: []byte("{}")}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.sendPingToAllClients()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sendPingToAllClients bloqueou com o canal de broadcast cheio")
+	}
+
+	select {
+	case data := <-client.send:
+		var message struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(data, &message); err != nil || message.Type != "ping" {
+			t.Fatalf("mensagem inesperada na fila do cliente: %s", data)
+		}
+	default:
+		t.Fatal("ping não foi entregue ao cliente")
+	}
+}
+
+// startHub inicia o loop do hub, encerrado ao fim do teste
+func startHub(t *testing.T, h *Hub) {
+	t.Helper()
+	go h.Run()
+	t.Cleanup(h.Shutdown)
+}
+
+// addClient registra um cliente de teste com a assinatura informada. Deve ser
+// chamado antes de startHub.
+func addClient(t *testing.T, h *Hub, id string, topics ...string) *Client {
+	t.Helper()
+
+	client := newTestClient(h)
+	client.id = id
+	client.send = make(chan []byte, 64)
+	if len(topics) > 0 {
+		if err := client.sub.subscribe(topics); err != nil {
+			t.Fatalf("subscribe(%v): %v", topics, err)
+		}
+	}
+	return client
+}
+
+// publishBarrier publica uma mensagem de sistema que marca o fim de uma
+// rodada: o hub a entrega a todos depois das anteriores
+func publishBarrier(h *Hub) {
+	h.broadcast <- &hubMessage{data: []byte(`{"type":"barrier"}`)}
+}
+
+// received lê as mensagens do cliente até a barreira e as identifica como
+// "tipo" ou "channel:N"
+func received(t *testing.T, client *Client) []string {
+	t.Helper()
+
+	var got []string
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case data, ok := <-client.send:
+			if !ok {
+				t.Fatalf("fila do cliente %s fechada", client.id)
+			}
+			var message struct {
+				Type    string `json:"type"`
+				Channel int    `json:"channel"`
+			}
+			if err := json.Unmarshal(data, &message); err != nil {
+				t.Fatalf("mensagem inválida para o cliente %s: %s", client.id, data)
+			}
+			switch message.Type {
+			case "barrier":
+				return got
+			case "ping":
+				continue
+			case "channel":
+				got = append(got, fmt.Sprintf("channel:%d", message.Channel))
+			default:
+				got = append(got, message.Type)
+			}
+		case <-timeout:
+			t.Fatalf("barreira não recebida pelo cliente %s; recebidas: %v", client.id, got)
+		}
+	}
+}
+
+// publishAll publica uma mensagem de cada tópico, do radar informado
+func publishAll(h *Hub, radarID string) {
+	header := func(messageType string) models.WebSocketMessage {
+		return models.WebSocketMessage{Type: messageType, Timestamp: time.Now(), RadarID: radarID}
+	}
+	h.publish(TopicMetrics, 0, models.MetricsMessage{WebSocketMessage: header("metrics"), Status: "ok"})
+	h.publish(TopicChannel, 2, models.ChannelMessage{WebSocketMessage: header("channel"), Channel: 2})
+	h.publish(TopicChannel, 5, models.ChannelMessage{WebSocketMessage: header("channel"), Channel: 5})
+	h.publish(TopicVelocityChanges, 0, models.VelocityChangeMessage{WebSocketMessage: header("velocity_changes")})
+	h.publish(TopicStatus, 0, models.StatusMessage{WebSocketMessage: header("status")})
+	h.publish(TopicAlarms, 0, models.AlarmsMessage{WebSocketMessage: header("alarms")})
+	h.publish(TopicEvents, 0, models.EventMessage{WebSocketMessage: header("event")})
+}
+
+func TestHubTopicRouting(t *testing.T) {
+	h := NewHub()
+	h.SetRadarID("radar-1")
+
+	all := []string{"metrics", "velocity_changes", "status", "alarms", "event"}
+	tests := []struct {
+		id     string
+		topics []string
+		want   []string
+	}{
+		{"padrão", nil, all},
+		{"apenas status", []string{TopicStatus}, []string{"status"}},
+		{"métricas e canal 2", []string{TopicMetrics, "channel:2"}, []string{"metrics", "channel:2"}},
+		{"apenas canais", []string{"channel:5", "channel:2"}, []string{"channel:2", "channel:5"}},
+		{"eventos e alarmes", []string{TopicEvents, TopicAlarms}, []string{"alarms", "event"}},
+		{"todos com canal", []string{"channel:5", topicAll}, all}, // "*" volta ao padrão
+		{"mesmo radar", []string{"radar:radar-1", TopicStatus}, []string{"status"}},
+		{"outro radar", []string{"radar:radar-2"}, nil},
+	}
+
+	clients := make([]*Client, len(tests))
+	for i, tt := range tests {
+		clients[i] = addClient(t, h, tt.id, tt.topics...)
+	}
+	startHub(t, h)
+
+	publishAll(h, "radar-1")
+	publishBarrier(h)
+
+	for i, tt := range tests {
+		if got := received(t, clients[i]); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: recebeu %v, esperado %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestHubUnsubscribe(t *testing.T) {
+	h := NewHub()
+	client := addClient(t, h, "cliente")
+	startHub(t, h)
+
+	// Sair de um tópico no modo padrão mantém os demais
+	if err := client.sub.unsubscribe([]string{TopicMetrics, TopicEvents}); err != nil {
+		t.Fatal(err)
+	}
+	publishAll(h, "")
+	publishBarrier(h)
+	if got, want := received(t, client), []string{"velocity_changes", "status", "alarms"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("após unsubscribe recebeu %v, esperado %v", got, want)
+	}
+
+	// "*" cancela tudo; mensagens de sistema continuam chegando
+	if err := client.sub.unsubscribe([]string{topicAll}); err != nil {
+		t.Fatal(err)
+	}
+	publishAll(h, "")
+	publishBarrier(h)
+	if got := received(t, client); len(got) != 0 {
+		t.Errorf("sem assinaturas recebeu %v", got)
+	}
+}
+
+func TestSubscriptionRateLimit(t *testing.T) {
+	sub := newSubscription()
+	if err := sub.subscribe([]string{TopicMetrics, TopicStatus, "channel:1", "channel:2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.setMaxRate(10); err != nil { // 1 a cada 100ms
+		t.Fatal(err)
+	}
+
+	metrics := &hubMessage{topic: TopicMetrics, radarID: "radar-1"}
+	otherRadar := &hubMessage{topic: TopicMetrics, radarID: "radar-2"}
+	channel1 := &hubMessage{topic: TopicChannel, channel: 1, radarID: "radar-1"}
+	channel2 := &hubMessage{topic: TopicChannel, channel: 2, radarID: "radar-1"}
+	status := &hubMessage{topic: TopicStatus, radarID: "radar-1"}
+
+	start := time.Now()
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	tests := []struct {
+		name string
+		msg  *hubMessage
+		now  time.Time
+		want bool
+	}{
+		{"primeira métrica", metrics, at(0), true},
+		{"métrica dentro do intervalo", metrics, at(50), false},
+		{"outro radar tem limite próprio", otherRadar, at(50), true},
+		{"canal 1", channel1, at(50), true},
+		{"canal 2 tem limite próprio", channel2, at(60), true},
+		{"canal 1 dentro do intervalo", channel1, at(100), false},
+		{"status não é limitado", status, at(60), true},
+		{"status repetido", status, at(61), true},
+		{"métrica após o intervalo", metrics, at(100), true},
+		{"descartada não reinicia o intervalo", metrics, at(150), false},
+		{"métrica no intervalo seguinte", metrics, at(200), true},
+	}
+
+	for _, tt := range tests {
+		if got := sub.accepts(tt.msg, tt.now); got != tt.want {
+			t.Errorf("%s: accepts = %v, esperado %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Sem limite, todas passam
+	if err := sub.setMaxRate(0); err != nil {
+		t.Fatal(err)
+	}
+	if !sub.accepts(metrics, at(201)) {
+		t.Error("métrica descartada sem limite de taxa")
+	}
+	if err := sub.setMaxRate(-1); err == nil {
+		t.Error("setMaxRate(-1) sem erro")
+	}
+}
+
+func TestHubPerClientRateLimit(t *testing.T) {
+	h := NewHub()
+	limited := addClient(t, h, "limitado", TopicMetrics, TopicStatus)
+	unlimited := addClient(t, h, "sem limite", TopicMetrics, TopicStatus)
+	if err := limited.sub.setMaxRate(5); err != nil { // 1 a cada 200ms
+		t.Fatal(err)
+	}
+	startHub(t, h)
+
+	publishBurst := func() {
+		for i := 0; i < 5; i++ {
+			h.publish(TopicMetrics, 0, models.MetricsMessage{WebSocketMessage: models.WebSocketMessage{Type: "metrics"}})
+			h.publish(TopicStatus, 0, models.StatusMessage{WebSocketMessage: models.WebSocketMessage{Type: "status"}})
+		}
+		publishBarrier(h)
+	}
+
+	count := func(messages []string, messageType string) int {
+		n := 0
+		for _, m := range messages {
+			if m == messageType {
+				n++
+			}
+		}
+		return n
+	}
+
+	// O limite de um cliente não afeta os demais nem os outros tópicos
+	publishBurst()
+	got := received(t, limited)
+	if count(got, "metrics") != 1 || count(got, "status") != 5 {
+		t.Errorf("cliente limitado recebeu %v, esperado 1 métrica e 5 status", got)
+	}
+	if got := received(t, unlimited); count(got, "metrics") != 5 || count(got, "status") != 5 {
+		t.Errorf("cliente sem limite recebeu %v", got)
+	}
+
+	// Passado o intervalo, a próxima métrica volta a ser entregue
+	time.Sleep(250 * time.Millisecond)
+	publishBurst()
+	if got := received(t, limited); count(got, "metrics") != 1 {
+		t.Errorf("após o intervalo o cliente limitado recebeu %v, esperado 1 métrica", got)
+	}
+	received(t, unlimited)
+}
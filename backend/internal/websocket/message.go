@@ -59,14 +59,14 @@ func NewHistoryMessage(index int, history []models.HistoryPoint) *models.History
 }
 
 // NewErrorMessage cria uma nova mensagem de erro
-func NewErrorMessage(message string, errorCode string) models.WebSocketMessage {
-	return models.WebSocketMessage{
-		Type:      "error",
-		Timestamp: time.Now(),
-		Error:     message,
-		Data: map[string]string{
-			"code": errorCode,
+func NewErrorMessage(message string, errorCode string) *models.ErrorMessage {
+	return &models.ErrorMessage{
+		WebSocketMessage: models.WebSocketMessage{
+			Type:      "error",
+			Timestamp: time.Now(),
+			Error:     message,
 		},
+		Code: errorCode,
 	}
 }
 
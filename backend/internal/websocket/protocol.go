@@ -0,0 +1,358 @@
+package websocket
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"radar_go/internal/auth"
+)
+
+// Versões do protocolo de comandos. Mensagens sem o campo "v" são tratadas
+// como versão 1, o formato usado pelo aplicativo desde o início.
+const (
+	ProtocolVersion    = 1
+	MinProtocolVersion = 1
+)
+
+// Códigos de erro enviados em mensagens "error"
+const (
+	ErrCodeInvalidFormat       = "invalid_format"
+	ErrCodeUnknownCommand      = "unknown_command"
+	ErrCodeInvalidParams       = "invalid_params"
+	ErrCodeUnsupportedVersion  = "unsupported_version"
+	ErrCodeInvalidSubscription = "invalid_subscription"
+	ErrCodeHistoryUnavailable  = "history_unavailable"
+	ErrCodeUnavailable         = "unavailable"
+	ErrCodeReplayNotActive     = "replay_not_active"
+	ErrCodeForbidden           = "forbidden"
+)
+
+// Funcionalidades anunciadas no welcome e em get_capabilities
+var protocolFeatures = []string{
+	"replyTo",          // Respostas trazem o id do comando em replyTo
+	"structuredErrors", // Erros trazem code, command, field e details
+	"subscriptions",    // subscribe/unsubscribe por tópico, canal e radar
+	"rateLimit",        // set_rate_limit para métricas e canais
+	"compactMetrics",   // Métricas delta em float32 nas codificações binárias
+	"backpressure",     // set_backpressure por cliente
+	"replay",           // Replay do histórico com pause, resume, seek e stop
+	"roles",            // Comandos restritos por papel (erro "forbidden")
+	"events",           // Registro de eventos operacionais no tópico "events"
+}
+
+// jsonSchema é o subconjunto de JSON Schema usado para descrever e validar
+// os parâmetros dos comandos. É serializado como está em get_capabilities.
+type jsonSchema struct {
+	Type        string                 `json:"type"`
+	Description string                 `json:"description,omitempty"`
+	Format      string                 `json:"format,omitempty"` // Apenas descritivo (ex.: "date-time")
+	Properties  map[string]*jsonSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	Items       *jsonSchema            `json:"items,omitempty"`
+	MinItems    int                    `json:"minItems,omitempty"`
+	Minimum     *float64               `json:"minimum,omitempty"`
+	Maximum     *float64               `json:"maximum,omitempty"`
+}
+
+// schemaError indica o campo que não atende ao schema
+type schemaError struct {
+	field  string
+	reason string
+}
+
+func (e *schemaError) Error() string {
+	return fmt.Sprintf("%s: %s", e.field, e.reason)
+}
+
+// validate verifica um valor decodificado de JSON. Propriedades não descritas
+// são aceitas, para que clientes mais novos possam enviar campos extras.
+func (s *jsonSchema) validate(value interface{}, field string) *schemaError {
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return &schemaError{field, "deve ser um objeto"}
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return &schemaError{joinField(field, name), "obrigatório"}
+			}
+		}
+		for name, prop := range s.Properties {
+			if v, ok := obj[name]; ok {
+				if err := prop.validate(v, joinField(field, name)); err != nil {
+					return err
+				}
+			}
+		}
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			return &schemaError{field, "deve ser uma lista"}
+		}
+		if len(arr) < s.MinItems {
+			return &schemaError{field, fmt.Sprintf("deve ter ao menos %d item(ns)", s.MinItems)}
+		}
+		if s.Items != nil {
+			for i, item := range arr {
+				if err := s.Items.validate(item, fmt.Sprintf("%s[%d]", field, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return &schemaError{field, "deve ser um texto"}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return &schemaError{field, "deve ser verdadeiro ou falso"}
+		}
+	case "number", "integer":
+		n, ok := value.(float64)
+		if !ok {
+			return &schemaError{field, "deve ser um número"}
+		}
+		if s.Type == "integer" && n != math.Trunc(n) {
+			return &schemaError{field, "deve ser um número inteiro"}
+		}
+		if s.Minimum != nil && n < *s.Minimum {
+			return &schemaError{field, fmt.Sprintf("deve ser no mínimo %v", *s.Minimum)}
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			return &schemaError{field, fmt.Sprintf("deve ser no máximo %v", *s.Maximum)}
+		}
+	}
+	return nil
+}
+
+// joinField monta o caminho de um campo aninhado (ex.: "params.index")
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// bound retorna um ponteiro para limites de schema
+func bound(v float64) *float64 {
+	return &v
+}
+
+// commandSpec descreve um comando aceito pelo servidor
+type commandSpec struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Since       int         `json:"since"` // Versão do protocolo que introduziu o comando
+	Params      *jsonSchema `json:"params"`
+	Reply       string      `json:"reply"` // Tipo da mensagem de resposta
+	Role        auth.Role   `json:"role"`  // Papel mínimo para executar o comando
+
+	handle func(c *Client, cmd commandRequest)
+}
+
+// commandRequest é um comando já validado
+type commandRequest struct {
+	Type   string
+	ID     string
+	Params map[string]interface{}
+	Time   int64 // Ping no formato antigo
+}
+
+// commandSpecs é o registro de comandos, indexado por nome
+var commandSpecs = map[string]*commandSpec{}
+
+// registerCommand adiciona um comando ao registro
+func registerCommand(spec *commandSpec) {
+	if spec.Params == nil {
+		spec.Params = &jsonSchema{Type: "object"}
+	}
+	if spec.Role == auth.RoleNone {
+		spec.Role = auth.RoleViewer
+	}
+	commandSpecs[spec.Name] = spec
+}
+
+// commandNames retorna os nomes dos comandos em ordem alfabética
+func commandNames() []string {
+	names := make([]string, 0, len(commandSpecs))
+	for name := range commandSpecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// protocolInfo descreve o protocolo para o cliente; com schemas, inclui a
+// descrição completa de cada comando (get_capabilities)
+func protocolInfo(schemas bool) map[string]interface{} {
+	info := map[string]interface{}{
+		"protocolVersion":    ProtocolVersion,
+		"minProtocolVersion": MinProtocolVersion,
+		"features":           protocolFeatures,
+		"encodings":          []string{EncodingJSON, EncodingCBOR},
+		"topics":             []string{TopicMetrics, TopicVelocityChanges, TopicStatus, TopicAlarms, TopicEvents, TopicChannel + ":N", radarFilterPrefix + "<id>"},
+	}
+
+	if !schemas {
+		info["commands"] = commandNames()
+		return info
+	}
+
+	commands := make([]*commandSpec, 0, len(commandSpecs))
+	for _, name := range commandNames() {
+		commands = append(commands, commandSpecs[name])
+	}
+	info["commands"] = commands
+	return info
+}
+
+func init() {
+	topicList := &jsonSchema{
+		Type:        "array",
+		Description: `Tópicos: "metrics", "velocity_changes", "status", "alarms", "events", "channel:N", "radar:<id>" ou "*"`,
+		Items:       &jsonSchema{Type: "string"},
+		MinItems:    1,
+	}
+	maxRate := &jsonSchema{
+		Type:        "number",
+		Description: "Mensagens por segundo de métricas e canais (0 = sem limite)",
+		Minimum:     bound(0),
+	}
+
+	registerCommand(&commandSpec{
+		Name:        "hello",
+		Description: "Negocia a versão do protocolo",
+		Since:       1,
+		Params: &jsonSchema{
+			Type:       "object",
+			Properties: map[string]*jsonSchema{"version": {Type: "integer", Minimum: bound(1)}},
+			Required:   []string{"version"},
+		},
+		Reply:  "hello",
+		handle: (*Client).handleHello,
+	})
+	registerCommand(&commandSpec{
+		Name:        "get_capabilities",
+		Description: "Descreve o protocolo, com o schema dos parâmetros de cada comando",
+		Since:       1,
+		Reply:       "capabilities",
+		handle:      (*Client).handleGetCapabilities,
+	})
+	registerCommand(&commandSpec{
+		Name:        "ping",
+		Description: "Mede a latência; time é devolvido no pong",
+		Since:       1,
+		Params: &jsonSchema{
+			Type:       "object",
+			Properties: map[string]*jsonSchema{"time": {Type: "integer", Description: "Timestamp do cliente em milissegundos"}},
+		},
+		Reply:  "pong",
+		handle: (*Client).handlePing,
+	})
+	registerCommand(&commandSpec{
+		Name:        "get_history",
+		Description: "Histórico de uma velocidade armazenado no Redis",
+		Since:       1,
+		Params: &jsonSchema{
+			Type: "object",
+			Properties: map[string]*jsonSchema{
+				"index": {Type: "integer", Description: "Índice da velocidade", Minimum: bound(1), Maximum: bound(channelCount)},
+			},
+			Required: []string{"index"},
+		},
+		Reply:  "velocity_history",
+		handle: (*Client).handleGetHistory,
+	})
+	registerCommand(&commandSpec{
+		Name:        "get_status",
+		Description: "Status atual do radar",
+		Since:       1,
+		Reply:       "status",
+		handle:      (*Client).handleGetStatus,
+	})
+	for _, name := range []string{"subscribe", "unsubscribe"} {
+		registerCommand(&commandSpec{
+			Name:        name,
+			Description: "Altera os tópicos assinados",
+			Since:       1,
+			Params: &jsonSchema{
+				Type:       "object",
+				Properties: map[string]*jsonSchema{"topics": topicList, "maxRate": maxRate},
+				Required:   []string{"topics"},
+			},
+			Reply:  "subscriptions",
+			handle: (*Client).handleSubscription,
+		})
+	}
+	registerCommand(&commandSpec{
+		Name:        "replay",
+		Description: "Reenvia o histórico gravado, com mensagens metrics/velocity_changes marcadas com replay",
+		Since:       1,
+		Params: &jsonSchema{
+			Type: "object",
+			Properties: map[string]*jsonSchema{
+				"from":  {Type: "string", Format: "date-time", Description: "Início do intervalo (RFC 3339)"},
+				"to":    {Type: "string", Format: "date-time", Description: "Fim do intervalo (RFC 3339, padrão: agora)"},
+				"speed": {Type: "number", Description: "Fator de velocidade (1 = tempo real)", Minimum: bound(replayMinSpeed), Maximum: bound(replayMaxSpeed)},
+			},
+			Required: []string{"from"},
+		},
+		Reply:  "replay_state",
+		handle: (*Client).handleReplay,
+	})
+	for name, description := range map[string]string{
+		"replay_pause":  "Pausa o replay",
+		"replay_resume": "Retoma o replay pausado",
+		"replay_stop":   "Interrompe o replay",
+	} {
+		registerCommand(&commandSpec{
+			Name:        name,
+			Description: description,
+			Since:       1,
+			Reply:       "replay_state",
+			handle:      (*Client).handleReplayControl,
+		})
+	}
+	registerCommand(&commandSpec{
+		Name:        "replay_seek",
+		Description: "Reposiciona o replay",
+		Since:       1,
+		Params: &jsonSchema{
+			Type: "object",
+			Properties: map[string]*jsonSchema{
+				"position": {Type: "string", Format: "date-time", Description: "Nova posição (RFC 3339)"},
+			},
+			Required: []string{"position"},
+		},
+		Reply:  "replay_state",
+		handle: (*Client).handleReplayControl,
+	})
+	registerCommand(&commandSpec{
+		Name:        "set_backpressure",
+		Description: "Define a política aplicada quando a fila de envio do cliente enche",
+		Since:       1,
+		Params: &jsonSchema{
+			Type: "object",
+			Properties: map[string]*jsonSchema{
+				"policy": {Type: "string", Description: `"drop_oldest", "coalesce" ou "downsample"`},
+			},
+			Required: []string{"policy"},
+		},
+		Reply:  "backpressure",
+		handle: (*Client).handleSetBackpressure,
+	})
+	registerCommand(&commandSpec{
+		Name:        "set_rate_limit",
+		Description: "Limita a taxa de métricas e canais",
+		Since:       1,
+		Params: &jsonSchema{
+			Type:       "object",
+			Properties: map[string]*jsonSchema{"maxRate": maxRate},
+			Required:   []string{"maxRate"},
+		},
+		Reply:  "subscriptions",
+		handle: (*Client).handleSubscription,
+	})
+}
@@ -0,0 +1,331 @@
+package websocket
+
+import (
+	"sort"
+	"time"
+
+	"radar_go/internal/models"
+)
+
+const (
+	// Amostras lidas do Redis por consulta
+	replayPageSize = 500
+
+	// Maior espera entre duas amostras, em tempo real, independentemente
+	// do intervalo gravado (lacunas no histórico não travam o replay)
+	replayMaxGap = 2 * time.Second
+
+	// Com a fila do cliente acima da metade, o replay aguarda antes de enviar
+	replayThrottle = 50 * time.Millisecond
+
+	// Limites do fator de velocidade
+	replayMinSpeed = 0.1
+	replayMaxSpeed = 100
+)
+
+// Estados informados em "replay_state"
+const (
+	ReplayPlaying  = "playing"
+	ReplayPaused   = "paused"
+	ReplayStopped  = "stopped"
+	ReplayFinished = "finished"
+	ReplayError    = "error"
+)
+
+// replayControl é um comando de controle enviado à sessão de replay
+type replayControl struct {
+	action   string // "pause", "resume", "seek" ou "stop"
+	position time.Time
+	replyTo  string
+}
+
+// replayEvent é uma amostra ou um grupo de mudanças de velocidade a reenviar
+type replayEvent struct {
+	at      time.Time
+	metrics *models.RadarMetrics
+	changes []models.VelocityChange
+}
+
+// replaySession reenvia o histórico do Redis a um cliente, respeitando os
+// intervalos originais divididos pelo fator de velocidade
+type replaySession struct {
+	hub    *Hub
+	client *Client
+	source DataProvider
+
+	from, to time.Time
+	speed    float64
+
+	// Estado, alterado apenas pela goroutine da sessão
+	position time.Time
+	last     time.Time // Timestamp do último evento enviado (zero após seek)
+	paused   bool
+
+	controls chan replayControl
+	quit     chan struct{}
+}
+
+// newReplaySession cria uma sessão para o intervalo [from, to]
+func newReplaySession(hub *Hub, client *Client, source DataProvider, from, to time.Time, speed float64) *replaySession {
+	return &replaySession{
+		hub:      hub,
+		client:   client,
+		source:   source,
+		from:     from,
+		to:       to,
+		speed:    speed,
+		position: from,
+		controls: make(chan replayControl, 8),
+		quit:     make(chan struct{}),
+	}
+}
+
+// run executa o replay até o fim do intervalo, stop ou desconexão do cliente
+func (r *replaySession) run(replyTo string) {
+	defer r.client.clearReplay(r)
+
+	r.client.log.Infof("Replay iniciado para o cliente %s: %s a %s (%.1fx)",
+		r.client.id, r.from.Format(time.RFC3339), r.to.Format(time.RFC3339), r.speed)
+	r.sendState(ReplayPlaying, replyTo)
+
+	cursor := r.from
+	for {
+		events, err := r.load(cursor)
+		if err != nil {
+			r.client.log.Warnf("Erro no replay do cliente %s: %v", r.client.id, err)
+			r.hub.sendError(r.client, "replay", "", ErrCodeHistoryUnavailable, "Histórico indisponível")
+			r.sendState(ReplayError, "")
+			return
+		}
+		if len(events) == 0 {
+			r.sendState(ReplayFinished, "")
+			r.client.log.Infof("Replay do cliente %s concluído", r.client.id)
+			return
+		}
+
+		next, ok := r.play(events)
+		if !ok {
+			return
+		}
+		cursor = next
+	}
+}
+
+// load lê a próxima página de amostras a partir de cursor, junto com as
+// mudanças de velocidade do mesmo período, em ordem cronológica
+func (r *replaySession) load(cursor time.Time) ([]replayEvent, error) {
+	samples, err := r.source.GetSamples(cursor, r.to, replayPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// Página cheia: as mudanças vão até a última amostra; as seguintes vêm com a próxima página
+	changesTo := r.to
+	if len(samples) == replayPageSize {
+		changesTo = samples[len(samples)-1].Timestamp
+	}
+	changes, err := r.source.GetVelocityChangesRange(cursor, changesTo)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]replayEvent, 0, len(samples)+len(changes))
+	for i := range samples {
+		events = append(events, replayEvent{at: samples[i].Timestamp, metrics: &samples[i]})
+	}
+	for _, change := range changes {
+		// Mudanças com o mesmo timestamp seguem em uma única mensagem
+		if n := len(events); n > 0 && events[n-1].metrics == nil && events[n-1].at.Equal(change.Timestamp) {
+			events[n-1].changes = append(events[n-1].changes, change)
+			continue
+		}
+		events = append(events, replayEvent{at: change.Timestamp, changes: []models.VelocityChange{change}})
+	}
+
+	// Amostras antes das mudanças do mesmo instante
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].at.Before(events[j].at)
+	})
+	return events, nil
+}
+
+// play envia os eventos no ritmo do replay. Retorna o cursor da próxima
+// página (após o último evento, ou a posição de um seek) e false em stop.
+func (r *replaySession) play(events []replayEvent) (time.Time, bool) {
+	for _, event := range events {
+		deadline := time.Now()
+		if !r.last.IsZero() {
+			wait := time.Duration(float64(event.at.Sub(r.last)) / r.speed)
+			if wait > replayMaxGap {
+				wait = replayMaxGap
+			}
+			deadline = deadline.Add(wait)
+		}
+
+	waiting:
+		for {
+			var timer *time.Timer
+			switch wait := time.Until(deadline); {
+			case r.paused:
+				// Aguarda apenas comandos de controle
+			case wait > 0:
+				timer = time.NewTimer(wait)
+			case r.throttled():
+				timer = time.NewTimer(replayThrottle)
+			default:
+				break waiting
+			}
+
+			var timeout <-chan time.Time
+			if timer != nil {
+				timeout = timer.C
+			}
+
+			select {
+			case <-timeout:
+				continue
+			case <-r.quit:
+				if timer != nil {
+					timer.Stop()
+				}
+				return time.Time{}, false
+			case ctl := <-r.controls:
+				if timer != nil {
+					timer.Stop()
+				}
+				switch ctl.action {
+				case "stop":
+					r.sendState(ReplayStopped, ctl.replyTo)
+					r.client.log.Infof("Replay do cliente %s interrompido", r.client.id)
+					return time.Time{}, false
+				case "seek":
+					// Posições fora do intervalo vão para o início ou o fim
+					if ctl.position.Before(r.from) {
+						ctl.position = r.from
+					} else if ctl.position.After(r.to) {
+						ctl.position = r.to
+					}
+					r.position = ctl.position
+					r.last = time.Time{}
+					r.sendState(r.state(), ctl.replyTo)
+					return ctl.position, true
+				case "pause":
+					r.paused = true
+				case "resume":
+					r.paused = false
+				}
+				r.sendState(r.state(), ctl.replyTo)
+			}
+		}
+
+		r.send(event)
+		r.position = event.at
+		r.last = event.at
+	}
+
+	return r.position.Add(time.Millisecond), true
+}
+
+// throttled verifica se a fila do cliente está acima da metade
+func (r *replaySession) throttled() bool {
+	return len(r.client.send) > cap(r.client.send)/2
+}
+
+// state retorna o estado atual da sessão
+func (r *replaySession) state() string {
+	if r.paused {
+		return ReplayPaused
+	}
+	return ReplayPlaying
+}
+
+// send reenvia um evento nos mesmos formatos das mensagens ao vivo, marcadas com replay
+func (r *replaySession) send(event replayEvent) {
+	radarID := r.hub.getRadarID()
+
+	if event.metrics != nil {
+		message := NewMetricsMessage(*event.metrics)
+		message.Timestamp = event.at
+		message.RadarID = radarID
+		message.Replay = true
+		r.hub.sendToClient(r.client, message)
+		return
+	}
+
+	message := NewVelocityChangeMessage(event.changes)
+	message.Timestamp = event.at
+	message.RadarID = radarID
+	message.Replay = true
+	r.hub.sendToClient(r.client, message)
+}
+
+// sendState envia o estado atual do replay
+func (r *replaySession) sendState(state string, replyTo string) {
+	r.hub.sendToClient(r.client, &models.ReplayStateMessage{
+		WebSocketMessage: models.WebSocketMessage{
+			Type:      "replay_state",
+			Timestamp: time.Now(),
+			RadarID:   r.hub.getRadarID(),
+			ReplyTo:   replyTo,
+			Replay:    true,
+		},
+		State:    state,
+		From:     r.from,
+		To:       r.to,
+		Position: r.position,
+		Speed:    r.speed,
+	})
+}
+
+// startReplay inicia um replay, encerrando o anterior do cliente, se houver
+func (c *Client) startReplay(session *replaySession, replyTo string) {
+	c.replayMu.Lock()
+	previous := c.replay
+	c.replay = session
+	c.replayMu.Unlock()
+
+	if previous != nil {
+		close(previous.quit)
+	}
+	go session.run(replyTo)
+}
+
+// controlReplay envia um comando à sessão ativa; retorna false se não houver
+// replay em andamento
+func (c *Client) controlReplay(ctl replayControl) bool {
+	c.replayMu.Lock()
+	defer c.replayMu.Unlock()
+
+	if c.replay == nil {
+		return false
+	}
+	select {
+	case c.replay.controls <- ctl:
+	default:
+		// Sessão ocupada com comandos anteriores; o cliente pode repetir
+		return false
+	}
+	return true
+}
+
+// stopReplay encerra o replay em andamento sem notificar (desconexão)
+func (c *Client) stopReplay() {
+	c.replayMu.Lock()
+	session := c.replay
+	c.replay = nil
+	c.replayMu.Unlock()
+
+	if session != nil {
+		close(session.quit)
+	}
+}
+
+// clearReplay remove a sessão concluída, se ainda for a ativa
+func (c *Client) clearReplay(session *replaySession) {
+	c.replayMu.Lock()
+	defer c.replayMu.Unlock()
+
+	if c.replay == session {
+		c.replay = nil
+	}
+}
@@ -0,0 +1,291 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"radar_go/internal/auth"
+	"radar_go/internal/models"
+)
+
+// fakeHistory é um DataProvider com amostras gravadas em memória
+type fakeHistory struct {
+	samples []models.RadarMetrics
+	changes []models.VelocityChange
+	err     error
+}
+
+func (f *fakeHistory) GetStatus() models.RadarStatus        { return models.RadarStatus{} }
+func (f *fakeHistory) GetLastMetrics() *models.RadarMetrics { return nil }
+func (f *fakeHistory) GetVelocityHistory(int) ([]models.HistoryPoint, error) {
+	return nil, nil
+}
+
+func (f *fakeHistory) GetSamples(from, to time.Time, limit int) ([]models.RadarMetrics, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var samples []models.RadarMetrics
+	for _, sample := range f.samples {
+		if !sample.Timestamp.Before(from) && !sample.Timestamp.After(to) && len(samples) < limit {
+			samples = append(samples, sample)
+		}
+	}
+	return samples, nil
+}
+
+func (f *fakeHistory) GetVelocityChangesRange(from, to time.Time) ([]models.VelocityChange, error) {
+	var changes []models.VelocityChange
+	for _, change := range f.changes {
+		if !change.Timestamp.Before(from) && !change.Timestamp.After(to) {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// replayStart é o início do histórico dos testes
+var replayStart = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// recordedHistory grava n amostras, uma a cada interval
+func recordedHistory(n int, interval time.Duration) *fakeHistory {
+	history := &fakeHistory{}
+	for i := 0; i < n; i++ {
+		history.samples = append(history.samples, models.RadarMetrics{
+			Timestamp:  replayStart.Add(time.Duration(i) * interval),
+			Velocities: [7]float64{float64(i)},
+			Status:     "ok",
+		})
+	}
+	return history
+}
+
+// replayMessage reúne os campos das mensagens do replay
+type replayMessage struct {
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	ReplyTo   string    `json:"replyTo"`
+	Replay    bool      `json:"replay"`
+	State     string    `json:"state"`
+	Position  time.Time `json:"position"`
+	Code      string    `json:"code"`
+}
+
+// newReplayClient cria um cliente com o histórico informado como fonte
+func newReplayClient(t *testing.T, history *fakeHistory) *Client {
+	t.Helper()
+
+	h := NewHub()
+	h.SetDataProvider(history)
+	client := newTestClient(h)
+	client.send = make(chan []byte, 64)
+	client.identity = &auth.Identity{Subject: "teste", Role: auth.RoleViewer}
+	client.protocolVersion = ProtocolVersion
+	t.Cleanup(client.stopReplay)
+	return client
+}
+
+// command envia um comando como o readPump
+func command(client *Client, text string) {
+	client.processIncomingMessage([]byte(text))
+}
+
+// nextMessage aguarda a próxima mensagem enviada ao cliente
+func nextMessage(t *testing.T, client *Client, timeout time.Duration) replayMessage {
+	t.Helper()
+
+	select {
+	case data := <-client.send:
+		var message replayMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			t.Fatalf("mensagem inválida: %s", data)
+		}
+		return message
+	case <-time.After(timeout):
+		t.Fatalf("nenhuma mensagem em %v", timeout)
+	}
+	return replayMessage{}
+}
+
+// expectState aguarda uma mensagem replay_state
+func expectState(t *testing.T, client *Client, state, replyTo string) replayMessage {
+	t.Helper()
+
+	message := nextMessage(t, client, 2*time.Second)
+	if message.Type != "replay_state" || message.State != state || message.ReplyTo != replyTo {
+		t.Fatalf("mensagem %+v, esperado replay_state %s em resposta a %q", message, state, replyTo)
+	}
+	return message
+}
+
+// expectSample aguarda a amostra gravada no segundo informado
+func expectSample(t *testing.T, client *Client, second int) {
+	t.Helper()
+
+	message := nextMessage(t, client, 2*time.Second)
+	want := replayStart.Add(time.Duration(second) * time.Second)
+	if message.Type != "metrics" || !message.Replay || !message.Timestamp.Equal(want) {
+		t.Fatalf("mensagem %+v, esperado amostra de %v marcada com replay", message, want)
+	}
+}
+
+// expectNothing verifica que nenhuma mensagem chega no período
+func expectNothing(t *testing.T, client *Client, period time.Duration) {
+	t.Helper()
+
+	select {
+	case data := <-client.send:
+		t.Fatalf("mensagem inesperada: %s", data)
+	case <-time.After(period):
+	}
+}
+
+// startReplayCommand envia o comando replay para o intervalo [from, to] em segundos
+func startReplayCommand(client *Client, from, to int, speed float64) {
+	command(client, fmt.Sprintf(`{"type":"replay","id":"r1","params":{"from":%q,"to":%q,"speed":%v}}`,
+		replayStart.Add(time.Duration(from)*time.Second).Format(time.RFC3339),
+		replayStart.Add(time.Duration(to)*time.Second).Format(time.RFC3339), speed))
+}
+
+func TestReplayPlaysToEnd(t *testing.T) {
+	history := recordedHistory(5, time.Second)
+	history.changes = []models.VelocityChange{
+		{Index: 1, OldValue: 1, NewValue: 2, Timestamp: replayStart.Add(2 * time.Second)},
+		{Index: 2, OldValue: 0, NewValue: 1, Timestamp: replayStart.Add(2 * time.Second)},
+	}
+	client := newReplayClient(t, history)
+
+	// 1s gravado a 100x: 10ms entre as amostras
+	startReplayCommand(client, 1, 4, 100)
+	expectState(t, client, ReplayPlaying, "r1")
+
+	expectSample(t, client, 1)
+	expectSample(t, client, 2)
+	if message := nextMessage(t, client, time.Second); message.Type != "velocity_changes" || !message.Replay {
+		t.Fatalf("mensagem %+v, esperado as mudanças do segundo 2 agrupadas", message)
+	}
+	expectSample(t, client, 3)
+	expectSample(t, client, 4)
+	expectState(t, client, ReplayFinished, "")
+
+	// Concluído, não há replay para controlar
+	command(client, `{"type":"replay_stop","id":"s1"}`)
+	if message := nextMessage(t, client, time.Second); message.Type != "error" || message.Code != ErrCodeReplayNotActive {
+		t.Errorf("stop após o fim: %+v, esperado erro %s", message, ErrCodeReplayNotActive)
+	}
+}
+
+func TestReplayPauseResume(t *testing.T) {
+	client := newReplayClient(t, recordedHistory(4, time.Second))
+
+	// 1s gravado a 5x: 200ms entre as amostras
+	startReplayCommand(client, 0, 3, 5)
+	expectState(t, client, ReplayPlaying, "r1")
+	expectSample(t, client, 0)
+
+	command(client, `{"type":"replay_pause","id":"p1"}`)
+	paused := expectState(t, client, ReplayPaused, "p1")
+	if !paused.Position.Equal(replayStart) {
+		t.Errorf("posição ao pausar = %v, esperado %v", paused.Position, replayStart)
+	}
+
+	// Pausado, nada é enviado mesmo após o intervalo das amostras
+	expectNothing(t, client, 500*time.Millisecond)
+
+	command(client, `{"type":"replay_resume","id":"p2"}`)
+	expectState(t, client, ReplayPlaying, "p2")
+	expectSample(t, client, 1)
+	expectSample(t, client, 2)
+}
+
+func TestReplaySeek(t *testing.T) {
+	client := newReplayClient(t, recordedHistory(10, time.Second))
+
+	// Em tempo real: 1s entre as amostras
+	startReplayCommand(client, 0, 9, 1)
+	expectState(t, client, ReplayPlaying, "r1")
+	expectSample(t, client, 0)
+
+	// Avança para o segundo 7: a amostra segue sem esperar o intervalo
+	command(client, fmt.Sprintf(`{"type":"replay_seek","id":"k1","params":{"position":%q}}`,
+		replayStart.Add(7*time.Second).Format(time.RFC3339)))
+	state := expectState(t, client, ReplayPlaying, "k1")
+	if want := replayStart.Add(7 * time.Second); !state.Position.Equal(want) {
+		t.Errorf("posição após seek = %v, esperado %v", state.Position, want)
+	}
+	expectSample(t, client, 7)
+
+	// Pausado, o seek mantém a pausa; posições fora do intervalo vão para o início
+	command(client, `{"type":"replay_pause","id":"p1"}`)
+	expectState(t, client, ReplayPaused, "p1")
+	command(client, fmt.Sprintf(`{"type":"replay_seek","id":"k2","params":{"position":%q}}`,
+		replayStart.Add(-time.Hour).Format(time.RFC3339)))
+	if state := expectState(t, client, ReplayPaused, "k2"); !state.Position.Equal(replayStart) {
+		t.Errorf("posição após seek antes do início = %v, esperado %v", state.Position, replayStart)
+	}
+	expectNothing(t, client, 200*time.Millisecond)
+
+	command(client, `{"type":"replay_resume","id":"p2"}`)
+	expectState(t, client, ReplayPlaying, "p2")
+	expectSample(t, client, 0)
+
+	// Posição inválida é recusada sem afetar o replay
+	command(client, `{"type":"replay_seek","id":"k3","params":{"position":"ontem"}}`)
+	if message := nextMessage(t, client, time.Second); message.Type != "error" || message.Code != ErrCodeInvalidParams {
+		t.Errorf("seek inválido: %+v, esperado erro %s", message, ErrCodeInvalidParams)
+	}
+}
+
+func TestReplayStop(t *testing.T) {
+	client := newReplayClient(t, recordedHistory(10, time.Second))
+
+	startReplayCommand(client, 0, 9, 1)
+	expectState(t, client, ReplayPlaying, "r1")
+	expectSample(t, client, 0)
+
+	command(client, `{"type":"replay_stop","id":"s1"}`)
+	expectState(t, client, ReplayStopped, "s1")
+	expectNothing(t, client, 1200*time.Millisecond)
+
+	waitFor(t, time.Second, "fim da sessão", func() bool {
+		client.replayMu.Lock()
+		defer client.replayMu.Unlock()
+		return client.replay == nil
+	})
+	command(client, `{"type":"replay_pause","id":"p1"}`)
+	if message := nextMessage(t, client, time.Second); message.Type != "error" || message.Code != ErrCodeReplayNotActive {
+		t.Errorf("pause após stop: %+v, esperado erro %s", message, ErrCodeReplayNotActive)
+	}
+}
+
+func TestReplayRestartAndErrors(t *testing.T) {
+	client := newReplayClient(t, recordedHistory(10, time.Second))
+
+	// Um novo replay encerra o anterior sem notificar
+	startReplayCommand(client, 0, 9, 1)
+	expectState(t, client, ReplayPlaying, "r1")
+	expectSample(t, client, 0)
+	startReplayCommand(client, 5, 9, 1)
+	expectState(t, client, ReplayPlaying, "r1")
+	expectSample(t, client, 5)
+	client.stopReplay()
+	expectNothing(t, client, 1200*time.Millisecond)
+
+	// Intervalo invertido
+	startReplayCommand(client, 5, 1, 1)
+	if message := nextMessage(t, client, time.Second); message.Type != "error" || message.Code != ErrCodeInvalidParams {
+		t.Errorf("intervalo invertido: %+v, esperado erro %s", message, ErrCodeInvalidParams)
+	}
+
+	// Falha ao ler o histórico
+	client.hub.SetDataProvider(&fakeHistory{err: errors.New("redis indisponível")})
+	startReplayCommand(client, 0, 9, 1)
+	expectState(t, client, ReplayPlaying, "r1")
+	if message := nextMessage(t, client, time.Second); message.Type != "error" || message.Code != ErrCodeHistoryUnavailable {
+		t.Errorf("erro do histórico: %+v, esperado erro %s", message, ErrCodeHistoryUnavailable)
+	}
+	expectState(t, client, ReplayError, "")
+}
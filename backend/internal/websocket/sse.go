@@ -0,0 +1,317 @@
+package websocket
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"radar_go/internal/auth"
+	"radar_go/pkg/logger"
+)
+
+const (
+	// Eventos mantidos em memória para retomada com Last-Event-ID
+	sseRingSize = 1024
+
+	// Eventos aguardando envio por assinante; acima disso a conexão é
+	// encerrada e o cliente retoma pelo Last-Event-ID
+	sseBufferSize = 256
+
+	// Intervalo dos comentários de heartbeat
+	sseHeartbeatInterval = 15 * time.Second
+
+	// Espera sugerida ao navegador antes de reconectar
+	sseRetry = 3 * time.Second
+)
+
+// sseSubscriber é uma conexão de /api/stream
+type sseSubscriber struct {
+	id        string
+	ipAddress string
+	sub       *subscription
+	events    chan *hubMessage
+	log       *logger.Logger // Campos clientId e remote em cada linha
+}
+
+// eventStream guarda os últimos eventos publicados, numerados em ordem, e os
+// assinantes SSE. É alimentado pelo loop do hub.
+type eventStream struct {
+	mu sync.Mutex
+
+	ring   []*hubMessage // Buffer circular com até sseRingSize eventos
+	head   int           // Posição do evento mais antigo
+	lastID uint64
+
+	subscribers map[*sseSubscriber]bool
+
+	overflows int64 // Assinantes encerrados por fila cheia
+}
+
+// newEventStream cria o buffer de eventos vazio
+func newEventStream() *eventStream {
+	return &eventStream{
+		ring:        make([]*hubMessage, 0, sseRingSize),
+		subscribers: make(map[*sseSubscriber]bool),
+	}
+}
+
+// publish numera a mensagem, guarda no buffer e entrega aos assinantes
+func (s *eventStream) publish(msg *hubMessage, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastID++
+	msg.id = s.lastID
+	if len(s.ring) < sseRingSize {
+		s.ring = append(s.ring, msg)
+	} else {
+		s.ring[s.head] = msg
+		s.head = (s.head + 1) % sseRingSize
+	}
+
+	for subscriber := range s.subscribers {
+		if !subscriber.sub.accepts(msg, now) {
+			continue
+		}
+		select {
+		case subscriber.events <- msg:
+		default:
+			// Cliente lento: encerra a conexão em vez de bloquear o hub
+			subscriber.log.Warnf("Fila do cliente SSE %s cheia, encerrando conexão", subscriber.id)
+			delete(s.subscribers, subscriber)
+			close(subscriber.events)
+			s.overflows++
+		}
+	}
+}
+
+// subscribe registra um assinante e retorna os eventos após lastID que ele
+// deve receber antes dos novos. gap indica que parte dos eventos pedidos já
+// saiu do buffer (ou que o servidor foi reiniciado).
+func (s *eventStream) subscribe(subscriber *sseSubscriber, lastID uint64, resume bool) (backlog []*hubMessage, gap bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if resume {
+		if lastID > s.lastID {
+			gap = true
+		} else if len(s.ring) > 0 && s.ring[s.head].id > lastID+1 {
+			gap = true
+		}
+
+		for i := 0; i < len(s.ring); i++ {
+			msg := s.ring[(s.head+i)%len(s.ring)]
+			if msg.id > lastID && subscriber.sub.matches(msg) {
+				backlog = append(backlog, msg)
+			}
+		}
+	}
+
+	s.subscribers[subscriber] = true
+	return backlog, gap
+}
+
+// unsubscribe remove o assinante, se ainda registrado
+func (s *eventStream) unsubscribe(subscriber *sseSubscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subscribers[subscriber] {
+		delete(s.subscribers, subscriber)
+		close(subscriber.events)
+	}
+}
+
+// closeAll encerra todos os assinantes (desligamento do hub)
+func (s *eventStream) closeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for subscriber := range s.subscribers {
+		delete(s.subscribers, subscriber)
+		close(subscriber.events)
+	}
+}
+
+// wantsChannel verifica se algum assinante SSE assinou o canal
+func (s *eventStream) wantsChannel(channel int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for subscriber := range s.subscribers {
+		if subscriber.sub.wantsChannel(channel) {
+			return true
+		}
+	}
+	return false
+}
+
+// stats retorna o número de assinantes, o último id e os encerrados por fila cheia
+func (s *eventStream) stats() (clients int, lastID uint64, overflows int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subscribers), s.lastID, s.overflows
+}
+
+// Contador para os ids dos clientes SSE
+var sseClientCounter int64
+
+// HandleStream atende /api/stream: os mesmos tópicos do WebSocket como
+// Server-Sent Events. Parâmetros: topics (ex.: "metrics,alarms,channel:2"),
+// radar e maxRate. A retomada usa o cabeçalho Last-Event-ID ou o parâmetro
+// lastEventId.
+func (h *Handler) HandleStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming não suportado", http.StatusInternalServerError)
+		return
+	}
+
+	sub, err := parseStreamFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	var lastID uint64
+	resume := lastEventID != ""
+	if resume {
+		lastID, err = strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			http.Error(w, "Last-Event-ID inválido: "+lastEventID, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// A conexão fica aberta além do WriteTimeout do servidor
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		h.hub.getLog().Debugf("Não foi possível remover o prazo de escrita do SSE: %v", err)
+	}
+
+	subscriber := &sseSubscriber{
+		id:        fmt.Sprintf("sse-%d", atomic.AddInt64(&sseClientCounter, 1)),
+		ipAddress: getIPAddress(r),
+		sub:       sub,
+		events:    make(chan *hubMessage, sseBufferSize),
+	}
+	subscriber.log = h.hub.getLog().With("clientId", subscriber.id, "remote", subscriber.ipAddress, "transport", "sse")
+	backlog, gap := h.hub.stream.subscribe(subscriber, lastID, resume)
+	defer h.hub.stream.unsubscribe(subscriber)
+
+	subscriber.log.Infof("Nova conexão SSE de %s (%s) como %s. ID: %s, retomada: %v, eventos reenviados: %d",
+		subscriber.ipAddress, r.UserAgent(), auth.FromContext(r.Context()), subscriber.id, resume, len(backlog))
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Desativa o buffer do nginx
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+	if gap {
+		// Parte dos eventos foi perdida; o cliente deve recarregar o estado
+		fmt.Fprintf(w, "event: gap\ndata: {\"type\":\"gap\",\"lastEventId\":%d}\n\n", lastID)
+	}
+	for _, msg := range backlog {
+		if err := writeEvent(w, msg); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			subscriber.log.Infof("Cliente SSE desconectado. ID: %s", subscriber.id)
+			return
+		case msg, ok := <-subscriber.events:
+			if !ok {
+				// Fila cheia ou hub encerrado
+				return
+			}
+			if err := writeEvent(w, msg); err != nil {
+				return
+			}
+			// Esvazia o que já estiver na fila antes do flush
+			for pending := len(subscriber.events); pending > 0; pending-- {
+				if err := writeEvent(w, <-subscriber.events); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprintf(w, ": heartbeat %d\n\n", time.Now().UnixMilli()); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent escreve uma mensagem do hub como evento SSE, nomeado pelo tópico
+func writeEvent(w http.ResponseWriter, msg *hubMessage) error {
+	if msg == nil {
+		return fmt.Errorf("fila encerrada")
+	}
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", msg.id, msg.topic, msg.data)
+	return err
+}
+
+// parseStreamFilters monta a assinatura a partir dos parâmetros topics, radar e maxRate
+func parseStreamFilters(r *http.Request) (*subscription, error) {
+	query := r.URL.Query()
+	sub := newSubscription()
+
+	var filters []string
+	for _, value := range query["topics"] {
+		filters = append(filters, splitList(value)...)
+	}
+	for _, value := range query["radar"] {
+		for _, radar := range splitList(value) {
+			filters = append(filters, radarFilterPrefix+radar)
+		}
+	}
+	if len(filters) > 0 {
+		if err := sub.subscribe(filters); err != nil {
+			return nil, err
+		}
+	}
+
+	if value := query.Get("maxRate"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("maxRate inválido: %q", value)
+		}
+		if err := sub.setMaxRate(rate); err != nil {
+			return nil, err
+		}
+	}
+	return sub, nil
+}
+
+// splitList separa uma lista separada por vírgulas, ignorando itens vazios
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
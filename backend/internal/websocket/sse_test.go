@@ -0,0 +1,296 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"radar_go/internal/models"
+)
+
+// sseEvent é um evento lido de /api/stream
+type sseEvent struct {
+	id    uint64
+	event string
+	data  string
+}
+
+// startStreamServer inicia o hub e um servidor HTTP com /api/stream
+func startStreamServer(t *testing.T, h *Hub) *httptest.Server {
+	t.Helper()
+
+	startHub(t, h)
+	server := httptest.NewServer(http.HandlerFunc(NewHandler(h).HandleStream))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// openStream conecta ao stream e lê os eventos em segundo plano
+func openStream(t *testing.T, server *httptest.Server, query, lastEventID string) (*http.Response, <-chan sseEvent) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	url := server.URL + "/api/stream"
+	if query != "" {
+		url += "?" + query
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("erro ao conectar ao stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	events := make(chan sseEvent, 2*sseRingSize)
+	go func() {
+		defer close(events)
+
+		scanner := bufio.NewScanner(resp.Body)
+		var event sseEvent
+		var fields bool
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				// Fim do evento; blocos só com retry ou comentários são ignorados
+				if fields {
+					events <- event
+				}
+				event, fields = sseEvent{}, false
+			case strings.HasPrefix(line, "id: "):
+				event.id, _ = strconv.ParseUint(strings.TrimPrefix(line, "id: "), 10, 64)
+				fields = true
+			case strings.HasPrefix(line, "event: "):
+				event.event = strings.TrimPrefix(line, "event: ")
+				fields = true
+			case strings.HasPrefix(line, "data: "):
+				event.data = strings.TrimPrefix(line, "data: ")
+				fields = true
+			}
+		}
+	}()
+	return resp, events
+}
+
+// nextEvent aguarda o próximo evento do stream
+func nextEvent(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("stream encerrado")
+		}
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("nenhum evento recebido")
+	}
+	return sseEvent{}
+}
+
+// publishTopic publica n mensagens do tópico e aguarda o hub numerá-las
+func publishTopic(t *testing.T, h *Hub, topic string, n int) {
+	t.Helper()
+
+	_, lastID, _ := h.stream.stats()
+	for i := 0; i < n; i++ {
+		h.publish(topic, 0, models.WebSocketMessage{Type: topic, Timestamp: time.Now()})
+	}
+	waitFor(t, 5*time.Second, "publicação no stream", func() bool {
+		_, id, _ := h.stream.stats()
+		return id == lastID+uint64(n)
+	})
+}
+
+func TestStreamTopicFiltering(t *testing.T) {
+	h := NewHub()
+	h.SetRadarID("radar-1")
+	server := startStreamServer(t, h)
+
+	resp, filtered := openStream(t, server, "topics=status,channel:2", "")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("resposta %d %q, esperado 200 text/event-stream", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	_, all := openStream(t, server, "", "")
+	_, events := openStream(t, server, "topics=events&radar=radar-1", "")
+	waitFor(t, 5*time.Second, "assinantes do stream", func() bool {
+		clients, _, _ := h.stream.stats()
+		return clients == 3
+	})
+
+	publishAll(h, "radar-1")
+
+	// Padrão: todos os tópicos, exceto os canais
+	var got []string
+	for i := 0; i < 5; i++ {
+		got = append(got, nextEvent(t, all).event)
+	}
+	if want := "metrics,velocity_changes,status,alarms,events"; strings.Join(got, ",") != want {
+		t.Errorf("stream sem filtro recebeu %v, esperado %s", got, want)
+	}
+
+	// Com filtro, apenas o canal e o status, com os mesmos ids
+	channel := nextEvent(t, filtered)
+	status := nextEvent(t, filtered)
+	if channel.event != TopicChannel || !strings.Contains(channel.data, `"channel":2`) || status.event != TopicStatus {
+		t.Errorf("stream filtrado recebeu %+v e %+v, esperado channel:2 e status", channel, status)
+	}
+	if channel.id != 2 || status.id != 5 {
+		t.Errorf("ids %d e %d, esperado 2 e 5", channel.id, status.id)
+	}
+
+	if event := nextEvent(t, events); event.event != TopicEvents || event.id != 7 {
+		t.Errorf("stream de eventos recebeu %+v, esperado o evento 7", event)
+	}
+
+	// O dado é a mesma mensagem JSON do WebSocket
+	var message map[string]interface{}
+	if err := json.Unmarshal([]byte(status.data), &message); err != nil || message["radarId"] != "radar-1" {
+		t.Errorf("dado do status inválido: %s", status.data)
+	}
+}
+
+func TestStreamInvalidRequests(t *testing.T) {
+	server := startStreamServer(t, NewHub())
+
+	for _, tt := range []struct {
+		name        string
+		query       string
+		lastEventID string
+	}{
+		{"tópico desconhecido", "topics=temperatura", ""},
+		{"canal inválido", "topics=channel:8", ""},
+		{"taxa inválida", "maxRate=rápido", ""},
+		{"taxa negativa", "maxRate=-1", ""},
+		{"Last-Event-ID inválido", "", "abc"},
+		{"lastEventId inválido", "lastEventId=-1", ""},
+	} {
+		resp, _ := openStream(t, server, tt.query, tt.lastEventID)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status %d, esperado 400", tt.name, resp.StatusCode)
+		}
+	}
+}
+
+func TestStreamResume(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		lastEventID string
+		want        []uint64
+	}{
+		{"cabeçalho", "", "3", []uint64{4, 5, 6, 7}},
+		{"parâmetro", "lastEventId=5", "", []uint64{6, 7}},
+		{"cabeçalho sobre o parâmetro", "lastEventId=1", "6", []uint64{7}},
+		{"filtro no reenvio", "topics=metrics", "0", []uint64{6}},
+		{"em dia", "", "7", nil},
+	}
+
+	for _, tt := range tests {
+		h := NewHub()
+		server := startStreamServer(t, h)
+
+		// Eventos publicados sem assinantes ficam no buffer: status 1-5, métrica 6, status 7
+		publishTopic(t, h, TopicStatus, 5)
+		publishTopic(t, h, TopicMetrics, 1)
+		publishTopic(t, h, TopicStatus, 1)
+
+		_, events := openStream(t, server, tt.query, tt.lastEventID)
+
+		// Um evento novo marca o fim do reenvio
+		publishTopic(t, h, TopicMetrics, 1)
+		publishTopic(t, h, TopicStatus, 1)
+
+		var got []uint64
+		for {
+			event := nextEvent(t, events)
+			if event.event == "gap" {
+				t.Errorf("%s: gap inesperado", tt.name)
+				continue
+			}
+			if event.id > 7 {
+				break
+			}
+			got = append(got, event.id)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: reenviados %v, esperado %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStreamResumeAfterRingOverflow(t *testing.T) {
+	h := NewHub()
+	server := startStreamServer(t, h)
+
+	// O buffer guarda apenas os últimos sseRingSize eventos: 11 a 1034
+	total := sseRingSize + 10
+	publishTopic(t, h, TopicStatus, total)
+
+	_, events := openStream(t, server, "", "5")
+	gap := nextEvent(t, events)
+	if gap.event != "gap" || gap.data != `{"type":"gap","lastEventId":5}` {
+		t.Fatalf("primeiro evento %+v, esperado gap", gap)
+	}
+	for want := uint64(11); want <= uint64(total); want++ {
+		if event := nextEvent(t, events); event.id != want {
+			t.Fatalf("evento %d reenviado, esperado %d", event.id, want)
+		}
+	}
+
+	// O evento mais antigo ainda no buffer não gera gap
+	_, events = openStream(t, server, "", "10")
+	if event := nextEvent(t, events); event.event == "gap" || event.id != 11 {
+		t.Errorf("retomada do evento 10: %+v, esperado o evento 11 sem gap", event)
+	}
+
+	// Id além do último publicado (servidor reiniciado): gap e apenas eventos novos
+	_, events = openStream(t, server, "", "5000")
+	if event := nextEvent(t, events); event.event != "gap" {
+		t.Errorf("retomada após reinício: %+v, esperado gap", event)
+	}
+	publishTopic(t, h, TopicStatus, 1)
+	if event := nextEvent(t, events); event.id != uint64(total)+1 {
+		t.Errorf("evento %+v, esperado o novo %d", event, total+1)
+	}
+}
+
+func TestStreamSlowSubscriber(t *testing.T) {
+	stream := newEventStream()
+	h := NewHub()
+
+	slow := &sseSubscriber{id: "lento", sub: newSubscription(), events: make(chan *hubMessage, 2), log: h.getLog()}
+	stream.subscribe(slow, 0, false)
+
+	// A fila cheia encerra o assinante em vez de bloquear o hub
+	for i := 0; i < 3; i++ {
+		stream.publish(&hubMessage{topic: TopicStatus, data: []byte(`{}`)}, time.Now())
+	}
+	clients, lastID, overflows := stream.stats()
+	if clients != 0 || lastID != 3 || overflows != 1 {
+		t.Errorf("assinantes %d, último id %d, encerrados %d; esperado 0, 3, 1", clients, lastID, overflows)
+	}
+	<-slow.events
+	<-slow.events
+	if _, ok := <-slow.events; ok {
+		t.Error("fila do assinante lento não foi fechada")
+	}
+
+	// unsubscribe posterior não fecha o canal de novo
+	stream.unsubscribe(slow)
+}
@@ -0,0 +1,279 @@
+package websocket
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tópicos que um cliente pode assinar
+const (
+	TopicMetrics         = "metrics"
+	TopicVelocityChanges = "velocity_changes"
+	TopicStatus          = "status"
+	TopicAlarms          = "alarms"
+	TopicEvents          = "events"  // Registro de eventos operacionais
+	TopicChannel         = "channel" // Assinado como "channel:N" (N = 1-7)
+
+	// Filtro de radar, assinado como "radar:<id>"
+	radarFilterPrefix = "radar:"
+
+	// Assina todos os tópicos (comportamento padrão de um cliente novo)
+	topicAll = "*"
+)
+
+// Número de canais de velocidade/posição do radar
+const channelCount = 7
+
+// hubMessage é uma mensagem serializada com os metadados usados no roteamento
+type hubMessage struct {
+	topic   string // Vazio = mensagem de sistema, entregue a todos
+	channel int    // Canal (1-7) para TopicChannel
+	radarID string
+	data    []byte      // JSON
+	payload interface{} // Mensagem original, para as codificações binárias
+	cbor    []byte      // CBOR, serializado no primeiro envio (apenas pelo loop do hub)
+	id      uint64      // Id do evento no buffer do SSE (0 = mensagem de sistema)
+}
+
+// subscription guarda os filtros e o limite de taxa de um cliente.
+// É alterada pelo readPump do cliente e consultada pelo hub.
+type subscription struct {
+	mu sync.Mutex
+
+	// Enquanto explicit for falso o cliente recebe todos os tópicos,
+	// exceto as mensagens por canal (redundantes com "metrics")
+	explicit bool
+	topics   map[string]bool
+	channels map[int]bool
+	radars   map[string]bool
+
+	// Limite de taxa para métricas e canais
+	minInterval time.Duration
+	lastSent    map[string]time.Time
+}
+
+// newSubscription cria uma assinatura que recebe todos os tópicos
+func newSubscription() *subscription {
+	return &subscription{
+		topics:   make(map[string]bool),
+		channels: make(map[int]bool),
+		radars:   make(map[string]bool),
+		lastSent: make(map[string]time.Time),
+	}
+}
+
+// subscribe adiciona filtros. A primeira assinatura de tópico substitui o
+// padrão "todos"; "*" volta ao padrão.
+func (s *subscription) subscribe(filters []string) error {
+	parsed, err := parseFilters(filters)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, f := range parsed {
+		switch {
+		case f.all:
+			s.explicit = false
+			s.topics = make(map[string]bool)
+			s.channels = make(map[int]bool)
+		case f.radar != "":
+			s.radars[f.radar] = true
+		case f.channel > 0:
+			s.explicit = true
+			s.channels[f.channel] = true
+		default:
+			s.explicit = true
+			s.topics[f.topic] = true
+		}
+	}
+	return nil
+}
+
+// unsubscribe remove filtros; "*" cancela todos os tópicos
+func (s *subscription) unsubscribe(filters []string) error {
+	parsed, err := parseFilters(filters)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, f := range parsed {
+		switch {
+		case f.all:
+			s.explicit = true
+			s.topics = make(map[string]bool)
+			s.channels = make(map[int]bool)
+		case f.radar != "":
+			delete(s.radars, f.radar)
+		default:
+			// Sair do modo "todos" preserva os demais tópicos
+			if !s.explicit {
+				s.explicit = true
+				for _, topic := range []string{TopicMetrics, TopicVelocityChanges, TopicStatus, TopicAlarms, TopicEvents} {
+					s.topics[topic] = true
+				}
+			}
+			if f.channel > 0 {
+				delete(s.channels, f.channel)
+			} else {
+				delete(s.topics, f.topic)
+			}
+		}
+	}
+	return nil
+}
+
+// setMaxRate define o máximo de mensagens por segundo por tópico limitado (0 = sem limite)
+func (s *subscription) setMaxRate(rate float64) error {
+	if rate < 0 {
+		return fmt.Errorf("taxa inválida: %v", rate)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.minInterval = 0
+	if rate > 0 {
+		s.minInterval = time.Duration(float64(time.Second) / rate)
+	}
+	return nil
+}
+
+// accepts verifica se a mensagem deve ser entregue ao cliente, aplicando
+// o limite de taxa às métricas e aos canais
+func (s *subscription) accepts(msg *hubMessage, now time.Time) bool {
+	if msg.topic == "" {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.matchesLocked(msg) {
+		return false
+	}
+
+	if s.minInterval > 0 && (msg.topic == TopicMetrics || msg.topic == TopicChannel) {
+		key := msg.topic + ":" + msg.radarID + ":" + strconv.Itoa(msg.channel)
+		if now.Sub(s.lastSent[key]) < s.minInterval {
+			return false
+		}
+		s.lastSent[key] = now
+	}
+	return true
+}
+
+// matches verifica apenas os filtros, sem o limite de taxa (reenvio do SSE)
+func (s *subscription) matches(msg *hubMessage) bool {
+	if msg.topic == "" {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.matchesLocked(msg)
+}
+
+// matchesLocked verifica tópico, canal e radar; requer s.mu
+func (s *subscription) matchesLocked(msg *hubMessage) bool {
+	if len(s.radars) > 0 && msg.radarID != "" && !s.radars[msg.radarID] {
+		return false
+	}
+
+	switch {
+	case msg.topic == TopicChannel:
+		if !s.channels[msg.channel] {
+			return false
+		}
+	case s.explicit && !s.topics[msg.topic]:
+		return false
+	}
+	return true
+}
+
+// wantsChannel verifica se o cliente assinou o canal (1-7)
+func (s *subscription) wantsChannel(channel int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.channels[channel]
+}
+
+// snapshot retorna as assinaturas atuais para confirmação ao cliente
+func (s *subscription) snapshot() (topics []string, radars []string, maxRate float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.explicit {
+		topics = []string{topicAll}
+	} else {
+		topics = []string{}
+		for topic := range s.topics {
+			topics = append(topics, topic)
+		}
+		sort.Strings(topics)
+	}
+
+	channels := make([]int, 0, len(s.channels))
+	for channel := range s.channels {
+		channels = append(channels, channel)
+	}
+	sort.Ints(channels)
+	for _, channel := range channels {
+		topics = append(topics, fmt.Sprintf("%s:%d", TopicChannel, channel))
+	}
+
+	for radar := range s.radars {
+		radars = append(radars, radar)
+	}
+	sort.Strings(radars)
+
+	if s.minInterval > 0 {
+		maxRate = float64(time.Second) / float64(s.minInterval)
+	}
+	return topics, radars, maxRate
+}
+
+// topicFilter é um filtro de assinatura já validado
+type topicFilter struct {
+	all     bool
+	topic   string
+	channel int
+	radar   string
+}
+
+// parseFilters valida filtros como "metrics", "channel:3" ou "radar:radar1"
+func parseFilters(filters []string) ([]topicFilter, error) {
+	if len(filters) == 0 {
+		return nil, fmt.Errorf("nenhum tópico informado")
+	}
+
+	parsed := make([]topicFilter, 0, len(filters))
+	for _, filter := range filters {
+		switch {
+		case filter == topicAll:
+			parsed = append(parsed, topicFilter{all: true})
+		case filter == TopicMetrics, filter == TopicVelocityChanges, filter == TopicStatus, filter == TopicAlarms, filter == TopicEvents:
+			parsed = append(parsed, topicFilter{topic: filter})
+		case strings.HasPrefix(filter, TopicChannel+":"):
+			channel, err := strconv.Atoi(strings.TrimPrefix(filter, TopicChannel+":"))
+			if err != nil || channel < 1 || channel > channelCount {
+				return nil, fmt.Errorf("canal inválido em %q (use 1-%d)", filter, channelCount)
+			}
+			parsed = append(parsed, topicFilter{topic: TopicChannel, channel: channel})
+		case strings.HasPrefix(filter, radarFilterPrefix) && len(filter) > len(radarFilterPrefix):
+			parsed = append(parsed, topicFilter{radar: strings.TrimPrefix(filter, radarFilterPrefix)})
+		default:
+			return nil, fmt.Errorf("tópico desconhecido: %q", filter)
+		}
+	}
+	return parsed, nil
+}
@@ -0,0 +1,167 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultDedupWindow é a janela padrão de supressão de avisos e erros repetidos
+const DefaultDedupWindow = 10 * time.Second
+
+// Supressão de avisos e erros repetidos, aplicada em write
+var dedup = &deduplicator{
+	window:  DefaultDedupWindow,
+	entries: make(map[string]*repetition),
+}
+
+// SetDedupWindow define a janela de supressão de repetições (0 desabilita).
+// Um aviso ou erro idêntico (mesmo nível, ponto do código, mensagem e erro)
+// é escrito uma vez por janela; as repetições são resumidas em "repetida N
+// vezes" ao fim dela. Mensagens diferentes do mesmo ponto do código são
+// sempre escritas. Os campos do logger não fazem parte da comparação: o
+// resumo traz os da última repetição.
+func SetDedupWindow(window time.Duration) {
+	dedup.mu.Lock()
+	dedup.window = window
+	dedup.mu.Unlock()
+
+	if window <= 0 {
+		dedup.flush(true)
+	}
+}
+
+// deduplicator guarda as mensagens escritas na janela atual
+type deduplicator struct {
+	mu       sync.Mutex
+	window   time.Duration
+	entries  map[string]*repetition // Por nível, fonte, mensagem e erro
+	flushing bool                   // Goroutine de resumos em execução
+}
+
+// repetition é uma mensagem e suas repetições suprimidas na janela
+type repetition struct {
+	level  Level
+	source string
+	since  time.Time // Início da janela (primeira ocorrência escrita)
+	count  int       // Repetições suprimidas
+
+	// Mensagem repetida e campos da última repetição, escritos no resumo
+	msg    string
+	err    error
+	fields []interface{}
+}
+
+// allow informa se a mensagem deve ser escrita ou contada como repetição
+func (d *deduplicator) allow(level Level, source, msg string, err error, fields []interface{}) bool {
+	now := time.Now()
+	key := dedupKey(level, source, msg, err)
+
+	d.mu.Lock()
+	if d.window <= 0 {
+		d.mu.Unlock()
+		return true
+	}
+
+	entry, ok := d.entries[key]
+	if ok && now.Sub(entry.since) < d.window {
+		entry.count++
+		entry.fields = fields
+		if !d.flushing {
+			d.flushing = true
+			go d.flushLoop()
+		}
+		d.mu.Unlock()
+		return false
+	}
+
+	// Janela encerrada: resumir as repetições e abrir uma nova
+	var summary *repetition
+	if ok && entry.count > 0 {
+		summary = entry
+	}
+	d.entries[key] = &repetition{level: level, source: source, since: now, msg: msg, err: err}
+	d.mu.Unlock()
+
+	if summary != nil {
+		summary.emit(now)
+	}
+	return true
+}
+
+// dedupKey identifica mensagens idênticas
+func dedupKey(level Level, source, msg string, err error) string {
+	key := level.String() + "|" + source + "|" + msg
+	if err != nil {
+		key += "|" + err.Error()
+	}
+	return key
+}
+
+// flushLoop escreve os resumos das janelas encerradas enquanto houver
+// mensagens acompanhadas
+func (d *deduplicator) flushLoop() {
+	for {
+		d.mu.Lock()
+		interval := d.window / 4
+		d.mu.Unlock()
+		if interval < 100*time.Millisecond {
+			interval = 100 * time.Millisecond
+		}
+		time.Sleep(interval)
+
+		if d.flush(false) == 0 {
+			d.mu.Lock()
+			if len(d.entries) == 0 {
+				d.flushing = false
+				d.mu.Unlock()
+				return
+			}
+			d.mu.Unlock()
+		}
+	}
+}
+
+// flush escreve os resumos das janelas encerradas (ou de todas, se all) e
+// remove essas janelas. Retorna quantas janelas continuam abertas.
+func (d *deduplicator) flush(all bool) int {
+	now := time.Now()
+	var summaries []*repetition
+
+	d.mu.Lock()
+	for key, entry := range d.entries {
+		if !all && now.Sub(entry.since) < d.window {
+			continue
+		}
+		if entry.count > 0 {
+			summaries = append(summaries, entry)
+		}
+		delete(d.entries, key)
+	}
+	open := len(d.entries)
+	d.mu.Unlock()
+
+	for _, summary := range summaries {
+		summary.emit(now)
+	}
+	return open
+}
+
+// emit escreve o resumo das repetições
+func (r *repetition) emit(now time.Time) {
+	elapsed := now.Sub(r.since).Round(100 * time.Millisecond)
+
+	mu.Lock()
+	structured := logFormat == FormatJSON
+	mu.Unlock()
+
+	// Em JSON, contagem e janela vão em campos; em texto, na mensagem
+	msg := r.msg
+	fields := r.fields
+	if structured {
+		fields = append(fields[:len(fields):len(fields)], "repeated", r.count, "window", elapsed)
+	} else {
+		msg = fmt.Sprintf("%s (repetida %d vezes em %v)", r.msg, r.count, elapsed)
+	}
+	emit(r.level, r.source, msg, r.err, fields)
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureLogs redireciona os logs para um buffer durante o teste
+func captureLogs(t *testing.T, window time.Duration) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	SetFormat(FormatText)
+	SetDedupWindow(window)
+
+	t.Cleanup(func() {
+		SetDedupWindow(DefaultDedupWindow)
+		SetOutput(os.Stdout)
+	})
+	return &buf
+}
+
+func TestDedupDifferentMessagesSameCallSite(t *testing.T) {
+	buf := captureLogs(t, time.Minute)
+
+	for _, name := range []string{"DB10", "DB11", "DB12"} {
+		Warnf("Bloco %s indisponível", name)
+	}
+	Sync()
+
+	out := buf.String()
+	for _, name := range []string{"DB10", "DB11", "DB12"} {
+		if !strings.Contains(out, "Bloco "+name+" indisponível") {
+			t.Errorf("mensagem de %s suprimida:\n%s", name, out)
+		}
+	}
+	if strings.Contains(out, "repetida") {
+		t.Errorf("mensagens diferentes resumidas como repetição:\n%s", out)
+	}
+}
+
+func TestDedupIdenticalMessages(t *testing.T) {
+	buf := captureLogs(t, time.Minute)
+
+	for i := 0; i < 5; i++ {
+		Errorf("Erro ao comunicar com o radar: %v", "timeout")
+	}
+	if n := strings.Count(buf.String(), "Erro ao comunicar com o radar: timeout"); n != 1 {
+		t.Fatalf("mensagem escrita %d vezes na janela, esperado 1:\n%s", n, buf.String())
+	}
+
+	Sync()
+	if !strings.Contains(buf.String(), "(repetida 4 vezes em") {
+		t.Errorf("resumo das repetições ausente:\n%s", buf.String())
+	}
+}
+
+func TestDedupSkipsAudit(t *testing.T) {
+	buf := captureLogs(t, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		Audit().Warnf("Autenticação recusada para %s", "10.0.0.1")
+	}
+	Sync()
+
+	if n := strings.Count(buf.String(), "Autenticação recusada para 10.0.0.1"); n != 3 {
+		t.Errorf("aviso de auditoria escrito %d vezes, esperado 3:\n%s", n, buf.String())
+	}
+}
+
+func TestDedupDisabled(t *testing.T) {
+	buf := captureLogs(t, 0)
+
+	for i := 0; i < 3; i++ {
+		Warn("Fila cheia")
+	}
+	if n := strings.Count(buf.String(), "Fila cheia"); n != 3 {
+		t.Errorf("mensagem escrita %d vezes com a supressão desabilitada, esperado 3", n)
+	}
+}
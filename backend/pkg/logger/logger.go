@@ -7,6 +7,8 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -40,6 +42,9 @@ var (
 	// Formato de timestamp
 	timeFormat = "2006-01-02 15:04:05.000"
 
+	// Formato das linhas: texto ou JSON (ver structured.go)
+	logFormat = FormatText
+
 	// Logs padrão - importante: definir depois da inicialização
 	infoLogger  *log.Logger
 	warnLogger  *log.Logger
@@ -74,6 +79,38 @@ func Init() {
 	initialized = true
 }
 
+// ParseLevel converte o nome do nível ("debug", "info", "warn", "error")
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return DEBUG, nil
+	case "info":
+		return INFO, nil
+	case "warn", "warning":
+		return WARN, nil
+	case "error":
+		return ERROR, nil
+	}
+	return INFO, fmt.Errorf("nível de log inválido %q (use debug, info, warn ou error)", name)
+}
+
+// String retorna o nome do nível em minúsculas ("debug", "info", ...)
+func (l Level) String() string {
+	switch l {
+	case DEBUG:
+		return "debug"
+	case INFO:
+		return "info"
+	case WARN:
+		return "warn"
+	case ERROR:
+		return "error"
+	case FATAL:
+		return "fatal"
+	}
+	return strconv.Itoa(int(l))
+}
+
 // SetLevel define o nível mínimo de log
 func SetLevel(level Level) {
 	mu.Lock()
@@ -115,44 +152,36 @@ func SetTimeFormat(format string) {
 	timeFormat = format
 }
 
-// EnableFileLogging habilita o log para arquivo
-func EnableFileLogging(logDir, prefix string) error {
-	mu.Lock()
-	defer mu.Unlock()
-
+// EnableFileLogging habilita o log em arquivo: <prefix>.log com todas as
+// mensagens e <prefix>_error.log com os erros, rotacionados conforme rotation.
+// Chamadas seguintes reabrem os arquivos com as novas opções.
+func EnableFileLogging(logDir, prefix string, rotation Rotation) error {
 	// Criar diretório, se não existir
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return fmt.Errorf("erro ao criar diretório de log: %w", err)
 	}
 
-	// Obter data/hora atual para nome do arquivo
-	timestamp := time.Now().Format("20060102_150405")
-	if prefix != "" {
-		prefix = prefix + "_"
+	if prefix == "" {
+		prefix = "log"
 	}
 
 	// Criar arquivo de log normal
-	logFilePath := filepath.Join(logDir, fmt.Sprintf("%s%s.log", prefix, timestamp))
-	logFile, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	logFile, err := openRotatingFile(filepath.Join(logDir, prefix+".log"), rotation)
 	if err != nil {
 		return fmt.Errorf("erro ao criar arquivo de log: %w", err)
 	}
 
 	// Criar arquivo de log de erro
-	errFilePath := filepath.Join(logDir, fmt.Sprintf("%s%s_error.log", prefix, timestamp))
-	errFile, err := os.OpenFile(errFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	errFile, err := openRotatingFile(filepath.Join(logDir, prefix+"_error.log"), rotation)
 	if err != nil {
 		logFile.Close()
 		return fmt.Errorf("erro ao criar arquivo de log de erro: %w", err)
 	}
 
+	mu.Lock()
+
 	// Fechar arquivos anteriores, se existirem
-	if fileOutput != nil {
-		fileOutput.Close()
-	}
-	if fileOutputErr != nil {
-		fileOutputErr.Close()
-	}
+	closeFiles()
 
 	// Configurar novos arquivos
 	fileOutput = logFile
@@ -166,18 +195,40 @@ func EnableFileLogging(logDir, prefix string) error {
 	warnLogger = log.New(multiOut, "", 0)
 	debugLogger = log.New(multiOut, "", 0)
 	errorLogger = log.New(multiErr, "", 0)
+	mu.Unlock()
 
 	// Registrar início do log
-	Info("Logging iniciado")
+	Infof("Logging iniciado em %s", logDir)
 	return nil
 }
 
+// DisableFileLogging fecha os arquivos de log e volta a escrever apenas no terminal
+func DisableFileLogging() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	closeFiles()
+
+	infoLogger = log.New(logOutput, "", 0)
+	warnLogger = log.New(logOutput, "", 0)
+	debugLogger = log.New(logOutput, "", 0)
+	errorLogger = log.New(errorOutput, "", 0)
+}
+
 // Sync persiste os logs em disco (para IO bufferizado)
 func Sync() {
+	// Resumos de repetições pendentes antes de fechar os arquivos
+	dedup.flush(true)
+
 	mu.Lock()
 	defer mu.Unlock()
 
 	// Fechar arquivos de log
+	closeFiles()
+}
+
+// closeFiles fecha os arquivos de log abertos. Deve ser chamado com mu travado.
+func closeFiles() {
 	if fileOutput != nil {
 		fileOutput.Close()
 		fileOutput = nil
@@ -193,14 +244,46 @@ func GetLogger() *log.Logger {
 	return infoLogger
 }
 
-// logMessage escreve uma mensagem de log com o nível especificado
-func logMessage(level Level, format string, args ...interface{}) {
-	if level < logLevel {
+// write escreve uma entrada de log com os campos de contexto e o erro (se
+// houver). Deve ser chamado diretamente pelas funções de log (Info, Errorf,
+// Logger.Warn etc.), para que a fonte seja quem as chamou.
+func write(level Level, l *Logger, err error, format string, args ...interface{}) {
+	if level < GetLevel() {
 		return
 	}
+	fields := l.context()
 
-	// Obter timestamp
-	timestamp := time.Now().Format(timeFormat)
+	// Fonte do log (arquivo e linha)
+	var source string
+	if includeFile {
+		_, file, line, ok := runtime.Caller(2)
+		if ok {
+			// Extrair somente o nome do arquivo (sem o caminho)
+			source = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+		}
+	}
+
+	// Formatar mensagem
+	var msg string
+	if len(args) == 0 {
+		msg = format
+	} else {
+		msg = fmt.Sprintf(format, args...)
+	}
+
+	// Repetições do mesmo aviso/erro são resumidas (ver dedup.go), exceto
+	// os avisos de segurança e auditoria
+	if level >= WARN && level < FATAL && !l.isAudit() && !dedup.allow(level, source, msg, err, fields) {
+		return
+	}
+
+	emit(level, source, msg, err, fields)
+}
+
+// emit formata e escreve a entrada no formato configurado
+func emit(level Level, source, msg string, err error, fields []interface{}) {
+	mu.Lock()
+	structured := logFormat == FormatJSON
 
 	var loggerToUse *log.Logger
 	var prefix string
@@ -222,95 +305,81 @@ func logMessage(level Level, format string, args ...interface{}) {
 		loggerToUse = errorLogger
 		prefix = "FATAL"
 	}
+	mu.Unlock()
 
-	// Fonte do log (arquivo e linha)
-	var source string
-	if includeFile {
-		_, file, line, ok := runtime.Caller(2)
-		if ok {
-			// Extrair somente o nome do arquivo (sem o caminho)
-			file = filepath.Base(file)
-			source = fmt.Sprintf(" [%s:%d]", file, line)
-		}
-	}
+	now := time.Now()
 
-	// Formatar mensagem
-	var msg string
-	if len(args) == 0 {
-		msg = format
+	var entry string
+	if structured {
+		entry = formatJSON(now, level, source, msg, err, fields)
 	} else {
-		msg = fmt.Sprintf(format, args...)
+		entry = formatText(now, prefix, source, msg, err, fields)
 	}
 
 	// Verificar se o logger foi inicializado
 	if loggerToUse == nil {
 		// Fallback para stderr
-		fmt.Fprintf(os.Stderr, "[%s] %s%s: %s\n", timestamp, prefix, source, msg)
+		fmt.Fprintln(os.Stderr, entry)
 	} else {
 		// Escrever log
-		loggerToUse.Printf("[%s] %s%s: %s", timestamp, prefix, source, msg)
+		loggerToUse.Print(entry)
 	}
 
 	// Se for FATAL, finalizar o programa
 	if level == FATAL {
+		if err != nil {
+			msg = fmt.Sprintf("%s: %v", msg, err)
+		}
 		panic(msg)
 	}
 }
 
 // Debug escreve mensagem de log com nível DEBUG
 func Debug(msg string) {
-	logMessage(DEBUG, "%s", msg)
+	write(DEBUG, nil, nil, "%s", msg)
 }
 
 // Debugf escreve mensagem de log formatada com nível DEBUG
 func Debugf(format string, args ...interface{}) {
-	logMessage(DEBUG, format, args...)
+	write(DEBUG, nil, nil, format, args...)
 }
 
 // Info escreve mensagem de log com nível INFO
 func Info(msg string) {
-	logMessage(INFO, "%s", msg)
+	write(INFO, nil, nil, "%s", msg)
 }
 
 // Infof escreve mensagem de log formatada com nível INFO
 func Infof(format string, args ...interface{}) {
-	logMessage(INFO, format, args...)
+	write(INFO, nil, nil, format, args...)
 }
 
 // Warn escreve mensagem de log com nível WARN
 func Warn(msg string) {
-	logMessage(WARN, "%s", msg)
+	write(WARN, nil, nil, "%s", msg)
 }
 
 // Warnf escreve mensagem de log formatada com nível WARN
 func Warnf(format string, args ...interface{}) {
-	logMessage(WARN, format, args...)
+	write(WARN, nil, nil, format, args...)
 }
 
 // Error escreve mensagem de log com nível ERROR
 func Error(msg string, err error) {
-	if err != nil {
-		logMessage(ERROR, "%s: %v", msg, err)
-	} else {
-		logMessage(ERROR, "%s", msg)
-	}
+	write(ERROR, nil, err, "%s", msg)
 }
 
 // Errorf escreve mensagem de log formatada com nível ERROR
 func Errorf(format string, args ...interface{}) {
-	logMessage(ERROR, format, args...)
+	write(ERROR, nil, nil, format, args...)
 }
 
 // Fatal escreve mensagem de log com nível FATAL e encerra o programa
 func Fatal(msg string, err error) {
-	if err != nil {
-		logMessage(FATAL, "%s: %v", msg, err)
-	} else {
-		logMessage(FATAL, "%s", msg)
-	}
+	write(FATAL, nil, err, "%s", msg)
 }
 
 // Fatalf escreve mensagem de log formatada com nível FATAL e encerra o programa
 func Fatalf(format string, args ...interface{}) {
-	logMessage(FATAL, format, args...)
+	write(FATAL, nil, nil, format, args...)
 }
@@ -0,0 +1,316 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Formato do timestamp no nome dos arquivos rotacionados
+const backupTimeFormat = "20060102-150405"
+
+// Rotation define quando os arquivos de log são rotacionados e quantos
+// arquivos antigos são mantidos. Valores zero desabilitam cada limite.
+type Rotation struct {
+	MaxSize    int64         // Tamanho máximo do arquivo em bytes
+	Daily      bool          // Rotacionar na virada do dia
+	MaxBackups int           // Máximo de arquivos rotacionados mantidos
+	MaxAge     time.Duration // Idade máxima dos arquivos rotacionados
+	Compress   bool          // Compactar os arquivos rotacionados com gzip
+}
+
+// rotatingFile é um arquivo de log (ex.: logs/radar.log) rotacionado por
+// tamanho e por dia. O arquivo atual é renomeado para
+// "radar-<timestamp>.log" e compactado em segundo plano.
+type rotatingFile struct {
+	path     string
+	rotation Rotation
+	now      func() time.Time // Relógio (substituído nos testes)
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time // Início do período do arquivo atual (para a rotação diária)
+
+	// Compactação e limpeza em segundo plano, uma por vez
+	millMu sync.Mutex
+	millWg sync.WaitGroup
+}
+
+// openRotatingFile abre (ou continua) o arquivo de log em path
+func openRotatingFile(path string, rotation Rotation) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, rotation: rotation, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	// Arquivos rotacionados deixados por execuções anteriores
+	f.mill()
+	return f, nil
+}
+
+// open abre o arquivo para acrescentar. Um arquivo existente mantém a data
+// da última escrita, para ser rotacionado se for de outro dia.
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.opened = f.now()
+	if f.size > 0 {
+		f.opened = info.ModTime()
+	}
+	return nil
+}
+
+// Write escreve no arquivo, rotacionando antes se necessário
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if f.shouldRotate(len(p)) {
+		if err := f.rotate(); err != nil {
+			// Continuar escrevendo no arquivo atual
+			fmt.Fprintf(os.Stderr, "Erro ao rotacionar %s: %v\n", f.path, err)
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// shouldRotate verifica os limites de tamanho e de dia
+func (f *rotatingFile) shouldRotate(next int) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.rotation.MaxSize > 0 && f.size+int64(next) > f.rotation.MaxSize {
+		return true
+	}
+	if f.rotation.Daily {
+		y1, m1, d1 := f.opened.Date()
+		y2, m2, d2 := f.now().Date()
+		return y1 != y2 || m1 != m2 || d1 != d2
+	}
+	return false
+}
+
+// rotate renomeia o arquivo atual e abre um novo. Deve ser chamado com f.mu travado.
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	if err := os.Rename(f.path, f.backupName(f.now())); err != nil {
+		// Sem renomear, continuar no mesmo arquivo
+		if openErr := f.open(); openErr != nil {
+			return openErr
+		}
+		return err
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.mill()
+	return nil
+}
+
+// backupName retorna um nome livre para o arquivo rotacionado
+func (f *rotatingFile) backupName(t time.Time) string {
+	base, ext := f.nameParts()
+	stamp := t.Format(backupTimeFormat)
+
+	name := filepath.Join(filepath.Dir(f.path), base+"-"+stamp+ext)
+	for i := 1; fileExists(name) || fileExists(name+".gz"); i++ {
+		name = filepath.Join(filepath.Dir(f.path), fmt.Sprintf("%s-%s.%d%s", base, stamp, i, ext))
+	}
+	return name
+}
+
+// nameParts separa o nome do arquivo em base e extensão ("radar", ".log")
+func (f *rotatingFile) nameParts() (string, string) {
+	name := filepath.Base(f.path)
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext), ext
+}
+
+// mill compacta e remove arquivos rotacionados em segundo plano
+func (f *rotatingFile) mill() {
+	f.millWg.Add(1)
+	go func() {
+		defer f.millWg.Done()
+		f.millMu.Lock()
+		defer f.millMu.Unlock()
+
+		if err := f.compressBackups(); err != nil {
+			fmt.Fprintf(os.Stderr, "Erro ao compactar logs de %s: %v\n", f.path, err)
+		}
+		if err := f.removeOldBackups(); err != nil {
+			fmt.Fprintf(os.Stderr, "Erro ao remover logs antigos de %s: %v\n", f.path, err)
+		}
+	}()
+}
+
+// backups lista os arquivos rotacionados, do mais recente para o mais antigo
+func (f *rotatingFile) backups() ([]os.FileInfo, error) {
+	base, ext := f.nameParts()
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []os.FileInfo
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, base+"-") ||
+			!(strings.HasSuffix(name, ext) || strings.HasSuffix(name, ext+".gz")) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, info)
+	}
+
+	// A compactação mantém a data original, que ordena os arquivos mesmo
+	// com vários rotacionados no mesmo segundo
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].ModTime().Equal(backups[j].ModTime()) {
+			return backups[i].ModTime().After(backups[j].ModTime())
+		}
+		return backups[i].Name() > backups[j].Name()
+	})
+	return backups, nil
+}
+
+// compressBackups compacta os arquivos rotacionados ainda não compactados
+func (f *rotatingFile) compressBackups() error {
+	if !f.rotation.Compress {
+		return nil
+	}
+	backups, err := f.backups()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(f.path)
+	for _, info := range backups {
+		if strings.HasSuffix(info.Name(), ".gz") {
+			continue
+		}
+		if err := compressFile(filepath.Join(dir, info.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeOldBackups aplica MaxBackups e MaxAge
+func (f *rotatingFile) removeOldBackups() error {
+	if f.rotation.MaxBackups <= 0 && f.rotation.MaxAge <= 0 {
+		return nil
+	}
+	backups, err := f.backups()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(f.path)
+	cutoff := f.now().Add(-f.rotation.MaxAge)
+	for i, info := range backups {
+		tooMany := f.rotation.MaxBackups > 0 && i >= f.rotation.MaxBackups
+		tooOld := f.rotation.MaxAge > 0 && info.ModTime().Before(cutoff)
+		if tooMany || tooOld {
+			if err := os.Remove(filepath.Join(dir, info.Name())); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Close fecha o arquivo e aguarda a compactação em andamento
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.mu.Unlock()
+
+	f.millWg.Wait()
+	return err
+}
+
+// compressFile compacta path em path.gz e remove o original
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	gz.Name = filepath.Base(path)
+	gz.ModTime = info.ModTime()
+	_, err = io.Copy(gz, src)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	// Manter a data original, usada por MaxAge
+	os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	src.Close()
+	return os.Remove(path)
+}
+
+// fileExists verifica se o caminho existe
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
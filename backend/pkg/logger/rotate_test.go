@@ -0,0 +1,310 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock é um relógio controlado pelo teste
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// openTestFile abre radar.log em um diretório temporário usando o relógio falso
+func openTestFile(t *testing.T, rotation Rotation, clock *fakeClock) (*rotatingFile, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	f, err := openRotatingFile(filepath.Join(dir, "radar.log"), rotation)
+	if err != nil {
+		t.Fatalf("openRotatingFile: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+
+	// Aguardar a limpeza inicial antes de trocar o relógio
+	f.millWg.Wait()
+	f.mu.Lock()
+	f.now = clock.Now
+	f.opened = clock.Now()
+	f.mu.Unlock()
+	return f, dir
+}
+
+// writeLine escreve uma linha no arquivo
+func writeLine(t *testing.T, f *rotatingFile, line string) {
+	t.Helper()
+	if _, err := f.Write([]byte(line + "\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+}
+
+// listDir retorna os nomes dos arquivos do diretório, em ordem
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readFile lê um arquivo de log, descompactando se terminar em .gz
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatalf("gzip.NewReader(%s): %v", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRotateBySize(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)}
+	f, dir := openTestFile(t, Rotation{MaxSize: 20}, clock)
+
+	writeLine(t, f, "linha 1 ....") // 13 bytes
+	writeLine(t, f, "linha 2")      // Ultrapassaria 20: rotaciona antes
+	clock.Advance(time.Second)
+	writeLine(t, f, "linha 3 ....") // 8 + 13 > 20: rotaciona de novo
+	f.Close()
+
+	want := []string{"radar-20240501-100000.log", "radar-20240501-100001.log", "radar.log"}
+	if got := listDir(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("arquivos = %v, esperado %v", got, want)
+	}
+	for name, content := range map[string]string{
+		"radar-20240501-100000.log": "linha 1 ....\n",
+		"radar-20240501-100001.log": "linha 2\n",
+		"radar.log":                 "linha 3 ....\n",
+	} {
+		if got := readFile(t, filepath.Join(dir, name)); got != content {
+			t.Errorf("%s = %q, esperado %q", name, got, content)
+		}
+	}
+}
+
+func TestRotateBySizeSameSecond(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)}
+	f, dir := openTestFile(t, Rotation{MaxSize: 10}, clock)
+
+	// Rotações no mesmo segundo recebem sufixos em vez de sobrescrever
+	for i := 0; i < 3; i++ {
+		writeLine(t, f, "0123456789")
+	}
+	f.Close()
+
+	want := []string{"radar-20240501-100000.1.log", "radar-20240501-100000.log", "radar.log"}
+	if got := listDir(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("arquivos = %v, esperado %v", got, want)
+	}
+}
+
+func TestRotateDaily(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 23, 59, 0, 0, time.Local)}
+	f, dir := openTestFile(t, Rotation{Daily: true}, clock)
+
+	writeLine(t, f, "dia 1")
+	clock.Advance(30 * time.Second)
+	writeLine(t, f, "ainda dia 1")
+	if got := listDir(t, dir); len(got) != 1 {
+		t.Fatalf("rotação antes da virada do dia: %v", got)
+	}
+
+	clock.Advance(time.Minute) // 00:00:30 do dia seguinte
+	writeLine(t, f, "dia 2")
+	clock.Advance(time.Hour)
+	writeLine(t, f, "ainda dia 2")
+	f.Close()
+
+	want := []string{"radar-20240502-000030.log", "radar.log"}
+	if got := listDir(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("arquivos = %v, esperado %v", got, want)
+	}
+	if got := readFile(t, filepath.Join(dir, want[0])); got != "dia 1\nainda dia 1\n" {
+		t.Errorf("arquivo rotacionado = %q", got)
+	}
+	if got := readFile(t, filepath.Join(dir, "radar.log")); got != "dia 2\nainda dia 2\n" {
+		t.Errorf("arquivo atual = %q", got)
+	}
+}
+
+func TestRotateDailyExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "radar.log")
+	if err := os.WriteFile(path, []byte("execução anterior\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	yesterday := time.Now().AddDate(0, 0, -1)
+	if err := os.Chtimes(path, yesterday, yesterday); err != nil {
+		t.Fatal(err)
+	}
+
+	// O arquivo de ontem é rotacionado na primeira escrita
+	f, err := openRotatingFile(path, Rotation{Daily: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeLine(t, f, "hoje")
+	f.Close()
+
+	if got := listDir(t, dir); len(got) != 2 {
+		t.Errorf("arquivos = %v, esperado o rotacionado e radar.log", got)
+	}
+	if got := readFile(t, path); got != "hoje\n" {
+		t.Errorf("arquivo atual = %q", got)
+	}
+}
+
+func TestRotateCompress(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)}
+	f, dir := openTestFile(t, Rotation{MaxSize: 10, Compress: true}, clock)
+
+	writeLine(t, f, "primeira")
+	clock.Advance(time.Second)
+	writeLine(t, f, "segunda")
+	f.Close() // Aguarda a compactação
+
+	want := []string{"radar-20240501-100001.log.gz", "radar.log"}
+	if got := listDir(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("arquivos = %v, esperado %v", got, want)
+	}
+	if got := readFile(t, filepath.Join(dir, want[0])); got != "primeira\n" {
+		t.Errorf("conteúdo compactado = %q, esperado %q", got, "primeira\n")
+	}
+}
+
+func TestRotateMaxBackups(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)}
+	f, dir := openTestFile(t, Rotation{MaxSize: 10, MaxBackups: 2}, clock)
+
+	for i := 0; i < 5; i++ {
+		writeLine(t, f, strings.Repeat("x", 9))
+		clock.Advance(time.Second)
+		f.millWg.Wait() // Ordem das datas de modificação
+	}
+	f.Close()
+
+	// Rotações às 10:00:01 a 10:00:04; restam as duas mais recentes
+	want := []string{"radar-20240501-100003.log", "radar-20240501-100004.log", "radar.log"}
+	if got := listDir(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("arquivos = %v, esperado %v", got, want)
+	}
+}
+
+func TestRotateMaxAge(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	f, dir := openTestFile(t, Rotation{MaxSize: 10, MaxAge: 24 * time.Hour}, clock)
+
+	// Arquivos deixados por execuções anteriores
+	old := filepath.Join(dir, "radar-20000101-000000.log.gz")
+	recent := filepath.Join(dir, "radar-20000102-000000.log")
+	other := filepath.Join(dir, "outro-20000101-000000.log")
+	for _, path := range []string{old, recent, other} {
+		if err := os.WriteFile(path, []byte("antigo\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chtimes(old, clock.Now().Add(-48*time.Hour), clock.Now().Add(-48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(recent, clock.Now().Add(-time.Hour), clock.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	writeLine(t, f, strings.Repeat("x", 9))
+	writeLine(t, f, "rotaciona")
+	f.millWg.Wait()
+
+	if fileExists(old) {
+		t.Error("arquivo com mais de MaxAge não removido")
+	}
+	if !fileExists(recent) || !fileExists(other) {
+		t.Errorf("arquivos removidos indevidamente: %v", listDir(t, dir))
+	}
+
+	// Com o relógio adiantado, os demais também expiram
+	clock.Advance(48 * time.Hour)
+	writeLine(t, f, "rotaciona de novo")
+	f.Close()
+
+	got := listDir(t, dir)
+	want := []string{"outro-20000101-000000.log", "radar.log"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("arquivos = %v, esperado %v", got, want)
+	}
+}
+
+func TestSetLevelAtRuntime(t *testing.T) {
+	buf := captureLogs(t, time.Minute)
+	previous := GetLevel()
+	t.Cleanup(func() { SetLevel(previous) })
+
+	SetLevel(INFO)
+	Debug("depuração oculta")
+	Info("informação visível")
+
+	SetLevel(DEBUG)
+	if !IsDebugEnabled() {
+		t.Error("IsDebugEnabled falso com nível DEBUG")
+	}
+	Debug("depuração visível")
+
+	SetLevel(ERROR)
+	Info("informação oculta")
+	Warn("aviso oculto")
+	Error("erro visível", nil)
+
+	out := buf.String()
+	for _, msg := range []string{"informação visível", "depuração visível", "erro visível"} {
+		if !strings.Contains(out, msg) {
+			t.Errorf("%q ausente:\n%s", msg, out)
+		}
+	}
+	for _, msg := range []string{"depuração oculta", "informação oculta", "aviso oculto"} {
+		if strings.Contains(out, msg) {
+			t.Errorf("%q registrado fora do nível:\n%s", msg, out)
+		}
+	}
+}
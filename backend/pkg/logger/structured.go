@@ -0,0 +1,206 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format é o formato das linhas de log
+type Format string
+
+const (
+	// FormatText escreve "[timestamp] LEVEL [arquivo:linha]: mensagem chave=valor"
+	FormatText Format = "text"
+	// FormatJSON escreve um objeto JSON por linha, para coletores de log
+	FormatJSON Format = "json"
+)
+
+// ParseFormat converte o nome do formato ("text" ou "json")
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(name))) {
+	case FormatText, "":
+		return FormatText, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	}
+	return FormatText, fmt.Errorf("formato de log inválido %q (use text ou json)", name)
+}
+
+// SetFormat define o formato das linhas de log
+func SetFormat(format Format) {
+	mu.Lock()
+	defer mu.Unlock()
+	logFormat = format
+}
+
+// Logger escreve logs com campos de contexto (componente, IDs de dispositivo
+// e cliente etc.), incluídos em cada linha. O valor zero (ou nil) não tem
+// campos e equivale às funções do pacote.
+type Logger struct {
+	fields []interface{} // Pares chave, valor
+	audit  bool          // Avisos e erros nunca são resumidos como repetições
+}
+
+// With cria um logger com os pares chave/valor informados,
+// ex.: logger.With("component", "radar", "radarId", cfg.ID)
+func With(keysAndValues ...interface{}) *Logger {
+	return (*Logger)(nil).With(keysAndValues...)
+}
+
+// With cria um logger filho com os campos deste e os pares informados
+func (l *Logger) With(keysAndValues ...interface{}) *Logger {
+	var fields []interface{}
+	if l != nil {
+		fields = append(fields, l.fields...)
+	}
+	fields = append(fields, keysAndValues...)
+	if len(fields)%2 != 0 {
+		fields = append(fields, "!SEM_VALOR")
+	}
+	return &Logger{fields: fields, audit: l.isAudit()}
+}
+
+// Audit retorna um logger para avisos de segurança e auditoria (ex.:
+// autenticação recusada), que são sempre escritos, mesmo repetidos
+func Audit() *Logger {
+	return &Logger{audit: true}
+}
+
+// Audit cria um logger filho com os campos deste, para avisos de segurança
+// e auditoria
+func (l *Logger) Audit() *Logger {
+	return &Logger{fields: l.context(), audit: true}
+}
+
+// context retorna os campos do logger (nil-safe)
+func (l *Logger) context() []interface{} {
+	if l == nil {
+		return nil
+	}
+	return l.fields
+}
+
+// isAudit indica um logger de segurança e auditoria (nil-safe)
+func (l *Logger) isAudit() bool {
+	return l != nil && l.audit
+}
+
+// Debug escreve mensagem de log com nível DEBUG
+func (l *Logger) Debug(msg string) {
+	write(DEBUG, l, nil, "%s", msg)
+}
+
+// Debugf escreve mensagem de log formatada com nível DEBUG
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	write(DEBUG, l, nil, format, args...)
+}
+
+// Info escreve mensagem de log com nível INFO
+func (l *Logger) Info(msg string) {
+	write(INFO, l, nil, "%s", msg)
+}
+
+// Infof escreve mensagem de log formatada com nível INFO
+func (l *Logger) Infof(format string, args ...interface{}) {
+	write(INFO, l, nil, format, args...)
+}
+
+// Warn escreve mensagem de log com nível WARN
+func (l *Logger) Warn(msg string) {
+	write(WARN, l, nil, "%s", msg)
+}
+
+// Warnf escreve mensagem de log formatada com nível WARN
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	write(WARN, l, nil, format, args...)
+}
+
+// Error escreve mensagem de log com nível ERROR; em JSON, o erro vai no campo "error"
+func (l *Logger) Error(msg string, err error) {
+	write(ERROR, l, err, "%s", msg)
+}
+
+// Errorf escreve mensagem de log formatada com nível ERROR
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	write(ERROR, l, nil, format, args...)
+}
+
+// formatText formata a linha no formato tradicional, com os campos ao final
+func formatText(now time.Time, prefix, source, msg string, err error, fields []interface{}) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", now.Format(timeFormat), prefix)
+	if source != "" {
+		fmt.Fprintf(&b, " [%s]", source)
+	}
+	b.WriteString(": ")
+	b.WriteString(msg)
+	if err != nil {
+		b.WriteString(": ")
+		b.WriteString(err.Error())
+	}
+
+	for i := 0; i+1 < len(fields); i += 2 {
+		value := fmt.Sprint(plainValue(fields[i+1]))
+		if value == "" || strings.ContainsAny(value, " =\"") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&b, " %v=%s", fields[i], value)
+	}
+	return b.String()
+}
+
+// formatJSON formata a linha como um objeto JSON: time, level, source, msg,
+// error e os campos de contexto, nesta ordem
+func formatJSON(now time.Time, level Level, source, msg string, err error, fields []interface{}) string {
+	var b bytes.Buffer
+	b.WriteString(`{"time":`)
+	writeJSON(&b, now.Format(time.RFC3339Nano))
+	b.WriteString(`,"level":`)
+	writeJSON(&b, level.String())
+	if source != "" {
+		b.WriteString(`,"source":`)
+		writeJSON(&b, source)
+	}
+	b.WriteString(`,"msg":`)
+	writeJSON(&b, msg)
+	if err != nil {
+		b.WriteString(`,"error":`)
+		writeJSON(&b, err.Error())
+	}
+
+	for i := 0; i+1 < len(fields); i += 2 {
+		b.WriteByte(',')
+		writeJSON(&b, fmt.Sprint(fields[i]))
+		b.WriteByte(':')
+		writeJSON(&b, plainValue(fields[i+1]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// writeJSON serializa um valor; se não for serializável, usa sua forma em texto
+func writeJSON(b *bytes.Buffer, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+	b.Write(data)
+}
+
+// plainValue converte erros e tipos com String() (ex.: time.Duration) para
+// texto, que seriam serializados em JSON como {} ou como número
+func plainValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Marshaler:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return value
+}